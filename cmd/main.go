@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
+	"github.com/renat/poc-ses/internal/config"
 	"github.com/renat/poc-ses/internal/handlers"
+	"github.com/renat/poc-ses/internal/metrics"
+	"github.com/renat/poc-ses/internal/services"
 	_ "github.com/renat/poc-ses/docs"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -25,24 +29,74 @@ import (
 // @BasePath  /api/v1
 
 func main() {
+	cfg := config.LoadConfig()
+	
+	if cfg.DatabaseCompactOnStart {
+		if err := services.CompactDatabase(cfg.DatabasePath); err != nil {
+			log.Fatalf("Falha ao compactar banco de dados: %v", err)
+		}
+	}
+	
+	db, err := services.OpenDatabase(cfg.DatabasePath)
+	if err != nil {
+		log.Fatalf("Falha ao abrir banco de dados: %v", err)
+	}
+	defer db.Close()
+	
 	r := gin.Default()
+	r.Use(metrics.GinMiddleware())
+	
+	// Métricas no formato do Prometheus
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 	
 	// Configurando versão da API
 	v1 := r.Group("/api/v1")
 	
 	// Configurando handlers
-	h := handlers.NewHandler()
+	h, err := handlers.NewHandler(cfg, db)
+	if err != nil {
+		log.Fatalf("Falha ao inicializar handlers: %v", err)
+	}
+	
+	// Iniciando tarefas em segundo plano
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.StartBackgroundJobs(ctx)
 	
 	// Rotas para gerenciar remetentes
 	v1.POST("/senders", h.RegisterSender)
 	v1.GET("/senders", h.ListSenders)
+	v1.GET("/senders/drift", h.ListSenderDrift)
+	v1.POST("/senders/reconcile", h.ReconcileSenders)
 	v1.GET("/senders/:email", h.GetSender)
+	v1.PUT("/senders/:email", h.UpdateSender)
 	v1.DELETE("/senders/:email", h.DeleteSender)
+	v1.POST("/senders/:email/verification/resend", h.ResendVerification)
+	v1.POST("/senders/:email/pause", h.PauseSender)
+	v1.POST("/senders/:email/resume", h.ResumeSender)
+	v1.GET("/senders/:email/mail-from", h.GetSenderMailFrom)
+	v1.PUT("/senders/:email/mail-from", h.SetSenderMailFrom)
+	v1.DELETE("/senders/:email/mail-from", h.DeleteSenderMailFrom)
+	
+	// Rotas para gerenciar domínios
+	v1.POST("/domains", h.RegisterDomain)
+	v1.GET("/domains", h.ListDomains)
+	v1.GET("/domains/:domain", h.GetDomain)
+	v1.DELETE("/domains/:domain", h.DeleteDomain)
+	v1.GET("/domains/:domain/health", h.CheckDomainHealth)
+	v1.GET("/domains/:domain/mail-from", h.GetMailFrom)
+	v1.PUT("/domains/:domain/mail-from", h.SetMailFrom)
+	v1.DELETE("/domains/:domain/mail-from", h.DeleteMailFrom)
 	
 	// Rotas para métricas
 	v1.GET("/metrics", h.GetMetrics)
 	v1.GET("/metrics/sender/:email", h.GetSenderMetrics)
 	
+	// Rotas de métricas locais
+	v1.GET("/analytics/:dimension", h.GetAnalyticsBreakdown)
+	v1.GET("/analytics/:dimension/:value", h.GetAnalyticsMetrics)
+	v1.POST("/analytics/rebuild", h.RebuildAnalytics)
+	
 	// Rotas para envio de e-mails
 	v1.POST("/emails/send", h.SendEmail)
 	v1.DELETE("/emails/cancel/:messageId", h.CancelEmail)
@@ -53,19 +107,85 @@ func main() {
 	v1.GET("/templates/:id", h.GetTemplate)
 	v1.DELETE("/templates/:id", h.DeleteTemplate)
 	
+	// Rotas para templates do e-mail de verificação de remetentes
+	v1.POST("/verification-templates", h.CreateVerificationTemplate)
+	v1.GET("/verification-templates", h.ListVerificationTemplates)
+	v1.GET("/verification-templates/:name", h.GetVerificationTemplate)
+	v1.PUT("/verification-templates/:name", h.UpdateVerificationTemplate)
+	v1.DELETE("/verification-templates/:name", h.DeleteVerificationTemplate)
+	
+	// Rotas para configuration sets e os seus destinos de eventos
+	v1.POST("/configuration-sets", h.CreateConfigurationSet)
+	v1.GET("/configuration-sets", h.ListConfigurationSets)
+	v1.GET("/configuration-sets/:name", h.GetConfigurationSet)
+	v1.PUT("/configuration-sets/:name", h.UpdateConfigurationSet)
+	v1.DELETE("/configuration-sets/:name", h.DeleteConfigurationSet)
+	v1.POST("/configuration-sets/:name/event-destinations", h.CreateEventDestination)
+	v1.PUT("/configuration-sets/:name/event-destinations/:destination", h.UpdateEventDestination)
+	v1.DELETE("/configuration-sets/:name/event-destinations/:destination", h.DeleteEventDestination)
+	v1.POST("/configuration-sets/:name/pause", h.PauseConfigurationSet)
+	v1.POST("/configuration-sets/:name/resume", h.ResumeConfigurationSet)
+	
+	// Rotas para os controles de envio e o painel da conta
+	v1.GET("/account", h.GetAccountDashboard)
+	v1.POST("/account/sending/pause", h.PauseAccountSending)
+	v1.POST("/account/sending/resume", h.ResumeAccountSending)
+	v1.POST("/account/kill-switch", h.EngageKillSwitch)
+	v1.DELETE("/account/kill-switch", h.ReleaseKillSwitch)
+	
 	// Rotas para monitoramento de entregas
 	v1.GET("/delivery/status/:messageId", h.GetDeliveryStatus)
+	v1.GET("/delivery/status/:messageId/events", h.GetDeliveryEvents)
+	v1.POST("/delivery/events", h.ReceiveSESEvent)
 	v1.GET("/delivery/status", h.GetAllDeliveryStatus)
+	v1.GET("/delivery/recipients/:email", h.GetRecipientDeliveries)
 	v1.GET("/delivery/report", h.GetRealTimeReport)
+	v1.GET("/delivery/latency", h.GetDeliveryLatency)
+	v1.GET("/delivery/sla", h.GetSLAConfig)
+	v1.PUT("/delivery/sla", h.UpdateSLAConfig)
+	v1.GET("/delivery/sla/breaches", h.GetSLABreaches)
 	
-	// Rotas para envio de e-mails
-	v1.POST("/emails/send", h.SendEmail)
+	// Rotas para monitoramento de reputação
+	v1.GET("/reputation", h.GetReputation)
+	v1.GET("/reputation/policy", h.GetReputationPolicy)
+	v1.PUT("/reputation/policy", h.UpdateReputationPolicy)
+	v1.GET("/reputation/alerts", h.ListReputationAlerts)
+	v1.GET("/reputation/paused", h.ListPausedSenders)
+	v1.GET("/anomalies", h.GetAnomalies)
+	
+	// Rotas para relatórios agendados
+	v1.POST("/reports/subscriptions", h.CreateReportSubscription)
+	v1.GET("/reports/subscriptions", h.ListReportSubscriptions)
+	v1.GET("/reports/subscriptions/:id", h.GetReportSubscription)
+	v1.DELETE("/reports/subscriptions/:id", h.DeleteReportSubscription)
+	v1.POST("/reports/subscriptions/:id/send", h.SendReportNow)
+	v1.GET("/reports/subscriptions/:id/preview", h.PreviewReport)
+	
+	// Rotas para exportação de dados
+	v1.GET("/exports/deliveries", h.ExportDeliveries)
+	v1.GET("/exports/events", h.ExportEvents)
+	v1.GET("/exports/metrics", h.ExportMetrics)
+	v1.POST("/exports/schedules", h.CreateExportSchedule)
+	v1.GET("/exports/schedules", h.ListExportSchedules)
+	v1.GET("/exports/schedules/:id", h.GetExportSchedule)
+	v1.DELETE("/exports/schedules/:id", h.DeleteExportSchedule)
+	v1.POST("/exports/schedules/:id/run", h.RunExportSchedule)
+	
+	// Rotas para workflows
+	v1.POST("/workflows", h.CreateWorkflow)
+	v1.GET("/workflows", h.ListWorkflows)
+	v1.GET("/workflows/:id", h.GetWorkflow)
+	v1.DELETE("/workflows/:id", h.DeleteWorkflow)
+	v1.POST("/workflows/:id/enrollments", h.EnrollContact)
+	v1.GET("/workflows/:id/enrollments", h.ListEnrollments)
+	v1.DELETE("/workflows/:id/enrollments/:enrollmentId", h.Unenroll)
+	v1.GET("/workflows/:id/funnel", h.GetWorkflowFunnel)
 	
 	// Swagger
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	
-	log.Println("Iniciando servidor na porta 8080...")
-	if err := r.Run(":8080"); err != nil {
+	log.Printf("Iniciando servidor na porta %s...", cfg.ServerPort)
+	if err := r.Run(":" + cfg.ServerPort); err != nil {
 		log.Fatalf("Falha ao iniciar servidor: %v", err)
 	}
 }
@@ -3,23 +3,54 @@ package config
 import (
 	"os"
 	"strings"
+	"time"
 )
 
 // Config representa a configuração da aplicação
 type Config struct {
-	AwsRegion string
-	AwsAccessKeyID string
-	AwsSecretAccessKey string
-	ServerPort string
+	AwsRegion              string
+	AwsAccessKeyID         string
+	AwsSecretAccessKey     string
+	ServerPort             string
+	DatabasePath           string
+	DatabaseCompactOnStart bool
+	WorkflowInterval       time.Duration
+	DeliveryRetention      time.Duration
+	RetentionInterval      time.Duration
+	MetricsInterval        time.Duration
+	MetricsCacheTTL        time.Duration
+	DeliverySLA            time.Duration
+	ReputationInterval     time.Duration
+	ReportSender           string
+	ExportDir              string
+	DNSServer              string
+	ReconcileInterval      time.Duration
+	VerificationWebhooks   []string
+	SNSTopicARNs           []string
 }
 
 // LoadConfig carrega as configurações do ambiente
 func LoadConfig() *Config {
 	return &Config{
-		AwsRegion:          getEnv("AWS_REGION", "us-east-1"),
-		AwsAccessKeyID:     getEnv("AWS_ACCESS_KEY_ID", ""),
-		AwsSecretAccessKey: getEnv("AWS_SECRET_ACCESS_KEY", ""),
-		ServerPort:         getEnv("SERVER_PORT", "8080"),
+		AwsRegion:              getEnv("AWS_REGION", "us-east-1"),
+		AwsAccessKeyID:         getEnv("AWS_ACCESS_KEY_ID", ""),
+		AwsSecretAccessKey:     getEnv("AWS_SECRET_ACCESS_KEY", ""),
+		ServerPort:             getEnv("SERVER_PORT", "8080"),
+		DatabasePath:           getEnv("DATABASE_PATH", "poc-ses.db"),
+		DatabaseCompactOnStart: getEnv("DATABASE_COMPACT_ON_START", "false") == "true",
+		WorkflowInterval:       getDurationEnv("WORKFLOW_INTERVAL", 30*time.Second),
+		DeliveryRetention:      getDurationEnv("DELIVERY_RETENTION", 90*24*time.Hour),
+		RetentionInterval:      getDurationEnv("RETENTION_INTERVAL", time.Hour),
+		MetricsInterval:        getDurationEnv("METRICS_INTERVAL", 5*time.Minute),
+		MetricsCacheTTL:        getDurationEnv("METRICS_CACHE_TTL", time.Minute),
+		DeliverySLA:            getDurationEnv("DELIVERY_SLA", 5*time.Minute),
+		ReputationInterval:     getDurationEnv("REPUTATION_INTERVAL", 5*time.Minute),
+		ReportSender:           getEnv("REPORT_SENDER", ""),
+		ExportDir:              getEnv("EXPORT_DIR", ""),
+		DNSServer:              getEnv("DNS_SERVER", ""),
+		ReconcileInterval:      getDurationEnv("SENDER_RECONCILE_INTERVAL", 15*time.Minute),
+		VerificationWebhooks:   getListEnv("VERIFICATION_WEBHOOKS"),
+		SNSTopicARNs:           getListEnv("SNS_TOPIC_ARNS"),
 	}
 }
 
@@ -31,3 +62,23 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getListEnv obtém uma lista separada por vírgulas do ambiente
+func getListEnv(key string) []string {
+	var items []string
+	for _, item := range strings.Split(getEnv(key, ""), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getDurationEnv obtém uma duração (ex: "30s", "5m") do ambiente ou retorna o valor padrão
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, ""))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
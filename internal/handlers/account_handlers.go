@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KillSwitchRequest representa os dados do acionamento do kill switch
+type KillSwitchRequest struct {
+	Reason string `json:"reason"`
+}
+
+// GetAccountDashboard godoc
+// @Summary      Obtém o painel da conta
+// @Description  Reúne o status de envio da conta no SES, o kill switch, a cota (GetSendQuota), as estatísticas das últimas duas semanas (GetSendStatistics), as métricas de reputação do SES com o status de revisão estimado (healthy, underReview, atRisk ou sendingPaused) e o monitor de reputação local. As seções que não puderam ser obtidas ficam ausentes, com o motivo em errors
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  services.AccountDashboard
+// @Router       /account [get]
+func (h *Handler) GetAccountDashboard(c *gin.Context) {
+	c.JSON(http.StatusOK, h.accountService.GetDashboard())
+}
+
+// PauseAccountSending godoc
+// @Summary      Desabilita os envios da conta no SES
+// @Description  Desabilita o envio de e-mails da conta inteira no SES, até a retomada
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /account/sending/pause [post]
+func (h *Handler) PauseAccountSending(c *gin.Context) {
+	if err := h.accountService.SetSendingEnabled(false); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao desabilitar envios da conta: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Envios da conta desabilitados"})
+}
+
+// ResumeAccountSending godoc
+// @Summary      Habilita os envios da conta no SES
+// @Description  Volta a habilitar o envio de e-mails da conta no SES. Não libera o kill switch
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /account/sending/resume [post]
+func (h *Handler) ResumeAccountSending(c *gin.Context) {
+	if err := h.accountService.SetSendingEnabled(true); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao habilitar envios da conta: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Envios da conta habilitados"})
+}
+
+// EngageKillSwitch godoc
+// @Summary      Aciona o kill switch
+// @Description  Suspende imediatamente todos os envios da API (e-mails, workflows, relatórios e alertas), sem depender do SES, até a liberação manual
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Param        killSwitch  body      KillSwitchRequest  false  "Motivo do acionamento"
+// @Success      200         {object}  services.KillSwitch
+// @Failure      500         {object}  map[string]string
+// @Router       /account/kill-switch [post]
+func (h *Handler) EngageKillSwitch(c *gin.Context) {
+	var req KillSwitchRequest
+	// O corpo é opcional
+	_ = c.ShouldBindJSON(&req)
+
+	killSwitch, err := h.accountService.EngageKillSwitch(req.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, killSwitch)
+}
+
+// ReleaseKillSwitch godoc
+// @Summary      Libera o kill switch
+// @Description  Libera os envios suspensos pelo kill switch
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /account/kill-switch [delete]
+func (h *Handler) ReleaseKillSwitch(c *gin.Context) {
+	if err := h.accountService.ReleaseKillSwitch(); err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "não está acionado") {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Kill switch liberado"})
+}
+
+// PauseConfigurationSet godoc
+// @Summary      Pausa os envios de um configuration set
+// @Description  Desabilita no SES os envios que usam o configuration set, até a retomada
+// @Tags         configuration-sets
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string  true  "Nome do configuration set"
+// @Success      200   {object}  services.ConfigurationSet
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /configuration-sets/{name}/pause [post]
+func (h *Handler) PauseConfigurationSet(c *gin.Context) {
+	h.setConfigurationSetSending(c, false)
+}
+
+// ResumeConfigurationSet godoc
+// @Summary      Retoma os envios de um configuration set
+// @Description  Volta a habilitar no SES os envios que usam o configuration set
+// @Tags         configuration-sets
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string  true  "Nome do configuration set"
+// @Success      200   {object}  services.ConfigurationSet
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /configuration-sets/{name}/resume [post]
+func (h *Handler) ResumeConfigurationSet(c *gin.Context) {
+	h.setConfigurationSetSending(c, true)
+}
+
+// setConfigurationSetSending habilita ou pausa os envios do configuration set do parâmetro name
+func (h *Handler) setConfigurationSetSending(c *gin.Context, enabled bool) {
+	set, err := h.sesService.SetConfigurationSetSendingEnabled(c.Param("name"), enabled)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao atualizar envios do configuration set: " + err.Error()})
+		return
+	}
+
+	if set == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Configuration set não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, set)
+}
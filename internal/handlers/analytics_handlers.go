@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/renat/poc-ses/internal/services"
+)
+
+// GetAnalyticsBreakdown godoc
+// @Summary      Lista as métricas locais por valor de uma dimensão
+// @Description  Retorna os totais e taxas de cada remetente, template, tag, domínio do destinatário, campanha ou configuration set, calculados a partir dos eventos registrados pela aplicação
+// @Tags         analytics
+// @Accept       json
+// @Produce      json
+// @Param        dimension  path      string  true   "Dimensão: sender, template, tag, domain, campaign ou configurationSet"
+// @Param        startDate  query     string  false  "Data inicial (formato: YYYY-MM-DD)"
+// @Param        endDate    query     string  false  "Data final (formato: YYYY-MM-DD)"
+// @Param        timezone   query     string  false  "Fuso horário IANA das datas (padrão: UTC)"
+// @Param        limit      query     int     false  "Número máximo de resultados"
+// @Success      200        {array}   services.SenderMetricsResponse
+// @Failure      400        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /analytics/{dimension} [get]
+func (h *Handler) GetAnalyticsBreakdown(c *gin.Context) {
+	opts, err := services.ParseSeriesOptions("", c.Query("timezone"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	breakdown, err := h.analyticsService.GetBreakdown(c.Param("dimension"), c.Query("startDate"), c.Query("endDate"), opts.Location, limit)
+	if err != nil {
+		c.JSON(metricsErrorStatus(err), gin.H{"error": "Falha ao obter métricas: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, breakdown)
+}
+
+// GetAnalyticsMetrics godoc
+// @Summary      Obtém as métricas locais de um valor de uma dimensão
+// @Description  Retorna totais, taxas e série temporal de um remetente, template, tag (nome:valor), domínio do destinatário, campanha ou configuration set
+// @Tags         analytics
+// @Accept       json
+// @Produce      json
+// @Param        dimension    path      string  true   "Dimensão: sender, template, tag, domain, campaign ou configurationSet"
+// @Param        value        path      string  true   "Valor da dimensão (ex: exemplo.com ou campaign:black-friday)"
+// @Param        startDate    query     string  false  "Data inicial (formato: YYYY-MM-DD)"
+// @Param        endDate      query     string  false  "Data final (formato: YYYY-MM-DD)"
+// @Param        granularity  query     string  false  "Granularidade da série: 1h, 1d ou 1w (padrão: 1d)"
+// @Param        timezone     query     string  false  "Fuso horário IANA dos períodos e datas (padrão: UTC)"
+// @Param        compareTo    query     string  false  "Período de comparação: previous, previousWeek ou previousYear"
+// @Success      200          {object}  services.SenderMetricsResponse
+// @Failure      400          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /analytics/{dimension}/{value} [get]
+func (h *Handler) GetAnalyticsMetrics(c *gin.Context) {
+	opts, err := services.ParseSeriesOptions(c.Query("granularity"), c.Query("timezone"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	metrics, err := h.analyticsService.GetMetrics(c.Param("dimension"), c.Param("value"), c.Query("startDate"), c.Query("endDate"), opts, c.Query("compareTo"))
+	if err != nil {
+		c.JSON(metricsErrorStatus(err), gin.H{"error": "Falha ao obter métricas: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, metrics)
+}
+
+// RebuildAnalytics godoc
+// @Summary      Recalcula as métricas locais
+// @Description  Recria as tabelas de agregação a partir dos status e eventos de entrega armazenados. As horas anteriores ao envio mais antigo ainda retido são mantidas.
+// @Tags         analytics
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /analytics/rebuild [post]
+func (h *Handler) RebuildAnalytics(c *gin.Context) {
+	processed, err := h.analyticsService.Rebuild()
+	if err != nil {
+		if strings.Contains(err.Error(), "em andamento") {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao recalcular métricas: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Métricas recalculadas com sucesso", "messages": processed})
+}
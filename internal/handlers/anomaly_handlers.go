@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/renat/poc-ses/internal/services"
+)
+
+// GetAnomalies godoc
+// @Summary      Lista as anomalias de volume e engajamento
+// @Description  Compara cada hora recente de cada remetente com a linha de base aprendida (mesma hora do mesmo dia da semana nas semanas anteriores) e retorna os picos de volume, quedas da taxa de abertura e picos da taxa de bounce
+// @Tags         reputation
+// @Accept       json
+// @Produce      json
+// @Param        hours   query     int     false  "Número de horas avaliadas (padrão: 24, máximo: 168)"
+// @Param        sender  query     string  false  "Filtra pelo e-mail do remetente"
+// @Param        type    query     string  false  "Filtra pelos tipos, separados por vírgula: volume_spike, open_rate_drop ou bounce_rate_spike"
+// @Success      200     {array}   services.Anomaly
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /anomalies [get]
+func (h *Handler) GetAnomalies(c *gin.Context) {
+	hours := 24
+	if v := c.Query("hours"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro hours inválido: use um número inteiro positivo"})
+			return
+		}
+		hours = min(parsed, services.MaxAnomalyHours)
+	}
+
+	anomalies, err := h.anomalyService.Detect(hours, c.Query("sender"), splitList(c.Query("type")))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "desconhecido") || strings.Contains(err.Error(), "inválida") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": "Falha ao detectar anomalias: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, anomalies)
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/renat/poc-ses/internal/services"
+)
+
+// CreateConfigurationSet godoc
+// @Summary      Cria um configuration set
+// @Description  Cria um configuration set no SES, com domínio personalizado para o rastreamento de aberturas e cliques (trackingRedirectDomain), métricas de reputação (reputationMetricsEnabled) e política de TLS (tlsPolicy: Require ou Optional) opcionais
+// @Tags         configuration-sets
+// @Accept       json
+// @Produce      json
+// @Param        configurationSet  body      services.ConfigurationSetRequest  true  "Configuration set"
+// @Success      201               {object}  services.ConfigurationSet
+// @Failure      400               {object}  map[string]string
+// @Failure      409               {object}  map[string]string
+// @Failure      500               {object}  map[string]string
+// @Router       /configuration-sets [post]
+func (h *Handler) CreateConfigurationSet(c *gin.Context) {
+	var req services.ConfigurationSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	set, err := h.sesService.CreateConfigurationSet(req)
+	if err != nil {
+		c.JSON(configurationSetErrorStatus(err), gin.H{"error": "Falha ao criar configuration set: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, set)
+}
+
+// ListConfigurationSets godoc
+// @Summary      Lista os configuration sets
+// @Description  Retorna os nomes dos configuration sets da conta
+// @Tags         configuration-sets
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   services.ConfigurationSet
+// @Failure      500  {object}  map[string]string
+// @Router       /configuration-sets [get]
+func (h *Handler) ListConfigurationSets(c *gin.Context) {
+	sets, err := h.sesService.ListConfigurationSets()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar configuration sets: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sets)
+}
+
+// GetConfigurationSet godoc
+// @Summary      Obtém um configuration set
+// @Description  Retorna o configuration set com as opções de rastreamento, reputação e entrega e os destinos de eventos
+// @Tags         configuration-sets
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string  true  "Nome do configuration set"
+// @Success      200   {object}  services.ConfigurationSet
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /configuration-sets/{name} [get]
+func (h *Handler) GetConfigurationSet(c *gin.Context) {
+	set, ok := h.findConfigurationSet(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, set)
+}
+
+// UpdateConfigurationSet godoc
+// @Summary      Atualiza um configuration set
+// @Description  Substitui o domínio de rastreamento (vazio remove o domínio personalizado) e, se informadas, as métricas de reputação e a política de TLS do configuration set
+// @Tags         configuration-sets
+// @Accept       json
+// @Produce      json
+// @Param        name              path      string                            true  "Nome do configuration set"
+// @Param        configurationSet  body      services.ConfigurationSetRequest  true  "Configuration set"
+// @Success      200               {object}  services.ConfigurationSet
+// @Failure      400               {object}  map[string]string
+// @Failure      404               {object}  map[string]string
+// @Failure      500               {object}  map[string]string
+// @Router       /configuration-sets/{name} [put]
+func (h *Handler) UpdateConfigurationSet(c *gin.Context) {
+	var req services.ConfigurationSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	// O nome do configuration set é o da rota
+	req.Name = c.Param("name")
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	set, err := h.sesService.UpdateConfigurationSet(req)
+	if err != nil {
+		c.JSON(configurationSetErrorStatus(err), gin.H{"error": "Falha ao atualizar configuration set: " + err.Error()})
+		return
+	}
+
+	if set == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Configuration set não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, set)
+}
+
+// DeleteConfigurationSet godoc
+// @Summary      Remove um configuration set
+// @Description  Remove o configuration set e os seus destinos de eventos. A remoção é recusada enquanto algum remetente cadastrado o usar como padrão
+// @Tags         configuration-sets
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string  true  "Nome do configuration set"
+// @Success      200   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /configuration-sets/{name} [delete]
+func (h *Handler) DeleteConfigurationSet(c *gin.Context) {
+	set, ok := h.findConfigurationSet(c)
+	if !ok {
+		return
+	}
+
+	if err := h.senderRegistry.DeleteConfigurationSet(set.Name); err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "em uso") {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": "Falha ao remover configuration set: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Configuration set removido com sucesso"})
+}
+
+// CreateEventDestination godoc
+// @Summary      Cria um destino de eventos
+// @Description  Cria um destino de eventos no configuration set, com exatamente um destino: tópico SNS (sns), métricas do CloudWatch (cloudWatch) ou Kinesis Firehose (firehose). Os tipos de evento são send, reject, bounce, complaint, delivery, open, click e renderingFailure
+// @Tags         configuration-sets
+// @Accept       json
+// @Produce      json
+// @Param        name         path      string                     true  "Nome do configuration set"
+// @Param        destination  body      services.EventDestination  true  "Destino de eventos"
+// @Success      201          {object}  services.EventDestination
+// @Failure      400          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Failure      409          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /configuration-sets/{name}/event-destinations [post]
+func (h *Handler) CreateEventDestination(c *gin.Context) {
+	var req services.EventDestination
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	set, ok := h.findConfigurationSet(c)
+	if !ok {
+		return
+	}
+
+	destination, err := h.sesService.CreateEventDestination(set.Name, req)
+	if err != nil {
+		c.JSON(configurationSetErrorStatus(err), gin.H{"error": "Falha ao criar destino de eventos: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, destination)
+}
+
+// UpdateEventDestination godoc
+// @Summary      Atualiza um destino de eventos
+// @Description  Substitui os tipos de evento, o destino e a habilitação do destino de eventos
+// @Tags         configuration-sets
+// @Accept       json
+// @Produce      json
+// @Param        name         path      string                     true  "Nome do configuration set"
+// @Param        destination  path      string                     true  "Nome do destino de eventos"
+// @Param        body         body      services.EventDestination  true  "Destino de eventos"
+// @Success      200          {object}  services.EventDestination
+// @Failure      400          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /configuration-sets/{name}/event-destinations/{destination} [put]
+func (h *Handler) UpdateEventDestination(c *gin.Context) {
+	var req services.EventDestination
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	// O nome do destino é o da rota
+	req.Name = c.Param("destination")
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	destination, err := h.sesService.UpdateEventDestination(c.Param("name"), req)
+	if err != nil {
+		c.JSON(configurationSetErrorStatus(err), gin.H{"error": "Falha ao atualizar destino de eventos: " + err.Error()})
+		return
+	}
+
+	if destination == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Destino de eventos não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, destination)
+}
+
+// DeleteEventDestination godoc
+// @Summary      Remove um destino de eventos
+// @Description  Remove o destino de eventos do configuration set
+// @Tags         configuration-sets
+// @Accept       json
+// @Produce      json
+// @Param        name         path      string  true  "Nome do configuration set"
+// @Param        destination  path      string  true  "Nome do destino de eventos"
+// @Success      200          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /configuration-sets/{name}/event-destinations/{destination} [delete]
+func (h *Handler) DeleteEventDestination(c *gin.Context) {
+	set, ok := h.findConfigurationSet(c)
+	if !ok {
+		return
+	}
+
+	found := false
+	for _, destination := range set.EventDestinations {
+		if destination.Name == c.Param("destination") {
+			found = true
+			break
+		}
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Destino de eventos não encontrado"})
+		return
+	}
+
+	if err := h.sesService.DeleteEventDestination(set.Name, c.Param("destination")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao remover destino de eventos: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Destino de eventos removido com sucesso"})
+}
+
+// findConfigurationSet obtém o configuration set do parâmetro name, respondendo 404 se não existir
+func (h *Handler) findConfigurationSet(c *gin.Context) (*services.ConfigurationSet, bool) {
+	set, err := h.sesService.GetConfigurationSet(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao obter configuration set: " + err.Error()})
+		return nil, false
+	}
+
+	if set == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Configuration set não encontrado"})
+		return nil, false
+	}
+
+	return set, true
+}
+
+// configurationSetErrorStatus classifica os erros de configuration sets e destinos de eventos
+func configurationSetErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "já existe"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "inválido"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/renat/poc-ses/internal/services"
+)
+
+// RegisterDomain godoc
+// @Summary      Cadastra um domínio
+// @Description  Cadastra o domínio no Amazon SES com Easy DKIM e retorna os registros TXT (verificação) e CNAME (DKIM) que devem ser publicados no DNS. Após a verificação, qualquer endereço do domínio pode enviar sem verificação individual
+// @Tags         domains
+// @Accept       json
+// @Produce      json
+// @Param        domain  body      services.DomainRequest  true  "Domínio"
+// @Success      201     {object}  services.DomainResponse
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /domains [post]
+func (h *Handler) RegisterDomain(c *gin.Context) {
+	var req services.DomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	domain, err := h.sesService.RegisterDomain(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao registrar domínio: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain)
+}
+
+// ListDomains godoc
+// @Summary      Lista os domínios cadastrados
+// @Description  Retorna os domínios cadastrados no Amazon SES com o estado de verificação, o estado do DKIM e os registros DNS
+// @Tags         domains
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   services.DomainResponse
+// @Failure      500  {object}  map[string]string
+// @Router       /domains [get]
+func (h *Handler) ListDomains(c *gin.Context) {
+	domains, err := h.sesService.ListDomains()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar domínios: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domains)
+}
+
+// GetDomain godoc
+// @Summary      Obtém o estado de um domínio
+// @Description  Retorna o estado de verificação e de DKIM do domínio e os registros DNS que devem ser publicados
+// @Tags         domains
+// @Accept       json
+// @Produce      json
+// @Param        domain  path      string  true  "Domínio"
+// @Success      200     {object}  services.DomainResponse
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /domains/{domain} [get]
+func (h *Handler) GetDomain(c *gin.Context) {
+	domain, ok := h.findDomain(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, domain)
+}
+
+// DeleteDomain godoc
+// @Summary      Remove um domínio
+// @Description  Remove a identidade do domínio do Amazon SES; endereços sem verificação individual deixam de poder enviar
+// @Tags         domains
+// @Accept       json
+// @Produce      json
+// @Param        domain  path      string  true  "Domínio"
+// @Success      200     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /domains/{domain} [delete]
+func (h *Handler) DeleteDomain(c *gin.Context) {
+	domain, ok := h.findDomain(c)
+	if !ok {
+		return
+	}
+
+	if err := h.sesService.DeleteDomain(domain.Domain); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao remover domínio: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Domínio removido com sucesso"})
+}
+
+// findDomain obtém o domínio do parâmetro domain, respondendo 404 se não existir
+func (h *Handler) findDomain(c *gin.Context) (*services.DomainResponse, bool) {
+	domain, err := h.sesService.GetDomain(c.Param("domain"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao obter domínio: " + err.Error()})
+		return nil, false
+	}
+
+	if domain == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Domínio não encontrado"})
+		return nil, false
+	}
+
+	return domain, true
+}
+
+// CheckDomainHealth godoc
+// @Summary      Verifica o DNS de um domínio de envio
+// @Description  Consulta o SPF (recursivamente, contando o limite de 10 consultas DNS), os seletores DKIM (os do Easy DKIM e os informados), a política DMARC e o MX e o SPF do MAIL FROM personalizado, e retorna pass, warn ou fail com sugestões de correção. Aceita um domínio ou o endereço de um remetente
+// @Tags         domains
+// @Accept       json
+// @Produce      json
+// @Param        domain     path      string  true   "Domínio ou endereço de e-mail"
+// @Param        selectors  query     string  false  "Seletores DKIM adicionais, separados por vírgula"
+// @Success      200        {object}  services.DNSHealthReport
+// @Failure      400        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /domains/{domain}/health [get]
+func (h *Handler) CheckDomainHealth(c *gin.Context) {
+	report, err := h.dnsHealthService.Check(c.Request.Context(), c.Param("domain"), splitList(c.Query("selectors")))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "inválido") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": "Falha ao verificar DNS: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetMailFrom godoc
+// @Summary      Obtém o MAIL FROM personalizado de um domínio
+// @Description  Retorna o domínio MAIL FROM personalizado do domínio, o estado da configuração no SES e os registros MX e SPF que devem ser publicados
+// @Tags         domains
+// @Accept       json
+// @Produce      json
+// @Param        domain  path      string  true  "Domínio"
+// @Success      200     {object}  services.MailFromAttributes
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /domains/{domain}/mail-from [get]
+func (h *Handler) GetMailFrom(c *gin.Context) {
+	h.getMailFrom(c, c.Param("domain"))
+}
+
+// GetSenderMailFrom godoc
+// @Summary      Obtém o MAIL FROM personalizado de um remetente
+// @Description  Retorna o domínio MAIL FROM personalizado do remetente, o estado da configuração no SES e os registros MX e SPF que devem ser publicados
+// @Tags         senders
+// @Accept       json
+// @Produce      json
+// @Param        email  path      string  true  "Endereço de e-mail do remetente"
+// @Success      200    {object}  services.MailFromAttributes
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /senders/{email}/mail-from [get]
+func (h *Handler) GetSenderMailFrom(c *gin.Context) {
+	h.getMailFrom(c, c.Param("email"))
+}
+
+// getMailFrom responde com o MAIL FROM personalizado da identidade (remetente ou domínio)
+func (h *Handler) getMailFrom(c *gin.Context, identity string) {
+	mailFrom, err := h.sesService.GetMailFromAttributes(identity)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao obter MAIL FROM: " + err.Error()})
+		return
+	}
+
+	if mailFrom == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "MAIL FROM personalizado não configurado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, mailFrom)
+}
+
+// SetMailFrom godoc
+// @Summary      Configura o MAIL FROM personalizado de um domínio
+// @Description  Define um subdomínio do domínio como MAIL FROM (envelope), para que o SPF se alinhe no DMARC, e retorna os registros MX e SPF que devem ser publicados. behaviorOnMxFailure define o que o SES faz se o MX não for encontrado: UseDefaultValue (padrão, usa amazonses.com) ou RejectMessage
+// @Tags         domains
+// @Accept       json
+// @Produce      json
+// @Param        domain    path      string                    true  "Domínio"
+// @Param        mailFrom  body      services.MailFromRequest  true  "Domínio MAIL FROM"
+// @Success      200       {object}  services.MailFromAttributes
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /domains/{domain}/mail-from [put]
+func (h *Handler) SetMailFrom(c *gin.Context) {
+	h.setMailFrom(c, c.Param("domain"))
+}
+
+// SetSenderMailFrom godoc
+// @Summary      Configura o MAIL FROM personalizado de um remetente
+// @Description  Define um subdomínio do domínio do remetente como MAIL FROM (envelope), para que o SPF se alinhe no DMARC, e retorna os registros MX e SPF que devem ser publicados. behaviorOnMxFailure define o que o SES faz se o MX não for encontrado: UseDefaultValue (padrão, usa amazonses.com) ou RejectMessage
+// @Tags         senders
+// @Accept       json
+// @Produce      json
+// @Param        email     path      string                    true  "Endereço de e-mail do remetente"
+// @Param        mailFrom  body      services.MailFromRequest  true  "Domínio MAIL FROM"
+// @Success      200       {object}  services.MailFromAttributes
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /senders/{email}/mail-from [put]
+func (h *Handler) SetSenderMailFrom(c *gin.Context) {
+	h.setMailFrom(c, c.Param("email"))
+}
+
+// setMailFrom configura o MAIL FROM personalizado da identidade (remetente ou domínio)
+func (h *Handler) setMailFrom(c *gin.Context, identity string) {
+	var req services.MailFromRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mailFrom, err := h.sesService.SetMailFromDomain(identity, req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "inválido") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": "Falha ao configurar MAIL FROM: " + err.Error()})
+		return
+	}
+
+	if mailFrom == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Identidade não encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, mailFrom)
+}
+
+// DeleteMailFrom godoc
+// @Summary      Remove o MAIL FROM personalizado de um domínio
+// @Description  Volta o domínio para o MAIL FROM padrão do SES (amazonses.com)
+// @Tags         domains
+// @Accept       json
+// @Produce      json
+// @Param        domain  path      string  true  "Domínio"
+// @Success      200     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /domains/{domain}/mail-from [delete]
+func (h *Handler) DeleteMailFrom(c *gin.Context) {
+	h.deleteMailFrom(c, c.Param("domain"))
+}
+
+// DeleteSenderMailFrom godoc
+// @Summary      Remove o MAIL FROM personalizado de um remetente
+// @Description  Volta o remetente para o MAIL FROM padrão do SES (amazonses.com)
+// @Tags         senders
+// @Accept       json
+// @Produce      json
+// @Param        email  path      string  true  "Endereço de e-mail do remetente"
+// @Success      200    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /senders/{email}/mail-from [delete]
+func (h *Handler) DeleteSenderMailFrom(c *gin.Context) {
+	h.deleteMailFrom(c, c.Param("email"))
+}
+
+// deleteMailFrom remove o MAIL FROM personalizado da identidade (remetente ou domínio)
+func (h *Handler) deleteMailFrom(c *gin.Context, identity string) {
+	if err := h.sesService.ClearMailFromDomain(identity); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao remover MAIL FROM: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "MAIL FROM personalizado removido com sucesso"})
+}
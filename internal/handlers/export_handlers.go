@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/renat/poc-ses/internal/services"
+)
+
+// ExportDeliveries godoc
+// @Summary      Exporta os status de entrega
+// @Description  Exporta em streaming uma linha por destinatário das mensagens enviadas no período, em CSV, NDJSON ou Parquet, sem carregar todos os registros em memória
+// @Tags         exports
+// @Produce      octet-stream
+// @Param        startDate  query     string  false  "Data inicial (YYYY-MM-DD)"
+// @Param        endDate    query     string  false  "Data final (YYYY-MM-DD)"
+// @Param        timezone   query     string  false  "Fuso horário IANA das datas (padrão: UTC)"
+// @Param        format     query     string  false  "Formato: csv (padrão), ndjson ou parquet"
+// @Param        from       query     string  false  "Remetente"
+// @Param        status     query     string  false  "Status, separados por vírgula"
+// @Success      200        {file}    file
+// @Failure      400        {object}  map[string]string
+// @Router       /exports/deliveries [get]
+func (h *Handler) ExportDeliveries(c *gin.Context) {
+	h.streamExport(c, services.ExportQuery{
+		Dataset:  services.ExportDatasetDeliveries,
+		Sender:   c.Query("from"),
+		Statuses: splitList(c.Query("status")),
+	})
+}
+
+// ExportEvents godoc
+// @Summary      Exporta os eventos de entrega
+// @Description  Exporta em streaming a linha do tempo (envio, entrega, aberturas, cliques, bounces...) das mensagens enviadas no período, em CSV, NDJSON ou Parquet
+// @Tags         exports
+// @Produce      octet-stream
+// @Param        startDate  query     string  false  "Data inicial (YYYY-MM-DD)"
+// @Param        endDate    query     string  false  "Data final (YYYY-MM-DD)"
+// @Param        timezone   query     string  false  "Fuso horário IANA das datas (padrão: UTC)"
+// @Param        format     query     string  false  "Formato: csv (padrão), ndjson ou parquet"
+// @Param        from       query     string  false  "Remetente"
+// @Success      200        {file}    file
+// @Failure      400        {object}  map[string]string
+// @Router       /exports/events [get]
+func (h *Handler) ExportEvents(c *gin.Context) {
+	h.streamExport(c, services.ExportQuery{
+		Dataset: services.ExportDatasetEvents,
+		Sender:  c.Query("from"),
+	})
+}
+
+// ExportMetrics godoc
+// @Summary      Exporta as métricas diárias
+// @Description  Exporta em streaming os contadores e as taxas diárias de cada valor da dimensão das métricas locais, com os dias no fuso horário informado, em CSV, NDJSON ou Parquet
+// @Tags         exports
+// @Produce      octet-stream
+// @Param        startDate  query     string  false  "Data inicial (YYYY-MM-DD)"
+// @Param        endDate    query     string  false  "Data final (YYYY-MM-DD)"
+// @Param        timezone   query     string  false  "Fuso horário IANA dos dias (padrão: UTC)"
+// @Param        format     query     string  false  "Formato: csv (padrão), ndjson ou parquet"
+// @Param        dimension  query     string  false  "Dimensão (padrão: sender)"
+// @Param        from       query     string  false  "Remetente (apenas na dimensão sender)"
+// @Success      200        {file}    file
+// @Failure      400        {object}  map[string]string
+// @Router       /exports/metrics [get]
+func (h *Handler) ExportMetrics(c *gin.Context) {
+	h.streamExport(c, services.ExportQuery{
+		Dataset:   services.ExportDatasetMetrics,
+		Sender:    c.Query("from"),
+		Dimension: c.Query("dimension"),
+	})
+}
+
+// streamExport valida a exportação e grava o arquivo diretamente na resposta.
+// Erros após o início do envio não podem mais alterar o status HTTP e são
+// apenas registrados no log.
+func (h *Handler) streamExport(c *gin.Context, query services.ExportQuery) {
+	query.Format = c.Query("format")
+	query.StartDate = c.Query("startDate")
+	query.EndDate = c.Query("endDate")
+	query.Timezone = c.Query("timezone")
+
+	if err := query.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", query.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="`+query.FileName()+`"`)
+	c.Status(http.StatusOK)
+
+	rows, err := h.exportService.Export(c.Request.Context(), c.Writer, query)
+	if err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			status := http.StatusInternalServerError
+			if strings.Contains(err.Error(), "inválid") {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Falha na exportação de %s após %d linhas: %v", query.Dataset, rows, err)
+	}
+}
+
+// CreateExportSchedule godoc
+// @Summary      Cria uma exportação agendada
+// @Description  Agenda a exportação dos últimos periodDays dias completos (padrão: 1) conforme a expressão cron, gravando o arquivo em um subdiretório de EXPORT_DIR (local) ou em um bucket compatível com S3
+// @Tags         exports
+// @Accept       json
+// @Produce      json
+// @Param        schedule  body      services.ExportScheduleRequest  true  "Definição da exportação agendada"
+// @Success      201       {object}  services.ExportSchedule
+// @Failure      400       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /exports/schedules [post]
+func (h *Handler) CreateExportSchedule(c *gin.Context) {
+	var req services.ExportScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Agendamento inválido: " + err.Error()})
+		return
+	}
+
+	schedule, err := h.exportService.CreateSchedule(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "não configurado") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": "Falha ao criar agendamento: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// ListExportSchedules godoc
+// @Summary      Lista as exportações agendadas
+// @Description  Retorna as exportações agendadas com a próxima execução e o resultado da última
+// @Tags         exports
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   services.ExportSchedule
+// @Failure      500  {object}  map[string]string
+// @Router       /exports/schedules [get]
+func (h *Handler) ListExportSchedules(c *gin.Context) {
+	schedules, err := h.exportService.ListSchedules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar agendamentos: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// GetExportSchedule godoc
+// @Summary      Obtém uma exportação agendada
+// @Description  Retorna os dados de uma exportação agendada
+// @Tags         exports
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "ID do agendamento"
+// @Success      200  {object}  services.ExportSchedule
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /exports/schedules/{id} [get]
+func (h *Handler) GetExportSchedule(c *gin.Context) {
+	schedule, ok := h.findExportSchedule(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteExportSchedule godoc
+// @Summary      Remove uma exportação agendada
+// @Description  Remove o agendamento; os arquivos já gravados são mantidos
+// @Tags         exports
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "ID do agendamento"
+// @Success      200  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /exports/schedules/{id} [delete]
+func (h *Handler) DeleteExportSchedule(c *gin.Context) {
+	schedule, ok := h.findExportSchedule(c)
+	if !ok {
+		return
+	}
+
+	if err := h.exportService.DeleteSchedule(schedule.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao remover agendamento: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Agendamento removido com sucesso"})
+}
+
+// RunExportSchedule godoc
+// @Summary      Executa uma exportação agendada imediatamente
+// @Description  Exporta os últimos dias completos do agendamento para o destino, sem alterar a próxima execução
+// @Tags         exports
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "ID do agendamento"
+// @Success      200  {object}  services.ExportSchedule
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /exports/schedules/{id}/run [post]
+func (h *Handler) RunExportSchedule(c *gin.Context) {
+	schedule, err := h.exportService.RunNow(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao executar exportação: " + err.Error()})
+		return
+	}
+
+	if schedule == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agendamento não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// findExportSchedule obtém o agendamento do parâmetro id, respondendo 404 se não existir
+func (h *Handler) findExportSchedule(c *gin.Context) (*services.ExportSchedule, bool) {
+	schedule, err := h.exportService.GetSchedule(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao obter agendamento: " + err.Error()})
+		return nil, false
+	}
+
+	if schedule == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agendamento não encontrado"})
+		return nil, false
+	}
+
+	return schedule, true
+}
@@ -1,31 +1,121 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 	"github.com/gin-gonic/gin"
+	"github.com/renat/poc-ses/internal/config"
 	"github.com/renat/poc-ses/internal/services"
+	bolt "go.etcd.io/bbolt"
 )
 
 // Handler struct holds services for API handlers
 type Handler struct {
-	sesService      *services.SESService
-	deliveryService *services.DeliveryService
+	cfg               *config.Config
+	sesService        *services.SESService
+	deliveryService   *services.DeliveryService
+	workflowService   *services.WorkflowService
+	analyticsService  *services.AnalyticsService
+	latencyService    *services.LatencyService
+	reputationService *services.ReputationService
+	anomalyService    *services.AnomalyService
+	reportService     *services.ReportService
+	exportService     *services.ExportService
+	dnsHealthService  *services.DNSHealthService
+	senderRegistry    *services.SenderRegistry
+	accountService    *services.AccountService
 }
 
 // NewHandler creates a new Handler instance
-func NewHandler() *Handler {
-	sesService := services.NewSESService()
-	return &Handler{
-		sesService:      sesService,
-		deliveryService: services.NewDeliveryService(sesService.GetCloudWatchClient()),
+func NewHandler(cfg *config.Config, db *bolt.DB) (*Handler, error) {
+	sesService := services.NewSESService(cfg.MetricsCacheTTL)
+	
+	deliveryStore, err := services.NewBoltDeliveryStore(db)
+	if err != nil {
+		return nil, err
+	}
+	deliveryService := services.NewDeliveryService(sesService.GetMetricsQuerier(), deliveryStore, services.NewSNSVerifier(cfg.SNSTopicARNs))
+	
+	workflowStore, err := services.NewBoltWorkflowStore(db)
+	if err != nil {
+		return nil, err
 	}
+	
+	analyticsStore, err := services.NewBoltAnalyticsStore(db)
+	if err != nil {
+		return nil, err
+	}
+	
+	slaStore, err := services.NewBoltSLAStore(db)
+	if err != nil {
+		return nil, err
+	}
+	
+	reputationStore, err := services.NewBoltReputationStore(db)
+	if err != nil {
+		return nil, err
+	}
+	
+	reportStore, err := services.NewBoltReportStore(db)
+	if err != nil {
+		return nil, err
+	}
+	
+	exportStore, err := services.NewBoltExportStore(db)
+	if err != nil {
+		return nil, err
+	}
+	
+	senderStore, err := services.NewBoltSenderStore(db)
+	if err != nil {
+		return nil, err
+	}
+	
+	accountStore, err := services.NewBoltAccountStore(db)
+	if err != nil {
+		return nil, err
+	}
+	
+	// As métricas locais devem ser registradas antes do monitor de reputação, que as consulta
+	analyticsService := services.NewAnalyticsService(analyticsStore, deliveryStore, deliveryService)
+	reputationService := services.NewReputationService(reputationStore, analyticsStore, sesService, deliveryService, cfg.ReputationInterval)
+	
+	return &Handler{
+		cfg:               cfg,
+		sesService:        sesService,
+		deliveryService:   deliveryService,
+		workflowService:   services.NewWorkflowService(workflowStore, sesService, deliveryService, cfg.WorkflowInterval),
+		analyticsService:  analyticsService,
+		latencyService:    services.NewLatencyService(deliveryStore, slaStore, cfg.DeliverySLA),
+		reputationService: reputationService,
+		anomalyService:    services.NewAnomalyService(analyticsStore),
+		reportService:     services.NewReportService(reportStore, sesService, analyticsService, deliveryService, cfg.ReportSender),
+		exportService:     services.NewExportService(exportStore, deliveryStore, analyticsStore, cfg.ExportDir, cfg.AwsRegion),
+		dnsHealthService:  services.NewDNSHealthService(sesService, services.NewDNSResolver(cfg.DNSServer), cfg.AwsRegion),
+		senderRegistry:    services.NewSenderRegistry(senderStore, sesService, cfg.ReconcileInterval, cfg.VerificationWebhooks),
+		accountService:    services.NewAccountService(accountStore, sesService, reputationService),
+	}, nil
+}
+
+// StartBackgroundJobs starts the background workers until ctx is cancelled
+func (h *Handler) StartBackgroundJobs(ctx context.Context) {
+	go h.workflowService.Run(ctx)
+	go h.deliveryService.RunRetention(ctx, h.cfg.DeliveryRetention, h.cfg.RetentionInterval)
+	go h.sesService.RunMetricsExport(ctx, h.cfg.MetricsInterval)
+	go h.reputationService.Run(ctx)
+	go h.reportService.Run(ctx)
+	go h.exportService.Run(ctx)
+	go h.senderRegistry.Run(ctx)
+	go h.senderRegistry.RunVerificationPoller(ctx)
+	go h.anomalyService.Run(ctx)
 }
 
 // RegisterSender godoc
 // @Summary      Registra um novo remetente de e-mail
-// @Description  Registra um novo endereço de e-mail como remetente no Amazon SES
+// @Description  Registra um novo endereço de e-mail como remetente no Amazon SES e grava os seus dados (nome, responsável, time, reply-to padrão, configuration set padrão, tags e observações). Com verificationTemplate, o e-mail de verificação usa o template personalizado
 // @Tags         senders
 // @Accept       json
 // @Produce      json
@@ -41,9 +131,13 @@ func (h *Handler) RegisterSender(c *gin.Context) {
 		return
 	}
 	
-	result, err := h.sesService.RegisterSender(req)
+	result, err := h.senderRegistry.Register(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao registrar remetente: " + err.Error()})
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "template de verificação") || strings.Contains(err.Error(), "configuration set") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": "Falha ao registrar remetente: " + err.Error()})
 		return
 	}
 	
@@ -52,7 +146,7 @@ func (h *Handler) RegisterSender(c *gin.Context) {
 
 // ListSenders godoc
 // @Summary      Lista todos os remetentes cadastrados
-// @Description  Retorna uma lista de todos os remetentes de e-mail cadastrados no Amazon SES
+// @Description  Retorna os remetentes cadastrados com os seus dados, reconciliados com as identidades do Amazon SES; os removidos do SES e os criados fora da API são sinalizados em drift
 // @Tags         senders
 // @Accept       json
 // @Produce      json
@@ -60,7 +154,7 @@ func (h *Handler) RegisterSender(c *gin.Context) {
 // @Failure      500  {object}  map[string]string
 // @Router       /senders [get]
 func (h *Handler) ListSenders(c *gin.Context) {
-	senders, err := h.sesService.ListSenders()
+	senders, err := h.senderRegistry.List()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar remetentes: " + err.Error()})
 		return
@@ -71,7 +165,7 @@ func (h *Handler) ListSenders(c *gin.Context) {
 
 // GetSender godoc
 // @Summary      Obtém informações de um remetente específico
-// @Description  Retorna detalhes de um remetente de e-mail específico
+// @Description  Retorna os dados do remetente, o status atual no SES e o histórico de verificação
 // @Tags         senders
 // @Accept       json
 // @Produce      json
@@ -83,7 +177,7 @@ func (h *Handler) ListSenders(c *gin.Context) {
 func (h *Handler) GetSender(c *gin.Context) {
 	email := c.Param("email")
 	
-	sender, err := h.sesService.GetSender(email)
+	sender, err := h.senderRegistry.Get(email)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao obter remetente: " + err.Error()})
 		return
@@ -111,7 +205,7 @@ func (h *Handler) GetSender(c *gin.Context) {
 func (h *Handler) DeleteSender(c *gin.Context) {
 	email := c.Param("email")
 	
-	err := h.sesService.DeleteSender(email)
+	err := h.senderRegistry.Delete(email)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao remover remetente: " + err.Error()})
 		return
@@ -126,8 +220,11 @@ func (h *Handler) DeleteSender(c *gin.Context) {
 // @Tags         metrics
 // @Accept       json
 // @Produce      json
-// @Param        startDate  query     string  false  "Data inicial (formato: YYYY-MM-DD)"
-// @Param        endDate    query     string  false  "Data final (formato: YYYY-MM-DD)"
+// @Param        startDate    query     string  false  "Data inicial (formato: YYYY-MM-DD)"
+// @Param        endDate      query     string  false  "Data final (formato: YYYY-MM-DD)"
+// @Param        granularity  query     string  false  "Granularidade da série: 5m, 1h, 1d ou 1w (padrão: 1d)"
+// @Param        timezone     query     string  false  "Fuso horário IANA dos períodos e datas (padrão: UTC)"
+// @Param        compareTo    query     string  false  "Período de comparação: previous, previousWeek ou previousYear"
 // @Success      200        {object}  services.MetricsResponse
 // @Failure      400        {object}  map[string]string
 // @Failure      500        {object}  map[string]string
@@ -136,9 +233,15 @@ func (h *Handler) GetMetrics(c *gin.Context) {
 	startDate := c.DefaultQuery("startDate", "")
 	endDate := c.DefaultQuery("endDate", "")
 	
-	metrics, err := h.sesService.GetMetrics(startDate, endDate)
+	opts, err := services.ParseSeriesOptions(c.Query("granularity"), c.Query("timezone"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	metrics, err := h.sesService.GetMetrics(startDate, endDate, opts, c.Query("compareTo"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao obter métricas: " + err.Error()})
+		c.JSON(metricsErrorStatus(err), gin.H{"error": "Falha ao obter métricas: " + err.Error()})
 		return
 	}
 	
@@ -147,13 +250,16 @@ func (h *Handler) GetMetrics(c *gin.Context) {
 
 // GetSenderMetrics godoc
 // @Summary      Obtém métricas de envio para um remetente específico
-// @Description  Retorna métricas detalhadas de envios de e-mails para um remetente específico
+// @Description  Retorna métricas detalhadas de envios de e-mails para um remetente específico, calculadas a partir dos eventos de entrega registrados pela aplicação
 // @Tags         metrics
 // @Accept       json
 // @Produce      json
-// @Param        email      path      string  true   "Endereço de e-mail do remetente"
-// @Param        startDate  query     string  false  "Data inicial (formato: YYYY-MM-DD)"
-// @Param        endDate    query     string  false  "Data final (formato: YYYY-MM-DD)"
+// @Param        email        path      string  true   "Endereço de e-mail do remetente"
+// @Param        startDate    query     string  false  "Data inicial (formato: YYYY-MM-DD)"
+// @Param        endDate      query     string  false  "Data final (formato: YYYY-MM-DD)"
+// @Param        granularity  query     string  false  "Granularidade da série: 1h, 1d ou 1w (padrão: 1d)"
+// @Param        timezone     query     string  false  "Fuso horário IANA dos períodos e datas (padrão: UTC)"
+// @Param        compareTo    query     string  false  "Período de comparação: previous, previousWeek ou previousYear"
 // @Success      200        {object}  services.SenderMetricsResponse
 // @Failure      400        {object}  map[string]string
 // @Failure      404        {object}  map[string]string
@@ -162,15 +268,17 @@ func (h *Handler) GetMetrics(c *gin.Context) {
 
 // SendEmail godoc
 // @Summary      Envia um e-mail usando um remetente verificado
-// @Description  Envia um e-mail usando um remetente previamente verificado no Amazon SES
+// @Description  Envia um e-mail usando um remetente previamente verificado no Amazon SES, com Reply-To, Return-Path, cabeçalhos personalizados, tags e configuration set opcionais
 // @Tags         emails
 // @Accept       json
 // @Produce      json
 // @Param        email  body      services.EmailRequest  true  "Detalhes do e-mail"
 // @Success      200    {object}  services.EmailResponse
 // @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
 // @Failure      404    {object}  map[string]string
 // @Failure      500    {object}  map[string]string
+// @Failure      503    {object}  map[string]string
 // @Router       /emails/send [post]
 
 // CreateTemplate godoc
@@ -243,23 +351,74 @@ func (h *Handler) GetMetrics(c *gin.Context) {
 // @Failure      500        {object}  map[string]string
 // @Router       /delivery/status/{messageId} [get]
 
+// GetDeliveryEvents godoc
+// @Summary      Obtém a linha do tempo de eventos de um e-mail
+// @Description  Retorna todos os eventos registrados para um e-mail (envio, atrasos, entrega, aberturas, cliques...) em ordem cronológica
+// @Tags         delivery
+// @Accept       json
+// @Produce      json
+// @Param        messageId  path      string  true  "ID da mensagem"
+// @Success      200        {array}   services.DeliveryEvent
+// @Failure      404        {object}  map[string]string
+// @Router       /delivery/status/{messageId}/events [get]
+
+// ReceiveSESEvent godoc
+// @Summary      Recebe eventos do SES via SNS
+// @Description  Endpoint de inscrição HTTPS do tópico SNS que recebe os eventos publicados pelo SES
+// @Tags         delivery
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /delivery/events [post]
+
 // GetAllDeliveryStatus godoc
-// @Summary      Lista todos os status de entrega recentes
-// @Description  Retorna uma lista com o status de entrega dos e-mails enviados recentemente
+// @Summary      Busca os status de entrega
+// @Description  Retorna uma página de status de entrega que atendem aos filtros. A primeira página traz o total de resultados e a contagem por status
 // @Tags         delivery
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   services.DeliveryStatus
+// @Param        sender     query     string  false  "Filtra pelo e-mail do remetente"
+// @Param        recipient  query     string  false  "Filtra pelo e-mail de um destinatário (To, Cc ou Bcc)"
+// @Param        status     query     string  false  "Filtra pelos status informados, separados por vírgula (ex: BOUNCED,COMPLAINED)"
+// @Param        subject    query     string  false  "Filtra pelos e-mails cujo assunto contém o texto"
+// @Param        tag        query     string  false  "Filtra pela tag, no formato nome ou nome:valor"
+// @Param        startDate  query     string  false  "Data inicial (formato: YYYY-MM-DD)"
+// @Param        endDate    query     string  false  "Data final (formato: YYYY-MM-DD)"
+// @Param        sort       query     string  false  "Ordenação por data de envio: sentAt ou -sentAt (padrão: -sentAt)"
+// @Param        cursor     query     string  false  "Cursor da próxima página, retornado em nextCursor"
+// @Param        limit      query     int     false  "Tamanho da página (padrão: 50, máximo: 500)"
+// @Param        fields     query     string  false  "Campos retornados em cada item, separados por vírgula (ex: messageId,status,subject)"
+// @Success      200  {object}  services.DeliveryPage
+// @Failure      400  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /delivery/status [get]
 
+// GetRecipientDeliveries godoc
+// @Summary      Lista as entregas de um destinatário
+// @Description  Retorna o status de entrega, para o destinatário informado, de cada mensagem enviada a ele
+// @Tags         delivery
+// @Accept       json
+// @Produce      json
+// @Param        email      path      string  true   "E-mail do destinatário"
+// @Param        sender     query     string  false  "Filtra pelo e-mail do remetente"
+// @Param        startDate  query     string  false  "Data inicial (formato: YYYY-MM-DD)"
+// @Param        endDate    query     string  false  "Data final (formato: YYYY-MM-DD)"
+// @Param        limit      query     int     false  "Número máximo de resultados"
+// @Success      200        {array}   services.RecipientDelivery
+// @Failure      400        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /delivery/recipients/{email} [get]
+
 // GetRealTimeReport godoc
 // @Summary      Obtém relatório em tempo real de entregas
 // @Description  Retorna um relatório detalhado das entregas de e-mail nas últimas horas
 // @Tags         delivery
 // @Accept       json
 // @Produce      json
-// @Param        hours  query     int  false  "Número de horas para análise (padrão: 24)"
+// @Param        hours     query     int     false  "Número de horas para análise (padrão: 24)"
+// @Param        timezone  query     string  false  "Fuso horário IANA das horas do relatório (padrão: UTC)"
 // @Success      200    {object}  services.DeliveryReport
 // @Failure      500    {object}  map[string]string
 // @Router       /delivery/report [get]
@@ -268,17 +427,30 @@ func (h *Handler) GetSenderMetrics(c *gin.Context) {
 	startDate := c.DefaultQuery("startDate", "")
 	endDate := c.DefaultQuery("endDate", "")
 	
-	metrics, err := h.sesService.GetSenderMetrics(email, startDate, endDate)
+	opts, err := services.ParseSeriesOptions(c.Query("granularity"), c.Query("timezone"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	sender, err := h.sesService.GetSender(email)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao obter métricas do remetente: " + err.Error()})
 		return
 	}
 	
-	if metrics == nil {
+	if sender == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Remetente não encontrado ou sem métricas disponíveis"})
 		return
 	}
 	
+	// O SES não publica métricas por remetente no CloudWatch: usar os eventos registrados localmente
+	metrics, err := h.analyticsService.GetMetrics(services.DimensionSender, email, startDate, endDate, opts, c.Query("compareTo"))
+	if err != nil {
+		c.JSON(metricsErrorStatus(err), gin.H{"error": "Falha ao obter métricas do remetente: " + err.Error()})
+		return
+	}
+	
 	c.JSON(http.StatusOK, metrics)
 }
 
@@ -290,6 +462,12 @@ func (h *Handler) SendEmail(c *gin.Context) {
 		return
 	}
 	
+	// Interpretar os endereços no formato RFC 5322; o rastreamento usa apenas o e-mail do remetente
+	if err := req.Normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
 	// Validação adicional para envio sem template
 	if req.TemplateId == "" && req.HtmlBody == "" && req.TextBody == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Pelo menos um tipo de corpo (HTML ou texto) deve ser fornecido"})
@@ -302,12 +480,21 @@ func (h *Handler) SendEmail(c *gin.Context) {
 		status := http.StatusInternalServerError
 		
 		// Verificar erros específicos
-		if strings.Contains(err.Error(), "remetente não encontrado") {
+		if strings.Contains(err.Error(), "Return-Path inválido") {
+			status = http.StatusBadRequest
+		} else if strings.Contains(err.Error(), "remetente não encontrado") {
 			status = http.StatusNotFound
 		} else if strings.Contains(err.Error(), "remetente não verificado") {
 			status = http.StatusBadRequest
 		} else if strings.Contains(err.Error(), "template não encontrado") {
 			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "remetente pausado") {
+			status = http.StatusForbidden
+		} else if strings.Contains(err.Error(), "ConfigurationSetDoesNotExist") {
+			status = http.StatusBadRequest
+		} else if strings.Contains(err.Error(), "kill switch") || strings.Contains(err.Error(), "SendingPaused") {
+			// Kill switch interno ou envios pausados no SES (conta ou configuration set)
+			status = http.StatusServiceUnavailable
 		}
 		
 		c.JSON(status, gin.H{"error": "Falha ao enviar e-mail: " + err.Error()})
@@ -315,7 +502,11 @@ func (h *Handler) SendEmail(c *gin.Context) {
 	}
 	
 	// Rastrear o status de entrega
-	h.deliveryService.TrackDelivery(req.From, result.MessageID, req.Subject)
+	h.deliveryService.TrackDelivery(req.From, result.MessageID, req.Subject, services.NewRecipients(req.To, req.Cc, req.Bcc), services.TrackOptions{
+		Tags:             result.Tags,
+		TemplateID:       req.TemplateId,
+		ConfigurationSet: result.ConfigurationSet,
+	})
 	
 	c.JSON(http.StatusOK, result)
 }
@@ -397,7 +588,7 @@ func (h *Handler) CancelEmail(c *gin.Context) {
 	}
 	
 	// Atualizar o status da entrega
-	h.deliveryService.UpdateDeliveryStatus(messageId, "CANCELLED", "Envio cancelado pelo usuário")
+	h.deliveryService.UpdateDeliveryStatus(messageId, services.StatusCancelled, "Envio cancelado pelo usuário")
 	
 	c.JSON(http.StatusOK, gin.H{"message": "E-mail cancelado com sucesso"})
 }
@@ -415,10 +606,142 @@ func (h *Handler) GetDeliveryStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, status)
 }
 
-// GetAllDeliveryStatus lista todos os status de entrega recentes
+// GetDeliveryEvents obtém a linha do tempo de eventos de um e-mail
+func (h *Handler) GetDeliveryEvents(c *gin.Context) {
+	messageId := c.Param("messageId")
+	
+	events, err := h.deliveryService.GetDeliveryEvents(messageId)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "mensagem não encontrada") {
+			status = http.StatusNotFound
+		}
+		
+		c.JSON(status, gin.H{"error": "Falha ao obter eventos: " + err.Error()})
+		return
+	}
+	
+	c.JSON(http.StatusOK, events)
+}
+
+// ReceiveSESEvent processa as mensagens do SNS com eventos do SES
+func (h *Handler) ReceiveSESEvent(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Falha ao ler mensagem: " + err.Error()})
+		return
+	}
+	
+	if err := h.deliveryService.HandleSNSMessage(body); err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "não autorizado") || strings.Contains(err.Error(), "assinatura") {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": "Falha ao processar evento: " + err.Error()})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"message": "Evento processado"})
+}
+
+// GetAllDeliveryStatus busca os status de entrega com filtros, ordenação e paginação
 func (h *Handler) GetAllDeliveryStatus(c *gin.Context) {
-	statuses := h.deliveryService.GetAllDeliveryStatus()
-	c.JSON(http.StatusOK, statuses)
+	filter, ok := parseDeliveryFilter(c)
+	if !ok {
+		return
+	}
+	filter.FromEmail = c.Query("sender")
+	filter.Recipient = c.Query("recipient")
+	filter.Subject = c.Query("subject")
+	filter.Tag = c.Query("tag")
+	filter.Statuses = splitList(c.Query("status"))
+	
+	query := services.DeliveryQuery{
+		Filter: filter,
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+		Limit:  filter.Limit,
+		Fields: splitList(c.Query("fields")),
+	}
+	
+	page, err := h.deliveryService.SearchDeliveries(query)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "inválido") || strings.Contains(err.Error(), "desconhecido") {
+			status = http.StatusBadRequest
+		}
+		
+		c.JSON(status, gin.H{"error": "Falha ao listar status de entrega: " + err.Error()})
+		return
+	}
+	
+	c.JSON(http.StatusOK, page)
+}
+
+// splitList separa uma lista de valores separados por vírgula, ignorando itens vazios
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetRecipientDeliveries lista o que aconteceu com as mensagens enviadas a um destinatário
+func (h *Handler) GetRecipientDeliveries(c *gin.Context) {
+	filter, ok := parseDeliveryFilter(c)
+	if !ok {
+		return
+	}
+	filter.FromEmail = c.Query("sender")
+	filter.Recipient = c.Param("email")
+	
+	deliveries, err := h.deliveryService.GetRecipientDeliveries(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar entregas do destinatário: " + err.Error()})
+		return
+	}
+	
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// parseDeliveryFilter lê o período e o limite da query string, respondendo com erro se forem inválidos
+func parseDeliveryFilter(c *gin.Context) (services.DeliveryFilter, bool) {
+	var filter services.DeliveryFilter
+	
+	if startDate := c.Query("startDate"); startDate != "" {
+		start, err := time.Parse("2006-01-02", startDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de data inicial inválido (use YYYY-MM-DD)"})
+			return filter, false
+		}
+		filter.Start = start
+	}
+	
+	if endDate := c.Query("endDate"); endDate != "" {
+		end, err := time.Parse("2006-01-02", endDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de data final inválido (use YYYY-MM-DD)"})
+			return filter, false
+		}
+		filter.End = end.Add(24*time.Hour - time.Nanosecond)
+	}
+	
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		filter.Limit = limit
+	}
+	
+	return filter, true
+}
+
+// metricsErrorStatus retorna 400 para parâmetros inválidos e 500 para as demais falhas
+func metricsErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "inválid") || strings.Contains(err.Error(), "anterior à data inicial") {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
 }
 
 // GetRealTimeReport gera um relatório em tempo real das entregas recentes
@@ -432,11 +755,20 @@ func (h *Handler) GetRealTimeReport(c *gin.Context) {
 		}
 	}
 	
-	report, err := h.deliveryService.GetRealTimeReport(hours)
+	opts, err := services.ParseSeriesOptions(services.GranularityHour, c.Query("timezone"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	report, err := h.deliveryService.GetRealTimeReport(hours, opts.Location)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao gerar relatório: " + err.Error()})
 		return
 	}
 	
+	// Incluir as anomalias do período, detectadas em segundo plano
+	report.Anomalies = h.anomalyService.Recent(hours)
+	
 	c.JSON(http.StatusOK, report)
 }
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/renat/poc-ses/internal/services"
+)
+
+// GetDeliveryLatency godoc
+// @Summary      Obtém os percentis de latência de entrega
+// @Description  Retorna p50, p90 e p99 do tempo entre o envio e a entrega, geral e por remetente, domínio do destinatário e provedor de caixa postal, com a conformidade com o SLA
+// @Tags         delivery
+// @Accept       json
+// @Produce      json
+// @Param        sender     query     string  false  "Filtra pelo e-mail do remetente"
+// @Param        startDate  query     string  false  "Data inicial (formato: YYYY-MM-DD; padrão: 7 dias antes da data final; período máximo: 31 dias)"
+// @Param        endDate    query     string  false  "Data final (formato: YYYY-MM-DD)"
+// @Success      200        {object}  services.LatencyReport
+// @Failure      400        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /delivery/latency [get]
+func (h *Handler) GetDeliveryLatency(c *gin.Context) {
+	filter, ok := parseDeliveryFilter(c)
+	if !ok {
+		return
+	}
+	filter.FromEmail = c.Query("sender")
+
+	report, err := h.latencyService.GetLatencyReport(filter)
+	if err != nil {
+		c.JSON(metricsErrorStatus(err), gin.H{"error": "Falha ao obter latência de entrega: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetSLABreaches godoc
+// @Summary      Lista as mensagens fora do SLA de entrega
+// @Description  Retorna os destinatários entregues acima do limite de SLA ou ainda pendentes há mais tempo que o limite, dos mais lentos para os mais rápidos
+// @Tags         delivery
+// @Accept       json
+// @Produce      json
+// @Param        sender     query     string  false  "Filtra pelo e-mail do remetente"
+// @Param        startDate  query     string  false  "Data inicial (formato: YYYY-MM-DD; padrão: 7 dias antes da data final; período máximo: 31 dias)"
+// @Param        endDate    query     string  false  "Data final (formato: YYYY-MM-DD)"
+// @Param        limit      query     int     false  "Número máximo de resultados"
+// @Success      200        {array}   services.SLABreach
+// @Failure      400        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /delivery/sla/breaches [get]
+func (h *Handler) GetSLABreaches(c *gin.Context) {
+	filter, ok := parseDeliveryFilter(c)
+	if !ok {
+		return
+	}
+	filter.FromEmail = c.Query("sender")
+
+	breaches, err := h.latencyService.GetSLABreaches(filter)
+	if err != nil {
+		c.JSON(metricsErrorStatus(err), gin.H{"error": "Falha ao obter violações de SLA: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, breaches)
+}
+
+// GetSLAConfig godoc
+// @Summary      Obtém a configuração de SLA de entrega
+// @Description  Retorna o limite padrão e os limites por remetente do tempo entre envio e entrega
+// @Tags         delivery
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  services.SLAConfig
+// @Failure      500  {object}  map[string]string
+// @Router       /delivery/sla [get]
+func (h *Handler) GetSLAConfig(c *gin.Context) {
+	config, err := h.latencyService.GetSLAConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// UpdateSLAConfig godoc
+// @Summary      Atualiza a configuração de SLA de entrega
+// @Description  Define o limite padrão e os limites por remetente, em segundos, do tempo entre envio e entrega
+// @Tags         delivery
+// @Accept       json
+// @Produce      json
+// @Param        config  body      services.SLAConfig  true  "Configuração de SLA"
+// @Success      200     {object}  services.SLAConfig
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /delivery/sla [put]
+func (h *Handler) UpdateSLAConfig(c *gin.Context) {
+	var req services.SLAConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	config, err := h.latencyService.UpdateSLAConfig(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "inválido") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/renat/poc-ses/internal/services"
+)
+
+// CreateReportSubscription godoc
+// @Summary      Cria uma assinatura de relatório por e-mail
+// @Description  Agenda o envio de um resumo das métricas (conta ou remetente) com gráficos SVG inline e anexos CSV, conforme a expressão cron e o fuso horário informados
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Param        subscription  body      services.ReportSubscriptionRequest  true  "Definição da assinatura"
+// @Success      201           {object}  services.ReportSubscription
+// @Failure      400           {object}  map[string]string
+// @Failure      500           {object}  map[string]string
+// @Router       /reports/subscriptions [post]
+func (h *Handler) CreateReportSubscription(c *gin.Context) {
+	var req services.ReportSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Assinatura inválida: " + err.Error()})
+		return
+	}
+
+	subscription, err := h.reportService.CreateSubscription(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao criar assinatura: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, subscription)
+}
+
+// ListReportSubscriptions godoc
+// @Summary      Lista as assinaturas de relatórios
+// @Description  Retorna todas as assinaturas com o próximo envio agendado e o resultado do último envio
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   services.ReportSubscription
+// @Failure      500  {object}  map[string]string
+// @Router       /reports/subscriptions [get]
+func (h *Handler) ListReportSubscriptions(c *gin.Context) {
+	subscriptions, err := h.reportService.ListSubscriptions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar assinaturas: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// GetReportSubscription godoc
+// @Summary      Obtém uma assinatura de relatório
+// @Description  Retorna os dados de uma assinatura de relatório
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "ID da assinatura"
+// @Success      200  {object}  services.ReportSubscription
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /reports/subscriptions/{id} [get]
+func (h *Handler) GetReportSubscription(c *gin.Context) {
+	subscription, ok := h.findReportSubscription(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// DeleteReportSubscription godoc
+// @Summary      Remove uma assinatura de relatório
+// @Description  Remove a assinatura e cancela os envios agendados
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "ID da assinatura"
+// @Success      200  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /reports/subscriptions/{id} [delete]
+func (h *Handler) DeleteReportSubscription(c *gin.Context) {
+	subscription, ok := h.findReportSubscription(c)
+	if !ok {
+		return
+	}
+
+	if err := h.reportService.DeleteSubscription(subscription.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao remover assinatura: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Assinatura removida com sucesso"})
+}
+
+// SendReportNow godoc
+// @Summary      Envia um relatório imediatamente
+// @Description  Monta e envia o relatório da assinatura aos destinatários, sem alterar o próximo envio agendado
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "ID da assinatura"
+// @Success      200  {object}  services.ReportSubscription
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /reports/subscriptions/{id}/send [post]
+func (h *Handler) SendReportNow(c *gin.Context) {
+	subscription, err := h.reportService.SendNow(c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "pausado") {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": "Falha ao enviar relatório: " + err.Error()})
+		return
+	}
+
+	if subscription == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assinatura não encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// PreviewReport godoc
+// @Summary      Pré-visualiza um relatório
+// @Description  Retorna o HTML do relatório da assinatura com os dados atuais, sem enviá-lo
+// @Tags         reports
+// @Produce      html
+// @Param        id   path      string  true  "ID da assinatura"
+// @Success      200  {string}  string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /reports/subscriptions/{id}/preview [get]
+func (h *Handler) PreviewReport(c *gin.Context) {
+	subscription, ok := h.findReportSubscription(c)
+	if !ok {
+		return
+	}
+
+	html, err := h.reportService.Preview(subscription.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao montar relatório: " + err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+}
+
+// findReportSubscription obtém a assinatura do parâmetro id, respondendo 404 se não existir
+func (h *Handler) findReportSubscription(c *gin.Context) (*services.ReportSubscription, bool) {
+	subscription, err := h.reportService.GetSubscription(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao obter assinatura: " + err.Error()})
+		return nil, false
+	}
+
+	if subscription == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assinatura não encontrada"})
+		return nil, false
+	}
+
+	return subscription, true
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/renat/poc-ses/internal/services"
+)
+
+// PauseSenderRequest representa os dados da pausa manual de um remetente
+type PauseSenderRequest struct {
+	Reason string `json:"reason"`
+}
+
+// GetReputation godoc
+// @Summary      Obtém a avaliação dos monitores de reputação
+// @Description  Retorna as taxas de bounce e de reclamação da conta e de cada remetente na janela móvel configurada, com o nível de cada monitor e os remetentes pausados
+// @Tags         reputation
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  services.ReputationStatus
+// @Failure      500  {object}  map[string]string
+// @Router       /reputation [get]
+func (h *Handler) GetReputation(c *gin.Context) {
+	status, err := h.reputationService.GetStatus()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao avaliar reputação: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// GetReputationPolicy godoc
+// @Summary      Obtém a política de monitoramento de reputação
+// @Description  Retorna a janela, os limites de alerta e críticos, a pausa automática e os canais de alerta
+// @Tags         reputation
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  services.ReputationPolicy
+// @Failure      500  {object}  map[string]string
+// @Router       /reputation/policy [get]
+func (h *Handler) GetReputationPolicy(c *gin.Context) {
+	policy, err := h.reputationService.GetPolicy()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateReputationPolicy godoc
+// @Summary      Atualiza a política de monitoramento de reputação
+// @Description  Define a janela, os limites de bounce e de reclamação (em percentual), o volume mínimo, a pausa automática e os canais de alerta (webhook, email ou log)
+// @Tags         reputation
+// @Accept       json
+// @Produce      json
+// @Param        policy  body      services.ReputationPolicy  true  "Política de monitoramento"
+// @Success      200     {object}  services.ReputationPolicy
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /reputation/policy [put]
+func (h *Handler) UpdateReputationPolicy(c *gin.Context) {
+	var req services.ReputationPolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	policy, err := h.reputationService.UpdatePolicy(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "inválid") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// ListReputationAlerts godoc
+// @Summary      Lista os alertas de reputação
+// @Description  Retorna as mudanças de nível dos monitores de reputação, das mais recentes para as mais antigas
+// @Tags         reputation
+// @Accept       json
+// @Produce      json
+// @Param        limit  query     int  false  "Número máximo de resultados (padrão: 100)"
+// @Success      200    {array}   services.ReputationAlert
+// @Failure      500    {object}  map[string]string
+// @Router       /reputation/alerts [get]
+func (h *Handler) ListReputationAlerts(c *gin.Context) {
+	limit := 100
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	alerts, err := h.reputationService.ListAlerts(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar alertas: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+// ListPausedSenders godoc
+// @Summary      Lista os remetentes pausados
+// @Description  Retorna os remetentes cujos envios estão sendo recusados, pausados automaticamente pelo monitor de reputação ou manualmente
+// @Tags         reputation
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   services.SenderPause
+// @Failure      500  {object}  map[string]string
+// @Router       /reputation/paused [get]
+func (h *Handler) ListPausedSenders(c *gin.Context) {
+	paused, err := h.reputationService.ListPausedSenders()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar remetentes pausados: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, paused)
+}
+
+// PauseSender godoc
+// @Summary      Pausa um remetente
+// @Description  Recusa os envios do remetente até a retomada manual
+// @Tags         reputation
+// @Accept       json
+// @Produce      json
+// @Param        email   path      string                       true   "Endereço de e-mail do remetente"
+// @Param        pause   body      handlers.PauseSenderRequest  false  "Motivo da pausa"
+// @Success      200     {object}  services.SenderPause
+// @Failure      500     {object}  map[string]string
+// @Router       /senders/{email}/pause [post]
+func (h *Handler) PauseSender(c *gin.Context) {
+	var req PauseSenderRequest
+	// O corpo é opcional
+	_ = c.ShouldBindJSON(&req)
+
+	pause, err := h.reputationService.PauseSender(c.Param("email"), req.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pause)
+}
+
+// ResumeSender godoc
+// @Summary      Retoma os envios de um remetente pausado
+// @Description  Remove a pausa do remetente. O monitor passa a considerar apenas os envios posteriores à retomada.
+// @Tags         reputation
+// @Accept       json
+// @Produce      json
+// @Param        email  path      string  true  "Endereço de e-mail do remetente"
+// @Success      200    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /senders/{email}/resume [post]
+func (h *Handler) ResumeSender(c *gin.Context) {
+	if err := h.reputationService.ResumeSender(c.Param("email")); err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "não está pausado") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Remetente retomado com sucesso"})
+}
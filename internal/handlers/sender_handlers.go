@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/renat/poc-ses/internal/services"
+)
+
+// UpdateSender godoc
+// @Summary      Atualiza os dados de um remetente
+// @Description  Substitui o nome, o responsável, o time, o reply-to padrão, o configuration set padrão, as tags e as observações do remetente. Uma identidade criada fora da API passa a ser gerenciada pela API
+// @Tags         senders
+// @Accept       json
+// @Produce      json
+// @Param        email   path      string                   true  "Endereço de e-mail do remetente"
+// @Param        sender  body      services.SenderMetadata  true  "Dados do remetente"
+// @Success      200     {object}  services.SenderResponse
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /senders/{email} [put]
+func (h *Handler) UpdateSender(c *gin.Context) {
+	var req services.SenderMetadata
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	sender, err := h.senderRegistry.Update(c.Param("email"), req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "configuration set") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": "Falha ao atualizar remetente: " + err.Error()})
+		return
+	}
+
+	if sender == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Remetente não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sender)
+}
+
+// ListSenderDrift godoc
+// @Summary      Lista as divergências entre o cadastro e o SES
+// @Description  Retorna, conforme a última reconciliação, os remetentes removidos do SES (missingInSes) e as identidades criadas fora da API (unmanaged)
+// @Tags         senders
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   services.SenderResponse
+// @Failure      500  {object}  map[string]string
+// @Router       /senders/drift [get]
+func (h *Handler) ListSenderDrift(c *gin.Context) {
+	senders, err := h.senderRegistry.Drift()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar divergências: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, senders)
+}
+
+// ReconcileSenders godoc
+// @Summary      Reconcilia o cadastro de remetentes com o SES
+// @Description  Compara o cadastro com as identidades de e-mail do SES imediatamente, registrando as mudanças de status e sinalizando as divergências
+// @Tags         senders
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  services.SenderReconcileResult
+// @Failure      500  {object}  map[string]string
+// @Router       /senders/reconcile [post]
+func (h *Handler) ReconcileSenders(c *gin.Context) {
+	result, err := h.senderRegistry.Reconcile()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao reconciliar remetentes: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ResendVerification godoc
+// @Summary      Reenvia o e-mail de verificação de um remetente
+// @Description  Pede ao SES um novo e-mail de verificação (o link expira em 24 horas) e reinicia o acompanhamento do status
+// @Tags         senders
+// @Accept       json
+// @Produce      json
+// @Param        email  path      string  true  "Endereço de e-mail do remetente"
+// @Success      200    {object}  services.SenderResponse
+// @Failure      404    {object}  map[string]string
+// @Failure      409    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /senders/{email}/verification/resend [post]
+func (h *Handler) ResendVerification(c *gin.Context) {
+	sender, err := h.senderRegistry.ResendVerification(c.Param("email"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "já verificado") {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": "Falha ao reenviar verificação: " + err.Error()})
+		return
+	}
+
+	if sender == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Remetente não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sender)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/renat/poc-ses/internal/services"
+)
+
+// CreateVerificationTemplate godoc
+// @Summary      Cria um template do e-mail de verificação
+// @Description  Cria um template personalizado do e-mail enviado pelo SES para verificar os remetentes, com os endereços para onde o destinatário é redirecionado após a confirmação (successRedirectUrl) ou a falha (failureRedirectUrl). O remetente do template (fromEmailAddress) deve estar verificado
+// @Tags         verification-templates
+// @Accept       json
+// @Produce      json
+// @Param        template  body      services.VerificationTemplateRequest  true  "Template do e-mail de verificação"
+// @Success      201       {object}  services.VerificationTemplate
+// @Failure      400       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /verification-templates [post]
+func (h *Handler) CreateVerificationTemplate(c *gin.Context) {
+	var req services.VerificationTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.sesService.CreateVerificationTemplate(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "já existe") {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": "Falha ao criar template de verificação: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// ListVerificationTemplates godoc
+// @Summary      Lista os templates do e-mail de verificação
+// @Description  Retorna os templates do e-mail de verificação, sem o conteúdo
+// @Tags         verification-templates
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   services.VerificationTemplate
+// @Failure      500  {object}  map[string]string
+// @Router       /verification-templates [get]
+func (h *Handler) ListVerificationTemplates(c *gin.Context) {
+	templates, err := h.sesService.ListVerificationTemplates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar templates de verificação: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetVerificationTemplate godoc
+// @Summary      Obtém um template do e-mail de verificação
+// @Description  Retorna o template do e-mail de verificação, com o conteúdo
+// @Tags         verification-templates
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string  true  "Nome do template"
+// @Success      200   {object}  services.VerificationTemplate
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /verification-templates/{name} [get]
+func (h *Handler) GetVerificationTemplate(c *gin.Context) {
+	template, ok := h.findVerificationTemplate(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// UpdateVerificationTemplate godoc
+// @Summary      Atualiza um template do e-mail de verificação
+// @Description  Substitui o remetente, o assunto, o conteúdo e os endereços de redirecionamento do template
+// @Tags         verification-templates
+// @Accept       json
+// @Produce      json
+// @Param        name      path      string                                true  "Nome do template"
+// @Param        template  body      services.VerificationTemplateRequest  true  "Template do e-mail de verificação"
+// @Success      200       {object}  services.VerificationTemplate
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /verification-templates/{name} [put]
+func (h *Handler) UpdateVerificationTemplate(c *gin.Context) {
+	var req services.VerificationTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	// O nome do template é o da rota
+	req.Name = c.Param("name")
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.sesService.UpdateVerificationTemplate(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao atualizar template de verificação: " + err.Error()})
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template de verificação não encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteVerificationTemplate godoc
+// @Summary      Remove um template do e-mail de verificação
+// @Description  Remove o template do e-mail de verificação do SES
+// @Tags         verification-templates
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string  true  "Nome do template"
+// @Success      200   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /verification-templates/{name} [delete]
+func (h *Handler) DeleteVerificationTemplate(c *gin.Context) {
+	template, ok := h.findVerificationTemplate(c)
+	if !ok {
+		return
+	}
+
+	if err := h.sesService.DeleteVerificationTemplate(template.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao remover template de verificação: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template de verificação removido com sucesso"})
+}
+
+// findVerificationTemplate obtém o template do parâmetro name, respondendo 404 se não existir
+func (h *Handler) findVerificationTemplate(c *gin.Context) (*services.VerificationTemplate, bool) {
+	template, err := h.sesService.GetVerificationTemplate(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao obter template de verificação: " + err.Error()})
+		return nil, false
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template de verificação não encontrado"})
+		return nil, false
+	}
+
+	return template, true
+}
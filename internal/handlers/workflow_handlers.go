@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/renat/poc-ses/internal/services"
+)
+
+// CreateWorkflow godoc
+// @Summary      Cria um novo workflow de e-mails
+// @Description  Cria uma jornada com etapas, atrasos e condições baseadas em eventos de entrega
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        workflow  body      services.WorkflowRequest  true  "Definição do workflow"
+// @Success      201       {object}  services.Workflow
+// @Failure      400       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /workflows [post]
+func (h *Handler) CreateWorkflow(c *gin.Context) {
+	var req services.WorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Workflow inválido: " + err.Error()})
+		return
+	}
+
+	workflow, err := h.workflowService.CreateWorkflow(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao criar workflow: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, workflow)
+}
+
+// ListWorkflows godoc
+// @Summary      Lista todos os workflows
+// @Description  Retorna todos os workflows cadastrados
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   services.Workflow
+// @Failure      500  {object}  map[string]string
+// @Router       /workflows [get]
+func (h *Handler) ListWorkflows(c *gin.Context) {
+	workflows, err := h.workflowService.ListWorkflows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar workflows: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, workflows)
+}
+
+// GetWorkflow godoc
+// @Summary      Obtém um workflow
+// @Description  Retorna a definição de um workflow específico
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "ID do workflow"
+// @Success      200  {object}  services.Workflow
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /workflows/{id} [get]
+func (h *Handler) GetWorkflow(c *gin.Context) {
+	workflow, ok := h.findWorkflow(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow)
+}
+
+// DeleteWorkflow godoc
+// @Summary      Remove um workflow
+// @Description  Remove um workflow e encerra todas as suas inscrições ativas
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "ID do workflow"
+// @Success      200  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /workflows/{id} [delete]
+func (h *Handler) DeleteWorkflow(c *gin.Context) {
+	workflow, ok := h.findWorkflow(c)
+	if !ok {
+		return
+	}
+
+	if err := h.workflowService.DeleteWorkflow(workflow.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao remover workflow: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Workflow removido com sucesso"})
+}
+
+// EnrollContact godoc
+// @Summary      Inscreve um contato em um workflow
+// @Description  Inscreve um contato, agendando a primeira etapa do workflow
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id          path      string                      true  "ID do workflow"
+// @Param        enrollment  body      services.EnrollmentRequest  true  "Dados do contato"
+// @Success      201         {object}  services.Enrollment
+// @Failure      400         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      409         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /workflows/{id}/enrollments [post]
+func (h *Handler) EnrollContact(c *gin.Context) {
+	var req services.EnrollmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	workflow, ok := h.findWorkflow(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.workflowService.Enroll(workflow, req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "já inscrito") {
+			status = http.StatusConflict
+		}
+
+		c.JSON(status, gin.H{"error": "Falha ao inscrever contato: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, enrollment)
+}
+
+// ListEnrollments godoc
+// @Summary      Lista as inscrições de um workflow
+// @Description  Retorna todas as inscrições de um workflow com o histórico de etapas
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "ID do workflow"
+// @Success      200  {array}   services.Enrollment
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /workflows/{id}/enrollments [get]
+func (h *Handler) ListEnrollments(c *gin.Context) {
+	workflow, ok := h.findWorkflow(c)
+	if !ok {
+		return
+	}
+
+	enrollments, err := h.workflowService.ListEnrollments(workflow.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar inscrições: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollments)
+}
+
+// Unenroll godoc
+// @Summary      Encerra uma inscrição
+// @Description  Remove manualmente um contato de um workflow
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id            path      string  true  "ID do workflow"
+// @Param        enrollmentId  path      string  true  "ID da inscrição"
+// @Success      200           {object}  services.Enrollment
+// @Failure      404           {object}  map[string]string
+// @Failure      500           {object}  map[string]string
+// @Router       /workflows/{id}/enrollments/{enrollmentId} [delete]
+func (h *Handler) Unenroll(c *gin.Context) {
+	enrollment, err := h.workflowService.Unenroll(c.Param("id"), c.Param("enrollmentId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao encerrar inscrição: " + err.Error()})
+		return
+	}
+
+	if enrollment == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Inscrição não encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// GetWorkflowFunnel godoc
+// @Summary      Obtém as métricas de funil de um workflow
+// @Description  Retorna, por etapa, quantas inscrições foram alcançadas, enviadas, entregues, abertas e clicadas
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "ID do workflow"
+// @Success      200  {object}  services.WorkflowFunnel
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /workflows/{id}/funnel [get]
+func (h *Handler) GetWorkflowFunnel(c *gin.Context) {
+	workflow, ok := h.findWorkflow(c)
+	if !ok {
+		return
+	}
+
+	funnel, err := h.workflowService.GetFunnel(workflow)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao calcular funil: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, funnel)
+}
+
+// findWorkflow obtém o workflow do parâmetro de rota, respondendo com erro se não existir
+func (h *Handler) findWorkflow(c *gin.Context) (*services.Workflow, bool) {
+	workflow, err := h.workflowService.GetWorkflow(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao obter workflow: " + err.Error()})
+		return nil, false
+	}
+
+	if workflow == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workflow não encontrado"})
+		return nil, false
+	}
+
+	return workflow, true
+}
@@ -0,0 +1,170 @@
+// Package metrics expõe as métricas do serviço no formato do Prometheus
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "poc_ses"
+
+// Registry reúne todas as métricas expostas em /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	emailsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "emails_sent_total",
+		Help:      "Envios de e-mail por remetente cadastrado (demais remetentes em \"other\"), resultado e provedor.",
+	}, []string{"sender", "status", "provider"})
+
+	awsRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "aws_request_duration_seconds",
+		Help:      "Latência das chamadas às APIs da AWS por serviço e operação.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "operation"})
+
+	awsRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "aws_request_errors_total",
+		Help:      "Chamadas às APIs da AWS que falharam, por serviço e operação.",
+	}, []string{"service", "operation"})
+
+	deliveryEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "delivery_events_total",
+		Help:      "Eventos de entrega registrados, por tipo.",
+	}, []string{"type"})
+
+	suppressionHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "suppression_hits_total",
+		Help:      "Envios descartados por destinatários em lista de supressão, por motivo.",
+	}, []string{"reason"})
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Requisições HTTP atendidas, por rota, método e status.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latência das requisições HTTP, por rota e método.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	cloudWatchRates = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cloudwatch_rate_percent",
+		Help:      "Taxas de entrega, abertura, clique, bounce e reclamação calculadas a partir do CloudWatch.",
+	}, []string{"metric"})
+
+	cloudWatchTotals = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cloudwatch_total",
+		Help:      "Totais de eventos do SES no período consultado no CloudWatch.",
+	}, []string{"metric"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		emailsSent,
+		awsRequestDuration,
+		awsRequestErrors,
+		deliveryEvents,
+		suppressionHits,
+		httpRequests,
+		httpRequestDuration,
+		cloudWatchRates,
+		cloudWatchTotals,
+	)
+}
+
+// Handler retorna o handler HTTP que expõe as métricas no formato do Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// GinMiddleware registra a contagem e a latência das requisições por rota do Gin
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		// Usar o padrão da rota para não criar uma série por parâmetro
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		httpRequests.WithLabelValues(route, c.Request.Method, strconv.Itoa(c.Writer.Status())).Inc()
+		httpRequestDuration.WithLabelValues(route, c.Request.Method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// InstrumentAWS adiciona à pilha de middlewares dos clientes da AWS a medição
+// de latência e erros por operação. Deve ser incluído em aws.Config.APIOptions.
+func InstrumentAWS(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("PrometheusMetrics",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			start := time.Now()
+			out, metadata, err := next.HandleInitialize(ctx, in)
+
+			service, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+			awsRequestDuration.WithLabelValues(service, operation).Observe(time.Since(start).Seconds())
+			if err != nil {
+				awsRequestErrors.WithLabelValues(service, operation).Inc()
+			}
+
+			return out, metadata, err
+		}), middleware.After)
+}
+
+// ObserveSend registra o resultado de um envio de e-mail
+func ObserveSend(sender, status, provider string) {
+	emailsSent.WithLabelValues(sender, status, provider).Inc()
+}
+
+// ObserveDeliveryEvent registra um evento de entrega
+func ObserveDeliveryEvent(eventType string) {
+	deliveryEvents.WithLabelValues(eventType).Inc()
+}
+
+// ObserveSuppression registra um envio descartado por lista de supressão
+func ObserveSuppression(reason string) {
+	suppressionHits.WithLabelValues(reason).Inc()
+}
+
+// RegisterQueue expõe a profundidade de uma fila, consultada a cada coleta
+func RegisterQueue(queue string, depth func() float64) {
+	Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "queue_depth",
+		Help:        "Itens aguardando processamento, por fila.",
+		ConstLabels: prometheus.Labels{"queue": queue},
+	}, depth))
+}
+
+// SetCloudWatchMetrics atualiza os gauges com as taxas e totais obtidos do CloudWatch
+func SetCloudWatchMetrics(rates, totals map[string]float64) {
+	for name, value := range rates {
+		cloudWatchRates.WithLabelValues(name).Set(value)
+	}
+	for name, value := range totals {
+		cloudWatchTotals.WithLabelValues(name).Set(value)
+	}
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+)
+
+// ErrSendingSuspended indica que o envio foi recusado por uma suspensão (kill
+// switch ou remetente pausado), e não por um problema da mensagem
+var ErrSendingSuspended = errors.New("envios suspensos")
+
+// suspendedRetryDelay é o intervalo para tentar novamente os envios agendados
+// recusados por uma suspensão
+const suspendedRetryDelay = 5 * time.Minute
+
+// Status de revisão da conta, estimado a partir das métricas de reputação
+// do SES e do status de envio da conta
+const (
+	EnforcementHealthy       = "healthy"
+	EnforcementUnderReview   = "underReview"
+	EnforcementAtRisk        = "atRisk"
+	EnforcementSendingPaused = "sendingPaused"
+)
+
+// Limites de reputação em que o SES coloca a conta em revisão ou pode pausar
+// os envios, em percentual
+const (
+	sesBounceReviewRate    = 5.0
+	sesBounceAtRiskRate    = 10.0
+	sesComplaintReviewRate = 0.1
+	sesComplaintAtRiskRate = 0.5
+)
+
+// KillSwitch representa a suspensão interna de todos os envios, independente
+// do status da conta no SES
+type KillSwitch struct {
+	Reason    string    `json:"reason"`
+	EngagedAt time.Time `json:"engagedAt"`
+}
+
+// SendTotals representa o total de envios no período das estatísticas do
+// SES, com as taxas em percentual das tentativas de entrega
+type SendTotals struct {
+	DeliveryAttempts int64   `json:"deliveryAttempts"`
+	Bounces          int64   `json:"bounces"`
+	Complaints       int64   `json:"complaints"`
+	Rejects          int64   `json:"rejects"`
+	BounceRate       float64 `json:"bounceRate"`
+	ComplaintRate    float64 `json:"complaintRate"`
+}
+
+// AccountDashboard reúne o status de envio, a cota, as estatísticas das
+// últimas duas semanas e a reputação da conta. As seções que não puderam ser
+// obtidas ficam ausentes e o motivo é informado em Errors, pelo nome da seção.
+type AccountDashboard struct {
+	SendingEnabled     *bool                     `json:"sendingEnabled,omitempty"`
+	KillSwitch         *KillSwitch               `json:"killSwitch,omitempty"`
+	EnforcementStatus  string                    `json:"enforcementStatus,omitempty"`
+	EnforcementReasons []string                  `json:"enforcementReasons,omitempty"`
+	Quota              *SendQuota                `json:"quota,omitempty"`
+	Reputation         *AccountReputationMetrics `json:"reputation,omitempty"`
+	Monitor            *MonitorState             `json:"monitor,omitempty"`
+	PausedSenders      []SenderPause             `json:"pausedSenders"`
+	Totals             *SendTotals               `json:"totals,omitempty"`
+	Statistics         []SendStatistic           `json:"statistics"`
+	Errors             map[string]string         `json:"errors,omitempty"`
+	GeneratedAt        time.Time                 `json:"generatedAt"`
+}
+
+// AccountService controla os envios da conta: o status de envio no SES e um
+// kill switch interno, verificado em todos os envios do SESService mesmo com
+// o SES disponível
+type AccountService struct {
+	store             AccountStore
+	sesService        *SESService
+	reputationService *ReputationService
+}
+
+// NewAccountService cria uma nova instância do AccountService, registrando a
+// verificação do kill switch nos envios do SESService
+func NewAccountService(store AccountStore, sesService *SESService, reputationService *ReputationService) *AccountService {
+	s := &AccountService{
+		store:             store,
+		sesService:        sesService,
+		reputationService: reputationService,
+	}
+
+	sesService.AddSendGuard(s.checkKillSwitch)
+	return s
+}
+
+// checkKillSwitch recusa os envios enquanto o kill switch estiver acionado.
+// Os alertas da aplicação são liberados, pois avisam justamente da situação
+// que costuma acionar o kill switch.
+func (s *AccountService) checkKillSwitch(req EmailRequest) error {
+	killSwitch, err := s.store.GetKillSwitch()
+	if err != nil {
+		return fmt.Errorf("falha ao verificar kill switch: %w", err)
+	}
+	if killSwitch != nil && req.systemAlert {
+		log.Printf("Kill switch acionado: alerta %q de %s enviado mesmo assim", req.Subject, req.From)
+		return nil
+	}
+	if killSwitch != nil {
+		return fmt.Errorf("%w pelo kill switch desde %s: %s", ErrSendingSuspended, killSwitch.EngagedAt.Format(time.RFC3339), killSwitch.Reason)
+	}
+	return nil
+}
+
+// isSendingSuspended indica se o envio foi recusado por uma suspensão interna
+// ou por envios pausados no SES (conta ou configuration set). Os envios
+// agendados recusados assim são reagendados sem contar como tentativa.
+func isSendingSuspended(err error) bool {
+	var accountPaused *types.AccountSendingPausedException
+	var setPaused *types.ConfigurationSetSendingPausedException
+	return errors.Is(err, ErrSendingSuspended) || errors.As(err, &accountPaused) || errors.As(err, &setPaused)
+}
+
+// GetKillSwitch retorna o kill switch, ou nil se ele não está acionado
+func (s *AccountService) GetKillSwitch() (*KillSwitch, error) {
+	return s.store.GetKillSwitch()
+}
+
+// EngageKillSwitch suspende todos os envios até a liberação manual
+func (s *AccountService) EngageKillSwitch(reason string) (*KillSwitch, error) {
+	if reason == "" {
+		reason = "acionado manualmente"
+	}
+
+	killSwitch := KillSwitch{Reason: reason, EngagedAt: time.Now()}
+	if err := s.store.SaveKillSwitch(killSwitch); err != nil {
+		return nil, fmt.Errorf("falha ao acionar kill switch: %w", err)
+	}
+
+	log.Printf("Kill switch acionado, envios suspensos: %s", reason)
+	return &killSwitch, nil
+}
+
+// ReleaseKillSwitch libera os envios suspensos pelo kill switch
+func (s *AccountService) ReleaseKillSwitch() error {
+	killSwitch, err := s.store.GetKillSwitch()
+	if err != nil {
+		return fmt.Errorf("falha ao obter kill switch: %w", err)
+	}
+	if killSwitch == nil {
+		return fmt.Errorf("kill switch não está acionado")
+	}
+
+	if err := s.store.DeleteKillSwitch(); err != nil {
+		return fmt.Errorf("falha ao liberar kill switch: %w", err)
+	}
+
+	log.Printf("Kill switch liberado, envios retomados")
+	return nil
+}
+
+// SetSendingEnabled habilita ou desabilita os envios da conta no SES
+func (s *AccountService) SetSendingEnabled(enabled bool) error {
+	if err := s.sesService.SetAccountSendingEnabled(enabled); err != nil {
+		return err
+	}
+
+	if enabled {
+		log.Printf("Envios da conta habilitados no SES")
+	} else {
+		log.Printf("Envios da conta desabilitados no SES")
+	}
+	return nil
+}
+
+// GetDashboard reúne o status de envio, a cota, as estatísticas e a
+// reputação da conta no SES e no monitor local. A falha de uma seção não
+// impede as demais: ela é registrada em Errors e a seção fica ausente.
+func (s *AccountService) GetDashboard() *AccountDashboard {
+	dashboard := &AccountDashboard{
+		PausedSenders: []SenderPause{},
+		Statistics:    []SendStatistic{},
+		Errors:        make(map[string]string),
+		GeneratedAt:   time.Now(),
+	}
+
+	if enabled, err := s.sesService.GetAccountSendingEnabled(); err != nil {
+		dashboard.Errors["sendingEnabled"] = err.Error()
+	} else {
+		dashboard.SendingEnabled = &enabled
+	}
+
+	if killSwitch, err := s.store.GetKillSwitch(); err != nil {
+		dashboard.Errors["killSwitch"] = fmt.Sprintf("falha ao obter kill switch: %v", err)
+	} else {
+		dashboard.KillSwitch = killSwitch
+	}
+
+	if quota, err := s.sesService.GetSendQuota(); err != nil {
+		dashboard.Errors["quota"] = err.Error()
+	} else {
+		dashboard.Quota = quota
+	}
+
+	if statistics, err := s.sesService.GetSendStatistics(); err != nil {
+		dashboard.Errors["statistics"] = err.Error()
+	} else {
+		totals := sendTotals(statistics)
+		dashboard.Statistics = statistics
+		dashboard.Totals = &totals
+	}
+
+	if reputation, err := s.sesService.GetAccountReputationMetrics(); err != nil {
+		dashboard.Errors["reputation"] = err.Error()
+	} else {
+		dashboard.Reputation = reputation
+	}
+
+	if status, err := s.reputationService.GetStatus(); err != nil {
+		dashboard.Errors["monitor"] = err.Error()
+	} else {
+		dashboard.Monitor = &status.Account
+		dashboard.PausedSenders = status.Paused
+	}
+
+	// O status de revisão depende do status de envio e, com os envios
+	// habilitados, também das métricas de reputação
+	if enabled := dashboard.SendingEnabled; enabled != nil && (dashboard.Reputation != nil || !*enabled) {
+		reputation := dashboard.Reputation
+		if reputation == nil {
+			reputation = &AccountReputationMetrics{}
+		}
+		dashboard.EnforcementStatus, dashboard.EnforcementReasons = enforcementStatus(*enabled, reputation)
+	}
+
+	return dashboard
+}
+
+// enforcementStatus estima o status de revisão da conta a partir das taxas
+// de reputação e dos limites do SES
+func enforcementStatus(sendingEnabled bool, reputation *AccountReputationMetrics) (string, []string) {
+	var reasons []string
+	status := EnforcementHealthy
+
+	check := func(name string, rate *float64, review, atRisk float64) {
+		if rate == nil || *rate < review {
+			return
+		}
+		if *rate >= atRisk {
+			status = EnforcementAtRisk
+			reasons = append(reasons, fmt.Sprintf("taxa de %s de %.2f%% acima de %.1f%%", name, *rate, atRisk))
+			return
+		}
+		if status == EnforcementHealthy {
+			status = EnforcementUnderReview
+		}
+		reasons = append(reasons, fmt.Sprintf("taxa de %s de %.2f%% acima de %.1f%%", name, *rate, review))
+	}
+	check("bounce", reputation.BounceRate, sesBounceReviewRate, sesBounceAtRiskRate)
+	check("reclamação", reputation.ComplaintRate, sesComplaintReviewRate, sesComplaintAtRiskRate)
+
+	// Os envios desabilitados (pelo SES ou manualmente) prevalecem sobre as taxas
+	if !sendingEnabled {
+		status = EnforcementSendingPaused
+		reasons = append([]string{"envios da conta desabilitados no SES"}, reasons...)
+	}
+
+	return status, reasons
+}
+
+// sendTotals soma as estatísticas de envio e calcula as taxas do período
+func sendTotals(statistics []SendStatistic) SendTotals {
+	var totals SendTotals
+	for _, point := range statistics {
+		totals.DeliveryAttempts += point.DeliveryAttempts
+		totals.Bounces += point.Bounces
+		totals.Complaints += point.Complaints
+		totals.Rejects += point.Rejects
+	}
+
+	if totals.DeliveryAttempts > 0 {
+		totals.BounceRate = float64(totals.Bounces) / float64(totals.DeliveryAttempts) * 100
+		totals.ComplaintRate = float64(totals.Complaints) / float64(totals.DeliveryAttempts) * 100
+	}
+	return totals
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAccountServiceCheckKillSwitch(t *testing.T) {
+	store, err := NewBoltAccountStore(openTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := &AccountService{store: store}
+
+	send := EmailRequest{From: "contato@exemplo.com", To: []string{"cliente@exemplo.com"}, Subject: "Oferta"}
+	alert := EmailRequest{From: "alertas@exemplo.com", To: []string{"ops@exemplo.com"}, Subject: "Reputação crítica", systemAlert: true}
+
+	tests := []struct {
+		name      string
+		engaged   bool
+		req       EmailRequest
+		suspended bool
+	}{
+		{name: "envio liberado", req: send},
+		{name: "alerta liberado", req: alert},
+		{name: "envio bloqueado pelo kill switch", engaged: true, req: send, suspended: true},
+		{name: "alerta liberado com o kill switch", engaged: true, req: alert},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.engaged {
+				if _, err := service.EngageKillSwitch("incidente"); err != nil {
+					t.Fatal(err)
+				}
+			} else if err := store.DeleteKillSwitch(); err != nil {
+				t.Fatal(err)
+			}
+
+			err := service.checkKillSwitch(tt.req)
+			if tt.suspended != errors.Is(err, ErrSendingSuspended) {
+				t.Errorf("checkKillSwitch() = %v, esperado suspenso: %v", err, tt.suspended)
+			}
+			if !tt.suspended && err != nil {
+				t.Errorf("erro inesperado: %v", err)
+			}
+		})
+	}
+}
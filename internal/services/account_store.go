@@ -0,0 +1,65 @@
+package services
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+const accountBucket = "account"
+
+// killSwitchKey é a chave do kill switch de envios no bucket
+const killSwitchKey = "kill_switch"
+
+// AccountStore define a persistência dos controles de envio da conta
+type AccountStore interface {
+	// GetKillSwitch retorna o kill switch, ou nil se ele não está acionado
+	GetKillSwitch() (*KillSwitch, error)
+	// SaveKillSwitch grava (ou substitui) o kill switch acionado
+	SaveKillSwitch(killSwitch KillSwitch) error
+	// DeleteKillSwitch remove o kill switch, liberando os envios
+	DeleteKillSwitch() error
+}
+
+// BoltAccountStore persiste os controles de envio da conta no banco embarcado
+type BoltAccountStore struct {
+	db *bolt.DB
+}
+
+// NewBoltAccountStore cria uma nova instância do BoltAccountStore
+func NewBoltAccountStore(db *bolt.DB) (*BoltAccountStore, error) {
+	if err := ensureBuckets(db, accountBucket); err != nil {
+		return nil, err
+	}
+
+	return &BoltAccountStore{db: db}, nil
+}
+
+// GetKillSwitch retorna o kill switch, ou nil se ele não está acionado
+func (s *BoltAccountStore) GetKillSwitch() (*KillSwitch, error) {
+	var killSwitch KillSwitch
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket([]byte(accountBucket)), killSwitchKey, &killSwitch)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &killSwitch, nil
+}
+
+// SaveKillSwitch grava (ou substitui) o kill switch acionado
+func (s *BoltAccountStore) SaveKillSwitch(killSwitch KillSwitch) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket([]byte(accountBucket)), killSwitchKey, killSwitch)
+	})
+}
+
+// DeleteKillSwitch remove o kill switch, liberando os envios
+func (s *BoltAccountStore) DeleteKillSwitch() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(accountBucket)).Delete([]byte(killSwitchKey))
+	})
+}
@@ -0,0 +1,558 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dimensões de agregação das métricas locais
+const (
+	DimensionSender           = "sender"
+	DimensionTemplate         = "template"
+	DimensionTag              = "tag"
+	DimensionDomain           = "domain"
+	DimensionCampaign         = "campaign"
+	DimensionConfigurationSet = "configurationSet"
+)
+
+// analyticsDimensions lista as dimensões aceitas nas consultas
+var analyticsDimensions = map[string]bool{
+	DimensionSender:           true,
+	DimensionTemplate:         true,
+	DimensionTag:              true,
+	DimensionDomain:           true,
+	DimensionCampaign:         true,
+	DimensionConfigurationSet: true,
+}
+
+// campaignTag é a tag que identifica a campanha de um envio
+const campaignTag = "campaign"
+
+// analyticsCounterFor mapeia o tipo de evento para o contador incrementado
+var analyticsCounterFor = map[string]func(c *AnalyticsCounters){
+	StatusSent:       func(c *AnalyticsCounters) { c.Sent++ },
+	StatusDelivered:  func(c *AnalyticsCounters) { c.Delivered++ },
+	StatusOpened:     func(c *AnalyticsCounters) { c.Opened++ },
+	StatusClicked:    func(c *AnalyticsCounters) { c.Clicked++ },
+	StatusBounced:    func(c *AnalyticsCounters) { c.Bounced++ },
+	StatusComplained: func(c *AnalyticsCounters) { c.Complaints++ },
+}
+
+// AnalyticsService agrega os eventos de entrega e engajamento registrados
+// localmente em tabelas por hora, por remetente, template, tag, domínio do
+// destinatário, campanha e configuration set. Assim como no CloudWatch, os
+// contadores são por destinatário e aberturas e cliques contam cada evento.
+type AnalyticsService struct {
+	store           AnalyticsStore
+	deliveryStore   DeliveryStore
+	deliveryService *DeliveryService
+	rebuild         *rebuildState
+	mutex           sync.Mutex
+}
+
+// NewAnalyticsService cria uma nova instância do AnalyticsService, registrando-o
+// como listener dos eventos de entrega
+func NewAnalyticsService(store AnalyticsStore, deliveryStore DeliveryStore, deliveryService *DeliveryService) *AnalyticsService {
+	s := &AnalyticsService{
+		store:           store,
+		deliveryStore:   deliveryStore,
+		deliveryService: deliveryService,
+	}
+
+	deliveryService.Subscribe(s.handleDeliveryEvent)
+	return s
+}
+
+// handleDeliveryEvent atualiza as tabelas de agregação com o evento. Durante
+// um recálculo, eventos de mensagens já recalculadas também são aplicados à
+// tabela de reconstrução.
+func (s *AnalyticsService) handleDeliveryEvent(status DeliveryStatus, event DeliveryEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	increments := rollupIncrements(status, event)
+	if err := s.store.Add(increments); err != nil {
+		log.Printf("Falha ao agregar evento %s da mensagem %s: %v", event.Type, event.MessageID, err)
+	}
+
+	if s.rebuild != nil && s.rebuild.covers(status) {
+		if err := s.store.AddRebuild(s.rebuild.filter(increments)); err != nil {
+			log.Printf("Falha ao agregar evento %s da mensagem %s no recálculo: %v", event.Type, event.MessageID, err)
+		}
+	}
+}
+
+// rebuildTimeout é quanto o Rebuild aguarda os eventos em andamento
+// terminarem antes de cada lote
+const rebuildTimeout = 30 * time.Second
+
+// rebuildBatchSize é a quantidade de mensagens recalculadas a cada pausa dos eventos
+const rebuildBatchSize = 100
+
+// rebuildState acompanha um recálculo em andamento
+type rebuildState struct {
+	// since é a primeira hora recalculada
+	since time.Time
+	// position é a posição (data de envio e ID) da última mensagem recalculada
+	position string
+}
+
+// deliveryPosition retorna a posição da mensagem na ordem de envio do DeliveryStore
+func deliveryPosition(status DeliveryStatus) string {
+	return timeKey(status.SentAt) + "\x00" + status.MessageID
+}
+
+// covers indica se a mensagem já foi recalculada
+func (r *rebuildState) covers(status DeliveryStatus) bool {
+	return deliveryPosition(status) <= r.position
+}
+
+// filter descarta os incrementos anteriores à primeira hora recalculada
+func (r *rebuildState) filter(increments []RollupIncrement) []RollupIncrement {
+	var result []RollupIncrement
+	for _, inc := range increments {
+		if !inc.Hour.Before(r.since) {
+			result = append(result, inc)
+		}
+	}
+	return result
+}
+
+// Rebuild recalcula as tabelas de agregação a partir dos status e eventos
+// armazenados, retornando a quantidade de mensagens processadas.
+//
+// Apenas as horas a partir do envio mais antigo ainda retido são
+// recalculadas; as anteriores, cujos status já foram removidos pela
+// retenção, são mantidas. O resultado é montado em uma tabela de
+// reconstrução e substitui essas horas em uma única transação, de modo que
+// uma falha no meio do caminho não altera as agregações. Os eventos são
+// pausados apenas durante cada lote de mensagens.
+func (s *AnalyticsService) Rebuild() (int, error) {
+	oldest, err := s.deliveryStore.ListAfter(DeliveryFilter{Limit: 1}, time.Time{}, "", false)
+	if err != nil {
+		return 0, err
+	}
+	if len(oldest) == 0 {
+		return 0, nil
+	}
+
+	// A hora do envio mais antigo pode conter eventos de mensagens já removidas
+	since := oldest[0].SentAt.UTC().Truncate(time.Hour)
+	if since.Before(oldest[0].SentAt) {
+		since = since.Add(time.Hour)
+	}
+
+	s.mutex.Lock()
+	if s.rebuild != nil {
+		s.mutex.Unlock()
+		return 0, fmt.Errorf("recálculo das métricas já em andamento")
+	}
+	if err := s.store.BeginRebuild(); err != nil {
+		s.mutex.Unlock()
+		return 0, fmt.Errorf("falha ao iniciar recálculo: %w", err)
+	}
+	s.rebuild = &rebuildState{since: since}
+	s.mutex.Unlock()
+
+	processed, err := s.rebuildAll()
+	if err != nil {
+		s.mutex.Lock()
+		s.rebuild = nil
+		s.mutex.Unlock()
+
+		if discardErr := s.store.DiscardRebuild(); discardErr != nil {
+			log.Printf("Falha ao descartar recálculo das métricas: %v", discardErr)
+		}
+		return 0, err
+	}
+
+	return processed, nil
+}
+
+// rebuildAll recalcula as mensagens em lotes e, com os eventos pausados,
+// recalcula as mensagens enviadas durante a varredura e aplica o resultado
+func (s *AnalyticsService) rebuildAll() (int, error) {
+	processed := 0
+	batch := make([]DeliveryStatus, 0, rebuildBatchSize)
+	flush := func() error {
+		err := s.deliveryService.PauseEvents(rebuildTimeout, func() error {
+			return s.rebuildBatch(batch)
+		})
+		processed += len(batch)
+		batch = batch[:0]
+		return err
+	}
+
+	err := s.deliveryStore.Iterate(DeliveryFilter{}, func(status DeliveryStatus) error {
+		batch = append(batch, status)
+		if len(batch) < rebuildBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil && len(batch) > 0 {
+		err = flush()
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.deliveryService.PauseEvents(rebuildTimeout, func() error {
+		s.mutex.Lock()
+		last := s.rebuild.position
+		s.mutex.Unlock()
+
+		sentAt, messageID := parseDeliveryPosition(last)
+		remaining, err := s.deliveryStore.ListAfter(DeliveryFilter{}, sentAt, messageID, false)
+		if err != nil {
+			return err
+		}
+		if err := s.rebuildBatch(remaining); err != nil {
+			return err
+		}
+		processed += len(remaining)
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if err := s.store.CommitRebuild(s.rebuild.since); err != nil {
+			return fmt.Errorf("falha ao aplicar recálculo: %w", err)
+		}
+		s.rebuild = nil
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return processed, nil
+}
+
+// rebuildBatch recalcula um lote de mensagens na tabela de reconstrução.
+// Deve ser chamado com os eventos pausados, para que cada evento seja
+// contado pela varredura ou pelo listener, nunca pelos dois.
+func (s *AnalyticsService) rebuildBatch(statuses []DeliveryStatus) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var increments []RollupIncrement
+	for _, status := range statuses {
+		// Relê o status, que pode ter recebido destinatários desde a leitura do lote
+		current, err := s.deliveryStore.Get(status.MessageID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			continue
+		}
+
+		events, err := s.deliveryStore.ListEvents(status.MessageID)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			increments = append(increments, s.rebuild.filter(rollupIncrements(*current, event))...)
+		}
+
+		if position := deliveryPosition(status); position > s.rebuild.position {
+			s.rebuild.position = position
+		}
+	}
+
+	return s.store.AddRebuild(increments)
+}
+
+// parseDeliveryPosition separa a data de envio e o ID de uma posição
+func parseDeliveryPosition(position string) (time.Time, string) {
+	nanos, messageID, found := strings.Cut(position, "\x00")
+	if !found {
+		return time.Time{}, ""
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, ""
+	}
+	return time.Unix(0, n), messageID
+}
+
+// GetMetrics obtém as métricas de um valor da dimensão (ex: remetente
+// "contato@exemplo.com" ou tag "campaign:black-friday"), na granularidade e
+// fuso horário informados. A granularidade mínima é de uma hora. Com
+// compareTo, inclui as métricas do período equivalente de comparação.
+func (s *AnalyticsService) GetMetrics(dimension, value, startDateStr, endDateStr string, opts SeriesOptions, compareTo string) (*SenderMetricsResponse, error) {
+	if !analyticsDimensions[dimension] {
+		return nil, fmt.Errorf("dimensão inválida: %s", dimension)
+	}
+	if opts.Granularity == Granularity5Minutes {
+		return nil, fmt.Errorf("granularidade inválida para métricas locais: %s", opts.Granularity)
+	}
+	if err := ValidateCompareTo(compareTo); err != nil {
+		return nil, err
+	}
+
+	startDate, endDate, err := parseDateRange(startDateStr, endDateStr, opts.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	value = normalizeDimensionValue(dimension, value)
+	total, result, series, err := s.querySeries(dimension, value, startDate, endDate, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := buildSeries(result, startDate, endDate, SeriesOptions{Granularity: GranularityDay, Location: opts.Location})
+	if err != nil {
+		return nil, err
+	}
+
+	response := countersResponse(dimension, value, total)
+	response.Period = fmt.Sprintf("%s a %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	response.Granularity = opts.Granularity
+	response.Timezone = opts.Location.String()
+	response.Series = series
+	response.Trend = seriesTrends(series)
+	response.DailyStats = make([]DailyMetrics, 0, len(days))
+	for _, day := range days {
+		response.DailyStats = append(response.DailyStats, DailyMetrics{
+			Date:         day.Timestamp.Format("2006-01-02"),
+			Sent:         day.Sent,
+			Delivered:    day.Delivered,
+			Opened:       day.Opened,
+			Clicked:      day.Clicked,
+			Bounced:      day.Bounced,
+			Complaints:   day.Complaints,
+			DeliveryRate: day.DeliveryRate,
+			OpenRate:     day.OpenRate,
+			ClickRate:    day.ClickRate,
+		})
+	}
+
+	// Consultar o período equivalente de comparação, se solicitado
+	if compareTo != "" {
+		prevStart, prevEnd := comparisonRange(compareTo, startDate, endDate)
+		_, previous, previousSeries, err := s.querySeries(dimension, value, prevStart, prevEnd, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		prevPeriod := fmt.Sprintf("%s a %s", prevStart.Format("2006-01-02"), prevEnd.Format("2006-01-02"))
+		response.Comparison = newMetricsComparison(compareTo, prevPeriod, result, previous, previousSeries)
+	}
+
+	return response, nil
+}
+
+// querySeries obtém as linhas por hora de um valor da dimensão no intervalo,
+// retornando os contadores totais, o resultado no formato usado pelas séries
+// do CloudWatch e a série na granularidade e fuso pedidos
+func (s *AnalyticsService) querySeries(dimension, value string, start, end time.Time, opts SeriesOptions) (AnalyticsCounters, *MetricsResult, []TimeSeriesPoint, error) {
+	var total AnalyticsCounters
+
+	rows, err := s.store.Rows(dimension, value, opts.bucketStart(start), end)
+	if err != nil {
+		return total, nil, nil, err
+	}
+
+	result := &MetricsResult{Points: make([]MetricPoint, 0, len(rows))}
+	for _, row := range rows {
+		total.add(row.Counters)
+		result.Points = append(result.Points, countersPoint(row.Hour, row.Counters))
+	}
+	result.Totals = total.totals()
+
+	series, err := buildSeries(result, start, end, opts)
+	if err != nil {
+		return total, nil, nil, err
+	}
+
+	return total, result, series, nil
+}
+
+// GetBreakdown obtém os totais de cada valor da dimensão no período, ordenados
+// pela quantidade de envios, com as datas no fuso horário informado. Limit
+// zero retorna todos os valores.
+func (s *AnalyticsService) GetBreakdown(dimension, startDateStr, endDateStr string, loc *time.Location, limit int) ([]SenderMetricsResponse, error) {
+	if !analyticsDimensions[dimension] {
+		return nil, fmt.Errorf("dimensão inválida: %s", dimension)
+	}
+
+	startDate, endDate, err := parseDateRange(startDateStr, endDateStr, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := s.store.Totals(dimension, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	period := fmt.Sprintf("%s a %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	result := make([]SenderMetricsResponse, 0, len(totals))
+	for value, counters := range totals {
+		response := countersResponse(dimension, value, counters)
+		response.Period = period
+		result = append(result, *response)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalSent != result[j].TotalSent {
+			return result[i].TotalSent > result[j].TotalSent
+		}
+		return result[i].Value < result[j].Value
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// rollupIncrements calcula os incrementos das tabelas de agregação para um
+// evento. Cada destinatário afetado conta uma vez em cada dimensão da mensagem
+// e no domínio do seu endereço.
+func rollupIncrements(status DeliveryStatus, event DeliveryEvent) []RollupIncrement {
+	count, tracked := analyticsCounterFor[event.Type]
+	if !tracked {
+		return nil
+	}
+
+	var counters AnalyticsCounters
+	count(&counters)
+
+	var increments []RollupIncrement
+	add := func(dimension, value string) {
+		if value == "" {
+			return
+		}
+		increments = append(increments, RollupIncrement{
+			Dimension: dimension,
+			Value:     normalizeDimensionValue(dimension, value),
+			Hour:      event.Timestamp,
+			Counters:  counters,
+		})
+	}
+
+	for _, recipient := range affectedRecipients(status, event) {
+		add(DimensionSender, status.FromEmail)
+		add(DimensionTemplate, status.TemplateID)
+		add(DimensionCampaign, status.Tags[campaignTag])
+		add(DimensionConfigurationSet, status.ConfigurationSet)
+		for name, value := range status.Tags {
+			add(DimensionTag, name+":"+value)
+		}
+		if at := strings.LastIndex(recipient, "@"); at >= 0 {
+			add(DimensionDomain, recipient[at+1:])
+		}
+	}
+
+	return increments
+}
+
+// affectedRecipients retorna os destinatários afetados pelo evento. Um item
+// vazio representa um destinatário não identificado, contado apenas nas
+// dimensões da mensagem.
+func affectedRecipients(status DeliveryStatus, event DeliveryEvent) []string {
+	if event.Recipient != "" {
+		return []string{event.Recipient}
+	}
+
+	if len(status.Recipients) == 0 {
+		return []string{""}
+	}
+
+	// Aberturas e cliques sem destinatário só são atribuídos quando há um único destinatário
+	if (event.Type == StatusOpened || event.Type == StatusClicked) && len(status.Recipients) > 1 {
+		return []string{""}
+	}
+
+	recipients := make([]string, 0, len(status.Recipients))
+	for _, r := range status.Recipients {
+		recipients = append(recipients, r.Email)
+	}
+	return recipients
+}
+
+// normalizeDimensionValue padroniza endereços e domínios em minúsculas
+func normalizeDimensionValue(dimension, value string) string {
+	value = strings.TrimSpace(value)
+	if dimension == DimensionSender || dimension == DimensionDomain {
+		return strings.ToLower(value)
+	}
+	return value
+}
+
+// countersPoint converte os contadores em um ponto de série, com as taxas calculadas
+func countersPoint(t time.Time, counters AnalyticsCounters) MetricPoint {
+	point := TimeSeriesPoint{
+		Sent:       counters.Sent,
+		Delivered:  counters.Delivered,
+		Opened:     counters.Opened,
+		Clicked:    counters.Clicked,
+		Bounced:    counters.Bounced,
+		Complaints: counters.Complaints,
+	}
+	point.recalculateRates()
+
+	return MetricPoint{
+		Timestamp: t,
+		Values: map[string]float64{
+			"send":          float64(point.Sent),
+			"delivery":      float64(point.Delivered),
+			"open":          float64(point.Opened),
+			"click":         float64(point.Clicked),
+			"bounce":        float64(point.Bounced),
+			"complaint":     float64(point.Complaints),
+			"deliveryRate":  point.DeliveryRate,
+			"openRate":      point.OpenRate,
+			"clickRate":     point.ClickRate,
+			"bounceRate":    point.BounceRate,
+			"complaintRate": point.ComplaintRate,
+		},
+	}
+}
+
+// totals retorna os contadores no formato de MetricsResult.Totals
+func (c AnalyticsCounters) totals() map[string]int64 {
+	return map[string]int64{
+		"send":      c.Sent,
+		"delivery":  c.Delivered,
+		"open":      c.Opened,
+		"click":     c.Clicked,
+		"bounce":    c.Bounced,
+		"complaint": c.Complaints,
+	}
+}
+
+// countersResponse monta a resposta de métricas de um valor da dimensão a partir dos contadores
+func countersResponse(dimension, value string, counters AnalyticsCounters) *SenderMetricsResponse {
+	totals := &MetricsResult{Totals: counters.totals()}
+	rates := totals.Rates()
+
+	response := &SenderMetricsResponse{
+		Dimension:       dimension,
+		Value:           value,
+		TotalSent:       counters.Sent,
+		TotalDelivered:  counters.Delivered,
+		TotalOpened:     counters.Opened,
+		TotalClicked:    counters.Clicked,
+		TotalBounced:    counters.Bounced,
+		TotalComplaints: counters.Complaints,
+		DeliveryRate:    rates.DeliveryRate,
+		OpenRate:        rates.OpenRate,
+		ClickRate:       rates.ClickRate,
+		BounceRate:      rates.BounceRate,
+		ComplaintRate:   rates.ComplaintRate,
+	}
+	if dimension == DimensionSender {
+		response.Email = value
+	}
+
+	return response
+}
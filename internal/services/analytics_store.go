@@ -0,0 +1,364 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	analyticsRollupsBucket       = "analytics_rollups"
+	analyticsRollupsByHourBucket = "analytics_rollups_by_hour"
+	analyticsRebuildBucket       = "analytics_rollups_rebuild"
+)
+
+// rollupHourFormat formata a hora (UTC) das chaves da tabela de agregação
+const rollupHourFormat = "2006010215"
+
+// AnalyticsCounters representa os contadores de envio e engajamento de uma agregação
+type AnalyticsCounters struct {
+	Sent       int64 `json:"sent"`
+	Delivered  int64 `json:"delivered"`
+	Opened     int64 `json:"opened"`
+	Clicked    int64 `json:"clicked"`
+	Bounced    int64 `json:"bounced"`
+	Complaints int64 `json:"complaints"`
+}
+
+// add soma os contadores informados
+func (c *AnalyticsCounters) add(other AnalyticsCounters) {
+	c.Sent += other.Sent
+	c.Delivered += other.Delivered
+	c.Opened += other.Opened
+	c.Clicked += other.Clicked
+	c.Bounced += other.Bounced
+	c.Complaints += other.Complaints
+}
+
+// RollupIncrement representa o incremento de uma linha da tabela de agregação
+type RollupIncrement struct {
+	Dimension string
+	Value     string
+	Hour      time.Time
+	Counters  AnalyticsCounters
+}
+
+// RollupRow representa os contadores agregados de uma hora
+type RollupRow struct {
+	Hour     time.Time
+	Counters AnalyticsCounters
+}
+
+// AnalyticsStore define a persistência das tabelas de agregação por hora
+type AnalyticsStore interface {
+	// Add aplica os incrementos de forma atômica
+	Add(increments []RollupIncrement) error
+	// Rows retorna as linhas por hora de um valor da dimensão no intervalo, em ordem cronológica
+	Rows(dimension, value string, start, end time.Time) ([]RollupRow, error)
+	// Totals retorna os contadores de cada valor da dimensão no intervalo
+	Totals(dimension string, start, end time.Time) (map[string]AnalyticsCounters, error)
+	// BeginRebuild cria uma tabela de reconstrução vazia, descartando a anterior
+	BeginRebuild() error
+	// AddRebuild aplica os incrementos à tabela de reconstrução
+	AddRebuild(increments []RollupIncrement) error
+	// CommitRebuild substitui, em uma única transação, as linhas a partir da
+	// hora informada pelas da tabela de reconstrução, que é descartada
+	CommitRebuild(since time.Time) error
+	// DiscardRebuild descarta a tabela de reconstrução
+	DiscardRebuild() error
+}
+
+// rollupKey monta a chave ordenável de uma linha da tabela de agregação
+func rollupKey(dimension, value string, hour time.Time) string {
+	return dimension + "\x00" + value + "\x00" + hour.UTC().Format(rollupHourFormat)
+}
+
+// rollupHourKey monta a chave do índice por hora, que ordena as linhas de uma
+// dimensão pela hora para que as consultas por período sejam buscas por faixa
+func rollupHourKey(dimension, value string, hour time.Time) string {
+	return dimension + "\x00" + hour.UTC().Format(rollupHourFormat) + "\x00" + value
+}
+
+// parseRollupKey separa a dimensão, o valor e a hora de uma chave
+func parseRollupKey(key []byte) (string, string, time.Time, error) {
+	parts := strings.Split(string(key), "\x00")
+	if len(parts) != 3 {
+		return "", "", time.Time{}, fmt.Errorf("chave de agregação inválida: %q", key)
+	}
+
+	hour, err := time.Parse(rollupHourFormat, parts[2])
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("chave de agregação inválida: %q", key)
+	}
+
+	return parts[0], parts[1], hour, nil
+}
+
+// inRange verifica se a hora está no intervalo [start, end)
+func inRange(hour, start, end time.Time) bool {
+	return !hour.Before(start.Truncate(time.Hour)) && hour.Before(end)
+}
+
+// BoltAnalyticsStore persiste as tabelas de agregação no banco embarcado
+type BoltAnalyticsStore struct {
+	db *bolt.DB
+}
+
+// NewBoltAnalyticsStore cria uma nova instância do BoltAnalyticsStore,
+// preenchendo o índice por hora das tabelas gravadas antes dele existir
+func NewBoltAnalyticsStore(db *bolt.DB) (*BoltAnalyticsStore, error) {
+	if err := ensureBuckets(db, analyticsRollupsBucket, analyticsRollupsByHourBucket); err != nil {
+		return nil, err
+	}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		rollups := tx.Bucket([]byte(analyticsRollupsBucket))
+		byHour := tx.Bucket([]byte(analyticsRollupsByHourBucket))
+		if k, _ := byHour.Cursor().First(); k != nil {
+			return nil
+		}
+
+		return rollups.ForEach(func(k, v []byte) error {
+			dimension, value, hour, err := parseRollupKey(k)
+			if err != nil {
+				return err
+			}
+			return byHour.Put([]byte(rollupHourKey(dimension, value, hour)), v)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao criar índice por hora das agregações: %w", err)
+	}
+
+	return &BoltAnalyticsStore{db: db}, nil
+}
+
+// addTo soma os incrementos às linhas do bucket informado
+func addTo(b *bolt.Bucket, increments []RollupIncrement) error {
+	for _, inc := range increments {
+		key := rollupKey(inc.Dimension, inc.Value, inc.Hour)
+
+		var counters AnalyticsCounters
+		if _, err := getJSON(b, key, &counters); err != nil {
+			return err
+		}
+		counters.add(inc.Counters)
+
+		if err := putJSON(b, key, counters); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putRow grava uma linha na tabela de agregação e no índice por hora
+func putRow(tx *bolt.Tx, key, value []byte) error {
+	dimension, rowValue, hour, err := parseRollupKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Bucket([]byte(analyticsRollupsBucket)).Put(key, value); err != nil {
+		return err
+	}
+	return tx.Bucket([]byte(analyticsRollupsByHourBucket)).Put([]byte(rollupHourKey(dimension, rowValue, hour)), value)
+}
+
+// Add aplica os incrementos de forma atômica
+func (s *BoltAnalyticsStore) Add(increments []RollupIncrement) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(analyticsRollupsBucket))
+
+		for _, inc := range increments {
+			key := rollupKey(inc.Dimension, inc.Value, inc.Hour)
+
+			var counters AnalyticsCounters
+			if _, err := getJSON(b, key, &counters); err != nil {
+				return err
+			}
+			counters.add(inc.Counters)
+
+			data, err := json.Marshal(counters)
+			if err != nil {
+				return fmt.Errorf("falha ao serializar %s: %w", key, err)
+			}
+			if err := putRow(tx, []byte(key), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Rows retorna as linhas por hora de um valor da dimensão no intervalo, em ordem cronológica
+func (s *BoltAnalyticsStore) Rows(dimension, value string, start, end time.Time) ([]RollupRow, error) {
+	rows := []RollupRow{}
+	prefix := []byte(dimension + "\x00" + value + "\x00")
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte(analyticsRollupsBucket)).Cursor()
+
+		for k, v := c.Seek([]byte(rollupKey(dimension, value, start.Truncate(time.Hour)))); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			_, _, hour, err := parseRollupKey(k)
+			if err != nil {
+				return err
+			}
+			if !inRange(hour, start, end) {
+				break
+			}
+
+			var counters AnalyticsCounters
+			if err := json.Unmarshal(v, &counters); err != nil {
+				return fmt.Errorf("falha ao desserializar %s: %w", k, err)
+			}
+			rows = append(rows, RollupRow{Hour: hour, Counters: counters})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// Totals retorna os contadores de cada valor da dimensão no intervalo,
+// percorrendo apenas as horas do intervalo no índice por hora
+func (s *BoltAnalyticsStore) Totals(dimension string, start, end time.Time) (map[string]AnalyticsCounters, error) {
+	totals := make(map[string]AnalyticsCounters)
+	prefix := []byte(dimension + "\x00")
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte(analyticsRollupsByHourBucket)).Cursor()
+
+		for k, v := c.Seek([]byte(rollupHourKey(dimension, "", start.Truncate(time.Hour)))); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			parts := strings.SplitN(string(k), "\x00", 3)
+			if len(parts) != 3 {
+				return fmt.Errorf("chave de agregação inválida: %q", k)
+			}
+			hour, err := time.Parse(rollupHourFormat, parts[1])
+			if err != nil {
+				return fmt.Errorf("chave de agregação inválida: %q", k)
+			}
+			// A busca começa na hora de start, então basta parar na hora de end
+			if !hour.Before(end) {
+				break
+			}
+
+			var counters AnalyticsCounters
+			if err := json.Unmarshal(v, &counters); err != nil {
+				return fmt.Errorf("falha ao desserializar %s: %w", k, err)
+			}
+
+			total := totals[parts[2]]
+			total.add(counters)
+			totals[parts[2]] = total
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+// BeginRebuild cria uma tabela de reconstrução vazia, descartando a anterior
+func (s *BoltAnalyticsStore) BeginRebuild() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(analyticsRebuildBucket)) != nil {
+			if err := tx.DeleteBucket([]byte(analyticsRebuildBucket)); err != nil {
+				return err
+			}
+		}
+		_, err := tx.CreateBucket([]byte(analyticsRebuildBucket))
+		return err
+	})
+}
+
+// AddRebuild aplica os incrementos à tabela de reconstrução
+func (s *BoltAnalyticsStore) AddRebuild(increments []RollupIncrement) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(analyticsRebuildBucket))
+		if b == nil {
+			return fmt.Errorf("nenhuma reconstrução das agregações em andamento")
+		}
+		return addTo(b, increments)
+	})
+}
+
+// CommitRebuild substitui, em uma única transação, as linhas a partir da
+// hora informada pelas da tabela de reconstrução, que é descartada. As
+// linhas anteriores são mantidas.
+func (s *BoltAnalyticsStore) CommitRebuild(since time.Time) error {
+	sinceHour := since.UTC().Format(rollupHourFormat)
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		rebuild := tx.Bucket([]byte(analyticsRebuildBucket))
+		if rebuild == nil {
+			return fmt.Errorf("nenhuma reconstrução das agregações em andamento")
+		}
+		rollups := tx.Bucket([]byte(analyticsRollupsBucket))
+		byHour := tx.Bucket([]byte(analyticsRollupsByHourBucket))
+
+		// Remove as linhas a partir de since de cada dimensão, saltando pelo
+		// índice por hora de uma dimensão para a seguinte
+		var stale [][2][]byte
+		c := byHour.Cursor()
+		for k, _ := c.First(); k != nil; {
+			dimension, _, _ := strings.Cut(string(k), "\x00")
+			prefix := []byte(dimension + "\x00")
+
+			for k, _ = c.Seek([]byte(dimension + "\x00" + sinceHour)); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+				parts := strings.SplitN(string(k), "\x00", 3)
+				if len(parts) != 3 {
+					return fmt.Errorf("chave de agregação inválida: %q", k)
+				}
+				stale = append(stale, [2][]byte{
+					append([]byte(nil), k...),
+					[]byte(parts[0] + "\x00" + parts[2] + "\x00" + parts[1]),
+				})
+			}
+			k, _ = c.Seek([]byte(dimension + "\x01"))
+		}
+
+		for _, keys := range stale {
+			if err := byHour.Delete(keys[0]); err != nil {
+				return err
+			}
+			if err := rollups.Delete(keys[1]); err != nil {
+				return err
+			}
+		}
+
+		err := rebuild.ForEach(func(k, v []byte) error {
+			_, _, hour, err := parseRollupKey(k)
+			if err != nil {
+				return err
+			}
+			if hour.Before(since) {
+				return nil
+			}
+			return putRow(tx, k, v)
+		})
+		if err != nil {
+			return err
+		}
+
+		return tx.DeleteBucket([]byte(analyticsRebuildBucket))
+	})
+}
+
+// DiscardRebuild descarta a tabela de reconstrução
+func (s *BoltAnalyticsStore) DiscardRebuild() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(analyticsRebuildBucket)) == nil {
+			return nil
+		}
+		return tx.DeleteBucket([]byte(analyticsRebuildBucket))
+	})
+}
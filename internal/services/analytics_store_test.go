@@ -0,0 +1,282 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func openTestDB(t *testing.T) *bolt.DB {
+	t.Helper()
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.NoSync = true
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func sent(dimension, value string, hour time.Time, count int64) RollupIncrement {
+	return RollupIncrement{Dimension: dimension, Value: value, Hour: hour, Counters: AnalyticsCounters{Sent: count}}
+}
+
+func sentTotals(totals map[string]AnalyticsCounters) map[string]int64 {
+	result := make(map[string]int64, len(totals))
+	for value, counters := range totals {
+		result[value] = counters.Sent
+	}
+	return result
+}
+
+func equalTotals(got, want map[string]int64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for value, count := range want {
+		if got[value] != count {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBoltAnalyticsStoreTotals(t *testing.T) {
+	store, err := NewBoltAnalyticsStore(openTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := func(hours int) time.Time { return storeBase.Add(time.Duration(hours) * time.Hour) }
+	err = store.Add([]RollupIncrement{
+		sent(DimensionSender, "a@exemplo.com", h(0), 1),
+		sent(DimensionSender, "a@exemplo.com", h(1), 2),
+		sent(DimensionSender, "b@exemplo.com", h(1), 4),
+		sent(DimensionSender, "b@exemplo.com", h(3), 8),
+		sent(DimensionDomain, "cliente.com", h(1), 16),
+		sent(DimensionTemplate, "boas-vindas", h(2), 32),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		dimension string
+		start     time.Time
+		end       time.Time
+		want      map[string]int64
+	}{
+		{name: "tudo", dimension: DimensionSender, start: h(-10), end: h(10), want: map[string]int64{"a@exemplo.com": 3, "b@exemplo.com": 12}},
+		{name: "fim exclusivo", dimension: DimensionSender, start: h(0), end: h(1), want: map[string]int64{"a@exemplo.com": 1}},
+		{name: "início no meio da hora", dimension: DimensionSender, start: h(1).Add(30 * time.Minute), end: h(3), want: map[string]int64{"a@exemplo.com": 2, "b@exemplo.com": 4}},
+		{name: "fim no meio da hora", dimension: DimensionSender, start: h(3), end: h(3).Add(time.Minute), want: map[string]int64{"b@exemplo.com": 8}},
+		{name: "não mistura dimensões", dimension: DimensionDomain, start: h(0), end: h(10), want: map[string]int64{"cliente.com": 16}},
+		{name: "período vazio", dimension: DimensionTemplate, start: h(3), end: h(10), want: map[string]int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totals, err := store.Totals(tt.dimension, tt.start, tt.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sentTotals(totals); !equalTotals(got, tt.want) {
+				t.Errorf("Totals() = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoltAnalyticsStoreBackfillsHourIndex(t *testing.T) {
+	db := openTestDB(t)
+	// Tabela gravada antes do índice por hora existir
+	err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(analyticsRollupsBucket))
+		if err != nil {
+			return err
+		}
+		return putJSON(b, rollupKey(DimensionSender, "a@exemplo.com", storeBase), AnalyticsCounters{Sent: 5})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewBoltAnalyticsStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	totals, err := store.Totals(DimensionSender, storeBase, storeBase.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sentTotals(totals); !equalTotals(got, map[string]int64{"a@exemplo.com": 5}) {
+		t.Errorf("Totals() = %v após preencher o índice", got)
+	}
+}
+
+func TestBoltAnalyticsStoreCommitRebuild(t *testing.T) {
+	h := func(hours int) time.Time { return storeBase.Add(time.Duration(hours) * time.Hour) }
+
+	tests := []struct {
+		name    string
+		commit  bool
+		want    map[string]int64
+		wantOld map[string]int64
+	}{
+		{
+			name:    "substitui apenas as horas a partir de since",
+			commit:  true,
+			want:    map[string]int64{"a@exemplo.com": 100, "c@exemplo.com": 7},
+			wantOld: map[string]int64{"a@exemplo.com": 1, "b@exemplo.com": 2},
+		},
+		{
+			name:    "descartar mantém as agregações",
+			want:    map[string]int64{"a@exemplo.com": 10, "b@exemplo.com": 20},
+			wantOld: map[string]int64{"a@exemplo.com": 1, "b@exemplo.com": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewBoltAnalyticsStore(openTestDB(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = store.Add([]RollupIncrement{
+				sent(DimensionSender, "a@exemplo.com", h(0), 1),
+				sent(DimensionSender, "b@exemplo.com", h(1), 2),
+				sent(DimensionSender, "a@exemplo.com", h(5), 10),
+				sent(DimensionSender, "b@exemplo.com", h(6), 20),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := store.BeginRebuild(); err != nil {
+				t.Fatal(err)
+			}
+			err = store.AddRebuild([]RollupIncrement{
+				// Anterior a since: ignorado na aplicação
+				sent(DimensionSender, "a@exemplo.com", h(1), 1000),
+				sent(DimensionSender, "a@exemplo.com", h(5), 100),
+				sent(DimensionSender, "c@exemplo.com", h(7), 7),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.commit {
+				err = store.CommitRebuild(h(4))
+			} else {
+				err = store.DiscardRebuild()
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recent, err := store.Totals(DimensionSender, h(4), h(10))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sentTotals(recent); !equalTotals(got, tt.want) {
+				t.Errorf("Totals() recente = %v, esperado %v", got, tt.want)
+			}
+
+			old, err := store.Totals(DimensionSender, h(0), h(4))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sentTotals(old); !equalTotals(got, tt.wantOld) {
+				t.Errorf("Totals() antigo = %v, esperado %v", got, tt.wantOld)
+			}
+
+			// A tabela por valor deve concordar com o índice por hora
+			rows, err := store.Rows(DimensionSender, "a@exemplo.com", h(0), h(10))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var total int64
+			for _, row := range rows {
+				total += row.Counters.Sent
+			}
+			if want := tt.want["a@exemplo.com"] + tt.wantOld["a@exemplo.com"]; total != want {
+				t.Errorf("Rows() soma %d, esperado %d", total, want)
+			}
+
+			if err := store.AddRebuild(nil); err == nil {
+				t.Error("AddRebuild() após encerrar a reconstrução deveria falhar")
+			}
+		})
+	}
+}
+
+func TestAnalyticsServiceRebuild(t *testing.T) {
+	analyticsStore, err := NewBoltAnalyticsStore(openTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deliveryStore := NewStatusCache()
+	deliveryService := NewDeliveryService(nil, deliveryStore, nil)
+	service := NewAnalyticsService(analyticsStore, deliveryStore, deliveryService)
+
+	// Agregações de mensagens já removidas pela retenção e uma contagem
+	// incorreta dentro do período retido
+	err = analyticsStore.Add([]RollupIncrement{
+		sent(DimensionSender, "a@exemplo.com", storeBase.Add(-48*time.Hour), 3),
+		sent(DimensionSender, "a@exemplo.com", storeBase.Add(2*time.Hour), 99),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mais mensagens que um lote, para exercitar a posição entre lotes
+	total := rebuildBatchSize + 5
+	for i := 0; i < total; i++ {
+		status := deliveryFixture(fmt.Sprintf("m%04d", i), "a@exemplo.com", storeBase.Add(time.Duration(30+i)*time.Minute), "r@cliente.com")
+		seedDeliveries(t, deliveryStore, status)
+		event := DeliveryEvent{ID: status.MessageID + "-sent", MessageID: status.MessageID, Type: StatusSent, Timestamp: status.SentAt}
+		if err := deliveryStore.AppendEvent(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	processed, err := service.Rebuild()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed != total {
+		t.Errorf("Rebuild() = %d, esperado %d", processed, total)
+	}
+
+	// A hora do envio mais antigo (12h30) não é recalculada; a partir das 13h
+	// contam as mensagens enviadas desde então
+	since := storeBase.Add(time.Hour)
+	recomputed := int64(total - 30)
+
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+		want  int64
+	}{
+		{name: "histórico anterior mantido", start: storeBase.Add(-72 * time.Hour), end: storeBase, want: 3},
+		{name: "horas retidas recalculadas", start: since, end: storeBase.Add(24 * time.Hour), want: recomputed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totals, err := analyticsStore.Totals(DimensionSender, tt.start, tt.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := totals["a@exemplo.com"].Sent; got != tt.want {
+				t.Errorf("Totals() = %d, esperado %d", got, tt.want)
+			}
+		})
+	}
+}
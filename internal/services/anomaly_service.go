@@ -0,0 +1,376 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxAnomalyHours é a maior janela de detecção, em horas (uma semana)
+const MaxAnomalyHours = 168
+
+// anomalyRefreshInterval é o intervalo de atualização das anomalias recentes
+// usadas no relatório em tempo real
+const anomalyRefreshInterval = 10 * time.Minute
+
+// Tipos de anomalia detectados
+const (
+	AnomalyVolumeSpike     = "volume_spike"
+	AnomalyOpenRateDrop    = "open_rate_drop"
+	AnomalyBounceRateSpike = "bounce_rate_spike"
+)
+
+// anomalyTypes lista os tipos aceitos no filtro da detecção
+var anomalyTypes = map[string]bool{
+	AnomalyVolumeSpike:     true,
+	AnomalyOpenRateDrop:    true,
+	AnomalyBounceRateSpike: true,
+}
+
+// Linhas de base usadas na detecção
+const (
+	BaselineWeekdayHour = "weekday-hour"
+	BaselineHourOfDay   = "hour-of-day"
+)
+
+const (
+	// anomalyBaselineWeeks é a quantidade de semanas de histórico da linha de base
+	anomalyBaselineWeeks = 4
+	// anomalyMinWeekdaySamples é o mínimo de semanas com histórico para usar a
+	// linha de base por dia da semana e hora
+	anomalyMinWeekdaySamples = 3
+	// anomalyHourOfDayDays é a quantidade de dias da linha de base por hora do
+	// dia, usada enquanto não há semanas suficientes
+	anomalyHourOfDayDays = 14
+	// anomalyMinHourOfDaySamples é o mínimo de dias com histórico para usar a
+	// linha de base por hora do dia
+	anomalyMinHourOfDaySamples = 7
+	// anomalyMinVolume é o volume mínimo na hora para avaliar uma anomalia
+	anomalyMinVolume = 50
+	// anomalyMinRateSample é o denominador mínimo de uma hora do histórico
+	// para que a taxa dela entre na linha de base
+	anomalyMinRateSample = 10
+	// anomalyZScore é o desvio mínimo, em desvios padrão, de uma anomalia
+	anomalyZScore = 3
+	// anomalyCriticalZScore é o desvio a partir do qual a anomalia é crítica
+	anomalyCriticalZScore = 6
+)
+
+// Anomaly representa uma hora em que o comportamento de um remetente se
+// afastou da linha de base aprendida. Observed e Expected são envios por hora
+// (volume_spike) ou taxas em percentual (open_rate_drop e bounce_rate_spike).
+type Anomaly struct {
+	Sender      string    `json:"sender"`
+	Type        string    `json:"type"`
+	Severity    string    `json:"severity"`
+	Hour        time.Time `json:"hour"`
+	Observed    float64   `json:"observed"`
+	Expected    float64   `json:"expected"`
+	StdDev      float64   `json:"stdDev"`
+	Score       float64   `json:"score"`
+	Baseline    string    `json:"baseline"`
+	Samples     int       `json:"samples"`
+	Description string    `json:"description"`
+}
+
+// AnomalyService detecta anomalias de volume e de engajamento por remetente,
+// comparando cada hora com a linha de base aprendida das métricas locais:
+// a mesma hora do mesmo dia da semana nas semanas anteriores ou, enquanto
+// não há histórico suficiente, a mesma hora dos dias anteriores. As horas
+// são avaliadas em UTC.
+type AnomalyService struct {
+	store AnalyticsStore
+
+	// recent guarda as anomalias da última semana, atualizadas em segundo plano
+	recent      []Anomaly
+	recentUntil time.Time
+	mutex       sync.RWMutex
+}
+
+// NewAnomalyService cria uma nova instância do AnomalyService
+func NewAnomalyService(store AnalyticsStore) *AnomalyService {
+	return &AnomalyService{store: store}
+}
+
+// Detect avalia as últimas horas completas e retorna as anomalias
+// encontradas, das mais recentes para as mais antigas. Sender vazio avalia
+// todos os remetentes com envios no período e types vazio inclui todos os tipos.
+func (s *AnomalyService) Detect(hours int, sender string, types []string) ([]Anomaly, error) {
+	if hours <= 0 || hours > MaxAnomalyHours {
+		return nil, fmt.Errorf("quantidade de horas inválida: %d (use de 1 a %d)", hours, MaxAnomalyHours)
+	}
+
+	wanted := make(map[string]bool, len(types))
+	for _, t := range types {
+		if !anomalyTypes[t] {
+			return nil, fmt.Errorf("tipo de anomalia desconhecido: %s", t)
+		}
+		wanted[t] = true
+	}
+
+	end := time.Now().UTC().Truncate(time.Hour)
+	start := end.Add(-time.Duration(hours) * time.Hour)
+	historyStart := start.AddDate(0, 0, -7*anomalyBaselineWeeks)
+
+	var senders []string
+	if sender != "" {
+		senders = []string{normalizeDimensionValue(DimensionSender, sender)}
+	} else {
+		totals, err := s.store.Totals(DimensionSender, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for value := range totals {
+			senders = append(senders, value)
+		}
+	}
+
+	anomalies := []Anomaly{}
+	for _, value := range senders {
+		rows, err := s.store.Rows(DimensionSender, value, historyStart, end)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		history := make(map[int64]AnalyticsCounters, len(rows))
+		for _, row := range rows {
+			history[row.Hour.Unix()] = row.Counters
+		}
+		firstSeen := rows[0].Hour
+
+		for hour := start; hour.Before(end); hour = hour.Add(time.Hour) {
+			for _, anomaly := range detectHour(value, hour, history, firstSeen) {
+				if len(wanted) == 0 || wanted[anomaly.Type] {
+					anomalies = append(anomalies, anomaly)
+				}
+			}
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		if !anomalies[i].Hour.Equal(anomalies[j].Hour) {
+			return anomalies[i].Hour.After(anomalies[j].Hour)
+		}
+		return anomalies[i].Score > anomalies[j].Score
+	})
+
+	return anomalies, nil
+}
+
+// Run atualiza periodicamente as anomalias da última semana até o contexto
+// ser cancelado
+func (s *AnomalyService) Run(ctx context.Context) {
+	ticker := time.NewTicker(anomalyRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		s.refresh()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh detecta as anomalias da última semana e as guarda para Recent
+func (s *AnomalyService) refresh() {
+	anomalies, err := s.Detect(MaxAnomalyHours, "", nil)
+	if err != nil {
+		log.Printf("Falha ao detectar anomalias: %v", err)
+		return
+	}
+
+	s.mutex.Lock()
+	s.recent = anomalies
+	s.recentUntil = time.Now().UTC().Truncate(time.Hour)
+	s.mutex.Unlock()
+}
+
+// Recent retorna as anomalias das últimas horas a partir da última
+// atualização em segundo plano, sem consultar as métricas
+func (s *AnomalyService) Recent(hours int) []Anomaly {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	start := s.recentUntil.Add(-time.Duration(hours) * time.Hour)
+	anomalies := []Anomaly{}
+	for _, anomaly := range s.recent {
+		if !anomaly.Hour.Before(start) {
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+	return anomalies
+}
+
+// detectHour avalia uma hora de um remetente contra a linha de base
+func detectHour(sender string, hour time.Time, history map[int64]AnalyticsCounters, firstSeen time.Time) []Anomaly {
+	current := history[hour.Unix()]
+	if current.Sent < anomalyMinVolume && current.Delivered < anomalyMinVolume {
+		return nil
+	}
+
+	baseline, samples := baselineHours(hour, firstSeen)
+	if samples == nil {
+		// Histórico insuficiente: a linha de base ainda está sendo aprendida
+		return nil
+	}
+
+	past := make([]AnalyticsCounters, len(samples))
+	for i, t := range samples {
+		past[i] = history[t.Unix()]
+	}
+
+	var anomalies []Anomaly
+	add := func(kind string, observed, expected, stddev float64, description string) {
+		score := (observed - expected) / stddev
+		severity := "warning"
+		if math.Abs(score) >= anomalyCriticalZScore {
+			severity = "critical"
+		}
+		anomalies = append(anomalies, Anomaly{
+			Sender:      sender,
+			Type:        kind,
+			Severity:    severity,
+			Hour:        hour,
+			Observed:    observed,
+			Expected:    expected,
+			StdDev:      stddev,
+			Score:       score,
+			Baseline:    baseline,
+			Samples:     len(samples),
+			Description: description,
+		})
+	}
+
+	// Pico de volume (ex: credenciais comprometidas): as horas sem envios contam como zero
+	volumes := make([]float64, len(past))
+	for i, c := range past {
+		volumes[i] = float64(c.Sent)
+	}
+	mean, stddev := meanStdDev(volumes)
+	stddev = math.Max(stddev, math.Max(math.Sqrt(mean), 1))
+	sent := float64(current.Sent)
+	if current.Sent >= anomalyMinVolume && sent >= 3*math.Max(mean, 1) && (sent-mean)/stddev >= anomalyZScore {
+		add(AnomalyVolumeSpike, sent, mean, stddev,
+			fmt.Sprintf("%d envios na hora, esperados cerca de %.0f", current.Sent, mean))
+	}
+
+	// Queda da taxa de abertura (ex: mensagens caindo no spam)
+	if current.Delivered >= anomalyMinVolume {
+		expected, stddev, ok := rateBaseline(past, func(c AnalyticsCounters) (int64, int64) { return c.Opened, c.Delivered })
+		observed := float64(current.Opened) / float64(current.Delivered) * 100
+		if ok && expected >= 5 {
+			stddev = math.Max(stddev, binomialStdDev(expected, current.Delivered))
+			if observed <= expected/2 && (observed-expected)/stddev <= -anomalyZScore {
+				add(AnomalyOpenRateDrop, observed, expected, stddev,
+					fmt.Sprintf("taxa de abertura de %.2f%% na hora, esperada cerca de %.2f%%", observed, expected))
+			}
+		}
+	}
+
+	// Pico da taxa de bounce
+	if current.Sent >= anomalyMinVolume {
+		expected, stddev, ok := rateBaseline(past, func(c AnalyticsCounters) (int64, int64) { return c.Bounced, c.Sent })
+		observed := float64(current.Bounced) / float64(current.Sent) * 100
+		if ok {
+			stddev = math.Max(stddev, binomialStdDev(expected, current.Sent))
+			if observed >= 2*expected && observed-expected >= 2 && (observed-expected)/stddev >= anomalyZScore {
+				add(AnomalyBounceRateSpike, observed, expected, stddev,
+					fmt.Sprintf("taxa de bounce de %.2f%% na hora, esperada cerca de %.2f%%", observed, expected))
+			}
+		}
+	}
+
+	return anomalies
+}
+
+// baselineHours retorna as horas do histórico usadas como linha de base: a
+// mesma hora do mesmo dia da semana nas semanas anteriores ou, sem semanas
+// suficientes, a mesma hora dos dias anteriores. Retorna nil enquanto não há
+// histórico suficiente desde a primeira atividade do remetente.
+func baselineHours(hour, firstSeen time.Time) (string, []time.Time) {
+	var weekly []time.Time
+	for week := 1; week <= anomalyBaselineWeeks; week++ {
+		if t := hour.AddDate(0, 0, -7*week); !t.Before(firstSeen) {
+			weekly = append(weekly, t)
+		}
+	}
+	if len(weekly) >= anomalyMinWeekdaySamples {
+		return BaselineWeekdayHour, weekly
+	}
+
+	var daily []time.Time
+	for day := 1; day <= anomalyHourOfDayDays; day++ {
+		if t := hour.AddDate(0, 0, -day); !t.Before(firstSeen) {
+			daily = append(daily, t)
+		}
+	}
+	if len(daily) >= anomalyMinHourOfDaySamples {
+		return BaselineHourOfDay, daily
+	}
+
+	return "", nil
+}
+
+// rateBaseline calcula a taxa esperada (agregada sobre o histórico) e o
+// desvio padrão das taxas das horas com denominador suficiente
+func rateBaseline(past []AnalyticsCounters, parts func(AnalyticsCounters) (int64, int64)) (float64, float64, bool) {
+	var rates []float64
+	var numerator, denominator int64
+
+	for _, c := range past {
+		n, d := parts(c)
+		if d < anomalyMinRateSample {
+			continue
+		}
+		rates = append(rates, float64(n)/float64(d)*100)
+		numerator += n
+		denominator += d
+	}
+
+	if len(rates) < 2 {
+		return 0, 0, false
+	}
+
+	_, stddev := meanStdDev(rates)
+	return float64(numerator) / float64(denominator) * 100, stddev, true
+}
+
+// binomialStdDev estima o desvio padrão, em pontos percentuais, de uma taxa
+// esperada medida sobre n mensagens. Serve de piso para históricos estáveis.
+func binomialStdDev(ratePercent float64, n int64) float64 {
+	p := ratePercent / 100
+	return math.Max(math.Sqrt(p*(1-p)/float64(n))*100, 0.5)
+}
+
+// meanStdDev calcula a média e o desvio padrão amostral dos valores
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	if len(values) < 2 {
+		return mean, 0
+	}
+
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)-1))
+}
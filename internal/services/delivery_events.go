@@ -0,0 +1,354 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Status de entrega. Também são usados como tipos de evento da linha do tempo.
+const (
+	StatusSent       = "SENT"
+	StatusDelayed    = "DELAYED"
+	StatusDelivered  = "DELIVERED"
+	StatusOpened     = "OPENED"
+	StatusClicked    = "CLICKED"
+	StatusBounced    = "BOUNCED"
+	StatusComplained = "COMPLAINED"
+	StatusRejected   = "REJECTED"
+	StatusCancelled  = "CANCELLED"
+)
+
+// DeliveryEvent representa um evento da linha do tempo de uma mensagem
+type DeliveryEvent struct {
+	ID            string          `json:"id"`
+	MessageID     string          `json:"messageId"`
+	Type          string          `json:"type"`
+	Recipient     string          `json:"recipient,omitempty"`
+	Description   string          `json:"description,omitempty"`
+	BounceSubType string          `json:"bounceSubType,omitempty"`
+	Timestamp     time.Time       `json:"timestamp"`
+	Payload       json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+}
+
+// deliveryTransitions define, para cada status, os eventos aceitos.
+// Status ausentes do mapa são finais e não aceitam novos eventos. Bounces
+// assíncronos podem chegar após a notificação de entrega.
+var deliveryTransitions = map[string][]string{
+	StatusSent:      {StatusDelayed, StatusDelivered, StatusOpened, StatusClicked, StatusBounced, StatusComplained, StatusRejected, StatusCancelled},
+	StatusDelayed:   {StatusDelayed, StatusDelivered, StatusOpened, StatusClicked, StatusBounced, StatusComplained},
+	StatusDelivered: {StatusDelivered, StatusOpened, StatusClicked, StatusBounced, StatusComplained},
+	StatusOpened:    {StatusDelivered, StatusOpened, StatusClicked, StatusComplained},
+	StatusClicked:   {StatusDelivered, StatusOpened, StatusClicked, StatusComplained},
+}
+
+// statusRank ordena os status pelo avanço no ciclo de vida da mensagem, para
+// que eventos fora de ordem (ex: DELIVERED após OPENED) não façam o status regredir
+var statusRank = map[string]int{
+	StatusSent:       0,
+	StatusDelayed:    1,
+	StatusDelivered:  2,
+	StatusOpened:     3,
+	StatusClicked:    4,
+	StatusComplained: 5,
+	StatusBounced:    5,
+	StatusRejected:   5,
+	StatusCancelled:  5,
+}
+
+// nextStatus valida a transição e retorna o status resultante da aplicação do evento
+func nextStatus(current, event string) (string, error) {
+	if _, known := statusRank[event]; !known {
+		return "", fmt.Errorf("tipo de evento desconhecido: %s", event)
+	}
+
+	allowed := false
+	for _, candidate := range deliveryTransitions[current] {
+		if candidate == event {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", fmt.Errorf("transição de status inválida: %s -> %s", current, event)
+	}
+
+	if statusRank[event] < statusRank[current] {
+		return current, nil
+	}
+	return event, nil
+}
+
+// applyEvent aplica o evento ao status da mensagem, validando a transição.
+// Mensagens com destinatários conhecidos têm o status de cada destinatário
+// validado individualmente e o status da mensagem consolidado a partir deles.
+func applyEvent(status *DeliveryStatus, event DeliveryEvent) error {
+	if len(status.Recipients) > 0 {
+		return applyRecipientEvent(status, event)
+	}
+
+	next, err := nextStatus(status.Status, event.Type)
+	if err != nil {
+		return err
+	}
+
+	if next != status.Status {
+		status.Status = next
+		status.StatusDescription = event.Description
+	}
+
+	applyEventTimestamps(status, event)
+	return nil
+}
+
+// applyEventTimestamps atualiza os marcos de entrega, abertura e clique da mensagem
+func applyEventTimestamps(status *DeliveryStatus, event DeliveryEvent) {
+	switch event.Type {
+	case StatusDelivered:
+		if status.DeliveredAt.IsZero() {
+			status.DeliveredAt = event.Timestamp
+		}
+	case StatusOpened:
+		if status.OpenedAt.IsZero() {
+			status.OpenedAt = event.Timestamp
+		}
+	case StatusClicked:
+		status.ClickCount++
+		status.LastClickAt = event.Timestamp
+	}
+}
+
+// sesEventTypes mapeia os tipos de evento do SES para os status de entrega
+var sesEventTypes = map[string]string{
+	"AmazonSnsSubscriptionSucceeded": "",
+	"Rendering Failure":              "",
+	"Subscription":                   "",
+	"Send":                           "",
+	"Delivery":                       StatusDelivered,
+	"DeliveryDelay":                  StatusDelayed,
+	"Open":                           StatusOpened,
+	"Click":                          StatusClicked,
+	"Bounce":                         StatusBounced,
+	"Complaint":                      StatusComplained,
+	"Reject":                         StatusRejected,
+}
+
+// sesEventDescriptions descreve os status originados de eventos do SES
+var sesEventDescriptions = map[string]string{
+	StatusDelivered:  "E-mail entregue ao servidor do destinatário",
+	StatusDelayed:    "Entrega temporariamente atrasada",
+	StatusOpened:     "E-mail aberto pelo destinatário",
+	StatusClicked:    "Link clicado pelo destinatário",
+	StatusBounced:    "E-mail rejeitado pelo servidor do destinatário (bounce)",
+	StatusComplained: "Destinatário marcou o e-mail como spam",
+	StatusRejected:   "E-mail rejeitado pelo SES",
+}
+
+// suppressionBounces são os subtipos de bounce gerados pelo SES quando o
+// destinatário está em uma lista de supressão e a mensagem não é enviada
+var suppressionBounces = map[string]bool{
+	"Suppressed":               true,
+	"OnAccountSuppressionList": true,
+}
+
+// sesEvent representa os campos usados de um evento publicado pelo SES,
+// tanto no formato de event publishing (eventType) quanto no de notificações
+// de identidade (notificationType)
+type sesEvent struct {
+	EventType        string `json:"eventType"`
+	NotificationType string `json:"notificationType"`
+	Mail             struct {
+		MessageID   string    `json:"messageId"`
+		Timestamp   time.Time `json:"timestamp"`
+		Destination []string  `json:"destination"`
+	} `json:"mail"`
+	Delivery *struct {
+		Timestamp  time.Time `json:"timestamp"`
+		Recipients []string  `json:"recipients"`
+	} `json:"delivery"`
+	DeliveryDelay *struct {
+		Timestamp         time.Time `json:"timestamp"`
+		DelayedRecipients []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"delayedRecipients"`
+	} `json:"deliveryDelay"`
+	Bounce *struct {
+		Timestamp         time.Time `json:"timestamp"`
+		BounceType        string    `json:"bounceType"`
+		BounceSubType     string    `json:"bounceSubType"`
+		BouncedRecipients []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint *struct {
+		Timestamp            time.Time `json:"timestamp"`
+		ComplainedRecipients []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+	Open *struct {
+		Timestamp time.Time `json:"timestamp"`
+	} `json:"open"`
+	Click *struct {
+		Timestamp time.Time `json:"timestamp"`
+		Link      string    `json:"link"`
+	} `json:"click"`
+}
+
+// ParseSESEvent converte um evento do SES (mensagem de uma notificação SNS) em
+// eventos de entrega, um por destinatário afetado. Eventos sem correspondência
+// na linha do tempo (ex: Send) retornam uma lista vazia.
+func ParseSESEvent(message []byte) ([]DeliveryEvent, error) {
+	var raw sesEvent
+	if err := json.Unmarshal(message, &raw); err != nil {
+		return nil, fmt.Errorf("evento do SES inválido: %w", err)
+	}
+
+	kind := raw.EventType
+	if kind == "" {
+		kind = raw.NotificationType
+	}
+
+	eventType, known := sesEventTypes[kind]
+	if !known {
+		return nil, fmt.Errorf("tipo de evento do SES desconhecido: %s", kind)
+	}
+	if eventType == "" {
+		return []DeliveryEvent{}, nil
+	}
+
+	timestamp := raw.Mail.Timestamp
+	recipients := raw.Mail.Destination
+	description := sesEventDescriptions[eventType]
+	bounceSubType := ""
+
+	switch {
+	case raw.Delivery != nil:
+		timestamp, recipients = raw.Delivery.Timestamp, raw.Delivery.Recipients
+	case raw.DeliveryDelay != nil:
+		timestamp, recipients = raw.DeliveryDelay.Timestamp, nil
+		for _, r := range raw.DeliveryDelay.DelayedRecipients {
+			recipients = append(recipients, r.EmailAddress)
+		}
+	case raw.Bounce != nil:
+		timestamp, recipients = raw.Bounce.Timestamp, nil
+		for _, r := range raw.Bounce.BouncedRecipients {
+			recipients = append(recipients, r.EmailAddress)
+		}
+		if raw.Bounce.BounceType != "" {
+			description += " (" + raw.Bounce.BounceType + ")"
+		}
+		bounceSubType = raw.Bounce.BounceSubType
+	case raw.Complaint != nil:
+		timestamp, recipients = raw.Complaint.Timestamp, nil
+		for _, r := range raw.Complaint.ComplainedRecipients {
+			recipients = append(recipients, r.EmailAddress)
+		}
+	case raw.Open != nil:
+		timestamp = raw.Open.Timestamp
+	case raw.Click != nil:
+		timestamp = raw.Click.Timestamp
+		if raw.Click.Link != "" {
+			description += ": " + raw.Click.Link
+		}
+	}
+
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	// Aberturas e cliques não identificam o destinatário no SES
+	if eventType == StatusOpened || eventType == StatusClicked || len(recipients) == 0 {
+		recipients = []string{""}
+	}
+
+	events := make([]DeliveryEvent, 0, len(recipients))
+	for _, recipient := range recipients {
+		events = append(events, DeliveryEvent{
+			MessageID:     raw.Mail.MessageID,
+			Type:          eventType,
+			Recipient:     strings.ToLower(recipient),
+			Description:   description,
+			BounceSubType: bounceSubType,
+			Timestamp:     timestamp,
+			Payload:       json.RawMessage(message),
+		})
+	}
+
+	return events, nil
+}
+
+// SNSMessage representa o envelope de uma mensagem entregue pelo Amazon SNS
+type SNSMessage struct {
+	Type             string `json:"Type"`
+	MessageId        string `json:"MessageId"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	Token            string `json:"Token"`
+	SubscribeURL     string `json:"SubscribeURL"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+// HandleSNSMessage processa uma mensagem do SNS com eventos do SES, após
+// verificar o tópico e a assinatura. Confirma inscrições do tópico e
+// registra os eventos de notificações; eventos de mensagens desconhecidas ou
+// com transições inválidas são apenas registrados em log.
+func (s *DeliveryService) HandleSNSMessage(body []byte) error {
+	var msg SNSMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return fmt.Errorf("mensagem SNS inválida: %w", err)
+	}
+
+	if err := s.snsVerifier.Verify(msg); err != nil {
+		return err
+	}
+
+	switch msg.Type {
+	case "SubscriptionConfirmation":
+		return confirmSNSSubscription(msg.SubscribeURL)
+	case "Notification":
+		events, err := ParseSESEvent([]byte(msg.Message))
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if _, err := s.RecordEvent(event); err != nil {
+				log.Printf("Evento %s ignorado para a mensagem %s: %v", event.Type, event.MessageID, err)
+			}
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// confirmSNSSubscription confirma a inscrição de um tópico SNS, aceitando apenas URLs da AWS
+func confirmSNSSubscription(subscribeURL string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil || u.Scheme != "https" || !strings.HasSuffix(u.Hostname(), ".amazonaws.com") {
+		return fmt.Errorf("URL de confirmação inválida: %s", subscribeURL)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return fmt.Errorf("falha ao confirmar inscrição SNS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("falha ao confirmar inscrição SNS: status %d", resp.StatusCode)
+	}
+
+	log.Printf("Inscrição SNS confirmada: %s", u.Host)
+	return nil
+}
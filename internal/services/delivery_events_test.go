@@ -0,0 +1,192 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+var allStatuses = []string{
+	StatusSent, StatusDelayed, StatusDelivered, StatusOpened, StatusClicked,
+	StatusBounced, StatusComplained, StatusRejected, StatusCancelled,
+}
+
+func TestNextStatus(t *testing.T) {
+	// Transições aceitas e o status resultante; qualquer outro par é recusado
+	allowed := map[[2]string]string{
+		{StatusSent, StatusDelayed}:    StatusDelayed,
+		{StatusSent, StatusDelivered}:  StatusDelivered,
+		{StatusSent, StatusOpened}:     StatusOpened,
+		{StatusSent, StatusClicked}:    StatusClicked,
+		{StatusSent, StatusBounced}:    StatusBounced,
+		{StatusSent, StatusComplained}: StatusComplained,
+		{StatusSent, StatusRejected}:   StatusRejected,
+		{StatusSent, StatusCancelled}:  StatusCancelled,
+
+		{StatusDelayed, StatusDelayed}:    StatusDelayed,
+		{StatusDelayed, StatusDelivered}:  StatusDelivered,
+		{StatusDelayed, StatusOpened}:     StatusOpened,
+		{StatusDelayed, StatusClicked}:    StatusClicked,
+		{StatusDelayed, StatusBounced}:    StatusBounced,
+		{StatusDelayed, StatusComplained}: StatusComplained,
+
+		{StatusDelivered, StatusDelivered}:  StatusDelivered,
+		{StatusDelivered, StatusOpened}:     StatusOpened,
+		{StatusDelivered, StatusClicked}:    StatusClicked,
+		{StatusDelivered, StatusBounced}:    StatusBounced,
+		{StatusDelivered, StatusComplained}: StatusComplained,
+
+		// Eventos fora de ordem não fazem o status regredir
+		{StatusOpened, StatusDelivered}:  StatusOpened,
+		{StatusOpened, StatusOpened}:     StatusOpened,
+		{StatusOpened, StatusClicked}:    StatusClicked,
+		{StatusOpened, StatusComplained}: StatusComplained,
+
+		{StatusClicked, StatusDelivered}:  StatusClicked,
+		{StatusClicked, StatusOpened}:     StatusClicked,
+		{StatusClicked, StatusClicked}:    StatusClicked,
+		{StatusClicked, StatusComplained}: StatusComplained,
+	}
+
+	for _, current := range allStatuses {
+		for _, event := range allStatuses {
+			t.Run(current+"->"+event, func(t *testing.T) {
+				got, err := nextStatus(current, event)
+				want, ok := allowed[[2]string{current, event}]
+				switch {
+				case ok && err != nil:
+					t.Errorf("nextStatus() erro inesperado: %v", err)
+				case ok && got != want:
+					t.Errorf("nextStatus() = %s, esperado %s", got, want)
+				case !ok:
+					assertError(t, err, "transição de status inválida")
+				}
+			})
+		}
+	}
+
+	t.Run("evento desconhecido", func(t *testing.T) {
+		_, err := nextStatus(StatusSent, "PROCESSED")
+		assertError(t, err, "tipo de evento desconhecido")
+	})
+	t.Run("status atual desconhecido", func(t *testing.T) {
+		_, err := nextStatus("", StatusDelivered)
+		assertError(t, err, "transição de status inválida")
+	})
+}
+
+func TestParseSESEvent(t *testing.T) {
+	mailAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	eventAt := time.Date(2026, 3, 1, 12, 0, 5, 0, time.UTC)
+
+	type parsed struct {
+		Type          string
+		Recipient     string
+		Description   string
+		BounceSubType string
+		Timestamp     time.Time
+	}
+
+	const mail = `"mail":{"messageId":"0100018e-abc","timestamp":"2026-03-01T12:00:00Z","destination":["a@cliente.com","B@cliente.com"]}`
+
+	tests := []struct {
+		name    string
+		message string
+		want    []parsed
+		err     string
+	}{
+		{name: "Send", message: `{"eventType":"Send",` + mail + `,"send":{}}`, want: []parsed{}},
+		{name: "Rendering Failure", message: `{"eventType":"Rendering Failure",` + mail + `,"failure":{"templateName":"boas-vindas"}}`, want: []parsed{}},
+		{name: "Subscription", message: `{"eventType":"Subscription",` + mail + `}`, want: []parsed{}},
+		{name: "confirmação de inscrição", message: `{"notificationType":"AmazonSnsSubscriptionSucceeded","message":"ok"}`, want: []parsed{}},
+		{
+			name:    "Delivery",
+			message: `{"eventType":"Delivery",` + mail + `,"delivery":{"timestamp":"2026-03-01T12:00:05Z","recipients":["a@cliente.com","B@cliente.com"]}}`,
+			want: []parsed{
+				{Type: StatusDelivered, Recipient: "a@cliente.com", Description: sesEventDescriptions[StatusDelivered], Timestamp: eventAt},
+				{Type: StatusDelivered, Recipient: "b@cliente.com", Description: sesEventDescriptions[StatusDelivered], Timestamp: eventAt},
+			},
+		},
+		{
+			name:    "DeliveryDelay",
+			message: `{"eventType":"DeliveryDelay",` + mail + `,"deliveryDelay":{"timestamp":"2026-03-01T12:00:05Z","delayType":"MailboxFull","delayedRecipients":[{"emailAddress":"a@cliente.com"}]}}`,
+			want:    []parsed{{Type: StatusDelayed, Recipient: "a@cliente.com", Description: sesEventDescriptions[StatusDelayed], Timestamp: eventAt}},
+		},
+		{
+			name:    "Open",
+			message: `{"eventType":"Open",` + mail + `,"open":{"timestamp":"2026-03-01T12:00:05Z","ipAddress":"192.0.2.1"}}`,
+			want:    []parsed{{Type: StatusOpened, Description: sesEventDescriptions[StatusOpened], Timestamp: eventAt}},
+		},
+		{
+			name:    "Click",
+			message: `{"eventType":"Click",` + mail + `,"click":{"timestamp":"2026-03-01T12:00:05Z","link":"https://exemplo.com/oferta"}}`,
+			want:    []parsed{{Type: StatusClicked, Description: sesEventDescriptions[StatusClicked] + ": https://exemplo.com/oferta", Timestamp: eventAt}},
+		},
+		{
+			name:    "Bounce",
+			message: `{"eventType":"Bounce",` + mail + `,"bounce":{"timestamp":"2026-03-01T12:00:05Z","bounceType":"Permanent","bounceSubType":"Suppressed","bouncedRecipients":[{"emailAddress":"B@cliente.com"}]}}`,
+			want: []parsed{{
+				Type:          StatusBounced,
+				Recipient:     "b@cliente.com",
+				Description:   sesEventDescriptions[StatusBounced] + " (Permanent)",
+				BounceSubType: "Suppressed",
+				Timestamp:     eventAt,
+			}},
+		},
+		{
+			name:    "Complaint",
+			message: `{"eventType":"Complaint",` + mail + `,"complaint":{"timestamp":"2026-03-01T12:00:05Z","complainedRecipients":[{"emailAddress":"a@cliente.com"}]}}`,
+			want:    []parsed{{Type: StatusComplained, Recipient: "a@cliente.com", Description: sesEventDescriptions[StatusComplained], Timestamp: eventAt}},
+		},
+		{
+			name:    "Reject usa os destinatários da mensagem",
+			message: `{"eventType":"Reject",` + mail + `,"reject":{"reason":"Bad content"}}`,
+			want: []parsed{
+				{Type: StatusRejected, Recipient: "a@cliente.com", Description: sesEventDescriptions[StatusRejected], Timestamp: mailAt},
+				{Type: StatusRejected, Recipient: "b@cliente.com", Description: sesEventDescriptions[StatusRejected], Timestamp: mailAt},
+			},
+		},
+		{
+			name:    "notificação de identidade",
+			message: `{"notificationType":"Bounce",` + mail + `,"bounce":{"timestamp":"2026-03-01T12:00:05Z","bounceType":"Transient","bounceSubType":"MailboxFull","bouncedRecipients":[{"emailAddress":"a@cliente.com"}]}}`,
+			want: []parsed{{
+				Type:          StatusBounced,
+				Recipient:     "a@cliente.com",
+				Description:   sesEventDescriptions[StatusBounced] + " (Transient)",
+				BounceSubType: "MailboxFull",
+				Timestamp:     eventAt,
+			}},
+		},
+		{name: "tipo desconhecido", message: `{"eventType":"Processed",` + mail + `}`, err: "tipo de evento do SES desconhecido"},
+		{name: "JSON inválido", message: `{"eventType":`, err: "evento do SES inválido"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := ParseSESEvent([]byte(tt.message))
+			assertError(t, err, tt.err)
+			if err != nil {
+				return
+			}
+
+			got := make([]parsed, 0, len(events))
+			for _, event := range events {
+				if event.MessageID != "0100018e-abc" {
+					t.Errorf("MessageID = %q", event.MessageID)
+				}
+				if string(event.Payload) != tt.message {
+					t.Errorf("Payload não preserva a mensagem original")
+				}
+				got = append(got, parsed{event.Type, event.Recipient, event.Description, event.BounceSubType, event.Timestamp.UTC()})
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseSESEvent() = %+v, esperado %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("evento %d = %+v, esperado %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
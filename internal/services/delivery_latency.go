@@ -0,0 +1,404 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultLatencyWindow é o período analisado quando a data inicial não é informada
+const defaultLatencyWindow = 7 * 24 * time.Hour
+
+// maxLatencyWindow é o maior período aceito nos relatórios de latência
+const maxLatencyWindow = 31 * 24 * time.Hour
+
+// mailboxProviders mapeia os domínios dos principais provedores de caixa postal.
+// Domínios próprios (ex: Google Workspace) são agrupados como "other".
+var mailboxProviders = map[string]string{
+	"gmail.com":      "gmail",
+	"googlemail.com": "gmail",
+	"outlook.com":    "microsoft",
+	"outlook.com.br": "microsoft",
+	"hotmail.com":    "microsoft",
+	"hotmail.com.br": "microsoft",
+	"live.com":       "microsoft",
+	"msn.com":        "microsoft",
+	"yahoo.com":      "yahoo",
+	"yahoo.com.br":   "yahoo",
+	"ymail.com":      "yahoo",
+	"rocketmail.com": "yahoo",
+	"icloud.com":     "apple",
+	"me.com":         "apple",
+	"mac.com":        "apple",
+	"aol.com":        "aol",
+	"proton.me":      "proton",
+	"protonmail.com": "proton",
+	"uol.com.br":     "uol",
+	"bol.com.br":     "uol",
+	"terra.com.br":   "terra",
+}
+
+// SLAConfig define o tempo máximo entre o envio e a entrega de uma mensagem.
+// Os limites por remetente substituem o limite padrão.
+type SLAConfig struct {
+	DefaultThresholdSeconds float64            `json:"defaultThresholdSeconds"`
+	SenderThresholds        map[string]float64 `json:"senderThresholds,omitempty"`
+	UpdatedAt               time.Time          `json:"updatedAt,omitempty"`
+}
+
+// thresholdFor retorna o limite de SLA aplicado às mensagens do remetente
+func (c SLAConfig) thresholdFor(sender string) time.Duration {
+	seconds := c.DefaultThresholdSeconds
+	if custom, exists := c.SenderThresholds[strings.ToLower(sender)]; exists {
+		seconds = custom
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// LatencyStats representa a distribuição do tempo entre envio e entrega, em
+// segundos. Breaches inclui as entregas acima do SLA e os destinatários ainda
+// pendentes há mais tempo que o limite; SLACompliance é o percentual entregue
+// dentro do limite.
+type LatencyStats struct {
+	Count         int     `json:"count"`
+	AvgSeconds    float64 `json:"avgSeconds"`
+	P50Seconds    float64 `json:"p50Seconds"`
+	P90Seconds    float64 `json:"p90Seconds"`
+	P99Seconds    float64 `json:"p99Seconds"`
+	MaxSeconds    float64 `json:"maxSeconds"`
+	Breaches      int     `json:"breaches"`
+	SLACompliance float64 `json:"slaCompliance"`
+}
+
+// LatencyGroup representa a latência de um remetente, domínio ou provedor
+type LatencyGroup struct {
+	Key string `json:"key"`
+	LatencyStats
+}
+
+// LatencyReport representa a latência de entrega no período, geral e por
+// remetente, domínio do destinatário e provedor de caixa postal
+type LatencyReport struct {
+	Period     string         `json:"period"`
+	Overall    LatencyStats   `json:"overall"`
+	BySender   []LatencyGroup `json:"bySender"`
+	ByDomain   []LatencyGroup `json:"byDomain"`
+	ByProvider []LatencyGroup `json:"byProvider"`
+}
+
+// SLABreach representa um destinatário entregue acima do SLA, ou ainda
+// pendente há mais tempo que o limite
+type SLABreach struct {
+	MessageID        string    `json:"messageId"`
+	FromEmail        string    `json:"fromEmail"`
+	Recipient        string    `json:"recipient,omitempty"`
+	Subject          string    `json:"subject"`
+	Status           string    `json:"status"`
+	SentAt           time.Time `json:"sentAt"`
+	DeliveredAt      time.Time `json:"deliveredAt,omitempty"`
+	Pending          bool      `json:"pending"`
+	LatencySeconds   float64   `json:"latencySeconds"`
+	ThresholdSeconds float64   `json:"thresholdSeconds"`
+}
+
+// latencySample representa o tempo de entrega de um destinatário
+type latencySample struct {
+	messageID   string
+	fromEmail   string
+	subject     string
+	sentAt      time.Time
+	recipient   string
+	state       string
+	deliveredAt time.Time
+	latency     time.Duration
+	threshold   time.Duration
+	pending     bool
+}
+
+// breached indica se o destinatário está fora do SLA
+func (s latencySample) breached() bool {
+	return s.latency > s.threshold
+}
+
+// LatencyService calcula a latência de entrega (do envio ao evento de
+// entrega do SES) e a conformidade com o SLA configurado
+type LatencyService struct {
+	deliveryStore    DeliveryStore
+	slaStore         SLAStore
+	defaultThreshold time.Duration
+}
+
+// NewLatencyService cria uma nova instância do LatencyService. O limite
+// padrão é usado enquanto a configuração de SLA não é gravada.
+func NewLatencyService(deliveryStore DeliveryStore, slaStore SLAStore, defaultThreshold time.Duration) *LatencyService {
+	return &LatencyService{
+		deliveryStore:    deliveryStore,
+		slaStore:         slaStore,
+		defaultThreshold: defaultThreshold,
+	}
+}
+
+// GetSLAConfig retorna a configuração de SLA vigente
+func (s *LatencyService) GetSLAConfig() (*SLAConfig, error) {
+	config, err := s.slaStore.Get()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter configuração de SLA: %w", err)
+	}
+
+	if config == nil {
+		config = &SLAConfig{DefaultThresholdSeconds: s.defaultThreshold.Seconds()}
+	}
+	return config, nil
+}
+
+// UpdateSLAConfig valida e grava a configuração de SLA
+func (s *LatencyService) UpdateSLAConfig(config SLAConfig) (*SLAConfig, error) {
+	if config.DefaultThresholdSeconds <= 0 {
+		return nil, fmt.Errorf("limite padrão de SLA inválido: deve ser maior que zero")
+	}
+
+	thresholds := make(map[string]float64, len(config.SenderThresholds))
+	for sender, seconds := range config.SenderThresholds {
+		if seconds <= 0 {
+			return nil, fmt.Errorf("limite de SLA inválido para o remetente %s: deve ser maior que zero", sender)
+		}
+		thresholds[strings.ToLower(strings.TrimSpace(sender))] = seconds
+	}
+	config.SenderThresholds = thresholds
+	config.UpdatedAt = time.Now()
+
+	if err := s.slaStore.Save(config); err != nil {
+		return nil, fmt.Errorf("falha ao gravar configuração de SLA: %w", err)
+	}
+	return &config, nil
+}
+
+// GetLatencyReport calcula os percentis de latência das mensagens enviadas no
+// período do filtro (por padrão, os últimos 7 dias; no máximo 31 dias)
+func (s *LatencyService) GetLatencyReport(filter DeliveryFilter) (*LatencyReport, error) {
+	filter.Limit = 0
+	samples, err := s.samples(&filter)
+	if err != nil {
+		return nil, err
+	}
+
+	bySender := make(map[string][]latencySample)
+	byDomain := make(map[string][]latencySample)
+	byProvider := make(map[string][]latencySample)
+	for _, sample := range samples {
+		sender := strings.ToLower(sample.fromEmail)
+		bySender[sender] = append(bySender[sender], sample)
+
+		if domain := recipientDomain(sample.recipient); domain != "" {
+			provider := mailboxProvider(domain)
+			byDomain[domain] = append(byDomain[domain], sample)
+			byProvider[provider] = append(byProvider[provider], sample)
+		}
+	}
+
+	return &LatencyReport{
+		Period:     fmt.Sprintf("%s a %s", filter.Start.Format("2006-01-02"), filter.End.Format("2006-01-02")),
+		Overall:    newLatencyStats(samples),
+		BySender:   latencyGroups(bySender),
+		ByDomain:   latencyGroups(byDomain),
+		ByProvider: latencyGroups(byProvider),
+	}, nil
+}
+
+// GetSLABreaches lista os destinatários fora do SLA no período do filtro,
+// dos mais lentos para os mais rápidos
+func (s *LatencyService) GetSLABreaches(filter DeliveryFilter) ([]SLABreach, error) {
+	limit := filter.Limit
+	filter.Limit = 0
+
+	samples, err := s.samples(&filter)
+	if err != nil {
+		return nil, err
+	}
+
+	breaches := []SLABreach{}
+	for _, sample := range samples {
+		if !sample.breached() {
+			continue
+		}
+		breaches = append(breaches, SLABreach{
+			MessageID:        sample.messageID,
+			FromEmail:        sample.fromEmail,
+			Recipient:        sample.recipient,
+			Subject:          sample.subject,
+			Status:           sample.state,
+			SentAt:           sample.sentAt,
+			DeliveredAt:      sample.deliveredAt,
+			Pending:          sample.pending,
+			LatencySeconds:   sample.latency.Seconds(),
+			ThresholdSeconds: sample.threshold.Seconds(),
+		})
+	}
+
+	sort.Slice(breaches, func(i, j int) bool {
+		return breaches[i].LatencySeconds > breaches[j].LatencySeconds
+	})
+
+	if limit > 0 && len(breaches) > limit {
+		breaches = breaches[:limit]
+	}
+	return breaches, nil
+}
+
+// samples obtém o tempo de entrega de cada destinatário das mensagens do
+// filtro, percorrendo apenas o período pedido (no máximo 31 dias).
+// Destinatários ainda pendentes só são incluídos quando já excederam o SLA;
+// os que falharam (bounce, rejeição...) são ignorados.
+func (s *LatencyService) samples(filter *DeliveryFilter) ([]latencySample, error) {
+	now := time.Now()
+	if filter.End.IsZero() {
+		filter.End = now
+	}
+	if filter.Start.IsZero() {
+		filter.Start = filter.End.Add(-defaultLatencyWindow)
+	}
+	if filter.End.Before(filter.Start) {
+		return nil, fmt.Errorf("data final anterior à data inicial")
+	}
+	if filter.End.Sub(filter.Start) > maxLatencyWindow {
+		return nil, fmt.Errorf("período inválido: o máximo é de %d dias", int(maxLatencyWindow.Hours()/24))
+	}
+
+	config, err := s.GetSLAConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []latencySample
+	err = s.deliveryStore.Iterate(*filter, func(status DeliveryStatus) error {
+		if status.SentAt.IsZero() {
+			return nil
+		}
+		threshold := config.thresholdFor(status.FromEmail)
+
+		add := func(recipient, state string, deliveredAt time.Time) {
+			sample := latencySample{
+				messageID:   status.MessageID,
+				fromEmail:   status.FromEmail,
+				subject:     status.Subject,
+				sentAt:      status.SentAt,
+				recipient:   recipient,
+				state:       state,
+				deliveredAt: deliveredAt,
+				threshold:   threshold,
+			}
+
+			switch {
+			case !deliveredAt.IsZero():
+				sample.latency = deliveredAt.Sub(status.SentAt)
+			case state == StatusSent || state == StatusDelayed:
+				sample.latency = now.Sub(status.SentAt)
+				sample.pending = true
+				if !sample.breached() {
+					return
+				}
+			default:
+				return
+			}
+			samples = append(samples, sample)
+		}
+
+		// Mensagens anteriores ao rastreamento por destinatário têm apenas o status geral
+		if len(status.Recipients) == 0 {
+			add("", status.Status, status.DeliveredAt)
+			return nil
+		}
+		for _, r := range status.Recipients {
+			add(r.Email, r.Status, r.DeliveredAt)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return samples, nil
+}
+
+// newLatencyStats calcula a distribuição de latência das amostras entregues e
+// a conformidade com o SLA
+func newLatencyStats(samples []latencySample) LatencyStats {
+	var stats LatencyStats
+	var latencies []float64
+	var sum float64
+	pendingBreaches := 0
+
+	for _, sample := range samples {
+		if sample.breached() {
+			stats.Breaches++
+		}
+		if sample.pending {
+			pendingBreaches++
+			continue
+		}
+		latencies = append(latencies, sample.latency.Seconds())
+		sum += sample.latency.Seconds()
+	}
+
+	stats.Count = len(latencies)
+	if evaluated := stats.Count + pendingBreaches; evaluated > 0 {
+		within := evaluated - stats.Breaches
+		stats.SLACompliance = float64(within) / float64(evaluated) * 100
+	}
+	if stats.Count == 0 {
+		return stats
+	}
+
+	sort.Float64s(latencies)
+	stats.AvgSeconds = sum / float64(stats.Count)
+	stats.P50Seconds = percentile(latencies, 50)
+	stats.P90Seconds = percentile(latencies, 90)
+	stats.P99Seconds = percentile(latencies, 99)
+	stats.MaxSeconds = latencies[len(latencies)-1]
+
+	return stats
+}
+
+// latencyGroups calcula a latência de cada grupo, ordenados pela quantidade de entregas
+func latencyGroups(groups map[string][]latencySample) []LatencyGroup {
+	result := make([]LatencyGroup, 0, len(groups))
+	for key, samples := range groups {
+		result = append(result, LatencyGroup{Key: key, LatencyStats: newLatencyStats(samples)})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// percentile retorna o percentil (método do rank mais próximo) dos valores ordenados
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// recipientDomain retorna o domínio do endereço, em minúsculas
+func recipientDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
+
+// mailboxProvider identifica o provedor de caixa postal pelo domínio do destinatário
+func mailboxProvider(domain string) string {
+	if provider, exists := mailboxProviders[domain]; exists {
+		return provider
+	}
+	return "other"
+}
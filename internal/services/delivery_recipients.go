@@ -0,0 +1,234 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Tipos de destinatário
+const (
+	RecipientTo  = "to"
+	RecipientCc  = "cc"
+	RecipientBcc = "bcc"
+)
+
+// RecipientStatus representa o status de entrega para um destinatário de uma mensagem
+type RecipientStatus struct {
+	Email             string    `json:"email"`
+	Type              string    `json:"type,omitempty"`
+	Status            string    `json:"status"`
+	StatusDescription string    `json:"statusDescription,omitempty"`
+	DeliveredAt       time.Time `json:"deliveredAt,omitempty"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// RecipientSummary consolida os status dos destinatários de uma mensagem
+type RecipientSummary struct {
+	Total      int `json:"total"`
+	Pending    int `json:"pending"`
+	Delayed    int `json:"delayed"`
+	Delivered  int `json:"delivered"`
+	Bounced    int `json:"bounced"`
+	Complained int `json:"complained"`
+	Rejected   int `json:"rejected"`
+	Cancelled  int `json:"cancelled"`
+}
+
+// RecipientDelivery representa o que aconteceu com uma mensagem para um destinatário específico
+type RecipientDelivery struct {
+	MessageID string          `json:"messageId"`
+	FromEmail string          `json:"fromEmail"`
+	Subject   string          `json:"subject"`
+	SentAt    time.Time       `json:"sentAt"`
+	Recipient RecipientStatus `json:"recipient"`
+}
+
+// NewRecipients monta a lista de destinatários de uma mensagem a partir dos campos To, Cc e Bcc
+func NewRecipients(to, cc, bcc []string) []RecipientStatus {
+	now := time.Now()
+	recipients := make([]RecipientStatus, 0, len(to)+len(cc)+len(bcc))
+	seen := make(map[string]bool)
+
+	add := func(addresses []string, kind string) {
+		for _, address := range addresses {
+			email := strings.ToLower(addressEmail(address))
+			if email == "" || seen[email] {
+				continue
+			}
+			seen[email] = true
+			recipients = append(recipients, RecipientStatus{
+				Email:     email,
+				Type:      kind,
+				Status:    StatusSent,
+				UpdatedAt: now,
+			})
+		}
+	}
+
+	add(to, RecipientTo)
+	add(cc, RecipientCc)
+	add(bcc, RecipientBcc)
+	return recipients
+}
+
+// findRecipient obtém o destinatário da mensagem, retornando nil se não existir
+func (s *DeliveryStatus) findRecipient(email string) *RecipientStatus {
+	for i := range s.Recipients {
+		if strings.EqualFold(s.Recipients[i].Email, email) {
+			return &s.Recipients[i]
+		}
+	}
+	return nil
+}
+
+// eventTargets retorna os destinatários afetados por um evento. Eventos sem
+// destinatário valem para todos, exceto aberturas e cliques, que o SES não
+// associa a um destinatário e só são atribuídos quando há um único destinatário.
+func eventTargets(status *DeliveryStatus, event DeliveryEvent) []*RecipientStatus {
+	if event.Recipient != "" {
+		recipient := status.findRecipient(event.Recipient)
+		if recipient == nil {
+			// Destinatário presente apenas no envelope enviado ao SES, fora dos
+			// cabeçalhos registrados no envio: equivale a uma cópia oculta
+			status.Recipients = append(status.Recipients, RecipientStatus{
+				Email:     strings.ToLower(event.Recipient),
+				Type:      RecipientBcc,
+				Status:    StatusSent,
+				UpdatedAt: event.Timestamp,
+			})
+			recipient = &status.Recipients[len(status.Recipients)-1]
+		}
+		return []*RecipientStatus{recipient}
+	}
+
+	if event.Type == StatusOpened || event.Type == StatusClicked {
+		if len(status.Recipients) == 1 {
+			return []*RecipientStatus{&status.Recipients[0]}
+		}
+		return nil
+	}
+
+	targets := make([]*RecipientStatus, 0, len(status.Recipients))
+	for i := range status.Recipients {
+		targets = append(targets, &status.Recipients[i])
+	}
+	return targets
+}
+
+// applyRecipientEvent aplica o evento aos destinatários afetados e recalcula
+// o status consolidado. Destinatários cujo status não aceita o evento são
+// ignorados (e registrados em log); o evento só é recusado se nenhum o aceitar.
+func applyRecipientEvent(status *DeliveryStatus, event DeliveryEvent) error {
+	targets := eventTargets(status, event)
+	if len(targets) == 0 {
+		// Evento sem destinatário identificado: validar contra o status consolidado
+		if _, err := nextStatus(status.Status, event.Type); err != nil {
+			return err
+		}
+	}
+
+	var skipped error
+	applied := 0
+	for _, recipient := range targets {
+		next, err := nextStatus(recipient.Status, event.Type)
+		if err != nil {
+			skipped = fmt.Errorf("destinatário %s: %w", recipient.Email, err)
+			log.Printf("Evento %s da mensagem %s ignorado para o %v", event.Type, status.MessageID, skipped)
+			continue
+		}
+		applied++
+
+		if next != recipient.Status {
+			recipient.Status = next
+			recipient.StatusDescription = event.Description
+		}
+		if event.Type == StatusDelivered && recipient.DeliveredAt.IsZero() {
+			recipient.DeliveredAt = event.Timestamp
+		}
+		recipient.UpdatedAt = event.Timestamp
+	}
+	if len(targets) > 0 && applied == 0 {
+		return skipped
+	}
+
+	applyEventTimestamps(status, event)
+
+	previous := status.Status
+	rollUpStatus(status)
+	if status.Status != previous {
+		status.StatusDescription = event.Description
+	}
+
+	return nil
+}
+
+// rollUpStatus recalcula o resumo e o status consolidado da mensagem a partir dos destinatários.
+// A mensagem é considerada entregue se ao menos um destinatário a recebeu, e só
+// assume um status de falha quando todos os destinatários falharam.
+func rollUpStatus(status *DeliveryStatus) {
+	summary := RecipientSummary{Total: len(status.Recipients)}
+	for _, recipient := range status.Recipients {
+		switch recipient.Status {
+		case StatusSent:
+			summary.Pending++
+		case StatusDelayed:
+			summary.Delayed++
+		case StatusDelivered, StatusOpened, StatusClicked:
+			summary.Delivered++
+		case StatusBounced:
+			summary.Bounced++
+		case StatusComplained:
+			summary.Complained++
+		case StatusRejected:
+			summary.Rejected++
+		case StatusCancelled:
+			summary.Cancelled++
+		}
+	}
+	status.Summary = &summary
+
+	failed := summary.Bounced + summary.Complained + summary.Rejected + summary.Cancelled
+	switch {
+	case summary.Total == 0:
+		return
+	case summary.Cancelled == summary.Total:
+		status.Status = StatusCancelled
+	case summary.Rejected == summary.Total:
+		status.Status = StatusRejected
+	case status.ClickCount > 0:
+		status.Status = StatusClicked
+	case !status.OpenedAt.IsZero():
+		status.Status = StatusOpened
+	case summary.Delivered > 0:
+		status.Status = StatusDelivered
+	case failed == summary.Total && summary.Complained > 0:
+		status.Status = StatusComplained
+	case failed == summary.Total:
+		status.Status = StatusBounced
+	case summary.Delayed > 0:
+		status.Status = StatusDelayed
+	default:
+		status.Status = StatusSent
+	}
+}
+
+// recipientDeliveries retorna a visão por destinatário das mensagens enviadas a um endereço
+func recipientDeliveries(statuses []DeliveryStatus, email string) []RecipientDelivery {
+	result := make([]RecipientDelivery, 0, len(statuses))
+	for i := range statuses {
+		recipient := statuses[i].findRecipient(email)
+		if recipient == nil {
+			continue
+		}
+		result = append(result, RecipientDelivery{
+			MessageID: statuses[i].MessageID,
+			FromEmail: statuses[i].FromEmail,
+			Subject:   statuses[i].Subject,
+			SentAt:    statuses[i].SentAt,
+			Recipient: *recipient,
+		})
+	}
+	return result
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// recipientsWith monta destinatários "para" com os status informados
+func recipientsWith(statuses ...string) []RecipientStatus {
+	recipients := make([]RecipientStatus, 0, len(statuses))
+	for i, status := range statuses {
+		recipients = append(recipients, RecipientStatus{
+			Email:  string(rune('a'+i)) + "@cliente.com",
+			Type:   RecipientTo,
+			Status: status,
+		})
+	}
+	return recipients
+}
+
+func TestRollUpStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		recipients  []RecipientStatus
+		clicks      int
+		opened      bool
+		want        string
+		wantSummary RecipientSummary
+	}{
+		{name: "sem destinatários mantém o status", want: StatusSent},
+		{name: "todos pendentes", recipients: recipientsWith(StatusSent, StatusSent), want: StatusSent, wantSummary: RecipientSummary{Total: 2, Pending: 2}},
+		{name: "um atrasado", recipients: recipientsWith(StatusSent, StatusDelayed), want: StatusDelayed, wantSummary: RecipientSummary{Total: 2, Pending: 1, Delayed: 1}},
+		{name: "atrasado e bounce", recipients: recipientsWith(StatusDelayed, StatusBounced), want: StatusDelayed, wantSummary: RecipientSummary{Total: 2, Delayed: 1, Bounced: 1}},
+		{name: "pendente e bounce", recipients: recipientsWith(StatusSent, StatusBounced), want: StatusSent, wantSummary: RecipientSummary{Total: 2, Pending: 1, Bounced: 1}},
+		{name: "um entregue basta", recipients: recipientsWith(StatusDelivered, StatusBounced, StatusComplained), want: StatusDelivered, wantSummary: RecipientSummary{Total: 3, Delivered: 1, Bounced: 1, Complained: 1}},
+		{name: "aberto e clicado contam como entregues", recipients: recipientsWith(StatusOpened, StatusClicked), want: StatusDelivered, wantSummary: RecipientSummary{Total: 2, Delivered: 2}},
+		{name: "todos com bounce", recipients: recipientsWith(StatusBounced, StatusBounced), want: StatusBounced, wantSummary: RecipientSummary{Total: 2, Bounced: 2}},
+		{name: "bounce e reclamação", recipients: recipientsWith(StatusBounced, StatusComplained), want: StatusComplained, wantSummary: RecipientSummary{Total: 2, Bounced: 1, Complained: 1}},
+		{name: "bounce e rejeição", recipients: recipientsWith(StatusBounced, StatusRejected), want: StatusBounced, wantSummary: RecipientSummary{Total: 2, Bounced: 1, Rejected: 1}},
+		{name: "cancelamento e bounce", recipients: recipientsWith(StatusCancelled, StatusBounced), want: StatusBounced, wantSummary: RecipientSummary{Total: 2, Bounced: 1, Cancelled: 1}},
+		{name: "todos rejeitados", recipients: recipientsWith(StatusRejected, StatusRejected), want: StatusRejected, wantSummary: RecipientSummary{Total: 2, Rejected: 2}},
+		{name: "todos cancelados", recipients: recipientsWith(StatusCancelled), want: StatusCancelled, wantSummary: RecipientSummary{Total: 1, Cancelled: 1}},
+		{name: "clique da mensagem", recipients: recipientsWith(StatusDelivered, StatusDelivered), clicks: 2, want: StatusClicked, wantSummary: RecipientSummary{Total: 2, Delivered: 2}},
+		{name: "abertura da mensagem", recipients: recipientsWith(StatusDelivered, StatusSent), opened: true, want: StatusOpened, wantSummary: RecipientSummary{Total: 2, Pending: 1, Delivered: 1}},
+		{name: "clique prevalece sobre abertura", recipients: recipientsWith(StatusDelivered), clicks: 1, opened: true, want: StatusClicked, wantSummary: RecipientSummary{Total: 1, Delivered: 1}},
+		{name: "cancelamento prevalece sobre clique", recipients: recipientsWith(StatusCancelled), clicks: 1, want: StatusCancelled, wantSummary: RecipientSummary{Total: 1, Cancelled: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := DeliveryStatus{Status: StatusSent, Recipients: tt.recipients, ClickCount: tt.clicks}
+			if tt.opened {
+				status.OpenedAt = storeBase
+			}
+
+			rollUpStatus(&status)
+			if status.Status != tt.want {
+				t.Errorf("Status = %s, esperado %s", status.Status, tt.want)
+			}
+			if status.Summary == nil || *status.Summary != tt.wantSummary {
+				t.Errorf("Summary = %+v, esperado %+v", status.Summary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestApplyRecipientEvent(t *testing.T) {
+	event := func(kind, recipient string) DeliveryEvent {
+		return DeliveryEvent{MessageID: "m1", Type: kind, Recipient: recipient, Description: kind, Timestamp: storeBase}
+	}
+
+	tests := []struct {
+		name       string
+		recipients []string
+		events     []DeliveryEvent
+		err        string
+		want       string
+		// Destinatários esperados no formato "email tipo STATUS"
+		wantRecipients []string
+		wantClicks     int
+	}{
+		{
+			name:           "entrega para um dos destinatários",
+			recipients:     []string{"a@cliente.com", "b@cliente.com"},
+			events:         []DeliveryEvent{event(StatusDelivered, "a@cliente.com")},
+			want:           StatusDelivered,
+			wantRecipients: []string{"a@cliente.com to DELIVERED", "b@cliente.com to SENT"},
+		},
+		{
+			name:           "destinatário com outra capitalização",
+			recipients:     []string{"a@cliente.com"},
+			events:         []DeliveryEvent{event(StatusDelivered, "A@Cliente.com")},
+			want:           StatusDelivered,
+			wantRecipients: []string{"a@cliente.com to DELIVERED"},
+		},
+		{
+			name:           "atraso e entrega",
+			recipients:     []string{"a@cliente.com", "b@cliente.com"},
+			events:         []DeliveryEvent{event(StatusDelayed, "a@cliente.com"), event(StatusDelivered, "b@cliente.com")},
+			want:           StatusDelivered,
+			wantRecipients: []string{"a@cliente.com to DELAYED", "b@cliente.com to DELIVERED"},
+		},
+		{
+			name:           "bounce de todos",
+			recipients:     []string{"a@cliente.com", "b@cliente.com"},
+			events:         []DeliveryEvent{event(StatusBounced, "a@cliente.com"), event(StatusBounced, "b@cliente.com")},
+			want:           StatusBounced,
+			wantRecipients: []string{"a@cliente.com to BOUNCED", "b@cliente.com to BOUNCED"},
+		},
+		{
+			name:           "bounce e reclamação",
+			recipients:     []string{"a@cliente.com", "b@cliente.com"},
+			events:         []DeliveryEvent{event(StatusBounced, "a@cliente.com"), event(StatusDelivered, "b@cliente.com"), event(StatusComplained, "b@cliente.com")},
+			want:           StatusComplained,
+			wantRecipients: []string{"a@cliente.com to BOUNCED", "b@cliente.com to COMPLAINED"},
+		},
+		{
+			name:           "bounce após entrega de outro destinatário",
+			recipients:     []string{"a@cliente.com", "b@cliente.com"},
+			events:         []DeliveryEvent{event(StatusDelivered, "a@cliente.com"), event(StatusBounced, "b@cliente.com")},
+			want:           StatusDelivered,
+			wantRecipients: []string{"a@cliente.com to DELIVERED", "b@cliente.com to BOUNCED"},
+		},
+		{
+			name:           "cancelamento de todos",
+			recipients:     []string{"a@cliente.com", "b@cliente.com"},
+			events:         []DeliveryEvent{event(StatusCancelled, "")},
+			want:           StatusCancelled,
+			wantRecipients: []string{"a@cliente.com to CANCELLED", "b@cliente.com to CANCELLED"},
+		},
+		{
+			name:           "cancelamento ignorado para quem já recebeu",
+			recipients:     []string{"a@cliente.com", "b@cliente.com"},
+			events:         []DeliveryEvent{event(StatusDelivered, "a@cliente.com"), event(StatusCancelled, "")},
+			want:           StatusDelivered,
+			wantRecipients: []string{"a@cliente.com to DELIVERED", "b@cliente.com to CANCELLED"},
+		},
+		{
+			name:           "rejeição de todos",
+			recipients:     []string{"a@cliente.com", "b@cliente.com"},
+			events:         []DeliveryEvent{event(StatusRejected, "a@cliente.com"), event(StatusRejected, "b@cliente.com")},
+			want:           StatusRejected,
+			wantRecipients: []string{"a@cliente.com to REJECTED", "b@cliente.com to REJECTED"},
+		},
+		{
+			name:           "evento recusado por todos os destinatários",
+			recipients:     []string{"a@cliente.com"},
+			events:         []DeliveryEvent{event(StatusBounced, "a@cliente.com"), event(StatusDelivered, "a@cliente.com")},
+			err:            "destinatário a@cliente.com: transição de status inválida",
+			want:           StatusBounced,
+			wantRecipients: []string{"a@cliente.com to BOUNCED"},
+		},
+		{
+			name:           "abertura com um destinatário",
+			recipients:     []string{"a@cliente.com"},
+			events:         []DeliveryEvent{event(StatusDelivered, "a@cliente.com"), event(StatusOpened, "")},
+			want:           StatusOpened,
+			wantRecipients: []string{"a@cliente.com to OPENED"},
+		},
+		{
+			name:           "abertura com vários destinatários",
+			recipients:     []string{"a@cliente.com", "b@cliente.com"},
+			events:         []DeliveryEvent{event(StatusDelivered, "a@cliente.com"), event(StatusOpened, "")},
+			want:           StatusOpened,
+			wantRecipients: []string{"a@cliente.com to DELIVERED", "b@cliente.com to SENT"},
+		},
+		{
+			name:           "cliques com vários destinatários",
+			recipients:     []string{"a@cliente.com", "b@cliente.com"},
+			events:         []DeliveryEvent{event(StatusDelivered, "a@cliente.com"), event(StatusClicked, ""), event(StatusClicked, "")},
+			want:           StatusClicked,
+			wantRecipients: []string{"a@cliente.com to DELIVERED", "b@cliente.com to SENT"},
+			wantClicks:     2,
+		},
+		{
+			name:           "abertura com vários destinatários após bounce de todos",
+			recipients:     []string{"a@cliente.com", "b@cliente.com"},
+			events:         []DeliveryEvent{event(StatusBounced, "a@cliente.com"), event(StatusBounced, "b@cliente.com"), event(StatusOpened, "")},
+			err:            "transição de status inválida: BOUNCED -> OPENED",
+			want:           StatusBounced,
+			wantRecipients: []string{"a@cliente.com to BOUNCED", "b@cliente.com to BOUNCED"},
+		},
+		{
+			name:           "destinatário desconhecido incluído como cópia oculta",
+			recipients:     []string{"a@cliente.com"},
+			events:         []DeliveryEvent{event(StatusBounced, "a@cliente.com"), event(StatusDelivered, "Oculto@Cliente.com")},
+			want:           StatusDelivered,
+			wantRecipients: []string{"a@cliente.com to BOUNCED", "oculto@cliente.com bcc DELIVERED"},
+		},
+		{
+			name:       "destinatário desconhecido em eventos seguidos",
+			recipients: []string{"a@cliente.com"},
+			events: []DeliveryEvent{
+				event(StatusDelivered, "oculto@cliente.com"),
+				event(StatusComplained, "OCULTO@cliente.com"),
+				event(StatusBounced, "a@cliente.com"),
+			},
+			want:           StatusComplained,
+			wantRecipients: []string{"a@cliente.com to BOUNCED", "oculto@cliente.com bcc COMPLAINED"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := deliveryFixture("m1", "loja@exemplo.com", storeBase)
+			status.Recipients = NewRecipients(tt.recipients, nil, nil)
+
+			var err error
+			for _, e := range tt.events {
+				err = applyRecipientEvent(&status, e)
+			}
+			assertError(t, err, tt.err)
+
+			if status.Status != tt.want {
+				t.Errorf("Status = %s, esperado %s", status.Status, tt.want)
+			}
+			if status.ClickCount != tt.wantClicks {
+				t.Errorf("ClickCount = %d, esperado %d", status.ClickCount, tt.wantClicks)
+			}
+
+			got := make([]string, 0, len(status.Recipients))
+			for _, r := range status.Recipients {
+				got = append(got, strings.Join([]string{r.Email, r.Type, r.Status}, " "))
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.wantRecipients, ", ") {
+				t.Errorf("destinatários = %v, esperado %v", got, tt.wantRecipients)
+			}
+		})
+	}
+}
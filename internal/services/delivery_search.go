@@ -0,0 +1,228 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limites de paginação da busca de status de entrega
+const (
+	DefaultDeliveryPageSize = 50
+	MaxDeliveryPageSize     = 500
+)
+
+// MaxDeliveryCount limita a contagem de resultados da primeira página. Acima
+// dele, Total e ByStatus refletem apenas os primeiros resultados e
+// TotalCapped indica que a contagem foi interrompida.
+const MaxDeliveryCount = 10000
+
+// errCountCapped interrompe a contagem ao atingir MaxDeliveryCount
+var errCountCapped = errors.New("contagem limitada")
+
+// DeliveryQuery define uma busca paginada de status de entrega.
+// Sort aceita "sentAt" ou "-sentAt" (padrão), a ordem do índice do store, que
+// é estável entre as páginas; Fields restringe os campos retornados em cada item.
+type DeliveryQuery struct {
+	Filter DeliveryFilter
+	Sort   string
+	Cursor string
+	Limit  int
+	Fields []string
+}
+
+// DeliveryPage representa uma página de resultados da busca de status de
+// entrega. Total e ByStatus são calculados apenas na primeira página (sem
+// cursor), até MaxDeliveryCount resultados.
+type DeliveryPage struct {
+	Items       []interface{}  `json:"items"`
+	Total       *int           `json:"total,omitempty"`
+	TotalCapped bool           `json:"totalCapped,omitempty"`
+	ByStatus    map[string]int `json:"byStatus,omitempty"`
+	NextCursor  string         `json:"nextCursor,omitempty"`
+}
+
+// deliveryCursor identifica a posição do último item retornado em uma página
+type deliveryCursor struct {
+	Sort      string `json:"s"`
+	Key       string `json:"k"`
+	MessageID string `json:"id"`
+}
+
+// SearchDeliveries busca os status de entrega que atendem ao filtro, com
+// ordenação por data de envio, paginação por cursor e seleção de campos. Cada
+// página percorre o índice do store a partir do cursor.
+func (s *DeliveryService) SearchDeliveries(query DeliveryQuery) (*DeliveryPage, error) {
+	sortSpec := query.Sort
+	if sortSpec == "" {
+		sortSpec = "-sentAt"
+	}
+	if sortSpec != "sentAt" && sortSpec != "-sentAt" {
+		return nil, fmt.Errorf("campo de ordenação inválido: %s (use sentAt ou -sentAt)", strings.TrimPrefix(sortSpec, "-"))
+	}
+	descending := sortSpec == "-sentAt"
+
+	if err := validateDeliveryFields(query.Fields); err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultDeliveryPageSize
+	}
+	if limit > MaxDeliveryPageSize {
+		limit = MaxDeliveryPageSize
+	}
+
+	var sentAt time.Time
+	messageID := ""
+	if query.Cursor != "" {
+		cursor, err := decodeDeliveryCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		// Cursores sem ordenação usam a ordenação padrão
+		if cursor.Sort == "" {
+			cursor.Sort = "-sentAt"
+		}
+		if cursor.Sort != sortSpec {
+			return nil, fmt.Errorf("cursor inválido: gerado para outra ordenação")
+		}
+
+		nanos, err := strconv.ParseInt(cursor.Key, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cursor inválido")
+		}
+		sentAt, messageID = time.Unix(0, nanos), cursor.MessageID
+	}
+
+	filter := query.Filter
+	filter.Limit = 0
+
+	page := &DeliveryPage{Items: []interface{}{}}
+	if query.Cursor == "" {
+		// A contagem percorre os resultados do filtro sem mantê-los em memória
+		total := 0
+		page.ByStatus = make(map[string]int)
+		err := s.store.Iterate(filter, func(status DeliveryStatus) error {
+			if total >= MaxDeliveryCount {
+				return errCountCapped
+			}
+			total++
+			page.ByStatus[status.Status]++
+			return nil
+		})
+		if errors.Is(err, errCountCapped) {
+			page.TotalCapped = true
+		} else if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	// Um item além do limite indica se há uma página seguinte
+	filter.Limit = limit + 1
+	statuses, err := s.store.ListAfter(filter, sentAt, messageID, descending)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(statuses) > limit
+	if hasMore {
+		statuses = statuses[:limit]
+	}
+
+	for _, status := range statuses {
+		item, err := selectDeliveryFields(status, query.Fields)
+		if err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, item)
+	}
+
+	if hasMore {
+		last := statuses[len(statuses)-1]
+		page.NextCursor = encodeDeliveryCursor(deliveryCursor{
+			Sort:      sortSpec,
+			Key:       timeKey(last.SentAt),
+			MessageID: last.MessageID,
+		})
+	}
+
+	return page, nil
+}
+
+// encodeDeliveryCursor serializa o cursor em um token opaco
+func encodeDeliveryCursor(cursor deliveryCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeDeliveryCursor lê um cursor gerado por encodeDeliveryCursor
+func decodeDeliveryCursor(token string) (deliveryCursor, error) {
+	var cursor deliveryCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, fmt.Errorf("cursor inválido")
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.MessageID == "" {
+		return cursor, fmt.Errorf("cursor inválido")
+	}
+
+	return cursor, nil
+}
+
+// deliveryStatusFields retorna os nomes JSON dos campos de DeliveryStatus
+func deliveryStatusFields() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(DeliveryStatus{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// validateDeliveryFields verifica se os campos selecionados existem em DeliveryStatus
+func validateDeliveryFields(fields []string) error {
+	known := deliveryStatusFields()
+	for _, field := range fields {
+		if !known[field] {
+			return fmt.Errorf("campo desconhecido: %s", field)
+		}
+	}
+	return nil
+}
+
+// selectDeliveryFields retorna o status completo ou, se houver seleção, apenas os campos pedidos
+func selectDeliveryFields(status DeliveryStatus, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return status, nil
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// searchAll percorre todas as páginas da busca, retornando os IDs na ordem
+// recebida e a quantidade de páginas
+func searchAll(t *testing.T, service *DeliveryService, query DeliveryQuery) ([]string, int) {
+	t.Helper()
+	ids := []string{}
+	pages := 0
+	for {
+		page, err := service.SearchDeliveries(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		if pages > 100 {
+			t.Fatal("paginação não terminou")
+		}
+		if query.Cursor != "" && page.Total != nil {
+			t.Error("páginas seguintes não deveriam repetir o total")
+		}
+		for _, item := range page.Items {
+			ids = append(ids, item.(DeliveryStatus).MessageID)
+		}
+		if page.NextCursor == "" {
+			return ids, pages
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func TestSearchDeliveriesPagination(t *testing.T) {
+	forEachDeliveryStore(t, func(t *testing.T, h deliveryStoreHarness) {
+		// Envios no mesmo instante são desempatados pelo MessageID
+		seedDeliveries(t, h.store,
+			deliveryFixture("m5", "a@exemplo.com", at(2), "r@cliente.com"),
+			deliveryFixture("m1", "a@exemplo.com", at(0), "r@cliente.com"),
+			deliveryFixture("m3", "b@exemplo.com", at(1), "r@cliente.com"),
+			deliveryFixture("m2", "a@exemplo.com", at(1), "r@cliente.com"),
+			deliveryFixture("m4", "a@exemplo.com", at(1), "r@cliente.com"),
+			deliveryFixture("m6", "a@exemplo.com", at(3), "r@cliente.com"),
+		)
+		service := NewDeliveryService(nil, h.store, nil)
+
+		ascending := []string{"m1", "m2", "m3", "m4", "m5", "m6"}
+		descending := []string{"m6", "m5", "m4", "m3", "m2", "m1"}
+
+		tests := []struct {
+			name      string
+			query     DeliveryQuery
+			want      []string
+			wantPages int
+		}{
+			{name: "padrão decrescente", query: DeliveryQuery{Limit: 4}, want: descending, wantPages: 2},
+			{name: "crescente", query: DeliveryQuery{Sort: "sentAt", Limit: 4}, want: ascending, wantPages: 2},
+			{name: "empates divididos entre páginas", query: DeliveryQuery{Sort: "sentAt", Limit: 2}, want: ascending, wantPages: 3},
+			{name: "empates divididos em ordem decrescente", query: DeliveryQuery{Sort: "-sentAt", Limit: 2}, want: descending, wantPages: 3},
+			{name: "página de um item", query: DeliveryQuery{Sort: "sentAt", Limit: 1}, want: ascending, wantPages: 6},
+			{name: "múltiplo exato do limite sem página vazia", query: DeliveryQuery{Limit: 3}, want: descending, wantPages: 2},
+			{name: "tudo em uma página", query: DeliveryQuery{Limit: 6}, want: descending, wantPages: 1},
+			{name: "limite maior que os resultados", query: DeliveryQuery{Limit: 50}, want: descending, wantPages: 1},
+			{
+				name:      "filtro preservado entre páginas",
+				query:     DeliveryQuery{Filter: DeliveryFilter{FromEmail: "a@exemplo.com"}, Sort: "sentAt", Limit: 2},
+				want:      []string{"m1", "m2", "m4", "m5", "m6"},
+				wantPages: 3,
+			},
+			{name: "sem resultados", query: DeliveryQuery{Filter: DeliveryFilter{FromEmail: "x@exemplo.com"}}, want: []string{}, wantPages: 1},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, pages := searchAll(t, service, tt.query)
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("SearchDeliveries() = %v, esperado %v", got, tt.want)
+				}
+				if pages != tt.wantPages {
+					t.Errorf("SearchDeliveries() em %d páginas, esperado %d", pages, tt.wantPages)
+				}
+			})
+		}
+	})
+}
+
+func TestSearchDeliveriesCounts(t *testing.T) {
+	cache := NewStatusCache()
+	delivered := deliveryFixture("m2", "a@exemplo.com", at(1), "r@cliente.com")
+	delivered.Status = StatusDelivered
+	seedDeliveries(t, cache,
+		deliveryFixture("m1", "a@exemplo.com", at(0), "r@cliente.com"),
+		delivered,
+		deliveryFixture("m3", "a@exemplo.com", at(2), "r@cliente.com"),
+	)
+	service := NewDeliveryService(nil, cache, nil)
+
+	page, err := service.SearchDeliveries(DeliveryQuery{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total == nil || *page.Total != 3 || page.TotalCapped {
+		t.Errorf("Total = %v, TotalCapped = %v, esperado 3 sem limite", page.Total, page.TotalCapped)
+	}
+	if page.ByStatus[StatusSent] != 2 || page.ByStatus[StatusDelivered] != 1 {
+		t.Errorf("ByStatus = %v", page.ByStatus)
+	}
+}
+
+func TestSearchDeliveriesCountCapped(t *testing.T) {
+	cache := NewStatusCache()
+	for i := 0; i <= MaxDeliveryCount; i++ {
+		seedDeliveries(t, cache, deliveryFixture(fmt.Sprintf("m%05d", i), "a@exemplo.com", at(i), "r@cliente.com"))
+	}
+	service := NewDeliveryService(nil, cache, nil)
+
+	page, err := service.SearchDeliveries(DeliveryQuery{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total == nil || *page.Total != MaxDeliveryCount || !page.TotalCapped {
+		t.Errorf("Total = %v, TotalCapped = %v, esperado %d limitado", page.Total, page.TotalCapped, MaxDeliveryCount)
+	}
+	if page.ByStatus[StatusSent] != MaxDeliveryCount {
+		t.Errorf("ByStatus = %v", page.ByStatus)
+	}
+	if len(page.Items) != 10 || page.NextCursor == "" {
+		t.Errorf("página com %d itens e cursor %q", len(page.Items), page.NextCursor)
+	}
+}
+
+func TestSearchDeliveriesInvalid(t *testing.T) {
+	cache := NewStatusCache()
+	seedDeliveries(t, cache,
+		deliveryFixture("m1", "a@exemplo.com", at(0), "r@cliente.com"),
+		deliveryFixture("m2", "a@exemplo.com", at(1), "r@cliente.com"),
+	)
+	service := NewDeliveryService(nil, cache, nil)
+
+	first, err := service.SearchDeliveries(DeliveryQuery{Sort: "sentAt", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query DeliveryQuery
+		err   string
+	}{
+		{name: "ordenação não indexada", query: DeliveryQuery{Sort: "updatedAt"}, err: "campo de ordenação inválido"},
+		{name: "ordenação decrescente não indexada", query: DeliveryQuery{Sort: "-subject"}, err: "campo de ordenação inválido"},
+		{name: "campo inexistente", query: DeliveryQuery{Fields: []string{"senha"}}, err: "desconhecido"},
+		{name: "cursor malformado", query: DeliveryQuery{Cursor: "%%%"}, err: "cursor inválido"},
+		{name: "cursor sem mensagem", query: DeliveryQuery{Cursor: encodeDeliveryCursor(deliveryCursor{Sort: "-sentAt", Key: "1"})}, err: "cursor inválido"},
+		{name: "cursor com chave inválida", query: DeliveryQuery{Cursor: encodeDeliveryCursor(deliveryCursor{Sort: "-sentAt", Key: "x", MessageID: "m1"})}, err: "cursor inválido"},
+		{name: "cursor de outra ordenação", query: DeliveryQuery{Sort: "-sentAt", Cursor: first.NextCursor}, err: "outra ordenação"},
+		{name: "cursor da mesma ordenação", query: DeliveryQuery{Sort: "sentAt", Cursor: first.NextCursor}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.SearchDeliveries(tt.query)
+			assertError(t, err, tt.err)
+		})
+	}
+}
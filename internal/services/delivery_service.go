@@ -3,13 +3,11 @@ package services
 import (
 	"context"
 	"fmt"
-	"sort"
+	"log"
 	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
-	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/renat/poc-ses/internal/metrics"
 )
 
 // DeliveryStatus representa o status de entrega atual 
@@ -25,6 +23,19 @@ type DeliveryStatus struct {
 	ClickCount        int       `json:"clickCount"`
 	LastClickAt       time.Time `json:"lastClickAt,omitempty"`
 	Subject           string    `json:"subject"`
+	Recipients        []RecipientStatus `json:"recipients,omitempty"`
+	Summary           *RecipientSummary `json:"summary,omitempty"`
+	Tags              map[string]string `json:"tags,omitempty"`
+	TemplateID        string            `json:"templateId,omitempty"`
+	ConfigurationSet  string            `json:"configurationSet,omitempty"`
+}
+
+// TrackOptions define os metadados opcionais de um envio rastreado, usados
+// nas buscas e na agregação das métricas locais
+type TrackOptions struct {
+	Tags             map[string]string
+	TemplateID       string
+	ConfigurationSet string
 }
 
 // DeliveryReport representa um relatório de entregas
@@ -40,10 +51,12 @@ type DeliveryReport struct {
 	ClickRate       float64           `json:"clickRate"`
 	DetailedStatus  []DeliveryStatus  `json:"detailedStatus"`
 	HourlyStats     []HourlyStats     `json:"hourlyStats"`
+	Anomalies       []Anomaly         `json:"anomalies"`
 }
 
 // HourlyStats representa estatísticas de entrega por hora
 type HourlyStats struct {
+	Timestamp  time.Time `json:"timestamp"`
 	Hour       int     `json:"hour"`
 	Sent       int     `json:"sent"`
 	Delivered  int     `json:"delivered"`
@@ -54,105 +67,217 @@ type HourlyStats struct {
 	ClickRate  float64 `json:"clickRate"`
 }
 
-// StatusCache representa um cache de status de entregas
-type StatusCache struct {
-	statuses map[string]DeliveryStatus
-	mutex    sync.RWMutex
-}
+// DeliveryListener é notificado a cada evento registrado para uma entrega,
+// recebendo o status já atualizado
+type DeliveryListener func(status DeliveryStatus, event DeliveryEvent)
 
 // DeliveryService gerencia informações sobre entregas de e-mails
 type DeliveryService struct {
-	cloudWatchClient *cloudwatch.Client
-	cache            *StatusCache
+	metricsQuerier *MetricsQuerier
+	store          DeliveryStore
+	listeners      []DeliveryListener
+	snsVerifier    *SNSVerifier
+	// eventMutex é compartilhado pelos registros de eventos e exclusivo em PauseEvents
+	eventMutex sync.RWMutex
 }
 
-// NewDeliveryService cria uma nova instância do DeliveryService
-func NewDeliveryService(cwClient *cloudwatch.Client) *DeliveryService {
+// NewDeliveryService cria uma nova instância do DeliveryService. As mensagens
+// do SNS são aceitas apenas com assinatura válida e de tópicos autorizados.
+func NewDeliveryService(metricsQuerier *MetricsQuerier, store DeliveryStore, snsVerifier *SNSVerifier) *DeliveryService {
 	return &DeliveryService{
-		cloudWatchClient: cwClient,
-		cache: &StatusCache{
-			statuses: make(map[string]DeliveryStatus),
-		},
+		metricsQuerier: metricsQuerier,
+		store:          store,
+		snsVerifier:    snsVerifier,
 	}
 }
 
-// TrackDelivery registra um novo e-mail enviado para rastreamento
-func (s *DeliveryService) TrackDelivery(email, messageId, subject string) {
+// TrackDelivery registra um novo e-mail enviado para rastreamento, com o status
+// de cada destinatário acompanhado separadamente. Os listeners recebem o evento SENT.
+func (s *DeliveryService) TrackDelivery(email, messageId, subject string, recipients []RecipientStatus, opts TrackOptions) {
+	s.eventMutex.RLock()
+	defer s.eventMutex.RUnlock()
+
+	now := time.Now()
 	status := DeliveryStatus{
-		ID:                fmt.Sprintf("%s-%d", messageId, time.Now().Unix()),
+		ID:                fmt.Sprintf("%s-%d", messageId, now.Unix()),
 		FromEmail:         email,
 		MessageID:         messageId,
-		Status:            "SENT",
+		Status:            StatusSent,
 		StatusDescription: "E-mail enviado e aguardando processamento",
-		SentAt:            time.Now(),
+		SentAt:            now,
 		Subject:           subject,
 		ClickCount:        0,
+		Recipients:        recipients,
+		Tags:              opts.Tags,
+		TemplateID:        opts.TemplateID,
+		ConfigurationSet:  opts.ConfigurationSet,
+	}
+	rollUpStatus(&status)
+
+	if err := s.store.Save(status); err != nil {
+		log.Printf("Falha ao registrar entrega %s: %v", messageId, err)
+		return
+	}
+
+	event := DeliveryEvent{
+		ID:          fmt.Sprintf("%s-%d", messageId, now.UnixNano()),
+		MessageID:   messageId,
+		Type:        StatusSent,
+		Description: status.StatusDescription,
+		Timestamp:   now,
 	}
+	if err := s.store.AppendEvent(event); err != nil {
+		log.Printf("Falha ao registrar evento da entrega %s: %v", messageId, err)
+	}
+	metrics.ObserveDeliveryEvent(event.Type)
+
+	for _, listener := range s.listeners {
+		listener(status, event)
+	}
+}
 
-	s.cache.mutex.Lock()
-	s.cache.statuses[messageId] = status
-	s.cache.mutex.Unlock()
+// Subscribe registra um listener para os eventos de entrega, incluindo o
+// registro do envio (SENT). Deve ser chamado durante a inicialização,
+// antes de o serviço receber eventos.
+func (s *DeliveryService) Subscribe(listener DeliveryListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// PauseEvents executa fn sem nenhum registro de envio ou evento em andamento,
+// bloqueando os novos registros até fn terminar. Desiste após o timeout.
+func (s *DeliveryService) PauseEvents(timeout time.Duration, fn func() error) error {
+	// TryLock em vez de Lock: um escritor aguardando bloqueia novos leitores, e
+	// um listener pode aguardar um lock cujo dono está registrando um envio
+	// (ex: o workflow), o que travaria os dois
+	deadline := time.Now().Add(timeout)
+	for !s.eventMutex.TryLock() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("tempo esgotado aguardando os eventos em andamento")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer s.eventMutex.Unlock()
+
+	return fn()
 }
 
 // UpdateDeliveryStatus atualiza o status de uma entrega
 func (s *DeliveryService) UpdateDeliveryStatus(messageId, status, description string) error {
-	s.cache.mutex.Lock()
-	defer s.cache.mutex.Unlock()
+	_, err := s.RecordEvent(DeliveryEvent{
+		MessageID:   messageId,
+		Type:        status,
+		Description: description,
+		Timestamp:   time.Now(),
+	})
+	return err
+}
+
+// RecordEvent valida o evento contra o status atual da mensagem, atualiza o
+// status e adiciona o evento à linha do tempo
+func (s *DeliveryService) RecordEvent(event DeliveryEvent) (*DeliveryStatus, error) {
+	s.eventMutex.RLock()
+	defer s.eventMutex.RUnlock()
 
-	current, exists := s.cache.statuses[messageId]
-	if !exists {
-		return fmt.Errorf("mensagem não encontrada: %s", messageId)
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if event.ID == "" {
+		event.ID = fmt.Sprintf("%s-%d", event.MessageID, time.Now().UnixNano())
 	}
 
-	current.Status = status
-	current.StatusDescription = description
+	current, err := s.store.Update(event.MessageID, func(current *DeliveryStatus) error {
+		return applyEvent(current, event)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao atualizar status da mensagem %s: %w", event.MessageID, err)
+	}
+	if current == nil {
+		return nil, fmt.Errorf("mensagem não encontrada: %s", event.MessageID)
+	}
+
+	if err := s.store.AppendEvent(event); err != nil {
+		return nil, fmt.Errorf("falha ao registrar evento da mensagem %s: %w", event.MessageID, err)
+	}
+	metrics.ObserveDeliveryEvent(event.Type)
+	if suppressionBounces[event.BounceSubType] {
+		metrics.ObserveSuppression(event.BounceSubType)
+	}
+
+	// Notificar listeners
+	for _, listener := range s.listeners {
+		listener(*current, event)
+	}
+
+	return current, nil
+}
 
-	if status == "DELIVERED" {
-		current.DeliveredAt = time.Now()
-	} else if status == "OPENED" {
-		current.OpenedAt = time.Now()
-	} else if status == "CLICKED" {
-		current.ClickCount++
-		current.LastClickAt = time.Now()
+// GetDeliveryEvents obtém a linha do tempo de eventos de uma entrega
+func (s *DeliveryService) GetDeliveryEvents(messageId string) ([]DeliveryEvent, error) {
+	if _, err := s.GetDeliveryStatus(messageId); err != nil {
+		return nil, err
 	}
 
-	s.cache.statuses[messageId] = current
-	return nil
+	return s.store.ListEvents(messageId)
 }
 
 // GetDeliveryStatus obtém o status atual de uma entrega
 func (s *DeliveryService) GetDeliveryStatus(messageId string) (*DeliveryStatus, error) {
-	s.cache.mutex.RLock()
-	defer s.cache.mutex.RUnlock()
-
-	status, exists := s.cache.statuses[messageId]
-	if !exists {
+	status, err := s.store.Get(messageId)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
 		return nil, fmt.Errorf("mensagem não encontrada: %s", messageId)
 	}
 
-	return &status, nil
+	return status, nil
 }
 
-// GetAllDeliveryStatus obtém todos os status de entrega
-func (s *DeliveryService) GetAllDeliveryStatus() []DeliveryStatus {
-	s.cache.mutex.RLock()
-	defer s.cache.mutex.RUnlock()
+// GetAllDeliveryStatus obtém os status de entrega que atendem ao filtro,
+// ordenados por data de envio (mais recente primeiro)
+func (s *DeliveryService) GetAllDeliveryStatus(filter DeliveryFilter) ([]DeliveryStatus, error) {
+	return s.store.List(filter)
+}
 
-	result := make([]DeliveryStatus, 0, len(s.cache.statuses))
-	for _, status := range s.cache.statuses {
-		result = append(result, status)
+// GetRecipientDeliveries obtém o que aconteceu com as mensagens enviadas a um destinatário
+func (s *DeliveryService) GetRecipientDeliveries(filter DeliveryFilter) ([]RecipientDelivery, error) {
+	statuses, err := s.store.List(filter)
+	if err != nil {
+		return nil, err
 	}
 
-	// Ordenar por data de envio (mais recente primeiro)
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].SentAt.After(result[j].SentAt)
-	})
+	return recipientDeliveries(statuses, filter.Recipient), nil
+}
+
+// RunRetention remove periodicamente os status mais antigos que a retenção
+// configurada e compacta os índices, até o contexto ser cancelado
+func (s *DeliveryService) RunRetention(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	return result
+	for {
+		removed, err := s.store.DeleteBefore(time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("Falha ao aplicar retenção de entregas: %v", err)
+		} else if removed > 0 {
+			log.Printf("Retenção de entregas: %d status removidos", removed)
+		}
+
+		if err := s.store.Compact(); err != nil {
+			log.Printf("Falha ao compactar índices de entregas: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
-// GetRealTimeReport gera um relatório em tempo real das entregas recentes
-func (s *DeliveryService) GetRealTimeReport(hours int) (*DeliveryReport, error) {
+// GetRealTimeReport gera um relatório em tempo real das entregas recentes,
+// com as estatísticas de cada hora do período no fuso horário informado
+func (s *DeliveryService) GetRealTimeReport(hours int, loc *time.Location) (*DeliveryReport, error) {
 	// Se não for especificado, usar as últimas 24 horas
 	if hours <= 0 {
 		hours = 24
@@ -162,106 +287,43 @@ func (s *DeliveryService) GetRealTimeReport(hours int) (*DeliveryReport, error)
 	endTime := time.Now()
 	startTime := endTime.Add(-time.Duration(hours) * time.Hour)
 
-	// Métricas a serem coletadas
-	metrics := map[string]string{
-		"Send":       "AWS/SES",
-		"Delivery":   "AWS/SES",
-		"Open":       "AWS/SES",
-		"Click":      "AWS/SES",
-		"Bounce":     "AWS/SES",
-		"Complaint":  "AWS/SES",
-	}
-
-	// Configurar período para CloudWatch
-	period := int32(3600) // 1 hora em segundos
-
-	// Coletar métricas do CloudWatch
-	metricData := make(map[string]int)
-	hourlyData := make(map[int]map[string]int)
-
-	for metricName, namespace := range metrics {
-		input := &cloudwatch.GetMetricStatisticsInput{
-			Namespace:  aws.String(namespace),
-			MetricName: aws.String(metricName),
-			StartTime:  &startTime,
-			EndTime:    &endTime,
-			Period:     &period,
-			Statistics: []cwtypes.Statistic{cwtypes.StatisticSum},
-		}
-
-		result, err := s.cloudWatchClient.GetMetricStatistics(context.Background(), input)
-		if err != nil {
-			return nil, fmt.Errorf("falha ao obter métrica %s: %w", metricName, err)
-		}
-
-		// Somar valores totais
-		sum := 0
-		for _, datapoint := range result.Datapoints {
-			sum += int(*datapoint.Sum)
-
-			// Agrupar por hora
-			hour := datapoint.Timestamp.Hour()
-			if _, ok := hourlyData[hour]; !ok {
-				hourlyData[hour] = make(map[string]int)
-			}
-			hourlyData[hour][metricName] += int(*datapoint.Sum)
-		}
-
-		metricData[metricName] = sum
+	result, series, err := s.metricsQuerier.QuerySeries(context.Background(), startTime, endTime, "", SeriesOptions{
+		Granularity: GranularityHour,
+		Location:    loc,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Preparar dados por hora
-	hourlyStats := make([]HourlyStats, 0, len(hourlyData))
-	for hour, data := range hourlyData {
-		sent := data["Send"]
-		delivered := data["Delivery"]
-		failed := data["Bounce"] + data["Complaint"]
-		opened := data["Open"]
-		clicked := data["Click"]
-
-		var openRate, clickRate float64
-		if delivered > 0 {
-			openRate = float64(opened) / float64(delivered) * 100
-			clickRate = float64(clicked) / float64(delivered) * 100
-		}
-
+	// Preparar dados por hora, em ordem cronológica e sem lacunas
+	hourlyStats := make([]HourlyStats, 0, len(series))
+	for _, point := range series {
 		hourlyStats = append(hourlyStats, HourlyStats{
-			Hour:      hour,
-			Sent:      sent,
-			Delivered: delivered,
-			Failed:    failed,
-			Opened:    opened,
-			Clicked:   clicked,
-			OpenRate:  openRate,
-			ClickRate: clickRate,
+			Timestamp: point.Timestamp,
+			Hour:      point.Timestamp.Hour(),
+			Sent:      int(point.Sent),
+			Delivered: int(point.Delivered),
+			Failed:    int(point.Bounced + point.Complaints),
+			Opened:    int(point.Opened),
+			Clicked:   int(point.Clicked),
+			OpenRate:  point.OpenRate,
+			ClickRate: point.ClickRate,
 		})
 	}
 
-	// Ordenar por hora
-	sort.Slice(hourlyStats, func(i, j int) bool {
-		return hourlyStats[i].Hour < hourlyStats[j].Hour
-	})
-
 	// Calcular totais e taxas
-	sent := metricData["Send"]
-	delivered := metricData["Delivery"]
-	failed := metricData["Bounce"] + metricData["Complaint"]
-	opened := metricData["Open"]
-	clicked := metricData["Click"]
-
-	var deliveryRate, openRate, clickRate float64
-	if sent > 0 {
-		deliveryRate = float64(delivered) / float64(sent) * 100
-	}
-	if delivered > 0 {
-		openRate = float64(opened) / float64(delivered) * 100
-		clickRate = float64(clicked) / float64(delivered) * 100
-	}
-
-	// Obter status detalhados de entregas recentes (máximo 100)
-	statuses := s.GetAllDeliveryStatus()
-	if len(statuses) > 100 {
-		statuses = statuses[:100]
+	rates := result.Rates()
+	sent := int(result.Totals["send"])
+	delivered := int(result.Totals["delivery"])
+	failed := int(result.Totals["bounce"] + result.Totals["complaint"])
+	opened := int(result.Totals["open"])
+	clicked := int(result.Totals["click"])
+	deliveryRate, openRate, clickRate := rates.DeliveryRate, rates.OpenRate, rates.ClickRate
+
+	// Obter status detalhados de entregas do período (máximo 100)
+	statuses, err := s.GetAllDeliveryStatus(DeliveryFilter{Start: startTime, End: endTime, Limit: 100})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter status de entregas: %w", err)
 	}
 
 	// Criar relatório
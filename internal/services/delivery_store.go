@@ -0,0 +1,675 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	deliveriesBucket            = "deliveries"
+	deliveriesByTimeBucket      = "deliveries_by_time"
+	deliveriesBySenderBucket    = "deliveries_by_sender"
+	deliveriesByRecipientBucket = "deliveries_by_recipient"
+	deliveryEventsBucket        = "delivery_events"
+)
+
+// DeliveryFilter define os critérios de busca de status de entrega.
+// Campos vazios não restringem a busca e Limit zero retorna todos os resultados.
+// Subject busca por trecho do assunto, e Tag aceita "nome" ou "nome:valor".
+type DeliveryFilter struct {
+	FromEmail string
+	Recipient string
+	Statuses  []string
+	Subject   string
+	Tag       string
+	Start     time.Time
+	End       time.Time
+	Limit     int
+}
+
+// DeliveryStore define a persistência dos status de entrega
+type DeliveryStore interface {
+	// Save grava (ou substitui) o status de uma mensagem
+	Save(status DeliveryStatus) error
+	// Get obtém o status de uma mensagem, retornando nil se não existir
+	Get(messageID string) (*DeliveryStatus, error)
+	// Update aplica fn ao status de forma atômica, retornando nil se a mensagem não existir
+	Update(messageID string, fn func(status *DeliveryStatus) error) (*DeliveryStatus, error)
+	// List retorna os status que atendem ao filtro, do envio mais recente para o mais antigo
+	List(filter DeliveryFilter) ([]DeliveryStatus, error)
+	// ListAfter retorna os status que atendem ao filtro em ordem de envio
+	// (decrescente ou crescente), começando após a posição da mensagem
+	// informada (exclusiva). Com messageID vazio, começa do início.
+	ListAfter(filter DeliveryFilter, sentAt time.Time, messageID string, descending bool) ([]DeliveryStatus, error)
+	// Iterate chama fn para cada status que atende ao filtro, do envio mais
+	// antigo para o mais recente, sem carregar todos os resultados em memória.
+	// Um erro retornado por fn interrompe a iteração.
+	Iterate(filter DeliveryFilter, fn func(status DeliveryStatus) error) error
+	// DeleteBefore remove os status enviados antes do instante informado
+	DeleteBefore(cutoff time.Time) (int, error)
+	// Compact remove entradas de índice que não apontam mais para nenhum status
+	Compact() error
+	// AppendEvent adiciona um evento à linha do tempo da mensagem
+	AppendEvent(event DeliveryEvent) error
+	// ListEvents retorna a linha do tempo da mensagem em ordem cronológica
+	ListEvents(messageID string) ([]DeliveryEvent, error)
+}
+
+// StatusCache implementa o DeliveryStore em memória, usado em testes e
+// quando não há banco de dados disponível
+type StatusCache struct {
+	statuses map[string]DeliveryStatus
+	events   map[string][]DeliveryEvent
+	mutex    sync.RWMutex
+}
+
+// NewStatusCache cria um novo StatusCache vazio
+func NewStatusCache() *StatusCache {
+	return &StatusCache{
+		statuses: make(map[string]DeliveryStatus),
+		events:   make(map[string][]DeliveryEvent),
+	}
+}
+
+// Save grava o status de uma mensagem
+func (c *StatusCache) Save(status DeliveryStatus) error {
+	c.mutex.Lock()
+	c.statuses[status.MessageID] = status
+	c.mutex.Unlock()
+	return nil
+}
+
+// Get obtém o status de uma mensagem, retornando nil se não existir
+func (c *StatusCache) Get(messageID string) (*DeliveryStatus, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	status, exists := c.statuses[messageID]
+	if !exists {
+		return nil, nil
+	}
+
+	return &status, nil
+}
+
+// Update aplica fn ao status de forma atômica, retornando nil se a mensagem não existir
+func (c *StatusCache) Update(messageID string, fn func(status *DeliveryStatus) error) (*DeliveryStatus, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	status, exists := c.statuses[messageID]
+	if !exists {
+		return nil, nil
+	}
+
+	if err := fn(&status); err != nil {
+		return nil, err
+	}
+
+	c.statuses[messageID] = status
+	return &status, nil
+}
+
+// List retorna os status que atendem ao filtro, do envio mais recente para o mais antigo
+func (c *StatusCache) List(filter DeliveryFilter) ([]DeliveryStatus, error) {
+	return c.ListAfter(filter, time.Time{}, "", true)
+}
+
+// ListAfter retorna os status que atendem ao filtro em ordem de envio,
+// começando após a posição da mensagem informada (exclusiva). A ordem é a
+// mesma do índice do BoltDeliveryStore: data de envio e ID da mensagem.
+func (c *StatusCache) ListAfter(filter DeliveryFilter, sentAt time.Time, messageID string, descending bool) ([]DeliveryStatus, error) {
+	key := func(status DeliveryStatus) string {
+		return timeKey(status.SentAt) + "\x00" + status.MessageID
+	}
+	position := timeKey(sentAt) + "\x00" + messageID
+
+	c.mutex.RLock()
+	statuses := make([]DeliveryStatus, 0, len(c.statuses))
+	for _, status := range c.statuses {
+		if !filter.matches(status) {
+			continue
+		}
+		if messageID != "" && (key(status) == position || (key(status) < position) != descending) {
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+	c.mutex.RUnlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return (key(statuses[i]) < key(statuses[j])) != descending
+	})
+
+	if filter.Limit > 0 && len(statuses) > filter.Limit {
+		statuses = statuses[:filter.Limit]
+	}
+	return statuses, nil
+}
+
+// Iterate chama fn para cada status que atende ao filtro, do envio mais antigo para o mais recente
+func (c *StatusCache) Iterate(filter DeliveryFilter, fn func(status DeliveryStatus) error) error {
+	statuses, err := c.ListAfter(filter, time.Time{}, "", false)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		if err := fn(status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteBefore remove os status enviados antes do instante informado
+func (c *StatusCache) DeleteBefore(cutoff time.Time) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	removed := 0
+	for id, status := range c.statuses {
+		if status.SentAt.Before(cutoff) {
+			delete(c.statuses, id)
+			delete(c.events, id)
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// Compact não tem efeito no cache em memória
+func (c *StatusCache) Compact() error {
+	return nil
+}
+
+// AppendEvent adiciona um evento à linha do tempo da mensagem
+func (c *StatusCache) AppendEvent(event DeliveryEvent) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	events := append(c.events[event.MessageID], event)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	c.events[event.MessageID] = events
+	return nil
+}
+
+// ListEvents retorna a linha do tempo da mensagem em ordem cronológica
+func (c *StatusCache) ListEvents(messageID string) ([]DeliveryEvent, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return append([]DeliveryEvent{}, c.events[messageID]...), nil
+}
+
+// matches verifica se o status atende ao filtro
+func (f DeliveryFilter) matches(status DeliveryStatus) bool {
+	if f.FromEmail != "" && !strings.EqualFold(status.FromEmail, f.FromEmail) {
+		return false
+	}
+	if f.Recipient != "" && status.findRecipient(f.Recipient) == nil {
+		return false
+	}
+	if len(f.Statuses) > 0 && !containsFold(f.Statuses, status.Status) {
+		return false
+	}
+	if f.Subject != "" && !strings.Contains(strings.ToLower(status.Subject), strings.ToLower(f.Subject)) {
+		return false
+	}
+	if f.Tag != "" && !hasTag(status.Tags, f.Tag) {
+		return false
+	}
+	if !f.Start.IsZero() && status.SentAt.Before(f.Start) {
+		return false
+	}
+	if !f.End.IsZero() && status.SentAt.After(f.End) {
+		return false
+	}
+	return true
+}
+
+// containsFold verifica se o valor está na lista, ignorando maiúsculas e minúsculas
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTag verifica se as tags contêm a tag informada, no formato "nome" ou "nome:valor"
+func hasTag(tags map[string]string, tag string) bool {
+	name, value, withValue := strings.Cut(tag, ":")
+	current, exists := tags[name]
+	if !exists {
+		return false
+	}
+	return !withValue || current == value
+}
+
+// BoltDeliveryStore persiste os status de entrega no banco embarcado, com
+// índices por data de envio, remetente e destinatário para buscas por período
+type BoltDeliveryStore struct {
+	db *bolt.DB
+}
+
+// NewBoltDeliveryStore cria uma nova instância do BoltDeliveryStore
+func NewBoltDeliveryStore(db *bolt.DB) (*BoltDeliveryStore, error) {
+	if err := ensureBuckets(db, deliveriesBucket, deliveriesByTimeBucket, deliveriesBySenderBucket, deliveriesByRecipientBucket, deliveryEventsBucket); err != nil {
+		return nil, err
+	}
+
+	return &BoltDeliveryStore{db: db}, nil
+}
+
+// timeKey formata o instante como chave ordenável
+func timeKey(t time.Time) string {
+	return fmt.Sprintf("%020d", t.UnixNano())
+}
+
+// senderPrefix monta o prefixo do índice por remetente (ou destinatário)
+func senderPrefix(email string) string {
+	return strings.ToLower(email) + "\x00"
+}
+
+// indexKeys retorna as chaves de índice de um status
+func (s *BoltDeliveryStore) indexKeys(status DeliveryStatus) (byTime, bySender []byte, byRecipient [][]byte) {
+	suffix := timeKey(status.SentAt) + "\x00" + status.MessageID
+	byTime = []byte(suffix)
+	bySender = []byte(senderPrefix(status.FromEmail) + suffix)
+	for _, recipient := range status.Recipients {
+		byRecipient = append(byRecipient, []byte(senderPrefix(recipient.Email)+suffix))
+	}
+	return byTime, bySender, byRecipient
+}
+
+// putIndexes grava (ou remove, com remove=true) as chaves de índice de um status
+func (s *BoltDeliveryStore) putIndexes(tx *bolt.Tx, status DeliveryStatus, remove bool) error {
+	timeIdx, senderIdx, recipientIdx := s.indexKeys(status)
+	value := []byte(status.MessageID)
+
+	entries := map[string][][]byte{
+		deliveriesByTimeBucket:      {timeIdx},
+		deliveriesBySenderBucket:    {senderIdx},
+		deliveriesByRecipientBucket: recipientIdx,
+	}
+	for bucket, keys := range entries {
+		b := tx.Bucket([]byte(bucket))
+		for _, key := range keys {
+			var err error
+			if remove {
+				err = b.Delete(key)
+			} else {
+				err = b.Put(key, value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// put grava o status e seus índices dentro de uma transação
+func (s *BoltDeliveryStore) put(tx *bolt.Tx, status DeliveryStatus) error {
+	deliveries := tx.Bucket([]byte(deliveriesBucket))
+
+	var previous DeliveryStatus
+	found, err := getJSON(deliveries, status.MessageID, &previous)
+	if err != nil {
+		return err
+	}
+	if found {
+		if err := s.putIndexes(tx, previous, true); err != nil {
+			return err
+		}
+	}
+
+	if err := s.putIndexes(tx, status, false); err != nil {
+		return err
+	}
+
+	return putJSON(deliveries, status.MessageID, status)
+}
+
+// Save grava (ou substitui) o status de uma mensagem
+func (s *BoltDeliveryStore) Save(status DeliveryStatus) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return s.put(tx, status)
+	})
+}
+
+// Get obtém o status de uma mensagem, retornando nil se não existir
+func (s *BoltDeliveryStore) Get(messageID string) (*DeliveryStatus, error) {
+	var status DeliveryStatus
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket([]byte(deliveriesBucket)), messageID, &status)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// Update aplica fn ao status de forma atômica, retornando nil se a mensagem não existir
+func (s *BoltDeliveryStore) Update(messageID string, fn func(status *DeliveryStatus) error) (*DeliveryStatus, error) {
+	var status DeliveryStatus
+	var found bool
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket([]byte(deliveriesBucket)), messageID, &status)
+		if err != nil || !found {
+			return err
+		}
+
+		if err := fn(&status); err != nil {
+			return err
+		}
+
+		return s.put(tx, status)
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// indexRange escolhe o índice mais seletivo disponível para o filtro
+// (destinatário, remetente ou data) e retorna o bucket, o prefixo e os
+// limites inferior e superior das chaves a percorrer
+func indexRange(filter DeliveryFilter) (string, string, []byte, []byte) {
+	bucket := deliveriesByTimeBucket
+	prefix := ""
+	switch {
+	case filter.Recipient != "":
+		bucket = deliveriesByRecipientBucket
+		prefix = senderPrefix(filter.Recipient)
+	case filter.FromEmail != "":
+		bucket = deliveriesBySenderBucket
+		prefix = senderPrefix(filter.FromEmail)
+	}
+
+	lower := []byte(prefix + timeKey(filter.Start))
+	if filter.Start.IsZero() {
+		lower = []byte(prefix)
+	}
+	upper := []byte(prefix + fmt.Sprintf("%020d", int64(math.MaxInt64)) + "\x01")
+	if !filter.End.IsZero() {
+		upper = []byte(prefix + timeKey(filter.End) + "\x01")
+	}
+
+	return bucket, prefix, lower, upper
+}
+
+// List retorna os status que atendem ao filtro, do envio mais recente para o mais antigo.
+// A busca percorre o índice mais seletivo disponível (destinatário, remetente ou data)
+// e aplica os demais critérios sobre os registros encontrados.
+func (s *BoltDeliveryStore) List(filter DeliveryFilter) ([]DeliveryStatus, error) {
+	return s.ListAfter(filter, time.Time{}, "", true)
+}
+
+// ListAfter retorna os status que atendem ao filtro em ordem de envio,
+// começando após a posição da mensagem informada (exclusiva). Percorre o
+// índice a partir dessa posição e para ao atingir filter.Limit resultados.
+func (s *BoltDeliveryStore) ListAfter(filter DeliveryFilter, sentAt time.Time, messageID string, descending bool) ([]DeliveryStatus, error) {
+	result := []DeliveryStatus{}
+	bucket, prefix, lower, upper := indexRange(filter)
+
+	if messageID != "" {
+		// As chaves do índice terminam com a data de envio e o ID da mensagem
+		position := []byte(prefix + timeKey(sentAt) + "\x00" + messageID)
+		if descending && bytes.Compare(position, upper) < 0 {
+			upper = position
+		}
+		// A menor chave posterior à posição é a própria posição seguida de \x00
+		if after := append(position, 0); !descending && bytes.Compare(after, lower) > 0 {
+			lower = after
+		}
+	}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		deliveries := tx.Bucket([]byte(deliveriesBucket))
+		c := tx.Bucket([]byte(bucket)).Cursor()
+
+		// Percorrer o índice em ordem decrescente a partir do limite superior,
+		// ou crescente a partir do limite inferior
+		var k, v []byte
+		next := c.Next
+		if descending {
+			next = c.Prev
+			k, v = c.Seek(upper)
+			if k == nil {
+				k, v = c.Last()
+			} else {
+				k, v = c.Prev()
+			}
+		} else {
+			k, v = c.Seek(lower)
+		}
+
+		for ; k != nil && bytes.Compare(k, lower) >= 0 && bytes.Compare(k, upper) < 0 && bytes.HasPrefix(k, []byte(prefix)); k, v = next() {
+			var status DeliveryStatus
+			found, err := getJSON(deliveries, string(v), &status)
+			if err != nil {
+				return err
+			}
+			if !found || !filter.matches(status) {
+				continue
+			}
+
+			result = append(result, status)
+			if filter.Limit > 0 && len(result) >= filter.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// iterateBatchSize é a quantidade de status lidos em cada transação do Iterate
+const iterateBatchSize = 500
+
+// Iterate chama fn para cada status que atende ao filtro, do envio mais
+// antigo para o mais recente. Os status são lidos em lotes, cada um em uma
+// transação própria, para que fn (ex: a escrita de uma exportação para um
+// cliente lento) não mantenha uma transação de leitura aberta.
+func (s *BoltDeliveryStore) Iterate(filter DeliveryFilter, fn func(status DeliveryStatus) error) error {
+	bucket, prefix, lower, upper := indexRange(filter)
+	count := 0
+	resume := false
+
+	for {
+		batch := make([]DeliveryStatus, 0, iterateBatchSize)
+		var last []byte
+
+		err := s.db.View(func(tx *bolt.Tx) error {
+			deliveries := tx.Bucket([]byte(deliveriesBucket))
+			c := tx.Bucket([]byte(bucket)).Cursor()
+
+			k, v := c.Seek(lower)
+			if resume && k != nil && bytes.Equal(k, lower) {
+				// A chave inicial da retomada já foi processada no lote anterior
+				k, v = c.Next()
+			}
+
+			for ; k != nil && bytes.Compare(k, upper) < 0 && bytes.HasPrefix(k, []byte(prefix)); k, v = c.Next() {
+				last = append(last[:0], k...)
+
+				var status DeliveryStatus
+				found, err := getJSON(deliveries, string(v), &status)
+				if err != nil {
+					return err
+				}
+				if found && filter.matches(status) {
+					batch = append(batch, status)
+				}
+				if len(batch) >= iterateBatchSize {
+					break
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, status := range batch {
+			if filter.Limit > 0 && count >= filter.Limit {
+				return nil
+			}
+			if err := fn(status); err != nil {
+				return err
+			}
+			count++
+		}
+
+		if last == nil || len(batch) < iterateBatchSize {
+			return nil
+		}
+		lower = last
+		resume = true
+	}
+}
+
+// DeleteBefore remove os status enviados antes do instante informado
+func (s *BoltDeliveryStore) DeleteBefore(cutoff time.Time) (int, error) {
+	removed := 0
+	limit := []byte(timeKey(cutoff))
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		deliveries := tx.Bucket([]byte(deliveriesBucket))
+		events := tx.Bucket([]byte(deliveryEventsBucket))
+		c := tx.Bucket([]byte(deliveriesByTimeBucket)).Cursor()
+
+		for k, v := c.First(); k != nil && bytes.Compare(k, limit) < 0; k, v = c.First() {
+			messageID := string(v)
+
+			var status DeliveryStatus
+			found, err := getJSON(deliveries, messageID, &status)
+			if err != nil {
+				return err
+			}
+			if !found {
+				// Entrada órfã no índice por data
+				if err := c.Delete(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// Remove o status, todas as suas entradas de índice e a linha do tempo
+			if err := s.putIndexes(tx, status, true); err != nil {
+				return err
+			}
+			if err := deleteWithPrefix(events, eventPrefix(messageID)); err != nil {
+				return err
+			}
+			if err := deliveries.Delete([]byte(messageID)); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+
+	return removed, err
+}
+
+// Compact remove entradas de índice que não apontam mais para nenhum status
+func (s *BoltDeliveryStore) Compact() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		deliveries := tx.Bucket([]byte(deliveriesBucket))
+
+		for _, name := range []string{deliveriesByTimeBucket, deliveriesBySenderBucket, deliveriesByRecipientBucket} {
+			var orphans [][]byte
+			err := tx.Bucket([]byte(name)).ForEach(func(k, v []byte) error {
+				if deliveries.Get(v) == nil {
+					orphans = append(orphans, append([]byte(nil), k...))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, k := range orphans {
+				if err := tx.Bucket([]byte(name)).Delete(k); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// eventPrefix monta o prefixo das chaves de eventos de uma mensagem
+func eventPrefix(messageID string) []byte {
+	return []byte(messageID + "\x00")
+}
+
+// deleteWithPrefix remove todas as chaves do bucket com o prefixo informado
+func deleteWithPrefix(b *bolt.Bucket, prefix []byte) error {
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+		if err := c.Delete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendEvent adiciona um evento à linha do tempo da mensagem
+func (s *BoltDeliveryStore) AppendEvent(event DeliveryEvent) error {
+	key := string(eventPrefix(event.MessageID)) + timeKey(event.Timestamp) + "\x00" + event.ID
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket([]byte(deliveryEventsBucket)), key, event)
+	})
+}
+
+// ListEvents retorna a linha do tempo da mensagem em ordem cronológica
+func (s *BoltDeliveryStore) ListEvents(messageID string) ([]DeliveryEvent, error) {
+	result := []DeliveryEvent{}
+	prefix := eventPrefix(messageID)
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte(deliveryEventsBucket)).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var event DeliveryEvent
+			if err := json.Unmarshal(v, &event); err != nil {
+				return fmt.Errorf("falha ao desserializar evento %s: %w", k, err)
+			}
+			result = append(result, event)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,542 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// deliveryStoreHarness expõe uma implementação do DeliveryStore aos testes.
+// drop remove um status sem passar pelo store, deixando órfãs as entradas de
+// índice; indexEntries conta as entradas de índice (-1 sem índices).
+type deliveryStoreHarness struct {
+	store        DeliveryStore
+	drop         func(messageID string)
+	indexEntries func() int
+}
+
+// forEachDeliveryStore executa o teste contra cada implementação do DeliveryStore
+func forEachDeliveryStore(t *testing.T, fn func(t *testing.T, h deliveryStoreHarness)) {
+	t.Run("memória", func(t *testing.T) {
+		cache := NewStatusCache()
+		fn(t, deliveryStoreHarness{
+			store: cache,
+			drop: func(messageID string) {
+				cache.mutex.Lock()
+				delete(cache.statuses, messageID)
+				cache.mutex.Unlock()
+			},
+			indexEntries: func() int { return -1 },
+		})
+	})
+
+	t.Run("bolt", func(t *testing.T) {
+		db, err := OpenDatabase(filepath.Join(t.TempDir(), "deliveries.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.NoSync = true
+		t.Cleanup(func() { db.Close() })
+
+		store, err := NewBoltDeliveryStore(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fn(t, deliveryStoreHarness{
+			store: store,
+			drop: func(messageID string) {
+				err := db.Update(func(tx *bolt.Tx) error {
+					return tx.Bucket([]byte(deliveriesBucket)).Delete([]byte(messageID))
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			indexEntries: func() int {
+				count := 0
+				db.View(func(tx *bolt.Tx) error {
+					for _, name := range []string{deliveriesByTimeBucket, deliveriesBySenderBucket, deliveriesByRecipientBucket} {
+						count += tx.Bucket([]byte(name)).Stats().KeyN
+					}
+					return nil
+				})
+				return count
+			},
+		})
+	})
+}
+
+var storeBase = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// at retorna o instante base deslocado em minutos
+func at(minutes int) time.Time {
+	return storeBase.Add(time.Duration(minutes) * time.Minute)
+}
+
+func deliveryFixture(messageID, from string, sentAt time.Time, recipients ...string) DeliveryStatus {
+	status := DeliveryStatus{
+		ID:        messageID,
+		MessageID: messageID,
+		FromEmail: from,
+		Status:    StatusSent,
+		SentAt:    sentAt,
+	}
+	for _, recipient := range recipients {
+		status.Recipients = append(status.Recipients, RecipientStatus{Email: recipient, Status: StatusSent})
+	}
+	return status
+}
+
+func seedDeliveries(t *testing.T, store DeliveryStore, statuses ...DeliveryStatus) {
+	t.Helper()
+	for _, status := range statuses {
+		if err := store.Save(status); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func messageIDs(statuses []DeliveryStatus) []string {
+	ids := []string{}
+	for _, status := range statuses {
+		ids = append(ids, status.MessageID)
+	}
+	return ids
+}
+
+func TestDeliveryStoreListAfter(t *testing.T) {
+	// m3 e m4 foram enviadas no mesmo instante e são ordenadas pelo ID
+	fixtures := []DeliveryStatus{
+		deliveryFixture("m1", "a@exemplo.com", at(0), "r1@cliente.com"),
+		deliveryFixture("m2", "b@exemplo.com", at(1), "r2@cliente.com"),
+		deliveryFixture("m3", "a@exemplo.com", at(2), "r1@cliente.com"),
+		deliveryFixture("m4", "A@Exemplo.com", at(2), "r2@cliente.com"),
+		deliveryFixture("m5", "b@exemplo.com", at(3), "R1@cliente.com"),
+		deliveryFixture("m6", "a@exemplo.com", at(4), "r2@cliente.com"),
+	}
+
+	tests := []struct {
+		name       string
+		filter     DeliveryFilter
+		sentAt     time.Time
+		messageID  string
+		descending bool
+		want       []string
+	}{
+		{
+			name:       "do início, decrescente",
+			descending: true,
+			want:       []string{"m6", "m5", "m4", "m3", "m2", "m1"},
+		},
+		{
+			name:   "do início, crescente com limite",
+			filter: DeliveryFilter{Limit: 2},
+			want:   []string{"m1", "m2"},
+		},
+		{
+			name:       "após o cursor, decrescente",
+			sentAt:     at(3),
+			messageID:  "m5",
+			descending: true,
+			want:       []string{"m4", "m3", "m2", "m1"},
+		},
+		{
+			name:      "após o cursor, crescente",
+			sentAt:    at(1),
+			messageID: "m2",
+			want:      []string{"m3", "m4", "m5", "m6"},
+		},
+		{
+			name:       "empate de data, decrescente",
+			sentAt:     at(2),
+			messageID:  "m4",
+			descending: true,
+			want:       []string{"m3", "m2", "m1"},
+		},
+		{
+			name:      "empate de data, crescente",
+			sentAt:    at(2),
+			messageID: "m3",
+			want:      []string{"m4", "m5", "m6"},
+		},
+		{
+			name:      "cursor no último, crescente",
+			sentAt:    at(4),
+			messageID: "m6",
+			want:      []string{},
+		},
+		{
+			name:       "cursor no primeiro, decrescente",
+			sentAt:     at(0),
+			messageID:  "m1",
+			descending: true,
+			want:       []string{},
+		},
+		{
+			name:       "cursor de mensagem removida",
+			sentAt:     at(1),
+			messageID:  "m2x",
+			descending: true,
+			want:       []string{"m2", "m1"},
+		},
+		{
+			name:       "limite após o cursor",
+			filter:     DeliveryFilter{Limit: 2},
+			sentAt:     at(4),
+			messageID:  "m6",
+			descending: true,
+			want:       []string{"m5", "m4"},
+		},
+		{
+			name:       "remetente após o cursor, decrescente",
+			filter:     DeliveryFilter{FromEmail: "a@exemplo.com"},
+			sentAt:     at(4),
+			messageID:  "m6",
+			descending: true,
+			want:       []string{"m4", "m3", "m1"},
+		},
+		{
+			name:      "remetente após o cursor, crescente",
+			filter:    DeliveryFilter{FromEmail: "A@EXEMPLO.COM"},
+			sentAt:    at(2),
+			messageID: "m3",
+			want:      []string{"m4", "m6"},
+		},
+		{
+			name:      "destinatário após o cursor",
+			filter:    DeliveryFilter{Recipient: "r1@cliente.com"},
+			sentAt:    at(0),
+			messageID: "m1",
+			want:      []string{"m3", "m5"},
+		},
+		{
+			name:       "cursor após o fim do período",
+			filter:     DeliveryFilter{End: at(3)},
+			sentAt:     at(4),
+			messageID:  "m6",
+			descending: true,
+			want:       []string{"m5", "m4", "m3", "m2", "m1"},
+		},
+		{
+			name:      "cursor antes do início do período",
+			filter:    DeliveryFilter{Start: at(2)},
+			sentAt:    at(0),
+			messageID: "m1",
+			want:      []string{"m3", "m4", "m5", "m6"},
+		},
+		{
+			name:       "período e cursor",
+			filter:     DeliveryFilter{Start: at(1), End: at(3)},
+			sentAt:     at(3),
+			messageID:  "m5",
+			descending: true,
+			want:       []string{"m4", "m3", "m2"},
+		},
+	}
+
+	forEachDeliveryStore(t, func(t *testing.T, h deliveryStoreHarness) {
+		seedDeliveries(t, h.store, fixtures...)
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				statuses, err := h.store.ListAfter(tt.filter, tt.sentAt, tt.messageID, tt.descending)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := messageIDs(statuses); !slices.Equal(got, tt.want) {
+					t.Errorf("ListAfter() = %v, esperado %v", got, tt.want)
+				}
+			})
+		}
+	})
+}
+
+func TestDeliveryStoreIterate(t *testing.T) {
+	// Mais de dois lotes, para exercitar a retomada entre transações
+	total := 2*iterateBatchSize + 1
+	fixtures := make([]DeliveryStatus, 0, total)
+	for i := 0; i < total; i++ {
+		from := "a@exemplo.com"
+		if i%2 == 1 {
+			from = "b@exemplo.com"
+		}
+		status := deliveryFixture(fmt.Sprintf("m%04d", i), from, storeBase.Add(time.Duration(i)*time.Second))
+		if i%3 == 0 {
+			status.Tags = map[string]string{"campaign": "lote"}
+		}
+		fixtures = append(fixtures, status)
+	}
+
+	expected := func(filter DeliveryFilter) []string {
+		ids := []string{}
+		for _, status := range fixtures {
+			if filter.Limit > 0 && len(ids) >= filter.Limit {
+				break
+			}
+			if filter.matches(status) {
+				ids = append(ids, status.MessageID)
+			}
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name   string
+		filter DeliveryFilter
+	}{
+		{name: "todos"},
+		{name: "por remetente", filter: DeliveryFilter{FromEmail: "b@exemplo.com"}},
+		{name: "filtro sem índice", filter: DeliveryFilter{Tag: "campaign:lote"}},
+		{name: "limite no limite do lote", filter: DeliveryFilter{Limit: iterateBatchSize}},
+		{name: "limite após o lote", filter: DeliveryFilter{Limit: iterateBatchSize + 1}},
+		{
+			name: "período entre lotes",
+			filter: DeliveryFilter{
+				Start: storeBase.Add(time.Duration(iterateBatchSize-2) * time.Second),
+				End:   storeBase.Add(time.Duration(2*iterateBatchSize+2) * time.Second),
+			},
+		},
+	}
+
+	forEachDeliveryStore(t, func(t *testing.T, h deliveryStoreHarness) {
+		seedDeliveries(t, h.store, fixtures...)
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got := []string{}
+				err := h.store.Iterate(tt.filter, func(status DeliveryStatus) error {
+					got = append(got, status.MessageID)
+					return nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := expected(tt.filter); !slices.Equal(got, want) {
+					t.Errorf("Iterate() retornou %d status, esperado %d (primeiro divergente: %v)", len(got), len(want), firstDiff(got, want))
+				}
+			})
+		}
+
+		t.Run("erro interrompe", func(t *testing.T) {
+			stop := errors.New("parar")
+			calls := 0
+			err := h.store.Iterate(DeliveryFilter{}, func(status DeliveryStatus) error {
+				calls++
+				if calls == iterateBatchSize+1 {
+					return stop
+				}
+				return nil
+			})
+			if !errors.Is(err, stop) {
+				t.Errorf("Iterate() erro = %v, esperado %v", err, stop)
+			}
+			if calls != iterateBatchSize+1 {
+				t.Errorf("Iterate() chamou fn %d vezes, esperado %d", calls, iterateBatchSize+1)
+			}
+		})
+	})
+}
+
+// firstDiff retorna a primeira posição em que as listas divergem
+func firstDiff(got, want []string) string {
+	for i := 0; i < len(got) && i < len(want); i++ {
+		if got[i] != want[i] {
+			return fmt.Sprintf("posição %d: %s, esperado %s", i, got[i], want[i])
+		}
+	}
+	return fmt.Sprintf("tamanhos %d e %d", len(got), len(want))
+}
+
+func TestDeliveryStoreDeleteBefore(t *testing.T) {
+	fixtures := []DeliveryStatus{
+		deliveryFixture("m1", "a@exemplo.com", at(0), "r1@cliente.com"),
+		deliveryFixture("m2", "b@exemplo.com", at(1), "r1@cliente.com"),
+		deliveryFixture("m3", "a@exemplo.com", at(2), "r1@cliente.com"),
+		deliveryFixture("m4", "b@exemplo.com", at(3), "r2@cliente.com"),
+	}
+
+	tests := []struct {
+		name      string
+		cutoff    time.Time
+		removed   int
+		remaining []string
+	}{
+		{name: "nada anterior", cutoff: at(0), removed: 0, remaining: []string{"m4", "m3", "m2", "m1"}},
+		{name: "data de envio igual ao corte é mantida", cutoff: at(2), removed: 2, remaining: []string{"m4", "m3"}},
+		{name: "todos", cutoff: at(10), removed: 4, remaining: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachDeliveryStore(t, func(t *testing.T, h deliveryStoreHarness) {
+				seedDeliveries(t, h.store, fixtures...)
+				for _, status := range fixtures {
+					event := DeliveryEvent{ID: status.MessageID + "-sent", MessageID: status.MessageID, Type: StatusSent, Timestamp: status.SentAt}
+					if err := h.store.AppendEvent(event); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				removed, err := h.store.DeleteBefore(tt.cutoff)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if removed != tt.removed {
+					t.Errorf("DeleteBefore() = %d, esperado %d", removed, tt.removed)
+				}
+
+				statuses, err := h.store.List(DeliveryFilter{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := messageIDs(statuses); !slices.Equal(got, tt.remaining) {
+					t.Errorf("List() = %v, esperado %v", got, tt.remaining)
+				}
+
+				for _, status := range fixtures {
+					kept := slices.Contains(tt.remaining, status.MessageID)
+
+					current, err := h.store.Get(status.MessageID)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if (current != nil) != kept {
+						t.Errorf("Get(%s) = %v, esperado mantido=%v", status.MessageID, current, kept)
+					}
+
+					events, err := h.store.ListEvents(status.MessageID)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if (len(events) == 1) != kept {
+						t.Errorf("ListEvents(%s) retornou %d eventos, esperado mantido=%v", status.MessageID, len(events), kept)
+					}
+				}
+
+				// Os índices por remetente e destinatário não devem apontar para status removidos
+				bySender, err := h.store.List(DeliveryFilter{FromEmail: "a@exemplo.com"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				byRecipient, err := h.store.List(DeliveryFilter{Recipient: "r1@cliente.com"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, status := range append(bySender, byRecipient...) {
+					if !slices.Contains(tt.remaining, status.MessageID) {
+						t.Errorf("status removido %s ainda listado", status.MessageID)
+					}
+				}
+
+				// Cada status mantido tem um índice por data, remetente e destinatário
+				if n := h.indexEntries(); n >= 0 && n != 3*len(tt.remaining) {
+					t.Errorf("índices após DeleteBefore = %d, esperado %d", n, 3*len(tt.remaining))
+				}
+			})
+		})
+	}
+}
+
+func TestDeliveryStoreCompact(t *testing.T) {
+	fixtures := []DeliveryStatus{
+		deliveryFixture("m1", "a@exemplo.com", at(0), "r1@cliente.com"),
+		deliveryFixture("m2", "a@exemplo.com", at(1), "r1@cliente.com", "r2@cliente.com"),
+		deliveryFixture("m3", "b@exemplo.com", at(2), "r2@cliente.com"),
+		deliveryFixture("m4", "b@exemplo.com", at(3), "r1@cliente.com"),
+	}
+	// Entradas de índice de cada fixture: data, remetente e uma por destinatário
+	entries := map[string]int{"m1": 3, "m2": 4, "m3": 3, "m4": 3}
+
+	tests := []struct {
+		name      string
+		dropped   []string
+		remaining []string
+	}{
+		{name: "sem órfãos", remaining: []string{"m4", "m3", "m2", "m1"}},
+		{name: "com órfãos", dropped: []string{"m2", "m3"}, remaining: []string{"m4", "m1"}},
+		{name: "todos órfãos", dropped: []string{"m1", "m2", "m3", "m4"}, remaining: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachDeliveryStore(t, func(t *testing.T, h deliveryStoreHarness) {
+				seedDeliveries(t, h.store, fixtures...)
+				for _, messageID := range tt.dropped {
+					h.drop(messageID)
+				}
+
+				if err := h.store.Compact(); err != nil {
+					t.Fatal(err)
+				}
+
+				want := 0
+				for _, messageID := range tt.remaining {
+					want += entries[messageID]
+				}
+				if n := h.indexEntries(); n >= 0 && n != want {
+					t.Errorf("índices após Compact = %d, esperado %d", n, want)
+				}
+
+				for _, filter := range []DeliveryFilter{{}, {FromEmail: "a@exemplo.com"}, {Recipient: "r2@cliente.com"}} {
+					statuses, err := h.store.List(filter)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for _, status := range statuses {
+						if !slices.Contains(tt.remaining, status.MessageID) {
+							t.Errorf("List(%+v) retornou o status removido %s", filter, status.MessageID)
+						}
+					}
+				}
+
+				statuses, err := h.store.List(DeliveryFilter{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := messageIDs(statuses); !slices.Equal(got, tt.remaining) {
+					t.Errorf("List() = %v, esperado %v", got, tt.remaining)
+				}
+			})
+		})
+	}
+}
+
+func TestDeliveryStoreSaveMovesIndexes(t *testing.T) {
+	forEachDeliveryStore(t, func(t *testing.T, h deliveryStoreHarness) {
+		seedDeliveries(t, h.store,
+			deliveryFixture("m1", "a@exemplo.com", at(0), "r1@cliente.com"),
+			deliveryFixture("m1", "b@exemplo.com", at(5), "r2@cliente.com"),
+		)
+
+		tests := []struct {
+			filter DeliveryFilter
+			want   []string
+		}{
+			{filter: DeliveryFilter{FromEmail: "a@exemplo.com"}, want: []string{}},
+			{filter: DeliveryFilter{FromEmail: "b@exemplo.com"}, want: []string{"m1"}},
+			{filter: DeliveryFilter{Recipient: "r1@cliente.com"}, want: []string{}},
+			{filter: DeliveryFilter{Recipient: "r2@cliente.com"}, want: []string{"m1"}},
+			{filter: DeliveryFilter{End: at(1)}, want: []string{}},
+			{filter: DeliveryFilter{Start: at(5)}, want: []string{"m1"}},
+		}
+		for _, tt := range tests {
+			statuses, err := h.store.List(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := messageIDs(statuses); !slices.Equal(got, tt.want) {
+				t.Errorf("List(%+v) = %v, esperado %v", tt.filter, got, tt.want)
+			}
+		}
+
+		if n := h.indexEntries(); n >= 0 && n != 3 {
+			t.Errorf("índices após regravar = %d, esperado 3", n)
+		}
+	})
+}
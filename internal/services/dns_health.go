@@ -0,0 +1,639 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Resultados das verificações de DNS
+const (
+	DNSCheckPass = "pass"
+	DNSCheckWarn = "warn"
+	DNSCheckFail = "fail"
+)
+
+const (
+	// spfLookupLimit é o limite de consultas DNS na avaliação de um SPF (RFC 7208, seção 4.6.4)
+	spfLookupLimit = 10
+	// spfLookupWarning é a quantidade de consultas a partir da qual o SPF é sinalizado como próximo do limite
+	spfLookupWarning = 8
+	// sesSPFInclude é o domínio que autoriza os servidores do SES no SPF
+	sesSPFInclude = "amazonses.com"
+	// dnsCheckTimeout limita a duração de uma verificação completa
+	dnsCheckTimeout = 20 * time.Second
+)
+
+// DNSResolver define as consultas DNS usadas pelo verificador. É satisfeito
+// por *net.Resolver, permitindo apontar as consultas para qualquer servidor.
+type DNSResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+// NewDNSResolver cria um resolvedor que consulta o servidor informado
+// (host:porta). Sem servidor, usa o resolvedor do sistema.
+func NewDNSResolver(server string) DNSResolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// DNSCheck representa o resultado de uma verificação de DNS
+type DNSCheck struct {
+	Name    string   `json:"name"`
+	Target  string   `json:"target"`
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Hint    string   `json:"hint,omitempty"`
+	Records []string `json:"records,omitempty"`
+	Lookups int      `json:"lookups,omitempty"`
+}
+
+// DNSHealthReport representa a verificação de SPF, DKIM, DMARC e MAIL FROM de um domínio
+type DNSHealthReport struct {
+	Domain    string     `json:"domain"`
+	Status    string     `json:"status"`
+	CheckedAt time.Time  `json:"checkedAt"`
+	Checks    []DNSCheck `json:"checks"`
+}
+
+// DNSHealthService verifica os registros DNS dos domínios de envio
+type DNSHealthService struct {
+	sesService *SESService
+	resolver   DNSResolver
+	region     string
+}
+
+// NewDNSHealthService cria uma nova instância do DNSHealthService
+func NewDNSHealthService(sesService *SESService, resolver DNSResolver, region string) *DNSHealthService {
+	return &DNSHealthService{
+		sesService: sesService,
+		resolver:   resolver,
+		region:     region,
+	}
+}
+
+// Check verifica o SPF, o DKIM (seletores do Easy DKIM e os informados), o
+// DMARC e o MAIL FROM do domínio. Aceita também um endereço de e-mail, caso
+// em que o domínio do endereço é verificado.
+func (s *DNSHealthService) Check(ctx context.Context, domain string, selectors []string) (*DNSHealthReport, error) {
+	if strings.Contains(domain, "@") {
+		domain = emailDomain(domain)
+	}
+	domain = normalizeDomain(domain)
+	if domain == "" || !strings.Contains(domain, ".") {
+		return nil, fmt.Errorf("domínio inválido: %q", domain)
+	}
+
+	identity, err := s.sesService.GetDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	var mailFrom *MailFromAttributes
+	if identity != nil {
+		if mailFrom, err = s.sesService.GetMailFromAttributes(domain); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dnsCheckTimeout)
+	defer cancel()
+
+	report := &DNSHealthReport{
+		Domain:    domain,
+		CheckedAt: time.Now(),
+	}
+
+	report.Checks = append(report.Checks, s.checkSPF(ctx, domain, false))
+	report.Checks = append(report.Checks, s.checkDKIM(ctx, domain, identity, selectors)...)
+	report.Checks = append(report.Checks, s.checkDMARC(ctx, domain))
+	report.Checks = append(report.Checks, s.checkMailFrom(ctx, domain, mailFrom)...)
+
+	report.Status = DNSCheckPass
+	for _, check := range report.Checks {
+		report.Status = worseDNSStatus(report.Status, check.Status)
+	}
+
+	return report, nil
+}
+
+// checkSPF avalia o SPF do domínio, contando as consultas DNS dos includes e
+// redirects. Com requireSES, a ausência do include do SES é uma falha.
+func (s *DNSHealthService) checkSPF(ctx context.Context, domain string, requireSES bool) DNSCheck {
+	check := DNSCheck{Name: "spf", Target: domain}
+
+	eval := &spfEvaluator{resolver: s.resolver, evaluating: map[string]bool{}}
+	record, err := eval.evaluate(ctx, domain, 0)
+	check.Lookups = eval.lookups
+	if record != "" {
+		check.Records = []string{record}
+	}
+
+	switch {
+	case errors.Is(err, errSPFNotFound):
+		check.Status = DNSCheckFail
+		check.Message = "nenhum registro SPF encontrado"
+		check.Hint = fmt.Sprintf("Publique um TXT em %s com \"v=spf1 include:%s ~all\"", domain, sesSPFInclude)
+	case err != nil:
+		check.Status = DNSCheckFail
+		check.Message = err.Error()
+		check.Hint = spfHint(err)
+	case eval.all == "+":
+		check.Status = DNSCheckFail
+		check.Message = "o SPF termina com +all e autoriza qualquer servidor a enviar pelo domínio"
+		check.Hint = "Substitua +all por ~all ou -all"
+	case eval.all == "" || eval.all == "?":
+		check.Status = DNSCheckWarn
+		check.Message = "o SPF não define uma política para servidores não autorizados"
+		check.Hint = "Termine o registro com ~all ou -all"
+	case !eval.includesSES:
+		check.Status = DNSCheckWarn
+		if requireSES {
+			check.Status = DNSCheckFail
+		}
+		check.Message = fmt.Sprintf("o SPF não inclui %s", sesSPFInclude)
+		check.Hint = fmt.Sprintf("Adicione include:%s ao registro para autorizar os servidores do SES", sesSPFInclude)
+	case eval.lookups >= spfLookupWarning:
+		check.Status = DNSCheckWarn
+		check.Message = fmt.Sprintf("o SPF usa %d de %d consultas DNS", eval.lookups, spfLookupLimit)
+		check.Hint = "Remova includes não utilizados ou substitua-os por ip4/ip6 antes de atingir o limite"
+	default:
+		check.Status = DNSCheckPass
+		check.Message = fmt.Sprintf("SPF válido, com %d consultas DNS", eval.lookups)
+	}
+
+	return check
+}
+
+// checkDKIM verifica os CNAMEs do Easy DKIM cadastrados no SES e os
+// registros TXT dos seletores informados
+func (s *DNSHealthService) checkDKIM(ctx context.Context, domain string, identity *DomainResponse, selectors []string) []DNSCheck {
+	var checks []DNSCheck
+
+	if identity != nil {
+		for _, record := range identity.Records {
+			if record.Purpose != "dkim" {
+				continue
+			}
+			checks = append(checks, s.checkEasyDKIM(ctx, record, identity.DkimStatus))
+		}
+	}
+
+	for _, selector := range selectors {
+		checks = append(checks, s.checkDKIMSelector(ctx, domain, strings.ToLower(strings.TrimSpace(selector))))
+	}
+
+	if len(checks) == 0 {
+		check := DNSCheck{
+			Name:    "dkim",
+			Target:  "_domainkey." + domain,
+			Status:  DNSCheckFail,
+			Message: "nenhum seletor DKIM para verificar",
+			Hint:    "Cadastre o domínio em POST /api/v1/domains para habilitar o Easy DKIM ou informe os seletores em uso",
+		}
+		if identity == nil {
+			check.Message = "o domínio não está cadastrado no SES e nenhum seletor DKIM foi informado"
+		}
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// checkEasyDKIM verifica se o CNAME de um token do Easy DKIM aponta para o SES
+func (s *DNSHealthService) checkEasyDKIM(ctx context.Context, record DNSRecord, dkimStatus string) DNSCheck {
+	check := DNSCheck{Name: "dkim", Target: record.Name}
+	hint := fmt.Sprintf("Publique um CNAME %s apontando para %s", record.Name, record.Value)
+
+	cname, err := s.resolver.LookupCNAME(ctx, record.Name)
+	if err != nil {
+		check.Status = DNSCheckFail
+		check.Message = dnsErrorMessage("CNAME", record.Name, err)
+		check.Hint = hint
+		return check
+	}
+
+	cname = normalizeDomain(cname)
+	check.Records = []string{cname}
+
+	switch {
+	case cname != record.Value:
+		check.Status = DNSCheckFail
+		check.Message = fmt.Sprintf("o CNAME aponta para %s em vez de %s", cname, record.Value)
+		check.Hint = hint
+	case dkimStatus != "Success":
+		check.Status = DNSCheckWarn
+		check.Message = fmt.Sprintf("o CNAME está correto, mas o DKIM ainda não foi confirmado pelo SES (status: %s)", dkimStatus)
+		check.Hint = "A confirmação do SES pode levar até 72 horas após a publicação dos registros"
+	default:
+		check.Status = DNSCheckPass
+		check.Message = "CNAME do Easy DKIM publicado e confirmado pelo SES"
+	}
+
+	return check
+}
+
+// checkDKIMSelector verifica a chave pública publicada em um seletor DKIM
+func (s *DNSHealthService) checkDKIMSelector(ctx context.Context, domain, selector string) DNSCheck {
+	name := selector + "._domainkey." + domain
+	check := DNSCheck{Name: "dkim", Target: name}
+
+	records, err := s.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		check.Status = DNSCheckFail
+		check.Message = dnsErrorMessage("TXT", name, err)
+		check.Hint = fmt.Sprintf("Publique a chave pública do seletor %s em %s", selector, name)
+		return check
+	}
+	check.Records = records
+
+	tags := parseTagList(strings.Join(records, ""))
+	key, hasKey := tags["p"]
+
+	switch {
+	case !hasKey:
+		check.Status = DNSCheckFail
+		check.Message = "o registro não contém a chave pública (tag p=)"
+		check.Hint = "Publique o registro gerado pelo serviço que assina as mensagens com este seletor"
+	case key == "":
+		check.Status = DNSCheckFail
+		check.Message = "a chave pública do seletor foi revogada (p= vazio)"
+		check.Hint = "Publique uma nova chave ou deixe de assinar com este seletor"
+	case tags["t"] == "y":
+		check.Status = DNSCheckWarn
+		check.Message = "o seletor está em modo de teste (t=y) e os receptores podem ignorar a assinatura"
+		check.Hint = "Remova t=y do registro após concluir os testes"
+	default:
+		check.Status = DNSCheckPass
+		check.Message = "chave pública DKIM publicada"
+	}
+
+	return check
+}
+
+// checkDMARC verifica a política DMARC do domínio ou, na falta dela, do domínio organizacional
+func (s *DNSHealthService) checkDMARC(ctx context.Context, domain string) DNSCheck {
+	name := "_dmarc." + domain
+	check := DNSCheck{Name: "dmarc", Target: name}
+	hint := fmt.Sprintf("Publique um TXT em %s com \"v=DMARC1; p=none; rua=mailto:dmarc@%s\" e endureça a política após analisar os relatórios", name, domain)
+
+	record, err := s.lookupDMARC(ctx, name)
+	policyTag := "p"
+	if err == nil && record == "" {
+		if org := organizationalDomain(domain); org != domain {
+			orgName := "_dmarc." + org
+			if record, err = s.lookupDMARC(ctx, orgName); record != "" {
+				check.Target = orgName
+				policyTag = "sp"
+			}
+		}
+	}
+
+	switch {
+	case err != nil:
+		check.Status = DNSCheckFail
+		check.Message = err.Error()
+		check.Hint = hint
+		return check
+	case record == "":
+		check.Status = DNSCheckFail
+		check.Message = "nenhum registro DMARC encontrado"
+		check.Hint = hint
+		return check
+	}
+	check.Records = []string{record}
+
+	tags := parseTagList(record)
+	policy, ok := tags[policyTag]
+	if !ok {
+		policy = tags["p"] // sp ausente: os subdomínios herdam a política do domínio
+	}
+
+	switch {
+	case policy == "":
+		check.Status = DNSCheckFail
+		check.Message = "o registro DMARC não define a política (tag p=)"
+		check.Hint = hint
+	case policy == "none":
+		check.Status = DNSCheckWarn
+		check.Message = "a política DMARC é p=none (apenas monitoramento)"
+		check.Hint = "Após confirmar nos relatórios que as mensagens legítimas passam no DMARC, mude para p=quarantine ou p=reject"
+	case policy != "quarantine" && policy != "reject":
+		check.Status = DNSCheckFail
+		check.Message = fmt.Sprintf("política DMARC inválida: %s", policy)
+		check.Hint = "Use p=none, p=quarantine ou p=reject"
+	case tags["pct"] != "" && tags["pct"] != "100":
+		check.Status = DNSCheckWarn
+		check.Message = fmt.Sprintf("a política %s é aplicada a apenas %s%% das mensagens", policy, tags["pct"])
+		check.Hint = "Aumente pct para 100 quando a política estiver estável"
+	default:
+		check.Status = DNSCheckPass
+		check.Message = fmt.Sprintf("política DMARC %s", policy)
+	}
+
+	if check.Status == DNSCheckPass && tags["rua"] == "" {
+		check.Hint = "Adicione rua=mailto:... para receber os relatórios agregados"
+	}
+
+	return check
+}
+
+// lookupDMARC obtém o registro DMARC publicado em name, retornando vazio se não houver
+func (s *DNSHealthService) lookupDMARC(ctx context.Context, name string) (string, error) {
+	records, err := s.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		if isDNSNotFound(err) {
+			return "", nil
+		}
+		return "", errors.New(dnsErrorMessage("TXT", name, err))
+	}
+
+	var found []string
+	for _, record := range records {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(record)), "v=dmarc1") {
+			found = append(found, record)
+		}
+	}
+
+	if len(found) > 1 {
+		return "", fmt.Errorf("há %d registros DMARC em %s; os receptores ignoram todos", len(found), name)
+	}
+	if len(found) == 0 {
+		return "", nil
+	}
+
+	return found[0], nil
+}
+
+// checkMailFrom verifica o MX e o SPF do domínio MAIL FROM personalizado
+func (s *DNSHealthService) checkMailFrom(ctx context.Context, domain string, mailFrom *MailFromAttributes) []DNSCheck {
+	if mailFrom == nil {
+		return []DNSCheck{{
+			Name:    "mailFromMx",
+			Target:  domain,
+			Status:  DNSCheckWarn,
+			Message: fmt.Sprintf("o domínio usa o MAIL FROM padrão do SES (%s), que não se alinha ao SPF do domínio no DMARC", sesSPFInclude),
+			Hint:    fmt.Sprintf("Configure um MAIL FROM personalizado (ex: bounce.%s); sem ele, o DMARC depende apenas do DKIM", domain),
+		}}
+	}
+
+	mailFromDomain := normalizeDomain(mailFrom.MailFromDomain)
+	expected := fmt.Sprintf("feedback-smtp.%s.amazonses.com", s.region)
+	check := DNSCheck{Name: "mailFromMx", Target: mailFromDomain}
+	hint := fmt.Sprintf("Publique um MX em %s com prioridade 10 apontando para %s", mailFromDomain, expected)
+
+	mxs, err := s.resolver.LookupMX(ctx, mailFromDomain)
+	if err != nil {
+		check.Status = DNSCheckFail
+		check.Message = dnsErrorMessage("MX", mailFromDomain, err)
+		check.Hint = hint
+	} else {
+		found := false
+		for _, mx := range mxs {
+			host := normalizeDomain(mx.Host)
+			check.Records = append(check.Records, fmt.Sprintf("%d %s", mx.Pref, host))
+			found = found || host == expected
+		}
+
+		switch {
+		case !found:
+			check.Status = DNSCheckFail
+			check.Message = fmt.Sprintf("nenhum MX aponta para %s", expected)
+			check.Hint = hint
+		case mailFrom.Status != "Success":
+			check.Status = DNSCheckWarn
+			check.Message = fmt.Sprintf("o MX está correto, mas o MAIL FROM ainda não foi confirmado pelo SES (status: %s)", mailFrom.Status)
+		default:
+			check.Status = DNSCheckPass
+			check.Message = "MX do MAIL FROM publicado e confirmado pelo SES"
+		}
+	}
+
+	// Sem o include do SES, o SPF do MAIL FROM falha em todas as mensagens
+	spf := s.checkSPF(ctx, mailFromDomain, true)
+	spf.Name = "mailFromSpf"
+
+	return []DNSCheck{check, spf}
+}
+
+// errSPFNotFound indica que o domínio não publica SPF
+var errSPFNotFound = errors.New("nenhum registro SPF encontrado")
+
+// spfEvaluator percorre recursivamente um registro SPF contando as consultas
+// DNS. Evaluating guarda apenas os domínios da cadeia de includes e redirects
+// em avaliação: um domínio incluído por dois caminhos (ex: A→B→D e A→C→D) é
+// avaliado e contado em cada um, e só é um ciclo se incluir a si mesmo.
+type spfEvaluator struct {
+	resolver    DNSResolver
+	lookups     int
+	evaluating  map[string]bool
+	includesSES bool
+	all         string
+}
+
+// evaluate avalia o SPF de domain e retorna o registro encontrado. O
+// qualificador do "all" só é considerado no registro principal (depth 0) e
+// nos seus redirects.
+func (e *spfEvaluator) evaluate(ctx context.Context, domain string, depth int) (string, error) {
+	domain = normalizeDomain(domain)
+	if e.evaluating[domain] {
+		return "", fmt.Errorf("o SPF de %s é incluído em ciclo", domain)
+	}
+	e.evaluating[domain] = true
+	defer delete(e.evaluating, domain)
+
+	record, err := e.lookup(ctx, domain)
+	if err != nil {
+		return "", err
+	}
+
+	var redirect string
+	hasAll := false
+
+	for _, term := range strings.Fields(record)[1:] {
+		term = strings.ToLower(term)
+
+		if name, value, ok := strings.Cut(term, "="); ok && !strings.ContainsAny(name, ":/") {
+			if name == "redirect" {
+				redirect = value
+			}
+			continue // exp e modificadores desconhecidos não geram consultas
+		}
+
+		qualifier := "+"
+		if strings.ContainsAny(term[:1], "+-~?") {
+			qualifier, term = term[:1], term[1:]
+		}
+
+		mechanism, arg, _ := strings.Cut(term, ":")
+		if i := strings.Index(mechanism, "/"); i >= 0 {
+			mechanism = mechanism[:i]
+		}
+
+		switch mechanism {
+		case "all":
+			hasAll = true
+			if depth == 0 {
+				e.all = qualifier
+			}
+		case "ip4", "ip6":
+		case "a", "mx", "ptr", "exists":
+			if err := e.count(); err != nil {
+				return record, err
+			}
+		case "include":
+			if err := e.count(); err != nil {
+				return record, err
+			}
+			if arg == sesSPFInclude {
+				e.includesSES = true
+			}
+			if strings.Contains(arg, "%") {
+				continue // macros dependem da mensagem e não podem ser resolvidas aqui
+			}
+			if _, err := e.evaluate(ctx, arg, depth+1); err != nil {
+				if errors.Is(err, errSPFNotFound) {
+					return record, fmt.Errorf("o include:%s não publica SPF", arg)
+				}
+				return record, err
+			}
+		default:
+			return record, fmt.Errorf("mecanismo SPF desconhecido: %s", term)
+		}
+	}
+
+	// O redirect é ignorado quando o registro tem "all"
+	if redirect != "" && !hasAll {
+		if err := e.count(); err != nil {
+			return record, err
+		}
+		if redirect == sesSPFInclude {
+			e.includesSES = true
+		}
+		if _, err := e.evaluate(ctx, redirect, depth); err != nil {
+			if errors.Is(err, errSPFNotFound) {
+				return record, fmt.Errorf("o redirect=%s não publica SPF", redirect)
+			}
+			return record, err
+		}
+	}
+
+	return record, nil
+}
+
+// count registra uma consulta DNS, falhando ao exceder o limite do SPF
+func (e *spfEvaluator) count() error {
+	e.lookups++
+	if e.lookups > spfLookupLimit {
+		return fmt.Errorf("o SPF excede o limite de %d consultas DNS", spfLookupLimit)
+	}
+	return nil
+}
+
+// lookup obtém o único registro SPF do domínio
+func (e *spfEvaluator) lookup(ctx context.Context, domain string) (string, error) {
+	records, err := e.resolver.LookupTXT(ctx, domain)
+	if err != nil {
+		if isDNSNotFound(err) {
+			return "", errSPFNotFound
+		}
+		return "", errors.New(dnsErrorMessage("TXT", domain, err))
+	}
+
+	var found []string
+	for _, record := range records {
+		fields := strings.Fields(record)
+		if len(fields) > 0 && strings.EqualFold(fields[0], "v=spf1") {
+			found = append(found, record)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", errSPFNotFound
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("há %d registros SPF em %s", len(found), domain)
+	}
+}
+
+// spfHint sugere a correção de um erro de avaliação do SPF
+func spfHint(err error) string {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "limite"):
+		return "Reduza os includes, substituindo-os por ip4/ip6 ou removendo provedores que não enviam mais pelo domínio"
+	case strings.Contains(msg, "registros SPF"):
+		return "Mescle os registros em um único TXT iniciado por v=spf1"
+	case strings.Contains(msg, "não publica SPF"):
+		return "Remova a referência ou corrija o domínio indicado"
+	case strings.Contains(msg, "desconhecido"):
+		return "Corrija a sintaxe do registro conforme a RFC 7208"
+	default:
+		return "Verifique se os servidores DNS do domínio respondem corretamente"
+	}
+}
+
+// parseTagList interpreta uma lista de tags "chave=valor; ..." (DKIM e DMARC)
+func parseTagList(record string) map[string]string {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(record, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		tags[strings.ToLower(strings.TrimSpace(name))] = strings.ToLower(strings.Join(strings.Fields(value), ""))
+	}
+	return tags
+}
+
+// organizationalDomain retorna o domínio organizacional (RFC 7489, seção
+// 3.2): o sufixo público da Public Suffix List mais um rótulo. Para nomes
+// que não têm um domínio registrável, retorna o próprio domínio.
+func organizationalDomain(domain string) string {
+	org, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return domain
+	}
+	return org
+}
+
+// isDNSNotFound indica se a consulta falhou porque o nome ou o registro não existe
+func isDNSNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// dnsErrorMessage descreve a falha de uma consulta DNS
+func dnsErrorMessage(recordType, name string, err error) string {
+	if isDNSNotFound(err) {
+		return fmt.Sprintf("nenhum registro %s encontrado em %s", recordType, name)
+	}
+	return fmt.Sprintf("falha na consulta %s de %s: %v", recordType, name, err)
+}
+
+// worseDNSStatus retorna o pior entre dois resultados
+func worseDNSStatus(a, b string) string {
+	rank := map[string]int{DNSCheckPass: 0, DNSCheckWarn: 1, DNSCheckFail: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+// stubResolver responde às consultas DNS a partir de registros fixos.
+// Nomes ausentes respondem como inexistentes (NXDOMAIN).
+type stubResolver struct {
+	txt   map[string][]string
+	mx    map[string][]*net.MX
+	cname map[string]string
+}
+
+func notFound(name string) error {
+	return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r stubResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if records, ok := r.txt[name]; ok {
+		return records, nil
+	}
+	return nil, notFound(name)
+}
+
+func (r stubResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	if records, ok := r.mx[name]; ok {
+		return records, nil
+	}
+	return nil, notFound(name)
+}
+
+func (r stubResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	if target, ok := r.cname[host]; ok {
+		return target, nil
+	}
+	return "", notFound(host)
+}
+
+func TestCheckSPF(t *testing.T) {
+	tests := []struct {
+		name       string
+		txt        map[string][]string
+		requireSES bool
+		status     string
+		lookups    int
+		message    string
+	}{
+		{
+			name:    "sem registro",
+			txt:     map[string][]string{},
+			status:  DNSCheckFail,
+			message: "nenhum registro SPF",
+		},
+		{
+			name: "include do SES",
+			txt: map[string][]string{
+				"exemplo.com":   {"v=spf1 include:amazonses.com -all"},
+				"amazonses.com": {"v=spf1 ip4:199.255.192.0/22 ~all"},
+			},
+			status:  DNSCheckPass,
+			lookups: 1,
+		},
+		{
+			name: "includes aninhados e mecanismos com consulta",
+			txt: map[string][]string{
+				"exemplo.com":   {"v=spf1 a mx include:_spf.prov.com include:amazonses.com ~all"},
+				"_spf.prov.com": {"v=spf1 include:a.prov.com include:b.prov.com ~all"},
+				"a.prov.com":    {"v=spf1 ip4:192.0.2.0/24 exists:%{i}.prov.com ~all"},
+				"b.prov.com":    {"v=spf1 ip6:2001:db8::/32 a:outro.prov.com ~all"},
+				"amazonses.com": {"v=spf1 ip4:199.255.192.0/22 ~all"},
+			},
+			status:  DNSCheckWarn,
+			lookups: 8,
+			message: "8 de 10",
+		},
+		{
+			name: "domínio incluído por dois caminhos não é ciclo",
+			txt: map[string][]string{
+				"exemplo.com":    {"v=spf1 include:a.prov.com include:b.prov.com include:amazonses.com -all"},
+				"a.prov.com":     {"v=spf1 include:comum.prov.com ~all"},
+				"b.prov.com":     {"v=spf1 include:comum.prov.com ~all"},
+				"comum.prov.com": {"v=spf1 ip4:192.0.2.0/24 ~all"},
+				"amazonses.com":  {"v=spf1 ip4:199.255.192.0/22 ~all"},
+			},
+			status:  DNSCheckPass,
+			lookups: 5,
+		},
+		{
+			name: "ciclo de includes",
+			txt: map[string][]string{
+				"exemplo.com": {"v=spf1 include:a.prov.com -all"},
+				"a.prov.com":  {"v=spf1 include:b.prov.com ~all"},
+				"b.prov.com":  {"v=spf1 include:a.prov.com ~all"},
+			},
+			status:  DNSCheckFail,
+			message: "ciclo",
+		},
+		{
+			name: "redirect conta como consulta",
+			txt: map[string][]string{
+				"exemplo.com":      {"v=spf1 redirect=_spf.exemplo.com"},
+				"_spf.exemplo.com": {"v=spf1 include:amazonses.com -all"},
+				"amazonses.com":    {"v=spf1 ip4:199.255.192.0/22 ~all"},
+			},
+			status:  DNSCheckPass,
+			lookups: 2,
+		},
+		{
+			name: "excede o limite de consultas",
+			txt: map[string][]string{
+				"exemplo.com": {"v=spf1 include:a.prov.com include:amazonses.com -all"},
+				"a.prov.com":  {"v=spf1 a mx a:x.prov.com a:y.prov.com mx:x.prov.com mx:y.prov.com a:z.prov.com mx:z.prov.com a:w.prov.com ~all"},
+			},
+			status:  DNSCheckFail,
+			message: "limite de 10",
+		},
+		{
+			name: "include sem SPF",
+			txt: map[string][]string{
+				"exemplo.com": {"v=spf1 include:inexistente.com -all"},
+			},
+			status:  DNSCheckFail,
+			message: "não publica SPF",
+		},
+		{
+			name: "registros duplicados",
+			txt: map[string][]string{
+				"exemplo.com": {"v=spf1 include:amazonses.com -all", "v=spf1 -all"},
+			},
+			status:  DNSCheckFail,
+			message: "2 registros SPF",
+		},
+		{
+			name: "+all",
+			txt: map[string][]string{
+				"exemplo.com":   {"v=spf1 include:amazonses.com +all"},
+				"amazonses.com": {"v=spf1 ip4:199.255.192.0/22 ~all"},
+			},
+			status:  DNSCheckFail,
+			message: "+all",
+		},
+		{
+			name: "sem o SES",
+			txt: map[string][]string{
+				"exemplo.com": {"v=spf1 ip4:192.0.2.1 -all"},
+			},
+			status:  DNSCheckWarn,
+			message: "não inclui amazonses.com",
+		},
+		{
+			name: "sem o SES no MAIL FROM",
+			txt: map[string][]string{
+				"exemplo.com": {"v=spf1 ip4:192.0.2.1 -all"},
+			},
+			requireSES: true,
+			status:     DNSCheckFail,
+			message:    "não inclui amazonses.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewDNSHealthService(nil, stubResolver{txt: tt.txt}, "us-east-1")
+
+			check := s.checkSPF(context.Background(), "exemplo.com", tt.requireSES)
+			if check.Status != tt.status {
+				t.Errorf("status = %s, esperado %s (%s)", check.Status, tt.status, check.Message)
+			}
+			if tt.lookups > 0 && check.Lookups != tt.lookups {
+				t.Errorf("consultas = %d, esperado %d", check.Lookups, tt.lookups)
+			}
+			if !strings.Contains(check.Message, tt.message) {
+				t.Errorf("mensagem = %q, esperado trecho %q", check.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestCheckDMARC(t *testing.T) {
+	tests := []struct {
+		name    string
+		domain  string
+		txt     map[string][]string
+		status  string
+		target  string
+		message string
+	}{
+		{
+			name:    "sem registro",
+			domain:  "exemplo.com",
+			txt:     map[string][]string{},
+			status:  DNSCheckFail,
+			target:  "_dmarc.exemplo.com",
+			message: "nenhum registro DMARC",
+		},
+		{
+			name:    "p=reject",
+			domain:  "exemplo.com",
+			txt:     map[string][]string{"_dmarc.exemplo.com": {"v=DMARC1; p=reject; rua=mailto:dmarc@exemplo.com"}},
+			status:  DNSCheckPass,
+			target:  "_dmarc.exemplo.com",
+			message: "reject",
+		},
+		{
+			name:    "p=none",
+			domain:  "exemplo.com",
+			txt:     map[string][]string{"_dmarc.exemplo.com": {"v=DMARC1; p=none"}},
+			status:  DNSCheckWarn,
+			target:  "_dmarc.exemplo.com",
+			message: "p=none",
+		},
+		{
+			name:    "pct parcial",
+			domain:  "exemplo.com",
+			txt:     map[string][]string{"_dmarc.exemplo.com": {"v=DMARC1; p=quarantine; pct=50"}},
+			status:  DNSCheckWarn,
+			target:  "_dmarc.exemplo.com",
+			message: "50%",
+		},
+		{
+			name:    "política inválida",
+			domain:  "exemplo.com",
+			txt:     map[string][]string{"_dmarc.exemplo.com": {"v=DMARC1; p=block"}},
+			status:  DNSCheckFail,
+			target:  "_dmarc.exemplo.com",
+			message: "inválida",
+		},
+		{
+			name:   "registros duplicados",
+			domain: "exemplo.com",
+			txt: map[string][]string{"_dmarc.exemplo.com": {
+				"v=DMARC1; p=reject",
+				"v=DMARC1; p=none",
+			}},
+			status:  DNSCheckFail,
+			target:  "_dmarc.exemplo.com",
+			message: "2 registros DMARC",
+		},
+		{
+			name:    "subdomínio herda sp do domínio organizacional",
+			domain:  "mail.exemplo.com",
+			txt:     map[string][]string{"_dmarc.exemplo.com": {"v=DMARC1; p=reject; sp=none"}},
+			status:  DNSCheckWarn,
+			target:  "_dmarc.exemplo.com",
+			message: "p=none",
+		},
+		{
+			name:    "subdomínio sem sp herda p",
+			domain:  "mail.exemplo.com",
+			txt:     map[string][]string{"_dmarc.exemplo.com": {"v=DMARC1; p=quarantine"}},
+			status:  DNSCheckPass,
+			target:  "_dmarc.exemplo.com",
+			message: "quarantine",
+		},
+		{
+			name:    "domínio organizacional com sufixo público de dois rótulos",
+			domain:  "mail.exemplo.com.br",
+			txt:     map[string][]string{"_dmarc.exemplo.com.br": {"v=DMARC1; p=reject"}},
+			status:  DNSCheckPass,
+			target:  "_dmarc.exemplo.com.br",
+			message: "reject",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewDNSHealthService(nil, stubResolver{txt: tt.txt}, "us-east-1")
+
+			check := s.checkDMARC(context.Background(), tt.domain)
+			if check.Status != tt.status {
+				t.Errorf("status = %s, esperado %s (%s)", check.Status, tt.status, check.Message)
+			}
+			if check.Target != tt.target {
+				t.Errorf("alvo = %s, esperado %s", check.Target, tt.target)
+			}
+			if !strings.Contains(check.Message, tt.message) {
+				t.Errorf("mensagem = %q, esperado trecho %q", check.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestCheckMailFrom(t *testing.T) {
+	spf := map[string][]string{
+		"bounce.exemplo.com": {"v=spf1 include:amazonses.com ~all"},
+		"amazonses.com":      {"v=spf1 ip4:199.255.192.0/22 ~all"},
+	}
+	sesMX := []*net.MX{{Host: "feedback-smtp.us-east-1.amazonses.com.", Pref: 10}}
+
+	tests := []struct {
+		name      string
+		mailFrom  *MailFromAttributes
+		mx        map[string][]*net.MX
+		status    string
+		spfStatus string
+		message   string
+	}{
+		{
+			name:    "MAIL FROM padrão do SES",
+			status:  DNSCheckWarn,
+			message: "MAIL FROM padrão",
+		},
+		{
+			name:      "MX correto e confirmado",
+			mailFrom:  &MailFromAttributes{MailFromDomain: "bounce.exemplo.com", Status: "Success"},
+			mx:        map[string][]*net.MX{"bounce.exemplo.com": sesMX},
+			status:    DNSCheckPass,
+			spfStatus: DNSCheckPass,
+			message:   "confirmado",
+		},
+		{
+			name:      "MX correto aguardando o SES",
+			mailFrom:  &MailFromAttributes{MailFromDomain: "bounce.exemplo.com", Status: "Pending"},
+			mx:        map[string][]*net.MX{"bounce.exemplo.com": sesMX},
+			status:    DNSCheckWarn,
+			spfStatus: DNSCheckPass,
+			message:   "Pending",
+		},
+		{
+			name:     "MX de outra região",
+			mailFrom: &MailFromAttributes{MailFromDomain: "bounce.exemplo.com", Status: "Success"},
+			mx: map[string][]*net.MX{"bounce.exemplo.com": {
+				{Host: "feedback-smtp.eu-west-1.amazonses.com.", Pref: 10},
+			}},
+			status:    DNSCheckFail,
+			spfStatus: DNSCheckPass,
+			message:   "nenhum MX aponta para feedback-smtp.us-east-1.amazonses.com",
+		},
+		{
+			name:      "sem MX",
+			mailFrom:  &MailFromAttributes{MailFromDomain: "bounce.exemplo.com", Status: "Failed"},
+			status:    DNSCheckFail,
+			spfStatus: DNSCheckPass,
+			message:   "nenhum registro MX",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewDNSHealthService(nil, stubResolver{txt: spf, mx: tt.mx}, "us-east-1")
+
+			checks := s.checkMailFrom(context.Background(), "exemplo.com", tt.mailFrom)
+			if checks[0].Status != tt.status {
+				t.Errorf("status = %s, esperado %s (%s)", checks[0].Status, tt.status, checks[0].Message)
+			}
+			if !strings.Contains(checks[0].Message, tt.message) {
+				t.Errorf("mensagem = %q, esperado trecho %q", checks[0].Message, tt.message)
+			}
+
+			if tt.spfStatus == "" {
+				if len(checks) != 1 {
+					t.Errorf("verificações = %d, esperado 1", len(checks))
+				}
+				return
+			}
+			if len(checks) != 2 || checks[1].Name != "mailFromSpf" || checks[1].Status != tt.spfStatus {
+				t.Errorf("verificação do SPF do MAIL FROM inesperada: %+v", checks[1:])
+			}
+		})
+	}
+}
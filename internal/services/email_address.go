@@ -0,0 +1,181 @@
+package services
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// EmailAddress representa um endereço RFC 5322 com nome de exibição opcional.
+// O domínio é mantido em ASCII (punycode), como exigido pelo SES.
+type EmailAddress struct {
+	Name  string
+	Email string
+}
+
+// ParseEmailAddress interpreta um endereço no formato "Nome" <email>, Nome
+// <email> ou email. Nomes codificados conforme a RFC 2047 são decodificados e
+// domínios internacionalizados são convertidos para punycode.
+func ParseEmailAddress(value string) (*EmailAddress, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("endereço vazio")
+	}
+
+	parsed, err := mail.ParseAddress(value)
+	if err != nil {
+		return nil, fmt.Errorf("endereço inválido %q: %v", value, err)
+	}
+
+	at := strings.LastIndex(parsed.Address, "@")
+	local, domain := parsed.Address[:at], parsed.Address[at+1:]
+
+	if !isASCII(domain) {
+		ascii, err := idna.Lookup.ToASCII(domain)
+		if err != nil {
+			return nil, fmt.Errorf("domínio inválido em %q: %v", value, err)
+		}
+		domain = ascii
+	}
+
+	if !strings.Contains(domain, ".") {
+		return nil, fmt.Errorf("domínio inválido em %q: informe um domínio completo", value)
+	}
+
+	// O String do net/mail coloca o local part entre aspas quando necessário
+	quoted := (&mail.Address{Address: local + "@" + domain}).String()
+
+	return &EmailAddress{
+		Name:  strings.TrimSpace(parsed.Name),
+		Email: strings.TrimSuffix(strings.TrimPrefix(quoted, "<"), ">"),
+	}, nil
+}
+
+// String formata o endereço conforme a RFC 5322, codificando nomes com
+// caracteres não ASCII conforme a RFC 2047. Sem nome, retorna apenas o e-mail.
+func (a EmailAddress) String() string {
+	if a.Name == "" {
+		return a.Email
+	}
+
+	formatted := (&mail.Address{Name: a.Name, Address: a.Email}).String()
+	// O net/mail coloca aspas em local parts já entre aspas novamente; o e-mail já está no formato final
+	at := strings.LastIndex(formatted, " <")
+	return formatted[:at] + " <" + a.Email + ">"
+}
+
+// RequiresSMTPUTF8 indica se o local part tem caracteres não ASCII (RFC 6531),
+// o que exige suporte a SMTPUTF8 em toda a cadeia de entrega
+func (a EmailAddress) RequiresSMTPUTF8() bool {
+	return !isASCII(a.Email)
+}
+
+// normalizeAddressList interpreta e formata os endereços de um campo,
+// recusando os que exigem SMTPUTF8, que o SES não suporta
+func normalizeAddressList(field string, values []string) ([]string, error) {
+	if len(values) == 0 {
+		return values, nil
+	}
+
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		address, err := ParseEmailAddress(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s inválido: %w", field, err)
+		}
+		if address.RequiresSMTPUTF8() {
+			return nil, fmt.Errorf("%s inválido: o endereço %s exige SMTPUTF8, que não é suportado pelo SES", field, address.Email)
+		}
+		formatted = append(formatted, address.String())
+	}
+
+	return formatted, nil
+}
+
+// Normalize interpreta os endereços do e-mail e valida os cabeçalhos
+// personalizados, as tags e o configuration set. O remetente e o Return-Path
+// passam a conter apenas o e-mail (o nome vai para FromName, se ainda não
+// informado) e os destinatários e o Reply-To são formatados conforme a
+// RFC 5322. Pode ser chamado mais de uma vez.
+func (r *EmailRequest) Normalize() error {
+	from, err := ParseEmailAddress(r.From)
+	if err != nil {
+		return fmt.Errorf("remetente inválido: %w", err)
+	}
+	if from.RequiresSMTPUTF8() {
+		return fmt.Errorf("remetente inválido: o endereço %s exige SMTPUTF8, que não é suportado pelo SES", from.Email)
+	}
+
+	r.From = from.Email
+	if r.FromName == "" {
+		r.FromName = from.Name
+	}
+
+	if len(r.To) == 0 {
+		return fmt.Errorf("destinatário inválido: informe pelo menos um destinatário")
+	}
+
+	if r.To, err = normalizeAddressList("destinatário", r.To); err != nil {
+		return err
+	}
+	if r.Cc, err = normalizeAddressList("destinatário em cópia", r.Cc); err != nil {
+		return err
+	}
+	if r.Bcc, err = normalizeAddressList("destinatário em cópia oculta", r.Bcc); err != nil {
+		return err
+	}
+	if r.ReplyTo, err = normalizeAddressList("Reply-To", r.ReplyTo); err != nil {
+		return err
+	}
+
+	if r.ReturnPath != "" {
+		returnPath, err := ParseEmailAddress(r.ReturnPath)
+		if err != nil {
+			return fmt.Errorf("Return-Path inválido: %w", err)
+		}
+		if returnPath.RequiresSMTPUTF8() {
+			return fmt.Errorf("Return-Path inválido: o endereço %s exige SMTPUTF8, que não é suportado pelo SES", returnPath.Email)
+		}
+		r.ReturnPath = returnPath.Email
+	}
+
+	if err := validateHeaders(r.Headers); err != nil {
+		return err
+	}
+	if err := validateMessageTags(r.Tags); err != nil {
+		return err
+	}
+	if r.ConfigurationSet != "" {
+		return validateConfigurationSetName(r.ConfigurationSet)
+	}
+
+	return nil
+}
+
+// FormattedFrom retorna o remetente com o nome de exibição, no formato RFC 5322
+func (r EmailRequest) FormattedFrom() string {
+	return EmailAddress{Name: r.FromName, Email: r.From}.String()
+}
+
+// addressEmail extrai o e-mail de um endereço RFC 5322, retornando o valor
+// original se ele não puder ser interpretado
+func addressEmail(value string) string {
+	address, err := ParseEmailAddress(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return address.Email
+}
+
+// isASCII indica se o texto contém apenas caracteres ASCII
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
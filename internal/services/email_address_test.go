@@ -0,0 +1,151 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEmailAddress(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		want      EmailAddress
+		formatted string
+		smtputf8  bool
+		err       string
+	}{
+		{name: "apenas e-mail", value: "joao@exemplo.com", want: EmailAddress{Email: "joao@exemplo.com"}, formatted: "joao@exemplo.com"},
+		{name: "espaços ao redor", value: "  joao@exemplo.com ", want: EmailAddress{Email: "joao@exemplo.com"}, formatted: "joao@exemplo.com"},
+		{name: "nome ASCII", value: "Loja <contato@exemplo.com>", want: EmailAddress{Name: "Loja", Email: "contato@exemplo.com"}, formatted: `"Loja" <contato@exemplo.com>`},
+		{name: "nome entre aspas com vírgula", value: `"Silva, Ana" <ana@exemplo.com>`, want: EmailAddress{Name: "Silva, Ana", Email: "ana@exemplo.com"}, formatted: `"Silva, Ana" <ana@exemplo.com>`},
+		{name: "nome com aspas escapadas", value: `"Ana \"Aninha\" Silva" <ana@exemplo.com>`, want: EmailAddress{Name: `Ana "Aninha" Silva`, Email: "ana@exemplo.com"}, formatted: `"Ana \"Aninha\" Silva" <ana@exemplo.com>`},
+		{name: "nome em comentário", value: "joao@exemplo.com (João)", want: EmailAddress{Name: "João", Email: "joao@exemplo.com"}, formatted: "=?utf-8?q?Jo=C3=A3o?= <joao@exemplo.com>"},
+		{name: "nome não ASCII", value: "João Silva <joao@exemplo.com>", want: EmailAddress{Name: "João Silva", Email: "joao@exemplo.com"}, formatted: "=?utf-8?q?Jo=C3=A3o_Silva?= <joao@exemplo.com>"},
+		{name: "RFC 2047 quoted-printable", value: "=?utf-8?q?Jo=C3=A3o?= <joao@exemplo.com>", want: EmailAddress{Name: "João", Email: "joao@exemplo.com"}, formatted: "=?utf-8?q?Jo=C3=A3o?= <joao@exemplo.com>"},
+		{name: "RFC 2047 base64", value: "=?UTF-8?B?Sm/Do28=?= <joao@exemplo.com>", want: EmailAddress{Name: "João", Email: "joao@exemplo.com"}, formatted: "=?utf-8?q?Jo=C3=A3o?= <joao@exemplo.com>"},
+		{name: "RFC 2047 em ISO-8859-1", value: "=?iso-8859-1?q?Jo=E3o?= <joao@exemplo.com>", want: EmailAddress{Name: "João", Email: "joao@exemplo.com"}, formatted: "=?utf-8?q?Jo=C3=A3o?= <joao@exemplo.com>"},
+		{name: "nome literal parecido com RFC 2047", value: `"=?utf-8?q?x?=" <ana@exemplo.com>`, want: EmailAddress{Name: "=?utf-8?q?x?=", Email: "ana@exemplo.com"}, formatted: `"=?utf-8?q?x?=" <ana@exemplo.com>`},
+		{name: "domínio internacionalizado", value: "joao@bücher.de", want: EmailAddress{Email: "joao@xn--bcher-kva.de"}, formatted: "joao@xn--bcher-kva.de"},
+		{name: "domínio internacionalizado em maiúsculas", value: "João <joao@BÜCHER.de>", want: EmailAddress{Name: "João", Email: "joao@xn--bcher-kva.de"}, formatted: "=?utf-8?q?Jo=C3=A3o?= <joao@xn--bcher-kva.de>"},
+		{name: "domínio já em punycode", value: "joao@xn--bcher-kva.de", want: EmailAddress{Email: "joao@xn--bcher-kva.de"}, formatted: "joao@xn--bcher-kva.de"},
+		{name: "local part entre aspas", value: `"ana silva"@exemplo.com`, want: EmailAddress{Email: `"ana silva"@exemplo.com`}, formatted: `"ana silva"@exemplo.com`},
+		{name: "local part entre aspas com nome", value: `Ana <"ana silva"@exemplo.com>`, want: EmailAddress{Name: "Ana", Email: `"ana silva"@exemplo.com`}, formatted: `"Ana" <"ana silva"@exemplo.com>`},
+		{name: "local part não ASCII", value: "joão@exemplo.com", want: EmailAddress{Email: "joão@exemplo.com"}, formatted: "joão@exemplo.com", smtputf8: true},
+		{name: "local part não ASCII com domínio internacionalizado", value: "joão@bücher.de", want: EmailAddress{Email: "joão@xn--bcher-kva.de"}, formatted: "joão@xn--bcher-kva.de", smtputf8: true},
+		{name: "vazio", value: "  ", err: "endereço vazio"},
+		{name: "sem arroba", value: "joao", err: "endereço inválido"},
+		{name: "sem fechar o ângulo", value: "<joao@exemplo.com", err: "endereço inválido"},
+		{name: "vários endereços", value: "Ana <ana@exemplo.com>, Bia <bia@exemplo.com>", err: "endereço inválido"},
+		{name: "domínio sem ponto", value: "joao@localhost", err: "informe um domínio completo"},
+		{name: "domínio internacionalizado inválido", value: "joao@ex_emplo.ção", err: "domínio inválido"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, err := ParseEmailAddress(tt.value)
+			assertError(t, err, tt.err)
+			if err != nil {
+				return
+			}
+
+			if *address != tt.want {
+				t.Errorf("ParseEmailAddress() = %+v, esperado %+v", *address, tt.want)
+			}
+			if got := address.String(); got != tt.formatted {
+				t.Errorf("String() = %q, esperado %q", got, tt.formatted)
+			}
+			if got := address.RequiresSMTPUTF8(); got != tt.smtputf8 {
+				t.Errorf("RequiresSMTPUTF8() = %v, esperado %v", got, tt.smtputf8)
+			}
+
+			// O endereço formatado é interpretado de volta no mesmo endereço
+			again, err := ParseEmailAddress(address.String())
+			if err != nil {
+				t.Fatalf("ParseEmailAddress(%q) falhou: %v", address.String(), err)
+			}
+			if *again != *address {
+				t.Errorf("ParseEmailAddress(String()) = %+v, esperado %+v", *again, *address)
+			}
+		})
+	}
+}
+
+func TestEmailRequestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		req  EmailRequest
+		want EmailRequest
+		err  string
+	}{
+		{
+			name: "nome do remetente vai para FromName",
+			req:  EmailRequest{From: "Loja <contato@exemplo.com>", To: []string{"cliente@exemplo.com"}},
+			want: EmailRequest{From: "contato@exemplo.com", FromName: "Loja", To: []string{"cliente@exemplo.com"}},
+		},
+		{
+			name: "FromName informado prevalece",
+			req:  EmailRequest{From: "Loja <contato@exemplo.com>", FromName: "Loja Oficial", To: []string{"cliente@exemplo.com"}},
+			want: EmailRequest{From: "contato@exemplo.com", FromName: "Loja Oficial", To: []string{"cliente@exemplo.com"}},
+		},
+		{
+			name: "remetente codificado conforme a RFC 2047",
+			req:  EmailRequest{From: "=?utf-8?q?Promo=C3=A7=C3=B5es?= <ofertas@exemplo.com>", To: []string{"cliente@exemplo.com"}},
+			want: EmailRequest{From: "ofertas@exemplo.com", FromName: "Promoções", To: []string{"cliente@exemplo.com"}},
+		},
+		{
+			name: "destinatários formatados",
+			req: EmailRequest{
+				From:    "contato@exemplo.com",
+				To:      []string{"João <joao@bücher.de>", " ana@exemplo.com "},
+				Cc:      []string{`"Silva, Bia" <bia@exemplo.com>`},
+				Bcc:     []string{"auditoria@exemplo.com"},
+				ReplyTo: []string{"Suporte <suporte@exemplo.com>"},
+			},
+			want: EmailRequest{
+				From:    "contato@exemplo.com",
+				To:      []string{"=?utf-8?q?Jo=C3=A3o?= <joao@xn--bcher-kva.de>", "ana@exemplo.com"},
+				Cc:      []string{`"Silva, Bia" <bia@exemplo.com>`},
+				Bcc:     []string{"auditoria@exemplo.com"},
+				ReplyTo: []string{`"Suporte" <suporte@exemplo.com>`},
+			},
+		},
+		{
+			name: "Return-Path apenas com o e-mail",
+			req:  EmailRequest{From: "contato@exemplo.com", To: []string{"cliente@exemplo.com"}, ReturnPath: "Bounces <bounces@bücher.de>"},
+			want: EmailRequest{From: "contato@exemplo.com", To: []string{"cliente@exemplo.com"}, ReturnPath: "bounces@xn--bcher-kva.de"},
+		},
+		{name: "remetente inválido", req: EmailRequest{From: "contato", To: []string{"cliente@exemplo.com"}}, err: "remetente inválido"},
+		{name: "remetente SMTPUTF8", req: EmailRequest{From: "joão@exemplo.com", To: []string{"cliente@exemplo.com"}}, err: "remetente inválido: o endereço joão@exemplo.com exige SMTPUTF8"},
+		{name: "sem destinatários", req: EmailRequest{From: "contato@exemplo.com"}, err: "informe pelo menos um destinatário"},
+		{name: "destinatário SMTPUTF8", req: EmailRequest{From: "contato@exemplo.com", To: []string{"joão@exemplo.com"}}, err: "destinatário inválido: o endereço joão@exemplo.com exige SMTPUTF8"},
+		{name: "cópia SMTPUTF8", req: EmailRequest{From: "contato@exemplo.com", To: []string{"a@exemplo.com"}, Cc: []string{"joão@exemplo.com"}}, err: "destinatário em cópia inválido"},
+		{name: "cópia oculta inválida", req: EmailRequest{From: "contato@exemplo.com", To: []string{"a@exemplo.com"}, Bcc: []string{"joao@localhost"}}, err: "destinatário em cópia oculta inválido"},
+		{name: "Reply-To SMTPUTF8", req: EmailRequest{From: "contato@exemplo.com", To: []string{"a@exemplo.com"}, ReplyTo: []string{"joão@exemplo.com"}}, err: "Reply-To inválido"},
+		{name: "Return-Path SMTPUTF8", req: EmailRequest{From: "contato@exemplo.com", To: []string{"a@exemplo.com"}, ReturnPath: "joão@exemplo.com"}, err: "Return-Path inválido"},
+		{name: "cabeçalho inválido", req: EmailRequest{From: "contato@exemplo.com", To: []string{"a@exemplo.com"}, Headers: map[string]string{"Bcc": "x@exemplo.com"}}, err: "reservado"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			err := req.Normalize()
+			assertError(t, err, tt.err)
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(req, tt.want) {
+				t.Errorf("Normalize() = %+v, esperado %+v", req, tt.want)
+			}
+
+			// Normalizar de novo não altera o resultado
+			again := req
+			if err := again.Normalize(); err != nil {
+				t.Fatalf("segundo Normalize() falhou: %v", err)
+			}
+			if !reflect.DeepEqual(again, req) {
+				t.Errorf("Normalize(Normalize(x)) = %+v, esperado %+v", again, req)
+			}
+		})
+	}
+}
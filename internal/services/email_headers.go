@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"mime"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+)
+
+const (
+	// maxCustomHeaders limita a quantidade de cabeçalhos personalizados por e-mail
+	maxCustomHeaders = 50
+	// maxHeaderLineLength é o tamanho máximo de uma linha de cabeçalho (RFC 5322)
+	maxHeaderLineLength = 998
+	// maxMessageTags é a quantidade máxima de tags por mensagem aceita pelo SES
+	maxMessageTags = 50
+)
+
+// reservedHeaders são os cabeçalhos definidos pelo serviço ou pelo SES, que
+// não podem ser informados como cabeçalhos personalizados
+var reservedHeaders = map[string]bool{
+	"from":                      true,
+	"sender":                    true,
+	"to":                        true,
+	"cc":                        true,
+	"bcc":                       true,
+	"reply-to":                  true,
+	"return-path":               true,
+	"subject":                   true,
+	"date":                      true,
+	"message-id":                true,
+	"mime-version":              true,
+	"content-type":              true,
+	"content-transfer-encoding": true,
+	"content-disposition":       true,
+	"dkim-signature":            true,
+	"received":                  true,
+}
+
+// sesName restringe nomes e valores de tags e nomes de configuration sets aos caracteres aceitos pelo SES
+var sesName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// validateHeaders verifica os cabeçalhos personalizados contra injeção de
+// cabeçalhos (quebras de linha) e nomes reservados
+func validateHeaders(headers map[string]string) error {
+	if len(headers) > maxCustomHeaders {
+		return fmt.Errorf("cabeçalhos inválidos: o limite é de %d cabeçalhos personalizados", maxCustomHeaders)
+	}
+
+	for name, value := range headers {
+		if name == "" {
+			return fmt.Errorf("cabeçalho inválido: nome vazio")
+		}
+		// Nomes de cabeçalho usam apenas caracteres ASCII visíveis, exceto ':' (RFC 5322)
+		for i := 0; i < len(name); i++ {
+			if name[i] < 33 || name[i] > 126 || name[i] == ':' {
+				return fmt.Errorf("cabeçalho inválido: nome %q contém caracteres não permitidos", name)
+			}
+		}
+
+		lower := strings.ToLower(name)
+		if reservedHeaders[lower] || strings.HasPrefix(lower, "x-ses-") {
+			return fmt.Errorf("cabeçalho inválido: %s é reservado", name)
+		}
+
+		if strings.ContainsAny(value, "\r\n\x00") {
+			return fmt.Errorf("cabeçalho inválido: o valor de %s contém quebras de linha", name)
+		}
+		if len(name)+len(value)+2 > maxHeaderLineLength {
+			return fmt.Errorf("cabeçalho inválido: %s excede %d caracteres", name, maxHeaderLineLength)
+		}
+	}
+
+	return nil
+}
+
+// validateMessageTags verifica as tags conforme as regras do SES
+func validateMessageTags(tags map[string]string) error {
+	if len(tags) > maxMessageTags {
+		return fmt.Errorf("tags inválidas: o limite do SES é de %d tags por mensagem", maxMessageTags)
+	}
+
+	for name, value := range tags {
+		if len(name) > 256 || !sesName.MatchString(name) {
+			return fmt.Errorf("tag inválida: o nome %q deve ter até 256 letras, números, '-' ou '_'", name)
+		}
+		if len(value) > 256 || !sesName.MatchString(value) {
+			return fmt.Errorf("tag inválida: o valor de %s deve ter até 256 letras, números, '-' ou '_'", name)
+		}
+	}
+
+	return nil
+}
+
+// validateConfigurationSetName verifica o nome de um configuration set
+func validateConfigurationSetName(name string) error {
+	if len(name) > 64 || !sesName.MatchString(name) {
+		return fmt.Errorf("configuration set inválido: use até 64 letras, números, '-' ou '_'")
+	}
+	return nil
+}
+
+// messageTags converte as tags do e-mail para o formato do SES, em ordem de nome
+func (r EmailRequest) messageTags() []types.MessageTag {
+	if len(r.Tags) == 0 {
+		return nil
+	}
+
+	tags := make([]types.MessageTag, 0, len(r.Tags))
+	for _, name := range sortedKeys(r.Tags) {
+		tags = append(tags, types.MessageTag{
+			Name:  aws.String(name),
+			Value: aws.String(r.Tags[name]),
+		})
+	}
+	return tags
+}
+
+// configurationSetName retorna o configuration set do e-mail, ou nil se não houver
+func (r EmailRequest) configurationSetName() *string {
+	if r.ConfigurationSet == "" {
+		return nil
+	}
+	return aws.String(r.ConfigurationSet)
+}
+
+// rawHeaders monta o bloco de cabeçalhos de endereçamento e personalizados de
+// uma mensagem raw. Os valores com caracteres não ASCII são codificados
+// conforme a RFC 2047.
+func (r EmailRequest) rawHeaders() string {
+	var b strings.Builder
+
+	write := func(name string, values ...string) {
+		if len(values) > 0 {
+			fmt.Fprintf(&b, "%s: %s\r\n", name, strings.Join(values, ", "))
+		}
+	}
+
+	write("From", r.FormattedFrom())
+	write("To", r.To...)
+	write("Cc", r.Cc...)
+	write("Reply-To", r.ReplyTo...)
+
+	for _, name := range sortedKeys(r.Headers) {
+		write(name, mime.QEncoding.Encode("utf-8", r.Headers[name]))
+	}
+
+	return b.String()
+}
+
+// envelopeDestinations retorna os e-mails de todos os destinatários (To, Cc
+// e Bcc) para o envelope das mensagens raw
+func (r EmailRequest) envelopeDestinations() []string {
+	destinations := make([]string, 0, len(r.To)+len(r.Cc)+len(r.Bcc))
+	for _, list := range [][]string{r.To, r.Cc, r.Bcc} {
+		for _, address := range list {
+			destinations = append(destinations, addressEmail(address))
+		}
+	}
+	return destinations
+}
+
+// sortedKeys retorna as chaves do mapa em ordem alfabética
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidateHeaders(t *testing.T) {
+	type headerTest struct {
+		name    string
+		headers map[string]string
+		err     string
+	}
+
+	tests := []headerTest{
+		{name: "válido", headers: map[string]string{"X-Campaign": "black-friday", "List-Unsubscribe": "<mailto:sair@exemplo.com>"}},
+		{name: "sem cabeçalhos", headers: nil},
+		{name: "nome vazio", headers: map[string]string{"": "valor"}, err: "nome vazio"},
+		{name: "CR no nome", headers: map[string]string{"X-A\rB": "valor"}, err: "caracteres não permitidos"},
+		{name: "LF no nome", headers: map[string]string{"X-A\nB": "valor"}, err: "caracteres não permitidos"},
+		{name: "NUL no nome", headers: map[string]string{"X-A\x00B": "valor"}, err: "caracteres não permitidos"},
+		{name: "espaço no nome", headers: map[string]string{"X Campaign": "valor"}, err: "caracteres não permitidos"},
+		{name: "dois-pontos no nome", headers: map[string]string{"X-Campaign:": "valor"}, err: "caracteres não permitidos"},
+		{name: "não ASCII no nome", headers: map[string]string{"X-Campanhã": "valor"}, err: "caracteres não permitidos"},
+		{name: "CR no valor", headers: map[string]string{"X-Campaign": "a\rb"}, err: "quebras de linha"},
+		{name: "LF no valor", headers: map[string]string{"X-Campaign": "a\nb"}, err: "quebras de linha"},
+		{name: "NUL no valor", headers: map[string]string{"X-Campaign": "a\x00b"}, err: "quebras de linha"},
+		{name: "injeção de Bcc", headers: map[string]string{"X-Campaign": "a\r\nBcc: alvo@exemplo.com"}, err: "quebras de linha"},
+		{name: "prefixo do SES", headers: map[string]string{"X-SES-CONFIGURATION-SET": "outro"}, err: "reservado"},
+		{name: "prefixo do SES em minúsculas", headers: map[string]string{"x-ses-message-tags": "a=b"}, err: "reservado"},
+		{
+			name:    "linha com 998 caracteres",
+			headers: map[string]string{"X-Long": strings.Repeat("a", maxHeaderLineLength-len("X-Long")-2)},
+		},
+		{
+			name:    "linha com 999 caracteres",
+			headers: map[string]string{"X-Long": strings.Repeat("a", maxHeaderLineLength-len("X-Long")-1)},
+			err:     "excede",
+		},
+		{name: "limite de cabeçalhos", headers: numberedHeaders(maxCustomHeaders)},
+		{name: "acima do limite de cabeçalhos", headers: numberedHeaders(maxCustomHeaders + 1), err: "limite"},
+	}
+
+	// Cada nome reservado é recusado, inclusive com outra capitalização
+	for name := range reservedHeaders {
+		tests = append(tests,
+			headerTest{name: "reservado " + name, headers: map[string]string{name: "valor"}, err: "reservado"},
+			headerTest{name: "reservado " + strings.ToUpper(name), headers: map[string]string{strings.ToUpper(name): "valor"}, err: "reservado"},
+		)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertError(t, validateHeaders(tt.headers), tt.err)
+		})
+	}
+}
+
+func TestValidateMessageTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags map[string]string
+		err  string
+	}{
+		{name: "válidas", tags: map[string]string{"campaign": "black-friday", "tenant_id": "A1"}},
+		{name: "sem tags", tags: nil},
+		{name: "nome vazio", tags: map[string]string{"": "valor"}, err: "o nome"},
+		{name: "valor vazio", tags: map[string]string{"campaign": ""}, err: "o valor de campaign"},
+		{name: "espaço no nome", tags: map[string]string{"minha tag": "valor"}, err: "o nome"},
+		{name: "ponto no nome", tags: map[string]string{"campaign.id": "valor"}, err: "o nome"},
+		{name: "dois-pontos no valor", tags: map[string]string{"campaign": "a:b"}, err: "o valor"},
+		{name: "acento no valor", tags: map[string]string{"campaign": "promoção"}, err: "o valor"},
+		{name: "quebra de linha no valor", tags: map[string]string{"campaign": "a\nb"}, err: "o valor"},
+		{name: "nome com 256 caracteres", tags: map[string]string{strings.Repeat("a", 256): "valor"}},
+		{name: "nome com 257 caracteres", tags: map[string]string{strings.Repeat("a", 257): "valor"}, err: "o nome"},
+		{name: "valor com 257 caracteres", tags: map[string]string{"campaign": strings.Repeat("a", 257)}, err: "o valor"},
+		{name: "limite de tags", tags: numberedHeaders(maxMessageTags)},
+		{name: "acima do limite de tags", tags: numberedHeaders(maxMessageTags + 1), err: "limite"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertError(t, validateMessageTags(tt.tags), tt.err)
+		})
+	}
+}
+
+func TestRawHeaders(t *testing.T) {
+	req := EmailRequest{
+		From:       "contato@exemplo.com",
+		FromName:   "Loja",
+		To:         []string{"cliente@exemplo.com"},
+		ReplyTo:    []string{"suporte@exemplo.com"},
+		ReturnPath: "bounces@exemplo.com",
+		Headers:    map[string]string{"X-Campaign": "promoção"},
+	}
+
+	headers := req.rawHeaders()
+	for _, want := range []string{
+		"From: \"Loja\" <contato@exemplo.com>\r\n",
+		"To: cliente@exemplo.com\r\n",
+		"Reply-To: suporte@exemplo.com\r\n",
+		"X-Campaign: =?utf-8?q?promo=C3=A7=C3=A3o?=\r\n",
+	} {
+		if !strings.Contains(headers, want) {
+			t.Errorf("rawHeaders() não contém %q:\n%s", want, headers)
+		}
+	}
+
+	// O Return-Path é definido pelo servidor que recebe a mensagem
+	if strings.Contains(headers, "Return-Path") {
+		t.Errorf("rawHeaders() não deveria escrever Return-Path:\n%s", headers)
+	}
+}
+
+// numberedHeaders gera n entradas válidas com nomes distintos
+func numberedHeaders(n int) map[string]string {
+	values := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		values[fmt.Sprintf("X-Item-%d", i)] = "valor"
+	}
+	return values
+}
+
+// assertError verifica que err é nil quando want é vazio, ou contém want
+func assertError(t *testing.T, err error, want string) {
+	t.Helper()
+	switch {
+	case want == "" && err != nil:
+		t.Errorf("erro inesperado: %v", err)
+	case want != "" && err == nil:
+		t.Errorf("esperado erro contendo %q", want)
+	case want != "" && !strings.Contains(err.Error(), want):
+		t.Errorf("erro = %q, esperado contendo %q", err, want)
+	}
+}
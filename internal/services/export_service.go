@@ -0,0 +1,643 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/robfig/cron/v3"
+)
+
+// Conjuntos de dados exportáveis
+const (
+	ExportDatasetDeliveries = "deliveries"
+	ExportDatasetEvents     = "events"
+	ExportDatasetMetrics    = "metrics"
+)
+
+// Formatos de exportação
+const (
+	ExportFormatCSV     = "csv"
+	ExportFormatNDJSON  = "ndjson"
+	ExportFormatParquet = "parquet"
+)
+
+// Destinos das exportações agendadas
+const (
+	ExportDestinationLocal = "local"
+	ExportDestinationS3    = "s3"
+)
+
+// exportContentTypes mapeia o formato para o Content-Type do arquivo
+var exportContentTypes = map[string]string{
+	ExportFormatCSV:     "text/csv; charset=utf-8",
+	ExportFormatNDJSON:  "application/x-ndjson",
+	ExportFormatParquet: "application/vnd.apache.parquet",
+}
+
+// defaultExportPeriodDays é o período padrão das exportações agendadas, em dias
+const defaultExportPeriodDays = 1
+
+// exportCheckInterval é o intervalo de verificação dos agendamentos vencidos
+const exportCheckInterval = time.Minute
+
+// ExportQuery define o conjunto de dados, o formato e o período de uma
+// exportação. As datas (YYYY-MM-DD) são interpretadas no fuso horário
+// informado, assim como os dias das métricas diárias.
+type ExportQuery struct {
+	Dataset   string   `json:"dataset"`
+	Format    string   `json:"format"`
+	StartDate string   `json:"startDate,omitempty"`
+	EndDate   string   `json:"endDate,omitempty"`
+	Timezone  string   `json:"timezone,omitempty"`
+	Sender    string   `json:"sender,omitempty"`
+	Statuses  []string `json:"statuses,omitempty"`
+	Dimension string   `json:"dimension,omitempty"`
+}
+
+// Validate valida a exportação e aplica os valores padrão
+func (q *ExportQuery) Validate() error {
+	switch q.Dataset {
+	case ExportDatasetDeliveries, ExportDatasetEvents:
+		q.Dimension = ""
+	case ExportDatasetMetrics:
+		if q.Dimension == "" {
+			q.Dimension = DimensionSender
+		}
+		if !analyticsDimensions[q.Dimension] {
+			return fmt.Errorf("dimensão inválida: %s", q.Dimension)
+		}
+		q.Statuses = nil
+	default:
+		return fmt.Errorf("conjunto de dados inválido: %s (use deliveries, events ou metrics)", q.Dataset)
+	}
+
+	if q.Format == "" {
+		q.Format = ExportFormatCSV
+	}
+	if _, ok := exportContentTypes[q.Format]; !ok {
+		return fmt.Errorf("formato de exportação inválido: %s (use csv, ndjson ou parquet)", q.Format)
+	}
+
+	if q.Timezone == "" {
+		q.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(q.Timezone); err != nil {
+		return fmt.Errorf("fuso horário inválido: %s", q.Timezone)
+	}
+
+	return nil
+}
+
+// ContentType retorna o Content-Type do arquivo exportado
+func (q ExportQuery) ContentType() string {
+	return exportContentTypes[q.Format]
+}
+
+// FileName retorna o nome do arquivo exportado
+func (q ExportQuery) FileName() string {
+	name := q.Dataset
+	if q.StartDate != "" || q.EndDate != "" {
+		name += "-" + q.StartDate + "_" + q.EndDate
+	}
+	return name + "." + q.Format
+}
+
+// ExportDestination define onde as exportações agendadas são gravadas: um
+// subdiretório de EXPORT_DIR (local) ou um bucket compatível com S3, com
+// endpoint opcional (ex: MinIO)
+type ExportDestination struct {
+	Type         string `json:"type" binding:"required"`
+	Path         string `json:"path,omitempty"`
+	Bucket       string `json:"bucket,omitempty"`
+	Prefix       string `json:"prefix,omitempty"`
+	Endpoint     string `json:"endpoint,omitempty"`
+	Region       string `json:"region,omitempty"`
+	UsePathStyle bool   `json:"usePathStyle,omitempty"`
+}
+
+// ExportScheduleRequest representa uma solicitação de exportação agendada.
+// A cada execução são exportados os últimos periodDays dias completos.
+type ExportScheduleRequest struct {
+	Name        string            `json:"name" binding:"required"`
+	Cron        string            `json:"cron" binding:"required"`
+	Timezone    string            `json:"timezone,omitempty"`
+	Dataset     string            `json:"dataset" binding:"required"`
+	Format      string            `json:"format,omitempty"`
+	Sender      string            `json:"sender,omitempty" binding:"omitempty,email"`
+	Dimension   string            `json:"dimension,omitempty"`
+	PeriodDays  int               `json:"periodDays,omitempty"`
+	Destination ExportDestination `json:"destination" binding:"required"`
+}
+
+// ExportSchedule representa uma exportação agendada
+type ExportSchedule struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Cron        string            `json:"cron"`
+	Timezone    string            `json:"timezone"`
+	Dataset     string            `json:"dataset"`
+	Format      string            `json:"format"`
+	Sender      string            `json:"sender,omitempty"`
+	Dimension   string            `json:"dimension,omitempty"`
+	PeriodDays  int               `json:"periodDays"`
+	Destination ExportDestination `json:"destination"`
+	NextRunAt   time.Time         `json:"nextRunAt"`
+	LastRunAt   *time.Time        `json:"lastRunAt,omitempty"`
+	LastError   string            `json:"lastError,omitempty"`
+	LastObject  string            `json:"lastObject,omitempty"`
+	LastRows    int64             `json:"lastRows"`
+	CreatedAt   time.Time         `json:"createdAt"`
+}
+
+// ExportService exporta os status de entrega, os eventos e as métricas
+// diárias em streaming, lendo os registros em lotes, e executa as
+// exportações agendadas para um diretório local ou bucket S3
+type ExportService struct {
+	store          ExportStore
+	deliveryStore  DeliveryStore
+	analyticsStore AnalyticsStore
+	baseDir        string
+	region         string
+	mutex          sync.Mutex
+}
+
+// NewExportService cria uma nova instância do ExportService. BaseDir é o
+// diretório das exportações locais e region a região padrão dos buckets S3.
+func NewExportService(store ExportStore, deliveryStore DeliveryStore, analyticsStore AnalyticsStore, baseDir, region string) *ExportService {
+	return &ExportService{
+		store:          store,
+		deliveryStore:  deliveryStore,
+		analyticsStore: analyticsStore,
+		baseDir:        baseDir,
+		region:         region,
+	}
+}
+
+// Export grava o conjunto de dados em w, no formato da consulta, e retorna a
+// quantidade de linhas exportadas. A exportação é interrompida quando o
+// contexto é cancelado (ex: o cliente encerra a conexão).
+func (s *ExportService) Export(ctx context.Context, w io.Writer, query ExportQuery) (int64, error) {
+	if err := query.Validate(); err != nil {
+		return 0, err
+	}
+
+	loc, _ := time.LoadLocation(query.Timezone)
+	start, end, err := parseDateRange(query.StartDate, query.EndDate, loc)
+	if err != nil {
+		return 0, err
+	}
+
+	switch query.Dataset {
+	case ExportDatasetDeliveries:
+		return s.exportDeliveries(ctx, w, query, start, end)
+	case ExportDatasetEvents:
+		return s.exportEvents(ctx, w, query, start, end)
+	default:
+		return s.exportMetrics(ctx, w, query, start, end, loc)
+	}
+}
+
+// exportDeliveries exporta uma linha por destinatário das mensagens enviadas no período
+func (s *ExportService) exportDeliveries(ctx context.Context, w io.Writer, query ExportQuery, start, end time.Time) (int64, error) {
+	ew, err := newExportWriter(query.Format, w, deliveryExportHeader, &DeliveryExportRow{})
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = s.deliveryStore.Iterate(deliveryExportFilter(query, start, end), func(status DeliveryStatus) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rows := deliveryExportRows(status)
+		for i := range rows {
+			if err := ew.Write(&rows[i]); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("falha ao exportar status de entrega: %w", err)
+	}
+
+	return count, ew.Close()
+}
+
+// exportEvents exporta a linha do tempo das mensagens enviadas no período
+func (s *ExportService) exportEvents(ctx context.Context, w io.Writer, query ExportQuery, start, end time.Time) (int64, error) {
+	ew, err := newExportWriter(query.Format, w, eventExportHeader, &EventExportRow{})
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = s.deliveryStore.Iterate(deliveryExportFilter(query, start, end), func(status DeliveryStatus) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		events, err := s.deliveryStore.ListEvents(status.MessageID)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := ew.Write(newEventExportRow(status, event)); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("falha ao exportar eventos: %w", err)
+	}
+
+	return count, ew.Close()
+}
+
+// exportMetrics exporta os contadores diários de cada valor da dimensão,
+// com os dias no fuso horário da consulta
+func (s *ExportService) exportMetrics(ctx context.Context, w io.Writer, query ExportQuery, start, end time.Time, loc *time.Location) (int64, error) {
+	ew, err := newExportWriter(query.Format, w, metricsExportHeader, &MetricsExportRow{})
+	if err != nil {
+		return 0, err
+	}
+
+	var values []string
+	if query.Sender != "" && query.Dimension == DimensionSender {
+		values = []string{normalizeDimensionValue(DimensionSender, query.Sender)}
+	} else {
+		totals, err := s.analyticsStore.Totals(query.Dimension, start, end)
+		if err != nil {
+			return 0, fmt.Errorf("falha ao exportar métricas: %w", err)
+		}
+		for value := range totals {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+	}
+
+	var count int64
+	for _, value := range values {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+
+		// As linhas por hora vêm em ordem cronológica: basta acumular até a troca de dia
+		rows, err := s.analyticsStore.Rows(query.Dimension, value, start, end)
+		if err != nil {
+			return count, fmt.Errorf("falha ao exportar métricas: %w", err)
+		}
+
+		var day string
+		var counters AnalyticsCounters
+		flush := func() error {
+			if day == "" {
+				return nil
+			}
+			count++
+			return ew.Write(newMetricsExportRow(day, query.Dimension, value, counters))
+		}
+
+		for _, row := range rows {
+			rowDay := row.Hour.In(loc).Format("2006-01-02")
+			if rowDay != day {
+				if err := flush(); err != nil {
+					return count, err
+				}
+				day, counters = rowDay, AnalyticsCounters{}
+			}
+			counters.add(row.Counters)
+		}
+		if err := flush(); err != nil {
+			return count, err
+		}
+	}
+
+	return count, ew.Close()
+}
+
+// deliveryExportFilter monta o filtro dos status de entrega da exportação
+func deliveryExportFilter(query ExportQuery, start, end time.Time) DeliveryFilter {
+	return DeliveryFilter{
+		FromEmail: query.Sender,
+		Statuses:  query.Statuses,
+		Start:     start,
+		End:       end,
+	}
+}
+
+// Validate valida o agendamento e aplica os valores padrão
+func (r *ExportScheduleRequest) Validate() error {
+	if _, err := cron.ParseStandard(r.Cron); err != nil {
+		return fmt.Errorf("expressão cron inválida: %w", err)
+	}
+
+	query := ExportQuery{Dataset: r.Dataset, Format: r.Format, Timezone: r.Timezone, Sender: r.Sender, Dimension: r.Dimension}
+	if err := query.Validate(); err != nil {
+		return err
+	}
+	r.Format, r.Timezone, r.Dimension = query.Format, query.Timezone, query.Dimension
+
+	if r.PeriodDays == 0 {
+		r.PeriodDays = defaultExportPeriodDays
+	}
+	if r.PeriodDays < 1 {
+		return fmt.Errorf("período inválido: %d dias", r.PeriodDays)
+	}
+
+	switch r.Destination.Type {
+	case ExportDestinationLocal:
+	case ExportDestinationS3:
+		if r.Destination.Bucket == "" {
+			return fmt.Errorf("bucket obrigatório no destino s3")
+		}
+	default:
+		return fmt.Errorf("destino inválido: %s (use local ou s3)", r.Destination.Type)
+	}
+
+	return nil
+}
+
+// CreateSchedule cria uma exportação agendada e calcula a primeira execução
+func (s *ExportService) CreateSchedule(req ExportScheduleRequest) (*ExportSchedule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.Destination.Type == ExportDestinationLocal && s.baseDir == "" {
+		return nil, fmt.Errorf("diretório de exportação não configurado (EXPORT_DIR)")
+	}
+
+	now := time.Now()
+	schedule := &ExportSchedule{
+		ID:          strings.ToLower(strings.ReplaceAll(req.Name, " ", "-")) + "-" + fmt.Sprintf("%d", now.Unix()),
+		Name:        req.Name,
+		Cron:        req.Cron,
+		Timezone:    req.Timezone,
+		Dataset:     req.Dataset,
+		Format:      req.Format,
+		Sender:      strings.ToLower(req.Sender),
+		Dimension:   req.Dimension,
+		PeriodDays:  req.PeriodDays,
+		Destination: req.Destination,
+		CreatedAt:   now,
+	}
+
+	next, err := nextCronRun(schedule.Cron, schedule.Timezone, now)
+	if err != nil {
+		return nil, err
+	}
+	schedule.NextRunAt = next
+
+	if err := s.store.SaveSchedule(schedule); err != nil {
+		return nil, fmt.Errorf("falha ao salvar agendamento: %w", err)
+	}
+
+	return schedule, nil
+}
+
+// ListSchedules lista as exportações agendadas
+func (s *ExportService) ListSchedules() ([]ExportSchedule, error) {
+	return s.store.ListSchedules()
+}
+
+// GetSchedule obtém uma exportação agendada pelo ID, retornando nil se não existir
+func (s *ExportService) GetSchedule(id string) (*ExportSchedule, error) {
+	return s.store.GetSchedule(id)
+}
+
+// DeleteSchedule remove uma exportação agendada
+func (s *ExportService) DeleteSchedule(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.store.DeleteSchedule(id)
+}
+
+// Run executa as exportações vencidas periodicamente até o contexto ser cancelado
+func (s *ExportService) Run(ctx context.Context) {
+	ticker := time.NewTicker(exportCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.processDue(ctx); err != nil {
+			log.Printf("Falha ao processar exportações agendadas: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processDue executa as exportações cujo horário já passou e agenda a próxima execução
+func (s *ExportService) processDue(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	schedules, err := s.store.ListSchedules()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range schedules {
+		schedule := &schedules[i]
+		if schedule.NextRunAt.After(now) {
+			continue
+		}
+
+		if err := s.execute(ctx, schedule, now); err != nil {
+			log.Printf("Falha na exportação agendada %s: %v", schedule.ID, err)
+		}
+
+		// Execuções perdidas não são recuperadas: a próxima é a primeira após o instante atual
+		next, err := nextCronRun(schedule.Cron, schedule.Timezone, now)
+		if err != nil {
+			log.Printf("Falha ao agendar exportação %s: %v", schedule.ID, err)
+			continue
+		}
+		schedule.NextRunAt = next
+
+		if err := s.store.SaveSchedule(schedule); err != nil {
+			log.Printf("Falha ao salvar agendamento %s: %v", schedule.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// RunNow executa imediatamente uma exportação agendada, sem alterar a
+// próxima execução. Retorna nil se o agendamento não existir.
+func (s *ExportService) RunNow(ctx context.Context, id string) (*ExportSchedule, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	schedule, err := s.store.GetSchedule(id)
+	if err != nil || schedule == nil {
+		return nil, err
+	}
+
+	runErr := s.execute(ctx, schedule, time.Now())
+	if err := s.store.SaveSchedule(schedule); err != nil {
+		return nil, fmt.Errorf("falha ao salvar agendamento: %w", err)
+	}
+	if runErr != nil {
+		return nil, runErr
+	}
+
+	return schedule, nil
+}
+
+// execute exporta os últimos dias completos do agendamento para o destino,
+// registrando o resultado no agendamento
+func (s *ExportService) execute(ctx context.Context, schedule *ExportSchedule, now time.Time) error {
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return fmt.Errorf("fuso horário inválido: %s", schedule.Timezone)
+	}
+
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	query := ExportQuery{
+		Dataset:   schedule.Dataset,
+		Format:    schedule.Format,
+		StartDate: today.AddDate(0, 0, -schedule.PeriodDays).Format("2006-01-02"),
+		EndDate:   today.AddDate(0, 0, -1).Format("2006-01-02"),
+		Timezone:  schedule.Timezone,
+		Sender:    schedule.Sender,
+		Dimension: schedule.Dimension,
+	}
+
+	object, rows, err := s.writeDestination(ctx, schedule.Destination, query)
+
+	schedule.LastRunAt = &now
+	schedule.LastError = ""
+	if err != nil {
+		schedule.LastError = err.Error()
+		return err
+	}
+	schedule.LastObject = object
+	schedule.LastRows = rows
+
+	log.Printf("Exportação %s gravada em %s (%d linhas)", schedule.ID, object, rows)
+	return nil
+}
+
+// writeDestination grava a exportação no destino e retorna o caminho (ou a
+// URL s3://) do arquivo gerado e a quantidade de linhas
+func (s *ExportService) writeDestination(ctx context.Context, dest ExportDestination, query ExportQuery) (string, int64, error) {
+	switch dest.Type {
+	case ExportDestinationLocal:
+		return s.writeLocal(ctx, dest, query)
+	case ExportDestinationS3:
+		return s.writeS3(ctx, dest, query)
+	default:
+		return "", 0, fmt.Errorf("destino inválido: %s", dest.Type)
+	}
+}
+
+// writeLocal grava a exportação em um subdiretório de EXPORT_DIR. O arquivo é
+// escrito com um nome temporário e renomeado ao final, para que leitores do
+// diretório nunca vejam um arquivo incompleto.
+func (s *ExportService) writeLocal(ctx context.Context, dest ExportDestination, query ExportQuery) (string, int64, error) {
+	if s.baseDir == "" {
+		return "", 0, fmt.Errorf("diretório de exportação não configurado (EXPORT_DIR)")
+	}
+
+	// Clean sobre um caminho absoluto impede que o subdiretório saia de EXPORT_DIR
+	dir := filepath.Join(s.baseDir, filepath.Clean("/"+dest.Path))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", 0, fmt.Errorf("falha ao criar diretório de exportação: %w", err)
+	}
+
+	path := filepath.Join(dir, query.FileName())
+	file, err := os.CreateTemp(dir, "."+query.FileName()+".*.tmp")
+	if err != nil {
+		return "", 0, fmt.Errorf("falha ao criar arquivo de exportação: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	rows, err := s.Export(ctx, file, query)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", rows, err
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", rows, fmt.Errorf("falha ao gravar arquivo de exportação: %w", err)
+	}
+
+	return path, rows, nil
+}
+
+// writeS3 grava a exportação em um arquivo temporário e o envia ao bucket.
+// O arquivo temporário mantém a memória constante e permite o envio com
+// tamanho conhecido, exigido por alguns provedores compatíveis com S3.
+func (s *ExportService) writeS3(ctx context.Context, dest ExportDestination, query ExportQuery) (string, int64, error) {
+	file, err := os.CreateTemp("", "poc-ses-export-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("falha ao criar arquivo de exportação: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	rows, err := s.Export(ctx, file, query)
+	if err != nil {
+		return "", rows, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", rows, err
+	}
+
+	region := dest.Region
+	if region == "" {
+		region = s.region
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return "", rows, fmt.Errorf("falha ao carregar configuração da AWS: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if dest.Endpoint != "" {
+			o.BaseEndpoint = aws.String(dest.Endpoint)
+		}
+		o.UsePathStyle = dest.UsePathStyle
+	})
+
+	key := query.Dataset + "/" + query.FileName()
+	if prefix := strings.Trim(dest.Prefix, "/"); prefix != "" {
+		key = prefix + "/" + key
+	}
+
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(dest.Bucket),
+		Key:         aws.String(key),
+		Body:        file,
+		ContentType: aws.String(query.ContentType()),
+	})
+	if err != nil {
+		return "", rows, fmt.Errorf("falha ao enviar exportação ao bucket %s: %w", dest.Bucket, err)
+	}
+
+	return "s3://" + dest.Bucket + "/" + key, rows, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const exportSchedulesBucket = "export_schedules"
+
+// ExportStore define a persistência dos agendamentos de exportação
+type ExportStore interface {
+	SaveSchedule(schedule *ExportSchedule) error
+	GetSchedule(id string) (*ExportSchedule, error)
+	ListSchedules() ([]ExportSchedule, error)
+	DeleteSchedule(id string) error
+}
+
+// BoltExportStore persiste os agendamentos de exportação no banco embarcado
+type BoltExportStore struct {
+	db *bolt.DB
+}
+
+// NewBoltExportStore cria uma nova instância do BoltExportStore
+func NewBoltExportStore(db *bolt.DB) (*BoltExportStore, error) {
+	if err := ensureBuckets(db, exportSchedulesBucket); err != nil {
+		return nil, err
+	}
+
+	return &BoltExportStore{db: db}, nil
+}
+
+// SaveSchedule grava (ou substitui) um agendamento
+func (s *BoltExportStore) SaveSchedule(schedule *ExportSchedule) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket([]byte(exportSchedulesBucket)), schedule.ID, schedule)
+	})
+}
+
+// GetSchedule obtém um agendamento pelo ID, retornando nil se não existir
+func (s *BoltExportStore) GetSchedule(id string) (*ExportSchedule, error) {
+	var schedule ExportSchedule
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket([]byte(exportSchedulesBucket)), id, &schedule)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+// ListSchedules lista os agendamentos em ordem de criação
+func (s *BoltExportStore) ListSchedules() ([]ExportSchedule, error) {
+	schedules := []ExportSchedule{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(exportSchedulesBucket)).ForEach(func(k, v []byte) error {
+			var schedule ExportSchedule
+			if err := json.Unmarshal(v, &schedule); err != nil {
+				return fmt.Errorf("falha ao desserializar agendamento %s: %w", k, err)
+			}
+			schedules = append(schedules, schedule)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+
+	return schedules, nil
+}
+
+// DeleteSchedule remove um agendamento
+func (s *BoltExportStore) DeleteSchedule(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(exportSchedulesBucket)).Delete([]byte(id))
+	})
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// parquetRowGroupSize limita o tamanho dos grupos de linhas mantidos em
+// memória antes de serem gravados no arquivo Parquet
+const parquetRowGroupSize = 16 * 1024 * 1024
+
+// parquetConcurrency é a quantidade de goroutines usadas na codificação Parquet
+const parquetConcurrency = 4
+
+// exportRow representa uma linha exportada. Os campos com a tag json são
+// usados no NDJSON e os campos com a tag parquet no Parquet, em que as datas
+// são gravadas como timestamps em milissegundos.
+type exportRow interface {
+	csvRecord() []string
+}
+
+// exportWriter grava as linhas de uma exportação em um formato
+type exportWriter interface {
+	Write(row exportRow) error
+	Close() error
+}
+
+// newExportWriter cria o writer do formato informado. O header só é usado no CSV
+// e o protótipo (ponteiro para o tipo da linha) define o esquema do Parquet.
+func newExportWriter(format string, w io.Writer, header []string, prototype exportRow) (exportWriter, error) {
+	switch format {
+	case ExportFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return nil, err
+		}
+		return &csvExportWriter{w: cw}, nil
+	case ExportFormatNDJSON:
+		bw := bufio.NewWriter(w)
+		return &ndjsonExportWriter{w: bw, encoder: json.NewEncoder(bw)}, nil
+	case ExportFormatParquet:
+		pw, err := writer.NewParquetWriterFromWriter(w, prototype, parquetConcurrency)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao criar esquema Parquet: %w", err)
+		}
+		pw.RowGroupSize = parquetRowGroupSize
+		pw.CompressionType = parquet.CompressionCodec_SNAPPY
+		return &parquetExportWriter{w: pw}, nil
+	default:
+		return nil, fmt.Errorf("formato de exportação inválido: %s (use csv, ndjson ou parquet)", format)
+	}
+}
+
+// csvExportWriter grava as linhas em CSV
+type csvExportWriter struct {
+	w     *csv.Writer
+	count int
+}
+
+func (e *csvExportWriter) Write(row exportRow) error {
+	if err := e.w.Write(row.csvRecord()); err != nil {
+		return err
+	}
+
+	// Descarregar periodicamente para manter o fluxo até o cliente
+	e.count++
+	if e.count%iterateBatchSize == 0 {
+		e.w.Flush()
+		return e.w.Error()
+	}
+	return nil
+}
+
+func (e *csvExportWriter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// ndjsonExportWriter grava uma linha JSON por registro
+type ndjsonExportWriter struct {
+	w       *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExportWriter) Write(row exportRow) error {
+	return e.encoder.Encode(row)
+}
+
+func (e *ndjsonExportWriter) Close() error {
+	return e.w.Flush()
+}
+
+// parquetExportWriter grava as linhas em Parquet, um grupo de linhas por vez
+type parquetExportWriter struct {
+	w *writer.ParquetWriter
+}
+
+func (e *parquetExportWriter) Write(row exportRow) error {
+	return e.w.Write(row)
+}
+
+func (e *parquetExportWriter) Close() error {
+	return e.w.WriteStop()
+}
+
+// DeliveryExportRow representa um destinatário de uma mensagem na exportação
+// dos status de entrega
+type DeliveryExportRow struct {
+	MessageID         string            `json:"messageId" parquet:"name=messageId, type=BYTE_ARRAY, convertedtype=UTF8"`
+	FromEmail         string            `json:"fromEmail" parquet:"name=fromEmail, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Subject           string            `json:"subject" parquet:"name=subject, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Status            string            `json:"status" parquet:"name=status, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	StatusDescription string            `json:"statusDescription" parquet:"name=statusDescription, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Recipient         string            `json:"recipient" parquet:"name=recipient, type=BYTE_ARRAY, convertedtype=UTF8"`
+	RecipientType     string            `json:"recipientType" parquet:"name=recipientType, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	RecipientStatus   string            `json:"recipientStatus" parquet:"name=recipientStatus, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	TemplateID        string            `json:"templateId" parquet:"name=templateId, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	ConfigurationSet  string            `json:"configurationSet" parquet:"name=configurationSet, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	ClickCount        int64             `json:"clickCount" parquet:"name=clickCount, type=INT64"`
+	Tags              map[string]string `json:"tags,omitempty"`
+	TagsJSON          string            `json:"-" parquet:"name=tags, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SentAt            time.Time         `json:"sentAt"`
+	DeliveredAt       *time.Time        `json:"deliveredAt,omitempty"`
+	OpenedAt          *time.Time        `json:"openedAt,omitempty"`
+	LastClickAt       *time.Time        `json:"lastClickAt,omitempty"`
+	SentAtMillis      int64             `json:"-" parquet:"name=sentAt, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	DeliveredAtMillis *int64            `json:"-" parquet:"name=deliveredAt, type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL"`
+	OpenedAtMillis    *int64            `json:"-" parquet:"name=openedAt, type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL"`
+	LastClickAtMillis *int64            `json:"-" parquet:"name=lastClickAt, type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL"`
+}
+
+// deliveryExportHeader são as colunas do CSV dos status de entrega
+var deliveryExportHeader = []string{"messageId", "fromEmail", "subject", "status", "statusDescription", "recipient", "recipientType", "recipientStatus", "templateId", "configurationSet", "clickCount", "tags", "sentAt", "deliveredAt", "openedAt", "lastClickAt"}
+
+// deliveryExportRows converte o status em uma linha por destinatário. Os
+// status anteriores ao rastreamento por destinatário geram uma única linha.
+func deliveryExportRows(status DeliveryStatus) []DeliveryExportRow {
+	base := DeliveryExportRow{
+		MessageID:         status.MessageID,
+		FromEmail:         status.FromEmail,
+		Subject:           status.Subject,
+		Status:            status.Status,
+		StatusDescription: status.StatusDescription,
+		TemplateID:        status.TemplateID,
+		ConfigurationSet:  status.ConfigurationSet,
+		ClickCount:        int64(status.ClickCount),
+		Tags:              status.Tags,
+		SentAt:            status.SentAt,
+		SentAtMillis:      status.SentAt.UnixMilli(),
+		OpenedAt:          optionalTime(status.OpenedAt),
+		LastClickAt:       optionalTime(status.LastClickAt),
+		OpenedAtMillis:    optionalMillis(status.OpenedAt),
+		LastClickAtMillis: optionalMillis(status.LastClickAt),
+	}
+	if len(status.Tags) > 0 {
+		tags, _ := json.Marshal(status.Tags)
+		base.TagsJSON = string(tags)
+	}
+
+	if len(status.Recipients) == 0 {
+		base.DeliveredAt = optionalTime(status.DeliveredAt)
+		base.DeliveredAtMillis = optionalMillis(status.DeliveredAt)
+		return []DeliveryExportRow{base}
+	}
+
+	rows := make([]DeliveryExportRow, len(status.Recipients))
+	for i, recipient := range status.Recipients {
+		row := base
+		row.Recipient = recipient.Email
+		row.RecipientType = recipient.Type
+		row.RecipientStatus = recipient.Status
+		row.DeliveredAt = optionalTime(recipient.DeliveredAt)
+		row.DeliveredAtMillis = optionalMillis(recipient.DeliveredAt)
+		if recipient.StatusDescription != "" {
+			row.StatusDescription = recipient.StatusDescription
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+func (r *DeliveryExportRow) csvRecord() []string {
+	return []string{
+		r.MessageID,
+		r.FromEmail,
+		r.Subject,
+		r.Status,
+		r.StatusDescription,
+		r.Recipient,
+		r.RecipientType,
+		r.RecipientStatus,
+		r.TemplateID,
+		r.ConfigurationSet,
+		strconv.FormatInt(r.ClickCount, 10),
+		r.TagsJSON,
+		formatExportTime(&r.SentAt),
+		formatExportTime(r.DeliveredAt),
+		formatExportTime(r.OpenedAt),
+		formatExportTime(r.LastClickAt),
+	}
+}
+
+// EventExportRow representa um evento da linha do tempo de uma mensagem na exportação
+type EventExportRow struct {
+	ID              string    `json:"id" parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	MessageID       string    `json:"messageId" parquet:"name=messageId, type=BYTE_ARRAY, convertedtype=UTF8"`
+	FromEmail       string    `json:"fromEmail" parquet:"name=fromEmail, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Type            string    `json:"type" parquet:"name=type, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Recipient       string    `json:"recipient" parquet:"name=recipient, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Description     string    `json:"description" parquet:"name=description, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Payload         string    `json:"payload,omitempty" parquet:"name=payload, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Timestamp       time.Time `json:"timestamp"`
+	TimestampMillis int64     `json:"-" parquet:"name=timestamp, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+}
+
+// eventExportHeader são as colunas do CSV dos eventos
+var eventExportHeader = []string{"id", "messageId", "fromEmail", "type", "recipient", "description", "payload", "timestamp"}
+
+// newEventExportRow converte um evento da linha do tempo em linha da exportação
+func newEventExportRow(status DeliveryStatus, event DeliveryEvent) *EventExportRow {
+	return &EventExportRow{
+		ID:              event.ID,
+		MessageID:       event.MessageID,
+		FromEmail:       status.FromEmail,
+		Type:            event.Type,
+		Recipient:       event.Recipient,
+		Description:     event.Description,
+		Payload:         string(event.Payload),
+		Timestamp:       event.Timestamp,
+		TimestampMillis: event.Timestamp.UnixMilli(),
+	}
+}
+
+func (r *EventExportRow) csvRecord() []string {
+	return []string{r.ID, r.MessageID, r.FromEmail, r.Type, r.Recipient, r.Description, r.Payload, formatExportTime(&r.Timestamp)}
+}
+
+// MetricsExportRow representa os contadores e as taxas diárias de um valor
+// de uma dimensão das métricas locais na exportação
+type MetricsExportRow struct {
+	Date          string  `json:"date" parquet:"name=date, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Dimension     string  `json:"dimension" parquet:"name=dimension, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Value         string  `json:"value" parquet:"name=value, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Sent          int64   `json:"sent" parquet:"name=sent, type=INT64"`
+	Delivered     int64   `json:"delivered" parquet:"name=delivered, type=INT64"`
+	Opened        int64   `json:"opened" parquet:"name=opened, type=INT64"`
+	Clicked       int64   `json:"clicked" parquet:"name=clicked, type=INT64"`
+	Bounced       int64   `json:"bounced" parquet:"name=bounced, type=INT64"`
+	Complaints    int64   `json:"complaints" parquet:"name=complaints, type=INT64"`
+	DeliveryRate  float64 `json:"deliveryRate" parquet:"name=deliveryRate, type=DOUBLE"`
+	OpenRate      float64 `json:"openRate" parquet:"name=openRate, type=DOUBLE"`
+	ClickRate     float64 `json:"clickRate" parquet:"name=clickRate, type=DOUBLE"`
+	BounceRate    float64 `json:"bounceRate" parquet:"name=bounceRate, type=DOUBLE"`
+	ComplaintRate float64 `json:"complaintRate" parquet:"name=complaintRate, type=DOUBLE"`
+}
+
+// metricsExportHeader são as colunas do CSV das métricas diárias
+var metricsExportHeader = []string{"date", "dimension", "value", "sent", "delivered", "opened", "clicked", "bounced", "complaints", "deliveryRate", "openRate", "clickRate", "bounceRate", "complaintRate"}
+
+// newMetricsExportRow monta a linha diária com as taxas calculadas sobre os contadores
+func newMetricsExportRow(date, dimension, value string, c AnalyticsCounters) *MetricsExportRow {
+	rates := (&MetricsResult{Totals: c.totals()}).Rates()
+
+	return &MetricsExportRow{
+		Date:          date,
+		Dimension:     dimension,
+		Value:         value,
+		Sent:          c.Sent,
+		Delivered:     c.Delivered,
+		Opened:        c.Opened,
+		Clicked:       c.Clicked,
+		Bounced:       c.Bounced,
+		Complaints:    c.Complaints,
+		DeliveryRate:  rates.DeliveryRate,
+		OpenRate:      rates.OpenRate,
+		ClickRate:     rates.ClickRate,
+		BounceRate:    rates.BounceRate,
+		ComplaintRate: rates.ComplaintRate,
+	}
+}
+
+func (r *MetricsExportRow) csvRecord() []string {
+	return []string{
+		r.Date,
+		r.Dimension,
+		r.Value,
+		strconv.FormatInt(r.Sent, 10),
+		strconv.FormatInt(r.Delivered, 10),
+		strconv.FormatInt(r.Opened, 10),
+		strconv.FormatInt(r.Clicked, 10),
+		strconv.FormatInt(r.Bounced, 10),
+		strconv.FormatInt(r.Complaints, 10),
+		strconv.FormatFloat(r.DeliveryRate, 'f', 4, 64),
+		strconv.FormatFloat(r.OpenRate, 'f', 4, 64),
+		strconv.FormatFloat(r.ClickRate, 'f', 4, 64),
+		strconv.FormatFloat(r.BounceRate, 'f', 4, 64),
+		strconv.FormatFloat(r.ComplaintRate, 'f', 4, 64),
+	}
+}
+
+// optionalTime retorna nil para o instante zero
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// optionalMillis retorna o instante em milissegundos, ou nil para o instante zero
+func optionalMillis(t time.Time) *int64 {
+	if t.IsZero() {
+		return nil
+	}
+	millis := t.UnixMilli()
+	return &millis
+}
+
+// formatExportTime formata o instante em RFC 3339 (UTC), vazio quando ausente
+func formatExportTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Períodos de comparação aceitos no parâmetro compareTo
+const (
+	CompareToPrevious     = "previous"
+	CompareToPreviousWeek = "previousWeek"
+	CompareToPreviousYear = "previousYear"
+)
+
+// trendWindow é a quantidade de períodos da média móvel das tendências
+const trendWindow = 7
+
+// flatTrendThreshold é a variação relativa, ao longo da série, abaixo da qual
+// a tendência é considerada estável
+const flatTrendThreshold = 0.05
+
+// MetricDelta representa a variação de uma métrica em relação ao período de
+// comparação. Nas taxas, Absolute está em pontos percentuais. Percent é a
+// variação relativa, nula quando o valor anterior é zero.
+type MetricDelta struct {
+	Current  float64  `json:"current"`
+	Previous float64  `json:"previous"`
+	Absolute float64  `json:"absolute"`
+	Percent  *float64 `json:"percent"`
+}
+
+// MetricsComparison representa as métricas do período de comparação e as
+// variações de cada total e taxa, indexadas pelo nome do campo na resposta
+// (ex: "totalSent", "bounceRate")
+type MetricsComparison struct {
+	CompareTo       string                 `json:"compareTo"`
+	Period          string                 `json:"period"`
+	TotalSent       int64                  `json:"totalSent"`
+	TotalDelivered  int64                  `json:"totalDelivered"`
+	TotalOpened     int64                  `json:"totalOpened"`
+	TotalClicked    int64                  `json:"totalClicked"`
+	TotalBounced    int64                  `json:"totalBounced"`
+	TotalComplaints int64                  `json:"totalComplaints"`
+	DeliveryRate    float64                `json:"deliveryRate"`
+	OpenRate        float64                `json:"openRate"`
+	ClickRate       float64                `json:"clickRate"`
+	BounceRate      float64                `json:"bounceRate"`
+	ComplaintRate   float64                `json:"complaintRate"`
+	Series          []TimeSeriesPoint      `json:"series"`
+	Deltas          map[string]MetricDelta `json:"deltas"`
+}
+
+// SeriesTrend representa a tendência de uma métrica da série temporal: a
+// média móvel de cada período e a inclinação da reta de mínimos quadrados,
+// em unidades da métrica por período
+type SeriesTrend struct {
+	Window        int       `json:"window"`
+	MovingAverage []float64 `json:"movingAverage"`
+	Slope         float64   `json:"slope"`
+	Direction     string    `json:"direction"`
+}
+
+// ValidateCompareTo valida o período de comparação. Valor vazio desativa a comparação.
+func ValidateCompareTo(compareTo string) error {
+	switch compareTo {
+	case "", CompareToPrevious, CompareToPreviousWeek, CompareToPreviousYear:
+		return nil
+	default:
+		return fmt.Errorf("período de comparação inválido: %s (use previous, previousWeek ou previousYear)", compareTo)
+	}
+}
+
+// comparisonRange calcula o intervalo equivalente do período de comparação.
+// O período anterior tem a mesma quantidade de dias (ou a mesma duração,
+// quando menor que um dia) e termina antes do início do intervalo atual.
+func comparisonRange(compareTo string, start, end time.Time) (time.Time, time.Time) {
+	switch compareTo {
+	case CompareToPreviousWeek:
+		return start.AddDate(0, 0, -7), end.AddDate(0, 0, -7)
+	case CompareToPreviousYear:
+		return start.AddDate(-1, 0, 0), end.AddDate(-1, 0, 0)
+	}
+
+	duration := end.Sub(start)
+	if duration < 24*time.Hour {
+		return start.Add(-duration), start
+	}
+
+	// Deslocar em dias de calendário para manter o alinhamento dos períodos
+	// mesmo com mudanças de horário de verão
+	days := int(math.Ceil(duration.Hours() / 24))
+	return start.AddDate(0, 0, -days), end.AddDate(0, 0, -days)
+}
+
+// newMetricsComparison monta a comparação entre os resultados do período atual e do anterior
+func newMetricsComparison(compareTo, period string, current, previous *MetricsResult, series []TimeSeriesPoint) *MetricsComparison {
+	currentRates, previousRates := current.Rates(), previous.Rates()
+
+	comparison := &MetricsComparison{
+		CompareTo:       compareTo,
+		Period:          period,
+		TotalSent:       previous.Totals["send"],
+		TotalDelivered:  previous.Totals["delivery"],
+		TotalOpened:     previous.Totals["open"],
+		TotalClicked:    previous.Totals["click"],
+		TotalBounced:    previous.Totals["bounce"],
+		TotalComplaints: previous.Totals["complaint"],
+		DeliveryRate:    previousRates.DeliveryRate,
+		OpenRate:        previousRates.OpenRate,
+		ClickRate:       previousRates.ClickRate,
+		BounceRate:      previousRates.BounceRate,
+		ComplaintRate:   previousRates.ComplaintRate,
+		Series:          series,
+		Deltas:          make(map[string]MetricDelta),
+	}
+
+	totals := map[string]string{
+		"totalSent":       "send",
+		"totalDelivered":  "delivery",
+		"totalOpened":     "open",
+		"totalClicked":    "click",
+		"totalBounced":    "bounce",
+		"totalComplaints": "complaint",
+	}
+	for field, id := range totals {
+		comparison.Deltas[field] = newMetricDelta(float64(current.Totals[id]), float64(previous.Totals[id]))
+	}
+
+	comparison.Deltas["deliveryRate"] = newMetricDelta(currentRates.DeliveryRate, previousRates.DeliveryRate)
+	comparison.Deltas["openRate"] = newMetricDelta(currentRates.OpenRate, previousRates.OpenRate)
+	comparison.Deltas["clickRate"] = newMetricDelta(currentRates.ClickRate, previousRates.ClickRate)
+	comparison.Deltas["bounceRate"] = newMetricDelta(currentRates.BounceRate, previousRates.BounceRate)
+	comparison.Deltas["complaintRate"] = newMetricDelta(currentRates.ComplaintRate, previousRates.ComplaintRate)
+
+	return comparison
+}
+
+// newMetricDelta calcula a variação absoluta e percentual entre dois valores
+func newMetricDelta(current, previous float64) MetricDelta {
+	delta := MetricDelta{
+		Current:  current,
+		Previous: previous,
+		Absolute: current - previous,
+	}
+	if previous != 0 {
+		percent := (current - previous) / math.Abs(previous) * 100
+		delta.Percent = &percent
+	}
+	return delta
+}
+
+// seriesTrends calcula a tendência de cada métrica da série, indexada pelo
+// nome do campo em TimeSeriesPoint (ex: "sent", "bounceRate")
+func seriesTrends(series []TimeSeriesPoint) map[string]SeriesTrend {
+	values := map[string][]float64{}
+	for _, p := range series {
+		values["sent"] = append(values["sent"], float64(p.Sent))
+		values["delivered"] = append(values["delivered"], float64(p.Delivered))
+		values["opened"] = append(values["opened"], float64(p.Opened))
+		values["clicked"] = append(values["clicked"], float64(p.Clicked))
+		values["bounced"] = append(values["bounced"], float64(p.Bounced))
+		values["complaints"] = append(values["complaints"], float64(p.Complaints))
+		values["deliveryRate"] = append(values["deliveryRate"], p.DeliveryRate)
+		values["openRate"] = append(values["openRate"], p.OpenRate)
+		values["clickRate"] = append(values["clickRate"], p.ClickRate)
+		values["bounceRate"] = append(values["bounceRate"], p.BounceRate)
+		values["complaintRate"] = append(values["complaintRate"], p.ComplaintRate)
+	}
+
+	trends := make(map[string]SeriesTrend, len(values))
+	for name, v := range values {
+		trends[name] = newSeriesTrend(v)
+	}
+	return trends
+}
+
+// newSeriesTrend calcula a média móvel (dos últimos trendWindow períodos,
+// ou dos disponíveis no início da série) e a inclinação dos valores
+func newSeriesTrend(values []float64) SeriesTrend {
+	trend := SeriesTrend{
+		Window:        trendWindow,
+		MovingAverage: make([]float64, len(values)),
+		Direction:     "flat",
+	}
+
+	var windowSum, sumX, sumY, sumXY, sumXX float64
+	for i, v := range values {
+		windowSum += v
+		if i >= trendWindow {
+			windowSum -= values[i-trendWindow]
+		}
+		trend.MovingAverage[i] = windowSum / float64(min(i+1, trendWindow))
+
+		x := float64(i)
+		sumX += x
+		sumY += v
+		sumXY += x * v
+		sumXX += x * x
+	}
+
+	n := float64(len(values))
+	if len(values) < 2 {
+		return trend
+	}
+	trend.Slope = (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+
+	// Classificar pela variação ao longo da série em relação à média
+	change := trend.Slope * (n - 1)
+	mean := sumY / n
+	switch {
+	case mean != 0 && math.Abs(change/mean) < flatTrendThreshold:
+	case change > 0:
+		trend.Direction = "up"
+	case change < 0:
+		trend.Direction = "down"
+	}
+
+	return trend
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// sesMetrics mapeia os IDs usados nas consultas às métricas do SES no CloudWatch
+var sesMetrics = []struct {
+	ID   string
+	Name string
+}{
+	{"send", "Send"},
+	{"delivery", "Delivery"},
+	{"open", "Open"},
+	{"click", "Click"},
+	{"bounce", "Bounce"},
+	{"complaint", "Complaint"},
+}
+
+// sesRateExpressions define as taxas por período calculadas com metric math,
+// em percentual. Períodos sem dados são preenchidos com zero.
+var sesRateExpressions = map[string]string{
+	"deliveryRate":  "IF(FILL(send, 0) > 0, 100 * FILL(delivery, 0) / FILL(send, 0), 0)",
+	"openRate":      "IF(FILL(delivery, 0) > 0, 100 * FILL(open, 0) / FILL(delivery, 0), 0)",
+	"clickRate":     "IF(FILL(delivery, 0) > 0, 100 * FILL(click, 0) / FILL(delivery, 0), 0)",
+	"bounceRate":    "IF(FILL(send, 0) > 0, 100 * FILL(bounce, 0) / FILL(send, 0), 0)",
+	"complaintRate": "IF(FILL(send, 0) > 0, 100 * FILL(complaint, 0) / FILL(send, 0), 0)",
+}
+
+// MetricsQuery define uma consulta às métricas do SES no CloudWatch.
+// Sender vazio consulta as métricas de toda a conta.
+type MetricsQuery struct {
+	Start  time.Time
+	End    time.Time
+	Period time.Duration
+	Sender string
+}
+
+// MetricPoint representa os valores das métricas e taxas em um período,
+// indexados pelo ID da consulta (ex: "send", "deliveryRate")
+type MetricPoint struct {
+	Timestamp time.Time
+	Values    map[string]float64
+}
+
+// MetricRates representa as taxas de entrega, abertura, clique, bounce e reclamação, em percentual
+type MetricRates struct {
+	DeliveryRate  float64
+	OpenRate      float64
+	ClickRate     float64
+	BounceRate    float64
+	ComplaintRate float64
+}
+
+// MetricsResult representa o resultado de uma consulta: os totais de cada
+// métrica no intervalo e os valores por período, em ordem cronológica
+type MetricsResult struct {
+	Totals map[string]int64
+	Points []MetricPoint
+}
+
+// Rates calcula as taxas do intervalo inteiro a partir dos totais
+func (r *MetricsResult) Rates() MetricRates {
+	var rates MetricRates
+	sent, delivered := r.Totals["send"], r.Totals["delivery"]
+
+	if sent > 0 {
+		rates.DeliveryRate = float64(delivered) / float64(sent) * 100
+		rates.BounceRate = float64(r.Totals["bounce"]) / float64(sent) * 100
+		rates.ComplaintRate = float64(r.Totals["complaint"]) / float64(sent) * 100
+	}
+	if delivered > 0 {
+		rates.OpenRate = float64(r.Totals["open"]) / float64(delivered) * 100
+		rates.ClickRate = float64(r.Totals["click"]) / float64(delivered) * 100
+	}
+
+	return rates
+}
+
+// cachedMetrics guarda o resultado de uma consulta até a expiração
+type cachedMetrics struct {
+	result    *MetricsResult
+	expiresAt time.Time
+}
+
+// MetricsQuerier consulta as métricas do SES no CloudWatch com uma única
+// requisição GetMetricData por consulta, mantendo os resultados em cache
+// pelo TTL configurado
+type MetricsQuerier struct {
+	client *cloudwatch.Client
+	ttl    time.Duration
+	cache  map[string]cachedMetrics
+	mutex  sync.Mutex
+}
+
+// NewMetricsQuerier cria uma nova instância do MetricsQuerier. TTL zero desativa o cache.
+func NewMetricsQuerier(client *cloudwatch.Client, ttl time.Duration) *MetricsQuerier {
+	return &MetricsQuerier{
+		client: client,
+		ttl:    ttl,
+		cache:  make(map[string]cachedMetrics),
+	}
+}
+
+// Query obtém os totais e a série por período das métricas do SES
+func (q *MetricsQuerier) Query(ctx context.Context, query MetricsQuery) (*MetricsResult, error) {
+	if query.Period < time.Minute {
+		return nil, fmt.Errorf("período de agregação inválido: %s", query.Period)
+	}
+
+	// Alinhar o intervalo ao minuto para que consultas próximas compartilhem o cache
+	query.Start = query.Start.Truncate(time.Minute)
+	query.End = query.End.Truncate(time.Minute)
+	key := fmt.Sprintf("%s|%d|%d|%d", query.Sender, query.Start.Unix(), query.End.Unix(), int64(query.Period.Seconds()))
+
+	if result := q.cached(key); result != nil {
+		return result, nil
+	}
+
+	result, err := q.fetch(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	q.store(key, result)
+	return result, nil
+}
+
+// cached retorna o resultado em cache ainda válido para a chave, ou nil
+func (q *MetricsQuerier) cached(key string) *MetricsResult {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	entry, exists := q.cache[key]
+	if !exists || time.Now().After(entry.expiresAt) {
+		return nil
+	}
+	return entry.result
+}
+
+// store guarda o resultado no cache, descartando as entradas expiradas
+func (q *MetricsQuerier) store(key string, result *MetricsResult) {
+	if q.ttl <= 0 {
+		return
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := time.Now()
+	for k, entry := range q.cache {
+		if now.After(entry.expiresAt) {
+			delete(q.cache, k)
+		}
+	}
+	q.cache[key] = cachedMetrics{result: result, expiresAt: now.Add(q.ttl)}
+}
+
+// fetch executa a consulta no CloudWatch, percorrendo todas as páginas do resultado
+func (q *MetricsQuerier) fetch(ctx context.Context, query MetricsQuery) (*MetricsResult, error) {
+	input := &cloudwatch.GetMetricDataInput{
+		StartTime:         aws.Time(query.Start),
+		EndTime:           aws.Time(query.End),
+		MetricDataQueries: buildMetricDataQueries(query),
+		ScanBy:            cwtypes.ScanByTimestampAscending,
+	}
+
+	points := make(map[time.Time]map[string]float64)
+	totals := make(map[string]int64, len(sesMetrics))
+
+	paginator := cloudwatch.NewGetMetricDataPaginator(q.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao obter métricas do CloudWatch: %w", err)
+		}
+
+		for _, series := range page.MetricDataResults {
+			id := aws.ToString(series.Id)
+			if series.StatusCode == cwtypes.StatusCodeForbidden || series.StatusCode == cwtypes.StatusCodeInternalError {
+				return nil, fmt.Errorf("falha ao obter métrica %s: %s", id, series.StatusCode)
+			}
+
+			for i, ts := range series.Timestamps {
+				if i >= len(series.Values) {
+					break
+				}
+				if _, ok := points[ts]; !ok {
+					points[ts] = make(map[string]float64)
+				}
+				points[ts][id] += series.Values[i]
+
+				if _, isRate := sesRateExpressions[id]; !isRate {
+					totals[id] += int64(series.Values[i])
+				}
+			}
+		}
+	}
+
+	result := &MetricsResult{Totals: totals, Points: make([]MetricPoint, 0, len(points))}
+	for ts, values := range points {
+		result.Points = append(result.Points, MetricPoint{Timestamp: ts, Values: values})
+	}
+	sort.Slice(result.Points, func(i, j int) bool {
+		return result.Points[i].Timestamp.Before(result.Points[j].Timestamp)
+	})
+
+	return result, nil
+}
+
+// buildMetricDataQueries monta as consultas das métricas do SES e das expressões de taxa
+func buildMetricDataQueries(query MetricsQuery) []cwtypes.MetricDataQuery {
+	period := int32(query.Period.Seconds())
+
+	var dimensions []cwtypes.Dimension
+	if query.Sender != "" {
+		dimensions = []cwtypes.Dimension{
+			{
+				Name:  aws.String("Source"),
+				Value: aws.String(query.Sender),
+			},
+		}
+	}
+
+	queries := make([]cwtypes.MetricDataQuery, 0, len(sesMetrics)+len(sesRateExpressions))
+	for _, m := range sesMetrics {
+		queries = append(queries, cwtypes.MetricDataQuery{
+			Id: aws.String(m.ID),
+			MetricStat: &cwtypes.MetricStat{
+				Metric: &cwtypes.Metric{
+					Namespace:  aws.String("AWS/SES"),
+					MetricName: aws.String(m.Name),
+					Dimensions: dimensions,
+				},
+				Period: aws.Int32(period),
+				Stat:   aws.String("Sum"),
+			},
+			ReturnData: aws.Bool(true),
+		})
+	}
+
+	ids := make([]string, 0, len(sesRateExpressions))
+	for id := range sesRateExpressions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		queries = append(queries, cwtypes.MetricDataQuery{
+			Id:         aws.String(id),
+			Expression: aws.String(sesRateExpressions[id]),
+			Period:     aws.Int32(period),
+			ReturnData: aws.Bool(true),
+		})
+	}
+
+	return queries
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Granularidades aceitas para as séries temporais de métricas
+const (
+	Granularity5Minutes = "5m"
+	GranularityHour     = "1h"
+	GranularityDay      = "1d"
+	GranularityWeek     = "1w"
+)
+
+// maxSeriesPoints limita a quantidade de períodos de uma série
+const maxSeriesPoints = 10000
+
+// SeriesOptions define a granularidade e o fuso horário de uma série temporal.
+// Os períodos diários e semanais (iniciados na segunda-feira) começam à
+// meia-noite do fuso informado.
+type SeriesOptions struct {
+	Granularity string
+	Location    *time.Location
+}
+
+// TimeSeriesPoint representa as métricas de um período da série temporal
+type TimeSeriesPoint struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Sent          int64     `json:"sent"`
+	Delivered     int64     `json:"delivered"`
+	Opened        int64     `json:"opened"`
+	Clicked       int64     `json:"clicked"`
+	Bounced       int64     `json:"bounced"`
+	Complaints    int64     `json:"complaints"`
+	DeliveryRate  float64   `json:"deliveryRate"`
+	OpenRate      float64   `json:"openRate"`
+	ClickRate     float64   `json:"clickRate"`
+	BounceRate    float64   `json:"bounceRate"`
+	ComplaintRate float64   `json:"complaintRate"`
+}
+
+// ParseSeriesOptions valida a granularidade e o fuso horário (nome IANA, ex:
+// "America/Sao_Paulo"). Valores vazios assumem granularidade diária em UTC.
+func ParseSeriesOptions(granularity, timezone string) (SeriesOptions, error) {
+	opts := SeriesOptions{Granularity: granularity, Location: time.UTC}
+	if opts.Granularity == "" {
+		opts.Granularity = GranularityDay
+	}
+
+	switch opts.Granularity {
+	case Granularity5Minutes, GranularityHour, GranularityDay, GranularityWeek:
+	default:
+		return opts, fmt.Errorf("granularidade inválida: %s (use 5m, 1h, 1d ou 1w)", granularity)
+	}
+
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return opts, fmt.Errorf("fuso horário inválido: %s", timezone)
+		}
+		opts.Location = loc
+	}
+
+	return opts, nil
+}
+
+// bucketStart retorna o início do período que contém o instante, no fuso das opções
+func (o SeriesOptions) bucketStart(t time.Time) time.Time {
+	t = t.In(o.Location)
+	switch o.Granularity {
+	case Granularity5Minutes:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()-t.Minute()%5, 0, 0, o.Location)
+	case GranularityHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, o.Location)
+	case GranularityWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, o.Location)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, o.Location)
+	}
+}
+
+// nextBucket retorna o início do período seguinte, respeitando mudanças de horário de verão
+func (o SeriesOptions) nextBucket(t time.Time) time.Time {
+	switch o.Granularity {
+	case Granularity5Minutes:
+		return t.Add(5 * time.Minute)
+	case GranularityHour:
+		return t.Add(time.Hour)
+	case GranularityWeek:
+		return t.AddDate(0, 0, 7)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// basePeriod escolhe o período consultado no CloudWatch, que agrega os dados
+// alinhados a UTC. Quando o fuso não está alinhado ao período da
+// granularidade, os dados são obtidos em períodos menores e reagrupados.
+func (o SeriesOptions) basePeriod(start, end time.Time) time.Duration {
+	_, startOffset := start.In(o.Location).Zone()
+	_, endOffset := end.In(o.Location).Zone()
+
+	switch {
+	case o.Granularity == Granularity5Minutes:
+		return 5 * time.Minute
+	case startOffset%3600 != 0 || endOffset%3600 != 0:
+		return 5 * time.Minute
+	case o.Granularity == GranularityHour:
+		return time.Hour
+	case startOffset != 0 || endOffset != 0:
+		return time.Hour
+	default:
+		// Semanas são reagrupadas a partir de dias, pois os períodos semanais
+		// do CloudWatch não começam na segunda-feira
+		return 24 * time.Hour
+	}
+}
+
+// QuerySeries obtém do CloudWatch as métricas do intervalo, retornando o
+// resultado bruto (com os totais) e a série na granularidade e fuso pedidos
+func (q *MetricsQuerier) QuerySeries(ctx context.Context, start, end time.Time, sender string, opts SeriesOptions) (*MetricsResult, []TimeSeriesPoint, error) {
+	start = opts.bucketStart(start)
+
+	result, err := q.Query(ctx, MetricsQuery{
+		Start:  start,
+		End:    end,
+		Period: opts.basePeriod(start, end),
+		Sender: sender,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	series, err := buildSeries(result, start, end, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result, series, nil
+}
+
+// buildSeries reagrupa os pontos do resultado nos períodos da série,
+// preenchendo com zero os períodos sem dados. As taxas calculadas pelo
+// CloudWatch são usadas quando o período corresponde a um único ponto;
+// caso contrário, são recalculadas sobre as somas.
+func buildSeries(result *MetricsResult, start, end time.Time, opts SeriesOptions) ([]TimeSeriesPoint, error) {
+	series := []TimeSeriesPoint{}
+	index := make(map[int64]int)
+
+	for t := opts.bucketStart(start); t.Before(end); t = opts.nextBucket(t) {
+		if len(series) >= maxSeriesPoints {
+			return nil, fmt.Errorf("intervalo inválido: mais de %d períodos para a granularidade %s", maxSeriesPoints, opts.Granularity)
+		}
+		index[t.Unix()] = len(series)
+		series = append(series, TimeSeriesPoint{Timestamp: t})
+	}
+
+	counts := make([]int, len(series))
+	for _, point := range result.Points {
+		i, ok := index[opts.bucketStart(point.Timestamp).Unix()]
+		if !ok {
+			continue
+		}
+
+		s := &series[i]
+		s.Sent += int64(point.Values["send"])
+		s.Delivered += int64(point.Values["delivery"])
+		s.Opened += int64(point.Values["open"])
+		s.Clicked += int64(point.Values["click"])
+		s.Bounced += int64(point.Values["bounce"])
+		s.Complaints += int64(point.Values["complaint"])
+		s.DeliveryRate = point.Values["deliveryRate"]
+		s.OpenRate = point.Values["openRate"]
+		s.ClickRate = point.Values["clickRate"]
+		s.BounceRate = point.Values["bounceRate"]
+		s.ComplaintRate = point.Values["complaintRate"]
+		counts[i]++
+	}
+
+	for i := range series {
+		if counts[i] > 1 {
+			series[i].recalculateRates()
+		}
+	}
+
+	return series, nil
+}
+
+// recalculateRates calcula as taxas do período a partir das somas
+func (p *TimeSeriesPoint) recalculateRates() {
+	totals := &MetricsResult{Totals: map[string]int64{
+		"send":      p.Sent,
+		"delivery":  p.Delivered,
+		"open":      p.Opened,
+		"click":     p.Clicked,
+		"bounce":    p.Bounced,
+		"complaint": p.Complaints,
+	}}
+
+	rates := totals.Rates()
+	p.DeliveryRate = rates.DeliveryRate
+	p.OpenRate = rates.OpenRate
+	p.ClickRate = rates.ClickRate
+	p.BounceRate = rates.BounceRate
+	p.ComplaintRate = rates.ComplaintRate
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dimensões dos gráficos SVG dos relatórios
+const (
+	chartWidth   = 640
+	chartHeight  = 220
+	chartPadding = 40
+)
+
+// chartSeries representa uma linha de um gráfico
+type chartSeries struct {
+	Name   string
+	Color  string
+	Values []float64
+}
+
+// reportTemplate é o layout HTML dos relatórios, com estilos inline para
+// compatibilidade com os clientes de e-mail
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"delta": formatDelta,
+}).Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, Helvetica, sans-serif; color: #222; max-width: 680px; margin: 0 auto;">
+<h2 style="margin-bottom: 4px;">{{.Title}}</h2>
+<p style="color: #666; margin-top: 0;">Período: {{.Period}} ({{.Timezone}}) · comparado a {{.PreviousPeriod}}</p>
+<table cellpadding="6" cellspacing="0" style="border-collapse: collapse; width: 100%; font-size: 14px;">
+<tr style="background: #f2f2f2; text-align: left;"><th>Métrica</th><th>Atual</th><th>Anterior</th><th>Variação</th></tr>
+{{range .Rows}}<tr style="border-bottom: 1px solid #eee;"><td>{{.Label}}</td><td>{{.Current}}</td><td>{{.Previous}}</td><td>{{delta .Delta .IsRate}}</td></tr>
+{{end}}</table>
+<h3>Volume</h3>
+{{.VolumeChart}}
+<h3>Taxas de bounce e reclamação</h3>
+{{.RatesChart}}
+<h3>Falhas recentes</h3>
+{{if .Failures}}<table cellpadding="6" cellspacing="0" style="border-collapse: collapse; width: 100%; font-size: 13px;">
+<tr style="background: #f2f2f2; text-align: left;"><th>Enviado em</th><th>Remetente</th><th>Assunto</th><th>Status</th></tr>
+{{range .Failures}}<tr style="border-bottom: 1px solid #eee;"><td>{{.SentAt.Format "02/01 15:04"}}</td><td>{{.FromEmail}}</td><td>{{.Subject}}</td><td>{{.Status}}</td></tr>
+{{end}}</table>{{if .MoreFailures}}<p style="color: #666;">E mais {{.MoreFailures}} falhas no anexo falhas.csv.</p>{{end}}
+{{else}}<p>Nenhuma falha no período.</p>{{end}}
+<p style="color: #999; font-size: 12px;">Gerado em {{.GeneratedAt.Format "02/01/2006 15:04 MST"}} pela assinatura "{{.Name}}".</p>
+</body>
+</html>
+`))
+
+// reportRow representa uma linha da tabela de resumo
+type reportRow struct {
+	Label    string
+	Current  string
+	Previous string
+	Delta    MetricDelta
+	IsRate   bool
+}
+
+// reportView reúne os dados usados no layout HTML
+type reportView struct {
+	Title          string
+	Name           string
+	Period         string
+	PreviousPeriod string
+	Timezone       string
+	GeneratedAt    time.Time
+	Rows           []reportRow
+	VolumeChart    template.HTML
+	RatesChart     template.HTML
+	Failures       []DeliveryStatus
+	MoreFailures   int
+}
+
+// maxReportFailures limita as falhas listadas no corpo do relatório
+const maxReportFailures = 20
+
+// renderReportHTML monta o corpo HTML do relatório com os gráficos SVG inline
+func renderReportHTML(data *ReportData) (string, error) {
+	view := reportView{
+		Title:          data.Title,
+		Name:           data.Subscription.Name,
+		Period:         data.Metrics.Period,
+		PreviousPeriod: data.Metrics.Comparison.Period,
+		Timezone:       data.Location.String(),
+		GeneratedAt:    data.GeneratedAt,
+		Rows:           reportRows(data.Metrics),
+		VolumeChart: template.HTML(svgLineChart(data.Metrics.Series, []chartSeries{
+			{Name: "Enviados", Color: "#1f77b4", Values: seriesValues(data.Metrics.Series, func(p TimeSeriesPoint) float64 { return float64(p.Sent) })},
+			{Name: "Entregues", Color: "#2ca02c", Values: seriesValues(data.Metrics.Series, func(p TimeSeriesPoint) float64 { return float64(p.Delivered) })},
+		}, data.Location, false)),
+		RatesChart: template.HTML(svgLineChart(data.Metrics.Series, []chartSeries{
+			{Name: "Bounce", Color: "#d62728", Values: seriesValues(data.Metrics.Series, func(p TimeSeriesPoint) float64 { return p.BounceRate })},
+			{Name: "Reclamação", Color: "#ff7f0e", Values: seriesValues(data.Metrics.Series, func(p TimeSeriesPoint) float64 { return p.ComplaintRate })},
+		}, data.Location, true)),
+		Failures: data.Failures,
+	}
+	if len(view.Failures) > maxReportFailures {
+		view.MoreFailures = len(view.Failures) - maxReportFailures
+		view.Failures = view.Failures[:maxReportFailures]
+	}
+
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, view); err != nil {
+		return "", fmt.Errorf("falha ao montar relatório: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// renderReportText monta o resumo em texto do relatório
+func renderReportText(data *ReportData) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", data.Title)
+	fmt.Fprintf(&b, "Período: %s (%s), comparado a %s\n\n", data.Metrics.Period, data.Location, data.Metrics.Comparison.Period)
+	for _, row := range reportRows(data.Metrics) {
+		fmt.Fprintf(&b, "%s: %s (anterior: %s, variação: %s)\n", row.Label, row.Current, row.Previous, formatDelta(row.Delta, row.IsRate))
+	}
+	fmt.Fprintf(&b, "\nFalhas no período: %d\n", len(data.Failures))
+	return b.String()
+}
+
+// reportRows monta as linhas da tabela de resumo com as variações
+func reportRows(m *ReportMetrics) []reportRow {
+	count := func(label, field string) reportRow {
+		d := m.Comparison.Deltas[field]
+		return reportRow{Label: label, Current: strconv.FormatFloat(d.Current, 'f', 0, 64), Previous: strconv.FormatFloat(d.Previous, 'f', 0, 64), Delta: d}
+	}
+	rate := func(label, field string) reportRow {
+		d := m.Comparison.Deltas[field]
+		return reportRow{Label: label, Current: strconv.FormatFloat(d.Current, 'f', 2, 64) + "%", Previous: strconv.FormatFloat(d.Previous, 'f', 2, 64) + "%", Delta: d, IsRate: true}
+	}
+
+	return []reportRow{
+		count("Enviados", "totalSent"),
+		count("Entregues", "totalDelivered"),
+		count("Aberturas", "totalOpened"),
+		count("Cliques", "totalClicked"),
+		count("Bounces", "totalBounced"),
+		count("Reclamações", "totalComplaints"),
+		rate("Taxa de entrega", "deliveryRate"),
+		rate("Taxa de abertura", "openRate"),
+		rate("Taxa de cliques", "clickRate"),
+		rate("Taxa de bounce", "bounceRate"),
+		rate("Taxa de reclamação", "complaintRate"),
+	}
+}
+
+// formatDelta formata a variação: pontos percentuais nas taxas e percentual nos totais
+func formatDelta(d MetricDelta, isRate bool) string {
+	if isRate {
+		return fmt.Sprintf("%+.2f p.p.", d.Absolute)
+	}
+	if d.Percent == nil {
+		return fmt.Sprintf("%+.0f", d.Absolute)
+	}
+	return fmt.Sprintf("%+.0f (%+.1f%%)", d.Absolute, *d.Percent)
+}
+
+// seriesValues extrai um valor de cada ponto da série
+func seriesValues(series []TimeSeriesPoint, value func(TimeSeriesPoint) float64) []float64 {
+	values := make([]float64, len(series))
+	for i, p := range series {
+		values[i] = value(p)
+	}
+	return values
+}
+
+// svgLineChart desenha um gráfico de linhas em SVG, com grade horizontal,
+// rótulos do primeiro, do último e do ponto central da série e legenda
+func svgLineChart(points []TimeSeriesPoint, lines []chartSeries, loc *time.Location, percent bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Arial, Helvetica, sans-serif" font-size="11">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	b.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/>`)
+
+	if len(points) == 0 {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" fill="#999">Sem dados no período</text></svg>`, chartWidth/2, chartHeight/2)
+		return b.String()
+	}
+
+	maxValue := 0.0
+	for _, line := range lines {
+		for _, v := range line.Values {
+			maxValue = math.Max(maxValue, v)
+		}
+	}
+	maxValue = niceCeil(maxValue)
+
+	plotWidth := float64(chartWidth - 2*chartPadding)
+	plotHeight := float64(chartHeight - 2*chartPadding)
+	x := func(i int) float64 {
+		if len(points) == 1 {
+			return chartPadding + plotWidth/2
+		}
+		return chartPadding + plotWidth*float64(i)/float64(len(points)-1)
+	}
+	y := func(v float64) float64 {
+		return chartPadding + plotHeight - plotHeight*v/maxValue
+	}
+
+	// Grade e rótulos do eixo vertical
+	for i := 0; i <= 4; i++ {
+		v := maxValue * float64(i) / 4
+		label := strconv.FormatFloat(v, 'f', -1, 64)
+		if percent {
+			label = strconv.FormatFloat(v, 'f', 2, 64) + "%"
+		}
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#eeeeee"/>`, chartPadding, y(v), chartWidth-chartPadding, y(v))
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end" fill="#666">%s</text>`, chartPadding-4, y(v)+4, template.HTMLEscapeString(label))
+	}
+
+	// Rótulos do eixo horizontal
+	for _, i := range []int{0, len(points) / 2, len(points) - 1} {
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" fill="#666">%s</text>`,
+			x(i), chartHeight-chartPadding+16, points[i].Timestamp.In(loc).Format("02/01 15h"))
+	}
+
+	for n, line := range lines {
+		coords := make([]string, len(line.Values))
+		for i, v := range line.Values {
+			coords[i] = fmt.Sprintf("%.1f,%.1f", x(i), y(v))
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, line.Color, strings.Join(coords, " "))
+
+		// Legenda
+		lx := chartPadding + n*120
+		fmt.Fprintf(&b, `<rect x="%d" y="12" width="10" height="10" fill="%s"/>`, lx, line.Color)
+		fmt.Fprintf(&b, `<text x="%d" y="21" fill="#333">%s</text>`, lx+14, template.HTMLEscapeString(line.Name))
+	}
+
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// niceCeil arredonda o máximo do eixo para 1, 2, 5 ou 10 vezes uma potência de dez
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, step := range []float64{1, 2, 5, 10} {
+		if v <= step*magnitude {
+			return step * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// seriesCSV gera o CSV da série temporal do relatório
+func seriesCSV(series []TimeSeriesPoint, loc *time.Location) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{"timestamp", "sent", "delivered", "opened", "clicked", "bounced", "complaints", "deliveryRate", "openRate", "clickRate", "bounceRate", "complaintRate"})
+	for _, p := range series {
+		w.Write([]string{
+			p.Timestamp.In(loc).Format(time.RFC3339),
+			strconv.FormatInt(p.Sent, 10),
+			strconv.FormatInt(p.Delivered, 10),
+			strconv.FormatInt(p.Opened, 10),
+			strconv.FormatInt(p.Clicked, 10),
+			strconv.FormatInt(p.Bounced, 10),
+			strconv.FormatInt(p.Complaints, 10),
+			strconv.FormatFloat(p.DeliveryRate, 'f', 4, 64),
+			strconv.FormatFloat(p.OpenRate, 'f', 4, 64),
+			strconv.FormatFloat(p.ClickRate, 'f', 4, 64),
+			strconv.FormatFloat(p.BounceRate, 'f', 4, 64),
+			strconv.FormatFloat(p.ComplaintRate, 'f', 4, 64),
+		})
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// failuresCSV gera o CSV das mensagens com falha no período
+func failuresCSV(failures []DeliveryStatus, loc *time.Location) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{"messageId", "fromEmail", "recipients", "subject", "status", "statusDescription", "sentAt"})
+	for _, f := range failures {
+		recipients := make([]string, len(f.Recipients))
+		for i, r := range f.Recipients {
+			recipients[i] = r.Email
+		}
+		w.Write([]string{
+			f.MessageID,
+			f.FromEmail,
+			strings.Join(recipients, ";"),
+			f.Subject,
+			f.Status,
+			f.StatusDescription,
+			f.SentAt.In(loc).Format(time.RFC3339),
+		})
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,456 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Escopos das assinaturas de relatórios
+const (
+	ReportScopeAccount = "account"
+	ReportScopeSender  = "sender"
+)
+
+// Formatos das assinaturas de relatórios
+const (
+	ReportFormatHTML    = "html"
+	ReportFormatCSV     = "csv"
+	ReportFormatHTMLCSV = "html+csv"
+)
+
+// defaultReportPeriodDays é o período padrão dos relatórios, em dias
+const defaultReportPeriodDays = 7
+
+// maxReportPeriodDays limita o período dos relatórios, em dias
+const maxReportPeriodDays = 90
+
+// reportCheckInterval é o intervalo de verificação das assinaturas vencidas
+const reportCheckInterval = time.Minute
+
+// ReportSubscriptionRequest representa uma solicitação de assinatura de relatório.
+// Cron aceita expressões de cinco campos e atalhos como @daily e @weekly,
+// avaliados no fuso horário da assinatura (UTC se vazio).
+type ReportSubscriptionRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	Cron       string   `json:"cron" binding:"required"`
+	Timezone   string   `json:"timezone,omitempty"`
+	Recipients []string `json:"recipients" binding:"required,min=1,dive,email"`
+	Scope      string   `json:"scope,omitempty"`
+	Sender     string   `json:"sender,omitempty" binding:"omitempty,email"`
+	Format     string   `json:"format,omitempty"`
+	PeriodDays int      `json:"periodDays,omitempty"`
+}
+
+// ReportSubscription representa uma assinatura de relatório enviado por e-mail
+type ReportSubscription struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Cron       string     `json:"cron"`
+	Timezone   string     `json:"timezone"`
+	Recipients []string   `json:"recipients"`
+	Scope      string     `json:"scope"`
+	Sender     string     `json:"sender,omitempty"`
+	Format     string     `json:"format"`
+	PeriodDays int        `json:"periodDays"`
+	NextRunAt  time.Time  `json:"nextRunAt"`
+	LastRunAt  *time.Time `json:"lastRunAt,omitempty"`
+	LastError  string     `json:"lastError,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// ReportMetrics reúne as métricas do período do relatório e a comparação
+// com o período anterior
+type ReportMetrics struct {
+	Period     string
+	Series     []TimeSeriesPoint
+	Comparison *MetricsComparison
+}
+
+// ReportData reúne os dados usados na montagem de um relatório
+type ReportData struct {
+	Title        string
+	Subscription *ReportSubscription
+	Location     *time.Location
+	GeneratedAt  time.Time
+	Metrics      *ReportMetrics
+	Failures     []DeliveryStatus
+}
+
+// ReportService gerencia as assinaturas de relatórios e envia, no horário
+// de cada uma, o resumo das métricas por e-mail a partir do remetente do sistema
+type ReportService struct {
+	store            ReportStore
+	sesService       *SESService
+	analyticsService *AnalyticsService
+	deliveryService  *DeliveryService
+	sender           string
+	mutex            sync.Mutex
+}
+
+// NewReportService cria uma nova instância do ReportService. Sender é o
+// remetente (verificado no SES) usado no envio dos relatórios.
+func NewReportService(store ReportStore, sesService *SESService, analyticsService *AnalyticsService, deliveryService *DeliveryService, sender string) *ReportService {
+	return &ReportService{
+		store:            store,
+		sesService:       sesService,
+		analyticsService: analyticsService,
+		deliveryService:  deliveryService,
+		sender:           sender,
+	}
+}
+
+// Validate valida a assinatura e aplica os valores padrão
+func (r *ReportSubscriptionRequest) Validate() error {
+	if _, err := cron.ParseStandard(r.Cron); err != nil {
+		return fmt.Errorf("expressão cron inválida: %w", err)
+	}
+
+	if r.Timezone == "" {
+		r.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		return fmt.Errorf("fuso horário inválido: %s", r.Timezone)
+	}
+
+	if r.Scope == "" {
+		r.Scope = ReportScopeAccount
+	}
+	switch r.Scope {
+	case ReportScopeAccount:
+		r.Sender = ""
+	case ReportScopeSender:
+		if r.Sender == "" {
+			return fmt.Errorf("remetente obrigatório no escopo sender")
+		}
+	default:
+		return fmt.Errorf("escopo inválido: %s (use account ou sender)", r.Scope)
+	}
+
+	if r.Format == "" {
+		r.Format = ReportFormatHTMLCSV
+	}
+	switch r.Format {
+	case ReportFormatHTML, ReportFormatCSV, ReportFormatHTMLCSV:
+	default:
+		return fmt.Errorf("formato inválido: %s (use html, csv ou html+csv)", r.Format)
+	}
+
+	if r.PeriodDays == 0 {
+		r.PeriodDays = defaultReportPeriodDays
+	}
+	if r.PeriodDays < 1 || r.PeriodDays > maxReportPeriodDays {
+		return fmt.Errorf("período inválido: %d dias (use de 1 a %d)", r.PeriodDays, maxReportPeriodDays)
+	}
+
+	return nil
+}
+
+// CreateSubscription cria uma assinatura de relatório e agenda o primeiro envio
+func (s *ReportService) CreateSubscription(req ReportSubscriptionRequest) (*ReportSubscription, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	subscription := &ReportSubscription{
+		ID:         strings.ToLower(strings.ReplaceAll(req.Name, " ", "-")) + "-" + fmt.Sprintf("%d", now.Unix()),
+		Name:       req.Name,
+		Cron:       req.Cron,
+		Timezone:   req.Timezone,
+		Recipients: req.Recipients,
+		Scope:      req.Scope,
+		Sender:     strings.ToLower(req.Sender),
+		Format:     req.Format,
+		PeriodDays: req.PeriodDays,
+		CreatedAt:  now,
+	}
+
+	next, err := nextCronRun(subscription.Cron, subscription.Timezone, now)
+	if err != nil {
+		return nil, err
+	}
+	subscription.NextRunAt = next
+
+	if err := s.store.SaveSubscription(subscription); err != nil {
+		return nil, fmt.Errorf("falha ao salvar assinatura: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// ListSubscriptions lista as assinaturas de relatórios
+func (s *ReportService) ListSubscriptions() ([]ReportSubscription, error) {
+	return s.store.ListSubscriptions()
+}
+
+// GetSubscription obtém uma assinatura pelo ID, retornando nil se não existir
+func (s *ReportService) GetSubscription(id string) (*ReportSubscription, error) {
+	return s.store.GetSubscription(id)
+}
+
+// DeleteSubscription remove uma assinatura
+func (s *ReportService) DeleteSubscription(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.store.DeleteSubscription(id)
+}
+
+// Run envia os relatórios vencidos periodicamente até o contexto ser cancelado
+func (s *ReportService) Run(ctx context.Context) {
+	ticker := time.NewTicker(reportCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.processDue(); err != nil {
+			log.Printf("Falha ao processar assinaturas de relatórios: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processDue envia os relatórios cujo horário já passou e agenda o próximo envio
+func (s *ReportService) processDue() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	subscriptions, err := s.store.ListSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		if subscription.NextRunAt.After(now) {
+			continue
+		}
+
+		if err := s.deliver(subscription, now); err != nil {
+			// Com os envios suspensos, o relatório é tentado novamente em vez
+			// de ser descartado até o próximo horário da assinatura
+			if isSendingSuspended(err) {
+				log.Printf("Relatório %s adiado, envios suspensos: %v", subscription.ID, err)
+				subscription.NextRunAt = now.Add(suspendedRetryDelay)
+				if err := s.store.SaveSubscription(subscription); err != nil {
+					log.Printf("Falha ao salvar assinatura %s: %v", subscription.ID, err)
+				}
+				continue
+			}
+			log.Printf("Falha ao enviar relatório %s: %v", subscription.ID, err)
+		}
+
+		// Execuções perdidas (ex: serviço parado) não são reenviadas: o
+		// próximo envio é o primeiro horário após o atual
+		next, err := nextCronRun(subscription.Cron, subscription.Timezone, now)
+		if err != nil {
+			log.Printf("Falha ao agendar relatório %s: %v", subscription.ID, err)
+			continue
+		}
+		subscription.NextRunAt = next
+
+		if err := s.store.SaveSubscription(subscription); err != nil {
+			log.Printf("Falha ao salvar assinatura %s: %v", subscription.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// SendNow envia imediatamente o relatório de uma assinatura, sem alterar o
+// próximo envio agendado. Retorna nil se a assinatura não existir.
+func (s *ReportService) SendNow(id string) (*ReportSubscription, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	subscription, err := s.store.GetSubscription(id)
+	if err != nil || subscription == nil {
+		return nil, err
+	}
+
+	sendErr := s.deliver(subscription, time.Now())
+	if err := s.store.SaveSubscription(subscription); err != nil {
+		return nil, fmt.Errorf("falha ao salvar assinatura: %w", err)
+	}
+	if sendErr != nil {
+		return nil, sendErr
+	}
+
+	return subscription, nil
+}
+
+// Preview monta o HTML do relatório de uma assinatura sem enviá-lo.
+// Retorna string vazia se a assinatura não existir.
+func (s *ReportService) Preview(id string) (string, error) {
+	subscription, err := s.store.GetSubscription(id)
+	if err != nil || subscription == nil {
+		return "", err
+	}
+
+	data, err := s.buildReport(subscription, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	return renderReportHTML(data)
+}
+
+// deliver monta e envia o relatório, registrando o resultado na assinatura
+func (s *ReportService) deliver(subscription *ReportSubscription, now time.Time) error {
+	err := s.send(subscription, now)
+
+	subscription.LastRunAt = &now
+	subscription.LastError = ""
+	if err != nil {
+		subscription.LastError = err.Error()
+	}
+
+	return err
+}
+
+// send monta o relatório no formato da assinatura e o envia aos destinatários
+func (s *ReportService) send(subscription *ReportSubscription, now time.Time) error {
+	if s.sender == "" {
+		return fmt.Errorf("remetente dos relatórios não configurado (REPORT_SENDER)")
+	}
+
+	data, err := s.buildReport(subscription, now)
+	if err != nil {
+		return err
+	}
+
+	req := EmailRequest{
+		From:    s.sender,
+		To:      subscription.Recipients,
+		Subject: fmt.Sprintf("[poc-ses] Relatório %s: %s", subscription.Name, data.Metrics.Period),
+	}
+
+	if subscription.Format == ReportFormatCSV {
+		req.TextBody = renderReportText(data)
+	} else {
+		html, err := renderReportHTML(data)
+		if err != nil {
+			return err
+		}
+		req.HtmlBody = html
+	}
+
+	if subscription.Format != ReportFormatHTML {
+		metricsCSV, err := seriesCSV(data.Metrics.Series, data.Location)
+		if err != nil {
+			return fmt.Errorf("falha ao gerar CSV de métricas: %w", err)
+		}
+		failedCSV, err := failuresCSV(data.Failures, data.Location)
+		if err != nil {
+			return fmt.Errorf("falha ao gerar CSV de falhas: %w", err)
+		}
+		req.Attachments = []Attachment{
+			{Filename: "metricas.csv", Content: base64.StdEncoding.EncodeToString(metricsCSV)},
+			{Filename: "falhas.csv", Content: base64.StdEncoding.EncodeToString(failedCSV)},
+		}
+	}
+
+	result, err := s.sesService.SendEmail(req)
+	if err != nil {
+		return fmt.Errorf("falha ao enviar relatório: %w", err)
+	}
+
+	s.deliveryService.TrackDelivery(result.From, result.MessageID, result.Subject, NewRecipients(req.To, nil, nil), TrackOptions{
+		Tags:             map[string]string{"report": subscription.ID},
+		ConfigurationSet: result.ConfigurationSet,
+	})
+
+	return nil
+}
+
+// buildReport consulta as métricas e as falhas dos últimos dias completos,
+// no fuso horário da assinatura, comparando com o período anterior
+func (s *ReportService) buildReport(subscription *ReportSubscription, now time.Time) (*ReportData, error) {
+	loc, err := time.LoadLocation(subscription.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("fuso horário inválido: %s", subscription.Timezone)
+	}
+
+	local := now.In(loc)
+	end := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	start := end.AddDate(0, 0, -subscription.PeriodDays)
+	startStr := start.Format("2006-01-02")
+	endStr := end.AddDate(0, 0, -1).Format("2006-01-02")
+
+	opts := SeriesOptions{Granularity: GranularityDay, Location: loc}
+	if subscription.PeriodDays == 1 {
+		opts.Granularity = GranularityHour
+	}
+
+	data := &ReportData{
+		Subscription: subscription,
+		Location:     loc,
+		GeneratedAt:  now.In(loc),
+	}
+
+	switch subscription.Scope {
+	case ReportScopeSender:
+		result, err := s.analyticsService.GetMetrics(DimensionSender, subscription.Sender, startStr, endStr, opts, CompareToPrevious)
+		if err != nil {
+			return nil, err
+		}
+		data.Title = "Relatório de envios de " + subscription.Sender
+		data.Metrics = &ReportMetrics{Period: result.Period, Series: result.Series, Comparison: result.Comparison}
+	default:
+		result, err := s.sesService.GetMetrics(startStr, endStr, opts, CompareToPrevious)
+		if err != nil {
+			return nil, err
+		}
+		data.Title = "Relatório de envios da conta"
+		data.Metrics = &ReportMetrics{Period: result.Period, Series: result.Series, Comparison: result.Comparison}
+	}
+	if data.Metrics.Comparison == nil {
+		return nil, fmt.Errorf("comparação com o período anterior indisponível")
+	}
+
+	// As falhas são buscadas no store pelo período do relatório, sem o limite
+	// do relatório em tempo real. O fim do período é exclusivo.
+	filter := DeliveryFilter{
+		Statuses: []string{StatusBounced, StatusComplained, StatusRejected},
+		Start:    start,
+		End:      end.Add(-time.Nanosecond),
+	}
+	if subscription.Scope == ReportScopeSender {
+		filter.FromEmail = subscription.Sender
+	}
+	failures, err := s.deliveryService.GetAllDeliveryStatus(filter)
+	if err != nil {
+		return nil, err
+	}
+	data.Failures = failures
+
+	return data, nil
+}
+
+// nextCronRun calcula a próxima execução da expressão cron, no fuso horário
+// informado, após o instante indicado
+func nextCronRun(spec, timezone string, after time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expressão cron inválida: %w", err)
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fuso horário inválido: %s", timezone)
+	}
+
+	return schedule.Next(after.In(loc)), nil
+}
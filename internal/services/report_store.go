@@ -0,0 +1,89 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const reportSubscriptionsBucket = "report_subscriptions"
+
+// ReportStore define a persistência das assinaturas de relatórios
+type ReportStore interface {
+	SaveSubscription(subscription *ReportSubscription) error
+	GetSubscription(id string) (*ReportSubscription, error)
+	ListSubscriptions() ([]ReportSubscription, error)
+	DeleteSubscription(id string) error
+}
+
+// BoltReportStore persiste as assinaturas de relatórios no banco embarcado
+type BoltReportStore struct {
+	db *bolt.DB
+}
+
+// NewBoltReportStore cria uma nova instância do BoltReportStore
+func NewBoltReportStore(db *bolt.DB) (*BoltReportStore, error) {
+	if err := ensureBuckets(db, reportSubscriptionsBucket); err != nil {
+		return nil, err
+	}
+
+	return &BoltReportStore{db: db}, nil
+}
+
+// SaveSubscription grava (ou substitui) uma assinatura
+func (s *BoltReportStore) SaveSubscription(subscription *ReportSubscription) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket([]byte(reportSubscriptionsBucket)), subscription.ID, subscription)
+	})
+}
+
+// GetSubscription obtém uma assinatura pelo ID, retornando nil se não existir
+func (s *BoltReportStore) GetSubscription(id string) (*ReportSubscription, error) {
+	var subscription ReportSubscription
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket([]byte(reportSubscriptionsBucket)), id, &subscription)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+// ListSubscriptions lista as assinaturas em ordem de criação
+func (s *BoltReportStore) ListSubscriptions() ([]ReportSubscription, error) {
+	subscriptions := []ReportSubscription{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(reportSubscriptionsBucket)).ForEach(func(k, v []byte) error {
+			var subscription ReportSubscription
+			if err := json.Unmarshal(v, &subscription); err != nil {
+				return fmt.Errorf("falha ao desserializar assinatura %s: %w", k, err)
+			}
+			subscriptions = append(subscriptions, subscription)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+
+	return subscriptions, nil
+}
+
+// DeleteSubscription remove uma assinatura
+func (s *BoltReportStore) DeleteSubscription(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(reportSubscriptionsBucket)).Delete([]byte(id))
+	})
+}
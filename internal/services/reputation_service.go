@@ -0,0 +1,637 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Níveis dos monitores de reputação
+const (
+	ReputationOK       = "ok"
+	ReputationWarning  = "warning"
+	ReputationCritical = "critical"
+)
+
+// reputationSeverity ordena os níveis do monitor
+var reputationSeverity = map[string]int{
+	ReputationOK:       0,
+	ReputationWarning:  1,
+	ReputationCritical: 2,
+}
+
+// Canais de envio dos alertas de reputação
+const (
+	AlertChannelWebhook = "webhook"
+	AlertChannelEmail   = "email"
+	AlertChannelLog     = "log"
+)
+
+// accountSubject identifica o monitor da conta inteira
+const accountSubject = "account"
+
+// webhookTimeout limita a duração das chamadas aos webhooks de alerta
+const webhookTimeout = 10 * time.Second
+
+// AlertChannel define um destino dos alertas de reputação: um webhook
+// (POST com o alerta em JSON), um e-mail enviado pelo SES ou o log da aplicação
+type AlertChannel struct {
+	Type string   `json:"type" binding:"required,oneof=webhook email log"`
+	URL  string   `json:"url,omitempty"`
+	From string   `json:"from,omitempty"`
+	To   []string `json:"to,omitempty"`
+}
+
+// ReputationPolicy define a janela e os limites de taxa de bounce e de
+// reclamação, em percentual, dos monitores da conta e de cada remetente.
+// O SES coloca a conta em revisão com cerca de 5% de bounce ou 0,1% de
+// reclamações, por isso os limites padrão ficam abaixo desses valores.
+type ReputationPolicy struct {
+	WindowHours           int            `json:"windowHours"`
+	MinSent               int64          `json:"minSent"`
+	BounceWarningRate     float64        `json:"bounceWarningRate"`
+	BounceCriticalRate    float64        `json:"bounceCriticalRate"`
+	ComplaintWarningRate  float64        `json:"complaintWarningRate"`
+	ComplaintCriticalRate float64        `json:"complaintCriticalRate"`
+	AutoPause             bool           `json:"autoPause"`
+	Channels              []AlertChannel `json:"channels"`
+	UpdatedAt             time.Time      `json:"updatedAt,omitempty"`
+}
+
+// defaultReputationPolicy é usada enquanto a política não é gravada
+var defaultReputationPolicy = ReputationPolicy{
+	WindowHours:           24,
+	MinSent:               100,
+	BounceWarningRate:     2,
+	BounceCriticalRate:    4,
+	ComplaintWarningRate:  0.05,
+	ComplaintCriticalRate: 0.08,
+	AutoPause:             true,
+	Channels:              []AlertChannel{{Type: AlertChannelLog}},
+}
+
+// Validate verifica os limites e os canais da política
+func (p ReputationPolicy) Validate() error {
+	if p.WindowHours <= 0 {
+		return fmt.Errorf("janela inválida: deve ser de pelo menos uma hora")
+	}
+	if p.MinSent < 0 {
+		return fmt.Errorf("volume mínimo inválido: não pode ser negativo")
+	}
+	if p.BounceWarningRate <= 0 || p.BounceCriticalRate < p.BounceWarningRate {
+		return fmt.Errorf("limites de bounce inválidos: o limite crítico deve ser maior ou igual ao de alerta, e ambos maiores que zero")
+	}
+	if p.ComplaintWarningRate <= 0 || p.ComplaintCriticalRate < p.ComplaintWarningRate {
+		return fmt.Errorf("limites de reclamação inválidos: o limite crítico deve ser maior ou igual ao de alerta, e ambos maiores que zero")
+	}
+
+	for i, channel := range p.Channels {
+		switch channel.Type {
+		case AlertChannelWebhook:
+			u, err := url.Parse(channel.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("canal %d inválido: webhook requer uma URL http ou https", i)
+			}
+		case AlertChannelEmail:
+			if channel.From == "" || len(channel.To) == 0 {
+				return fmt.Errorf("canal %d inválido: e-mail requer remetente (from) e destinatários (to)", i)
+			}
+		case AlertChannelLog:
+		default:
+			return fmt.Errorf("canal %d inválido: tipo desconhecido %s", i, channel.Type)
+		}
+	}
+
+	return nil
+}
+
+// classify retorna o nível do monitor e os limites excedidos. Volumes abaixo
+// do mínimo não são avaliados, para evitar alertas por poucos envios.
+func (p ReputationPolicy) classify(sent int64, bounceRate, complaintRate float64) (string, []string) {
+	if sent == 0 || sent < p.MinSent {
+		return ReputationOK, nil
+	}
+
+	level := ReputationOK
+	var reasons []string
+	check := func(name string, rate, warning, critical float64) {
+		switch {
+		case rate >= critical:
+			level = ReputationCritical
+			reasons = append(reasons, fmt.Sprintf("taxa de %s de %.2f%% atingiu o limite crítico de %.2f%%", name, rate, critical))
+		case rate >= warning:
+			if level == ReputationOK {
+				level = ReputationWarning
+			}
+			reasons = append(reasons, fmt.Sprintf("taxa de %s de %.2f%% atingiu o limite de alerta de %.2f%%", name, rate, warning))
+		}
+	}
+
+	check("bounce", bounceRate, p.BounceWarningRate, p.BounceCriticalRate)
+	check("reclamação", complaintRate, p.ComplaintWarningRate, p.ComplaintCriticalRate)
+
+	return level, reasons
+}
+
+// MonitorState representa a última avaliação do monitor da conta (Subject
+// "account") ou de um remetente (Subject "sender:<email>")
+type MonitorState struct {
+	Subject       string    `json:"subject"`
+	Sender        string    `json:"sender,omitempty"`
+	Level         string    `json:"level"`
+	Reasons       []string  `json:"reasons,omitempty"`
+	Sent          int64     `json:"sent"`
+	Bounced       int64     `json:"bounced"`
+	Complaints    int64     `json:"complaints"`
+	BounceRate    float64   `json:"bounceRate"`
+	ComplaintRate float64   `json:"complaintRate"`
+	ResumedAt     time.Time `json:"resumedAt,omitempty"`
+	EvaluatedAt   time.Time `json:"evaluatedAt"`
+}
+
+// ReputationAlert representa uma mudança de nível de um monitor
+type ReputationAlert struct {
+	ID            string    `json:"id"`
+	Subject       string    `json:"subject"`
+	Sender        string    `json:"sender,omitempty"`
+	Level         string    `json:"level"`
+	PreviousLevel string    `json:"previousLevel"`
+	Reasons       []string  `json:"reasons,omitempty"`
+	Sent          int64     `json:"sent"`
+	BounceRate    float64   `json:"bounceRate"`
+	ComplaintRate float64   `json:"complaintRate"`
+	WindowHours   int       `json:"windowHours"`
+	SenderPaused  bool      `json:"senderPaused"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// SenderPause representa um remetente impedido de enviar até ser retomado manualmente
+type SenderPause struct {
+	Email         string    `json:"email"`
+	Reason        string    `json:"reason"`
+	Automatic     bool      `json:"automatic"`
+	BounceRate    float64   `json:"bounceRate,omitempty"`
+	ComplaintRate float64   `json:"complaintRate,omitempty"`
+	PausedAt      time.Time `json:"pausedAt"`
+}
+
+// ReputationStatus representa a avaliação atual dos monitores e os remetentes pausados
+type ReputationStatus struct {
+	WindowHours int            `json:"windowHours"`
+	Account     MonitorState   `json:"account"`
+	Senders     []MonitorState `json:"senders"`
+	Paused      []SenderPause  `json:"paused"`
+}
+
+// ReputationService monitora as taxas de bounce e de reclamação da conta e
+// de cada remetente em uma janela móvel, calculadas a partir das métricas
+// locais. As mudanças de nível geram alertas nos canais configurados e, no
+// nível crítico, o remetente é pausado: os envios dele são recusados até a
+// retomada manual.
+type ReputationService struct {
+	store          ReputationStore
+	analyticsStore AnalyticsStore
+	sesService     *SESService
+	httpClient     *http.Client
+	interval       time.Duration
+	wake           chan struct{}
+	mutex          sync.Mutex
+}
+
+// NewReputationService cria uma nova instância do ReputationService,
+// registrando a verificação de pausa nos envios do SESService e antecipando
+// a avaliação a cada bounce ou reclamação recebido
+func NewReputationService(store ReputationStore, analyticsStore AnalyticsStore, sesService *SESService, deliveryService *DeliveryService, interval time.Duration) *ReputationService {
+	s := &ReputationService{
+		store:          store,
+		analyticsStore: analyticsStore,
+		sesService:     sesService,
+		httpClient:     &http.Client{Timeout: webhookTimeout},
+		interval:       interval,
+		wake:           make(chan struct{}, 1),
+	}
+
+	sesService.AddSendGuard(s.checkPaused)
+	deliveryService.Subscribe(s.handleDeliveryEvent)
+	return s
+}
+
+// Run avalia os monitores periodicamente até o contexto ser cancelado
+func (s *ReputationService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Check(); err != nil {
+			log.Printf("Falha ao avaliar monitores de reputação: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// handleDeliveryEvent antecipa a avaliação quando chega um bounce ou reclamação
+func (s *ReputationService) handleDeliveryEvent(status DeliveryStatus, event DeliveryEvent) {
+	if event.Type != StatusBounced && event.Type != StatusComplained {
+		return
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// checkPaused recusa os envios de remetentes pausados
+func (s *ReputationService) checkPaused(req EmailRequest) error {
+	pause, err := s.store.GetPause(req.From)
+	if err != nil {
+		return fmt.Errorf("falha ao verificar pausa do remetente: %w", err)
+	}
+	if pause != nil {
+		return fmt.Errorf("%w: remetente pausado desde %s: %s", ErrSendingSuspended, pause.PausedAt.Format(time.RFC3339), pause.Reason)
+	}
+	return nil
+}
+
+// GetPolicy retorna a política de monitoramento vigente
+func (s *ReputationService) GetPolicy() (*ReputationPolicy, error) {
+	policy, err := s.store.GetPolicy()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter política de reputação: %w", err)
+	}
+
+	if policy == nil {
+		defaults := defaultReputationPolicy
+		policy = &defaults
+	}
+	return policy, nil
+}
+
+// UpdatePolicy valida e grava a política de monitoramento
+func (s *ReputationService) UpdatePolicy(policy ReputationPolicy) (*ReputationPolicy, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	policy.UpdatedAt = time.Now()
+	if err := s.store.SavePolicy(policy); err != nil {
+		return nil, fmt.Errorf("falha ao gravar política de reputação: %w", err)
+	}
+	return &policy, nil
+}
+
+// GetStatus avalia os monitores sem emitir alertas
+func (s *ReputationService) GetStatus() (*ReputationStatus, error) {
+	policy, err := s.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := s.measure(policy, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	paused, err := s.store.ListPauses()
+	if err != nil {
+		return nil, err
+	}
+
+	senders := states[1:]
+	sort.Slice(senders, func(i, j int) bool {
+		if senders[i].Level != senders[j].Level {
+			return reputationSeverity[senders[i].Level] > reputationSeverity[senders[j].Level]
+		}
+		return senders[i].Sent > senders[j].Sent
+	})
+
+	return &ReputationStatus{
+		WindowHours: policy.WindowHours,
+		Account:     states[0],
+		Senders:     senders,
+		Paused:      paused,
+	}, nil
+}
+
+// ListAlerts retorna os alertas mais recentes primeiro
+func (s *ReputationService) ListAlerts(limit int) ([]ReputationAlert, error) {
+	return s.store.ListAlerts(limit)
+}
+
+// ListPausedSenders retorna os remetentes pausados
+func (s *ReputationService) ListPausedSenders() ([]SenderPause, error) {
+	return s.store.ListPauses()
+}
+
+// PauseSender pausa manualmente um remetente
+func (s *ReputationService) PauseSender(email, reason string) (*SenderPause, error) {
+	if reason == "" {
+		reason = "pausado manualmente"
+	}
+
+	pause := SenderPause{
+		Email:    strings.ToLower(email),
+		Reason:   reason,
+		PausedAt: time.Now(),
+	}
+	if err := s.store.SavePause(pause); err != nil {
+		return nil, fmt.Errorf("falha ao pausar remetente: %w", err)
+	}
+
+	log.Printf("Remetente %s pausado: %s", pause.Email, reason)
+	return &pause, nil
+}
+
+// ResumeSender retoma os envios de um remetente pausado. A partir da
+// retomada, o monitor do remetente considera apenas as horas seguintes,
+// para que os eventos que causaram a pausa não o pausem novamente.
+func (s *ReputationService) ResumeSender(email string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	email = strings.ToLower(email)
+	pause, err := s.store.GetPause(email)
+	if err != nil {
+		return fmt.Errorf("falha ao obter pausa do remetente: %w", err)
+	}
+	if pause == nil {
+		return fmt.Errorf("remetente não está pausado")
+	}
+
+	if err := s.store.DeletePause(email); err != nil {
+		return fmt.Errorf("falha ao retomar remetente: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.store.SaveState(MonitorState{
+		Subject:     senderSubject(email),
+		Sender:      email,
+		Level:       ReputationOK,
+		ResumedAt:   now,
+		EvaluatedAt: now,
+	}); err != nil {
+		return fmt.Errorf("falha ao atualizar monitor do remetente: %w", err)
+	}
+
+	log.Printf("Remetente %s retomado", email)
+	return nil
+}
+
+// Check avalia os monitores e emite os alertas das mudanças de nível
+func (s *ReputationService) Check() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	policy, err := s.GetPolicy()
+	if err != nil {
+		return err
+	}
+
+	states, err := s.measure(policy, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, state := range states {
+		if err := s.evaluate(policy, state); err != nil {
+			log.Printf("Falha ao avaliar monitor %s: %v", state.Subject, err)
+		}
+	}
+	return nil
+}
+
+// measure calcula as taxas da conta (primeiro item) e de cada remetente com
+// envios na janela ou com monitor fora do nível normal
+func (s *ReputationService) measure(policy *ReputationPolicy, now time.Time) ([]MonitorState, error) {
+	start := now.Add(-time.Duration(policy.WindowHours) * time.Hour)
+
+	totals, err := s.analyticsStore.Totals(DimensionSender, start, now)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter métricas dos remetentes: %w", err)
+	}
+
+	previous, err := s.store.ListStates()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter estado dos monitores: %w", err)
+	}
+
+	var account AnalyticsCounters
+	for _, counters := range totals {
+		account.add(counters)
+	}
+
+	// Incluir os remetentes sem envios na janela, para que voltem ao nível normal
+	resumedAt := make(map[string]time.Time)
+	for _, state := range previous {
+		if state.Sender == "" {
+			continue
+		}
+		resumedAt[state.Sender] = state.ResumedAt
+		if _, exists := totals[state.Sender]; !exists && state.Level != ReputationOK {
+			totals[state.Sender] = AnalyticsCounters{}
+		}
+	}
+
+	states := []MonitorState{newMonitorState(policy, accountSubject, "", account, time.Time{}, now)}
+	for sender, counters := range totals {
+		// Após a retomada manual, considerar apenas as horas completas seguintes
+		if resumed := resumedAt[sender]; resumed.After(start) {
+			counters = AnalyticsCounters{}
+			rows, err := s.analyticsStore.Rows(DimensionSender, sender, resumed.Truncate(time.Hour).Add(time.Hour), now)
+			if err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				counters.add(row.Counters)
+			}
+		}
+
+		states = append(states, newMonitorState(policy, senderSubject(sender), sender, counters, resumedAt[sender], now))
+	}
+
+	return states, nil
+}
+
+// evaluate grava o estado do monitor e, quando o nível muda, emite o alerta
+// e pausa o remetente que atingiu o nível crítico
+func (s *ReputationService) evaluate(policy *ReputationPolicy, state MonitorState) error {
+	previous, err := s.store.GetState(state.Subject)
+	if err != nil {
+		return err
+	}
+
+	previousLevel := ReputationOK
+	if previous != nil {
+		previousLevel = previous.Level
+	}
+
+	if state.Level != previousLevel {
+		alert := ReputationAlert{
+			ID:            fmt.Sprintf("%s-%d", state.Subject, state.EvaluatedAt.UnixNano()),
+			Subject:       state.Subject,
+			Sender:        state.Sender,
+			Level:         state.Level,
+			PreviousLevel: previousLevel,
+			Reasons:       state.Reasons,
+			Sent:          state.Sent,
+			BounceRate:    state.BounceRate,
+			ComplaintRate: state.ComplaintRate,
+			WindowHours:   policy.WindowHours,
+			CreatedAt:     state.EvaluatedAt,
+		}
+
+		if state.Level == ReputationCritical && state.Sender != "" && policy.AutoPause {
+			paused, err := s.autoPause(state)
+			if err != nil {
+				log.Printf("Falha ao pausar remetente %s: %v", state.Sender, err)
+			}
+			alert.SenderPaused = paused
+		}
+
+		if err := s.store.AddAlert(alert); err != nil {
+			log.Printf("Falha ao registrar alerta %s: %v", alert.ID, err)
+		}
+		s.dispatch(policy.Channels, alert)
+	}
+
+	return s.store.SaveState(state)
+}
+
+// autoPause pausa o remetente que atingiu o nível crítico, retornando false
+// se ele já estava pausado
+func (s *ReputationService) autoPause(state MonitorState) (bool, error) {
+	existing, err := s.store.GetPause(state.Sender)
+	if err != nil || existing != nil {
+		return false, err
+	}
+
+	pause := SenderPause{
+		Email:         state.Sender,
+		Reason:        "pausado automaticamente: " + strings.Join(state.Reasons, "; "),
+		Automatic:     true,
+		BounceRate:    state.BounceRate,
+		ComplaintRate: state.ComplaintRate,
+		PausedAt:      state.EvaluatedAt,
+	}
+	if err := s.store.SavePause(pause); err != nil {
+		return false, err
+	}
+
+	log.Printf("Remetente %s pausado automaticamente: %s", state.Sender, strings.Join(state.Reasons, "; "))
+	return true, nil
+}
+
+// dispatch envia o alerta a cada canal. Falhas são registradas no log e não
+// impedem o envio aos demais canais.
+func (s *ReputationService) dispatch(channels []AlertChannel, alert ReputationAlert) {
+	for _, channel := range channels {
+		var err error
+		switch channel.Type {
+		case AlertChannelWebhook:
+			err = s.postWebhook(channel.URL, alert)
+		case AlertChannelEmail:
+			_, err = s.sesService.SendEmail(EmailRequest{
+				From:        channel.From,
+				To:          channel.To,
+				Subject:     alertTitle(alert),
+				TextBody:    alertText(alert),
+				systemAlert: true,
+			})
+		case AlertChannelLog:
+			log.Printf("%s: %s", alertTitle(alert), strings.Join(alert.Reasons, "; "))
+		}
+
+		switch {
+		case err != nil && isSendingSuspended(err):
+			// O remetente do alerta está pausado ou a conta está suspensa no SES:
+			// o alerta só fica registrado aqui e no histórico de alertas
+			log.Printf("ALERTA NÃO ENTREGUE: alerta %s bloqueado no canal %s, envios suspensos: %v. %s: %s", alert.ID, channel.Type, err, alertTitle(alert), strings.Join(alert.Reasons, "; "))
+		case err != nil:
+			log.Printf("Falha ao enviar alerta %s pelo canal %s: %v", alert.ID, channel.Type, err)
+		}
+	}
+}
+
+// postWebhook envia o alerta em JSON para a URL informada
+func (s *ReputationService) postWebhook(target string, alert ReputationAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.httpClient.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook respondeu com status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// newMonitorState calcula as taxas e o nível do monitor a partir dos contadores
+func newMonitorState(policy *ReputationPolicy, subject, sender string, counters AnalyticsCounters, resumedAt, now time.Time) MonitorState {
+	rates := (&MetricsResult{Totals: counters.totals()}).Rates()
+	level, reasons := policy.classify(counters.Sent, rates.BounceRate, rates.ComplaintRate)
+
+	return MonitorState{
+		Subject:       subject,
+		Sender:        sender,
+		Level:         level,
+		Reasons:       reasons,
+		Sent:          counters.Sent,
+		Bounced:       counters.Bounced,
+		Complaints:    counters.Complaints,
+		BounceRate:    rates.BounceRate,
+		ComplaintRate: rates.ComplaintRate,
+		ResumedAt:     resumedAt,
+		EvaluatedAt:   now,
+	}
+}
+
+// senderSubject monta o identificador do monitor de um remetente
+func senderSubject(email string) string {
+	return "sender:" + strings.ToLower(email)
+}
+
+// alertTitle monta o título do alerta
+func alertTitle(alert ReputationAlert) string {
+	target := "da conta"
+	if alert.Sender != "" {
+		target = "do remetente " + alert.Sender
+	}
+	return fmt.Sprintf("[poc-ses] Reputação %s: %s (antes: %s)", target, alert.Level, alert.PreviousLevel)
+}
+
+// alertText monta o corpo em texto do alerta enviado por e-mail
+func alertText(alert ReputationAlert) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", alertTitle(alert))
+	fmt.Fprintf(&b, "Janela: últimas %d horas\n", alert.WindowHours)
+	fmt.Fprintf(&b, "Envios: %d\n", alert.Sent)
+	fmt.Fprintf(&b, "Taxa de bounce: %.2f%%\n", alert.BounceRate)
+	fmt.Fprintf(&b, "Taxa de reclamação: %.3f%%\n", alert.ComplaintRate)
+	for _, reason := range alert.Reasons {
+		fmt.Fprintf(&b, "- %s\n", reason)
+	}
+	if alert.SenderPaused {
+		fmt.Fprintf(&b, "\nO remetente foi pausado e os envios serão recusados até a retomada manual (POST /api/v1/senders/%s/resume).\n", alert.Sender)
+	}
+	return b.String()
+}
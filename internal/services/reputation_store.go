@@ -0,0 +1,216 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	reputationPolicyBucket = "reputation_policy"
+	reputationStateBucket  = "reputation_state"
+	reputationAlertsBucket = "reputation_alerts"
+	pausedSendersBucket    = "paused_senders"
+)
+
+// reputationPolicyKey é a chave da política de monitoramento no bucket
+const reputationPolicyKey = "policy"
+
+// ReputationStore define a persistência da política de monitoramento de
+// reputação, do estado de cada monitor, dos alertas emitidos e dos
+// remetentes pausados
+type ReputationStore interface {
+	// GetPolicy retorna a política gravada, ou nil se ainda não foi definida
+	GetPolicy() (*ReputationPolicy, error)
+	// SavePolicy grava (ou substitui) a política
+	SavePolicy(policy ReputationPolicy) error
+	// GetState retorna o estado do monitor, ou nil se ainda não foi avaliado
+	GetState(subject string) (*MonitorState, error)
+	// SaveState grava (ou substitui) o estado do monitor
+	SaveState(state MonitorState) error
+	// ListStates retorna o estado de todos os monitores já avaliados
+	ListStates() ([]MonitorState, error)
+	// AddAlert registra um alerta emitido
+	AddAlert(alert ReputationAlert) error
+	// ListAlerts retorna os alertas mais recentes primeiro. Limit zero retorna todos.
+	ListAlerts(limit int) ([]ReputationAlert, error)
+	// GetPause retorna a pausa do remetente, ou nil se ele não está pausado
+	GetPause(email string) (*SenderPause, error)
+	// SavePause grava (ou substitui) a pausa do remetente
+	SavePause(pause SenderPause) error
+	// DeletePause remove a pausa do remetente
+	DeletePause(email string) error
+	// ListPauses retorna os remetentes pausados
+	ListPauses() ([]SenderPause, error)
+}
+
+// BoltReputationStore persiste os dados de monitoramento de reputação no banco embarcado
+type BoltReputationStore struct {
+	db *bolt.DB
+}
+
+// NewBoltReputationStore cria uma nova instância do BoltReputationStore
+func NewBoltReputationStore(db *bolt.DB) (*BoltReputationStore, error) {
+	if err := ensureBuckets(db, reputationPolicyBucket, reputationStateBucket, reputationAlertsBucket, pausedSendersBucket); err != nil {
+		return nil, err
+	}
+
+	return &BoltReputationStore{db: db}, nil
+}
+
+// GetPolicy retorna a política gravada, ou nil se ainda não foi definida
+func (s *BoltReputationStore) GetPolicy() (*ReputationPolicy, error) {
+	var policy ReputationPolicy
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket([]byte(reputationPolicyBucket)), reputationPolicyKey, &policy)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// SavePolicy grava (ou substitui) a política
+func (s *BoltReputationStore) SavePolicy(policy ReputationPolicy) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket([]byte(reputationPolicyBucket)), reputationPolicyKey, policy)
+	})
+}
+
+// GetState retorna o estado do monitor, ou nil se ainda não foi avaliado
+func (s *BoltReputationStore) GetState(subject string) (*MonitorState, error) {
+	var state MonitorState
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket([]byte(reputationStateBucket)), subject, &state)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// SaveState grava (ou substitui) o estado do monitor
+func (s *BoltReputationStore) SaveState(state MonitorState) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket([]byte(reputationStateBucket)), state.Subject, state)
+	})
+}
+
+// ListStates retorna o estado de todos os monitores já avaliados
+func (s *BoltReputationStore) ListStates() ([]MonitorState, error) {
+	states := []MonitorState{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(reputationStateBucket)).ForEach(func(k, v []byte) error {
+			var state MonitorState
+			if err := json.Unmarshal(v, &state); err != nil {
+				return fmt.Errorf("falha ao desserializar estado %s: %w", k, err)
+			}
+			states = append(states, state)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return states, nil
+}
+
+// AddAlert registra um alerta emitido
+func (s *BoltReputationStore) AddAlert(alert ReputationAlert) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket([]byte(reputationAlertsBucket)), timeKey(alert.CreatedAt)+"\x00"+alert.ID, alert)
+	})
+}
+
+// ListAlerts retorna os alertas mais recentes primeiro. Limit zero retorna todos.
+func (s *BoltReputationStore) ListAlerts(limit int) ([]ReputationAlert, error) {
+	alerts := []ReputationAlert{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte(reputationAlertsBucket)).Cursor()
+
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			if limit > 0 && len(alerts) >= limit {
+				break
+			}
+
+			var alert ReputationAlert
+			if err := json.Unmarshal(v, &alert); err != nil {
+				return fmt.Errorf("falha ao desserializar alerta %s: %w", k, err)
+			}
+			alerts = append(alerts, alert)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// GetPause retorna a pausa do remetente, ou nil se ele não está pausado
+func (s *BoltReputationStore) GetPause(email string) (*SenderPause, error) {
+	var pause SenderPause
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket([]byte(pausedSendersBucket)), strings.ToLower(email), &pause)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &pause, nil
+}
+
+// SavePause grava (ou substitui) a pausa do remetente
+func (s *BoltReputationStore) SavePause(pause SenderPause) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket([]byte(pausedSendersBucket)), strings.ToLower(pause.Email), pause)
+	})
+}
+
+// DeletePause remove a pausa do remetente
+func (s *BoltReputationStore) DeletePause(email string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(pausedSendersBucket)).Delete([]byte(strings.ToLower(email)))
+	})
+}
+
+// ListPauses retorna os remetentes pausados
+func (s *BoltReputationStore) ListPauses() ([]SenderPause, error) {
+	pauses := []SenderPause{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(pausedSendersBucket)).ForEach(func(k, v []byte) error {
+			var pause SenderPause
+			if err := json.Unmarshal(v, &pause); err != nil {
+				return fmt.Errorf("falha ao desserializar pausa %s: %w", k, err)
+			}
+			pauses = append(pauses, pause)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pauses, nil
+}
@@ -0,0 +1,515 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Divergências entre o cadastro local e as identidades do SES
+const (
+	// SenderDriftMissing indica um remetente cadastrado que não existe mais no SES (ex: removido pelo console)
+	SenderDriftMissing = "missingInSes"
+	// SenderDriftUnmanaged indica uma identidade do SES criada fora da API
+	SenderDriftUnmanaged = "unmanaged"
+)
+
+// maxVerificationHistory limita as mudanças de status mantidas por remetente
+const maxVerificationHistory = 50
+
+// SenderMetadata representa os dados cadastrais de um remetente
+type SenderMetadata struct {
+	Name             string            `json:"name,omitempty"`
+	Owner            string            `json:"owner,omitempty"`
+	Team             string            `json:"team,omitempty"`
+	ReplyTo          string            `json:"replyTo,omitempty" binding:"omitempty,email"`
+	Tags             map[string]string `json:"tags,omitempty"`
+	Notes            string            `json:"notes,omitempty"`
+	ConfigurationSet string            `json:"configurationSet,omitempty"`
+}
+
+// VerificationChange representa uma mudança do status de verificação de um remetente
+type VerificationChange struct {
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
+}
+
+// SenderRecord representa o cadastro persistido de um remetente
+type SenderRecord struct {
+	Email string `json:"email"`
+	SenderMetadata
+	VerificationStatus  string               `json:"verificationStatus"`
+	VerificationHistory []VerificationChange `json:"verificationHistory"`
+	Drift               string               `json:"drift,omitempty"`
+	DriftDetectedAt     *time.Time           `json:"driftDetectedAt,omitempty"`
+	RegisteredAt        time.Time            `json:"registeredAt"`
+	UpdatedAt           time.Time            `json:"updatedAt"`
+
+	// Acompanhamento da verificação pendente (ver sender_verification.go)
+	VerificationTemplate    string     `json:"verificationTemplate,omitempty"`
+	VerificationRequestedAt *time.Time `json:"verificationRequestedAt,omitempty"`
+	VerificationExpired     bool       `json:"verificationExpired,omitempty"`
+	VerificationChecks      int        `json:"verificationChecks,omitempty"`
+	NextVerificationCheck   *time.Time `json:"nextVerificationCheck,omitempty"`
+}
+
+// SenderReconcileResult representa o resultado de uma reconciliação com o SES
+type SenderReconcileResult struct {
+	CheckedAt     time.Time `json:"checkedAt"`
+	Identities    int       `json:"identities"`
+	Registered    int       `json:"registered"`
+	StatusChanges int       `json:"statusChanges"`
+	MissingInSES  []string  `json:"missingInSes"`
+	Unmanaged     []string  `json:"unmanaged"`
+}
+
+// SenderRegistry mantém o cadastro local dos remetentes (nome, responsável,
+// tags, histórico de verificação...) e o reconcilia periodicamente com as
+// identidades do SES
+type SenderRegistry struct {
+	store      SenderStore
+	sesService *SESService
+	interval   time.Duration
+	webhooks   []string
+	httpClient *http.Client
+	mu         sync.Mutex
+}
+
+// NewSenderRegistry cria uma nova instância do SenderRegistry. Os eventos de
+// verificação são registrados no log e enviados aos webhooks informados.
+func NewSenderRegistry(store SenderStore, sesService *SESService, interval time.Duration, webhooks []string) *SenderRegistry {
+	r := &SenderRegistry{
+		store:      store,
+		sesService: sesService,
+		interval:   interval,
+		webhooks:   webhooks,
+		httpClient: &http.Client{Timeout: webhookTimeout},
+	}
+	sesService.SetSenderLookup(r.lookup)
+
+	return r
+}
+
+// lookup obtém os dados cadastrados do remetente, usados como padrão nos envios
+func (r *SenderRegistry) lookup(email string) (*SenderMetadata, error) {
+	record, err := r.store.GetSender(email)
+	if err != nil || record == nil {
+		return nil, err
+	}
+	return &record.SenderMetadata, nil
+}
+
+// Register cadastra o remetente no SES e grava os seus dados. Um novo
+// cadastro de um remetente existente atualiza os dados e mantém o histórico.
+func (r *SenderRegistry) Register(req SenderRequest) (*SenderResponse, error) {
+	if err := r.sesService.checkConfigurationSet(req.ConfigurationSet); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.sesService.RegisterSender(req); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, err := r.store.GetSender(req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if record == nil {
+		record = &SenderRecord{Email: req.Email, RegisteredAt: now}
+	}
+
+	record.SenderMetadata = req.SenderMetadata
+	record.VerificationTemplate = req.VerificationTemplate
+	record.UpdatedAt = now
+	record.clearDrift()
+	record.restartVerification(now)
+
+	if err := r.store.SaveSender(record); err != nil {
+		return nil, err
+	}
+
+	return record.response(nil, true), nil
+}
+
+// List reconcilia o cadastro com o SES e lista os remetentes, incluindo os
+// removidos do SES e os criados fora da API, sinalizados em Drift
+func (r *SenderRegistry) List() ([]SenderResponse, error) {
+	statuses, err := r.sesService.EmailIdentityStatuses()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	records, _, err := r.reconcile(statuses, time.Now())
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// O GetIdentityMailFromDomainAttributes aceita no máximo 100 identidades por chamada
+	var identities []string
+	for _, record := range records {
+		if record.Drift != SenderDriftMissing {
+			identities = append(identities, record.Email)
+		}
+	}
+
+	mailFrom := make(map[string]*MailFromAttributes, len(identities))
+	for start := 0; start < len(identities); start += 100 {
+		attrs, err := r.sesService.mailFromAttributes(identities[start:min(start+100, len(identities))])
+		if err != nil {
+			return nil, err
+		}
+		for identity, attr := range attrs {
+			mailFrom[identity] = attr
+		}
+	}
+
+	senders := make([]SenderResponse, 0, len(records))
+	for i := range records {
+		senders = append(senders, *records[i].response(mailFrom[records[i].Email], false))
+	}
+
+	return senders, nil
+}
+
+// Get obtém o remetente com o status atual no SES e o histórico de
+// verificação, retornando nil se ele não existir no SES nem no cadastro
+func (r *SenderRegistry) Get(email string) (*SenderResponse, error) {
+	sender, err := r.sesService.GetSender(email)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, err := r.store.GetSender(email)
+	if err != nil {
+		return nil, err
+	}
+
+	if sender == nil && record == nil {
+		return nil, nil // Remetente não encontrado
+	}
+
+	now := time.Now()
+	changed := false
+	if record == nil {
+		record = newUnmanagedSender(email, now)
+		changed = true
+	}
+
+	var mailFrom *MailFromAttributes
+	if sender == nil {
+		changed = record.markMissing(now) || changed
+	} else {
+		changed = r.applyStatus(record, sender.VerificationStatus, now) || changed
+		mailFrom = sender.MailFrom
+	}
+
+	if changed {
+		if err := r.store.SaveSender(record); err != nil {
+			return nil, err
+		}
+	}
+
+	return record.response(mailFrom, true), nil
+}
+
+// Update substitui os dados cadastrais do remetente. Uma identidade criada
+// fora da API passa a ser gerenciada pela API. Retorna nil se o remetente
+// não existir.
+func (r *SenderRegistry) Update(email string, metadata SenderMetadata) (*SenderResponse, error) {
+	if err := r.sesService.checkConfigurationSet(metadata.ConfigurationSet); err != nil {
+		return nil, err
+	}
+
+	sender, err := r.sesService.GetSender(email)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, err := r.store.GetSender(email)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if record == nil {
+		if sender == nil {
+			return nil, nil // Remetente não encontrado
+		}
+		record = &SenderRecord{Email: email, RegisteredAt: now}
+	}
+
+	var mailFrom *MailFromAttributes
+	if sender != nil {
+		r.applyStatus(record, sender.VerificationStatus, now)
+		if record.Drift == SenderDriftUnmanaged {
+			record.clearDrift()
+		}
+		mailFrom = sender.MailFrom
+	}
+
+	record.SenderMetadata = metadata
+	record.UpdatedAt = now
+
+	if err := r.store.SaveSender(record); err != nil {
+		return nil, err
+	}
+
+	return record.response(mailFrom, true), nil
+}
+
+// Delete remove o remetente do SES e do cadastro
+func (r *SenderRegistry) Delete(email string) error {
+	if err := r.sesService.DeleteSender(email); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.store.DeleteSender(email)
+}
+
+// DeleteConfigurationSet remove o configuration set do SES, recusando a
+// remoção enquanto algum remetente cadastrado o usar como padrão
+func (r *SenderRegistry) DeleteConfigurationSet(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records, err := r.store.ListSenders()
+	if err != nil {
+		return err
+	}
+
+	var senders []string
+	for _, record := range records {
+		if record.ConfigurationSet == name {
+			senders = append(senders, record.Email)
+		}
+	}
+	if len(senders) > 0 {
+		sort.Strings(senders)
+		return fmt.Errorf("configuration set em uso pelos remetentes: %s", strings.Join(senders, ", "))
+	}
+
+	return r.sesService.DeleteConfigurationSet(name)
+}
+
+// Drift lista os remetentes que divergem do SES na última reconciliação
+func (r *SenderRegistry) Drift() ([]SenderResponse, error) {
+	records, err := r.store.ListSenders()
+	if err != nil {
+		return nil, err
+	}
+
+	senders := []SenderResponse{}
+	for i := range records {
+		if records[i].Drift != "" {
+			senders = append(senders, *records[i].response(nil, false))
+		}
+	}
+
+	return senders, nil
+}
+
+// Reconcile compara o cadastro com as identidades de e-mail do SES,
+// registrando as mudanças de status e sinalizando as divergências
+func (r *SenderRegistry) Reconcile() (*SenderReconcileResult, error) {
+	statuses, err := r.sesService.EmailIdentityStatuses()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, result, err := r.reconcile(statuses, time.Now())
+	return result, err
+}
+
+// Run reconcilia o cadastro periodicamente até o contexto ser cancelado
+func (r *SenderRegistry) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Reconcile(); err != nil {
+			log.Printf("Falha ao reconciliar remetentes com o SES: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcile aplica os status do SES ao cadastro e retorna todos os
+// remetentes. Deve ser chamado com r.mu travado.
+func (r *SenderRegistry) reconcile(statuses map[string]string, now time.Time) ([]SenderRecord, *SenderReconcileResult, error) {
+	records, err := r.store.ListSenders()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &SenderReconcileResult{
+		CheckedAt:    now,
+		Identities:   len(statuses),
+		MissingInSES: []string{},
+		Unmanaged:    []string{},
+	}
+
+	known := make(map[string]bool, len(records))
+	for i := range records {
+		record := &records[i]
+		known[record.Email] = true
+
+		previous := record.VerificationStatus
+		var changed bool
+		if status, ok := statuses[record.Email]; ok {
+			changed = r.applyStatus(record, status, now)
+		} else {
+			if record.Drift != SenderDriftMissing {
+				log.Printf("Remetente %s não existe mais no SES", record.Email)
+			}
+			changed = record.markMissing(now)
+		}
+
+		if record.VerificationStatus != previous {
+			result.StatusChanges++
+		}
+
+		if changed {
+			if err := r.store.SaveSender(record); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	var unknown []string
+	for email := range statuses {
+		if !known[email] {
+			unknown = append(unknown, email)
+		}
+	}
+	sort.Strings(unknown)
+
+	for _, email := range unknown {
+		log.Printf("Remetente %s foi criado no SES fora da API", email)
+
+		record := newUnmanagedSender(email, now)
+		record.markPresent(statuses[email], now)
+		if err := r.store.SaveSender(record); err != nil {
+			return nil, nil, err
+		}
+		records = append(records, *record)
+	}
+
+	for _, record := range records {
+		switch record.Drift {
+		case SenderDriftMissing:
+			result.MissingInSES = append(result.MissingInSES, record.Email)
+		case SenderDriftUnmanaged:
+			result.Unmanaged = append(result.Unmanaged, record.Email)
+		default:
+			result.Registered++
+		}
+	}
+
+	return records, result, nil
+}
+
+// newUnmanagedSender cria o cadastro de uma identidade encontrada no SES que não foi criada pela API
+func newUnmanagedSender(email string, now time.Time) *SenderRecord {
+	return &SenderRecord{
+		Email:           email,
+		Drift:           SenderDriftUnmanaged,
+		DriftDetectedAt: &now,
+		RegisteredAt:    now,
+		UpdatedAt:       now,
+	}
+}
+
+// observe registra o status de verificação, acrescentando ao histórico
+// quando ele muda. Retorna true se o status mudou.
+func (rec *SenderRecord) observe(status string, now time.Time) bool {
+	if rec.VerificationStatus == status {
+		return false
+	}
+
+	rec.VerificationStatus = status
+	rec.VerificationHistory = append(rec.VerificationHistory, VerificationChange{Status: status, At: now})
+	if len(rec.VerificationHistory) > maxVerificationHistory {
+		rec.VerificationHistory = rec.VerificationHistory[len(rec.VerificationHistory)-maxVerificationHistory:]
+	}
+
+	return true
+}
+
+// markPresent registra o status atual no SES, desfazendo a sinalização de
+// remetente removido. Retorna true se o cadastro mudou.
+func (rec *SenderRecord) markPresent(status string, now time.Time) bool {
+	changed := rec.observe(status, now)
+	if rec.Drift == SenderDriftMissing {
+		rec.clearDrift()
+		changed = true
+	}
+	return changed
+}
+
+// markMissing sinaliza que o remetente não existe mais no SES. Retorna true se o cadastro mudou.
+func (rec *SenderRecord) markMissing(now time.Time) bool {
+	if rec.Drift == SenderDriftMissing {
+		return false
+	}
+
+	rec.Drift = SenderDriftMissing
+	rec.DriftDetectedAt = &now
+	return true
+}
+
+// clearDrift remove a sinalização de divergência
+func (rec *SenderRecord) clearDrift() {
+	rec.Drift = ""
+	rec.DriftDetectedAt = nil
+}
+
+// response converte o cadastro na resposta da API; o histórico de
+// verificação só é incluído quando withHistory é true
+func (rec *SenderRecord) response(mailFrom *MailFromAttributes, withHistory bool) *SenderResponse {
+	response := &SenderResponse{
+		Email:                   rec.Email,
+		SenderMetadata:          rec.SenderMetadata,
+		VerificationStatus:      rec.VerificationStatus,
+		RegisteredAt:            rec.RegisteredAt,
+		MailFrom:                mailFrom,
+		Drift:                   rec.Drift,
+		DriftDetectedAt:         rec.DriftDetectedAt,
+		VerificationRequestedAt: rec.VerificationRequestedAt,
+		VerificationTemplate:    rec.VerificationTemplate,
+	}
+
+	if withHistory {
+		response.VerificationHistory = rec.VerificationHistory
+	}
+
+	return response
+}
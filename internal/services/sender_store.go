@@ -0,0 +1,89 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const sendersBucket = "senders"
+
+// SenderStore define a persistência do cadastro de remetentes
+type SenderStore interface {
+	SaveSender(record *SenderRecord) error
+	GetSender(email string) (*SenderRecord, error)
+	ListSenders() ([]SenderRecord, error)
+	DeleteSender(email string) error
+}
+
+// BoltSenderStore persiste o cadastro de remetentes no banco embarcado
+type BoltSenderStore struct {
+	db *bolt.DB
+}
+
+// NewBoltSenderStore cria uma nova instância do BoltSenderStore
+func NewBoltSenderStore(db *bolt.DB) (*BoltSenderStore, error) {
+	if err := ensureBuckets(db, sendersBucket); err != nil {
+		return nil, err
+	}
+
+	return &BoltSenderStore{db: db}, nil
+}
+
+// SaveSender grava (ou substitui) o cadastro de um remetente
+func (s *BoltSenderStore) SaveSender(record *SenderRecord) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket([]byte(sendersBucket)), record.Email, record)
+	})
+}
+
+// GetSender obtém o cadastro de um remetente, retornando nil se não existir
+func (s *BoltSenderStore) GetSender(email string) (*SenderRecord, error) {
+	var record SenderRecord
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket([]byte(sendersBucket)), email, &record)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// ListSenders lista os remetentes em ordem de cadastro
+func (s *BoltSenderStore) ListSenders() ([]SenderRecord, error) {
+	records := []SenderRecord{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(sendersBucket)).ForEach(func(k, v []byte) error {
+			var record SenderRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("falha ao desserializar remetente %s: %w", k, err)
+			}
+			records = append(records, record)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].RegisteredAt.Before(records[j].RegisteredAt)
+	})
+
+	return records, nil
+}
+
+// DeleteSender remove o cadastro de um remetente
+func (s *BoltSenderStore) DeleteSender(email string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(sendersBucket)).Delete([]byte(email))
+	})
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+)
+
+// Tipos dos eventos de verificação de remetentes
+const (
+	VerificationEventVerified = "verified"
+	VerificationEventFailed   = "failed"
+	VerificationEventExpired  = "expired"
+)
+
+const (
+	// verificationPollTick é o intervalo em que o poller procura verificações pendentes vencidas
+	verificationPollTick = 30 * time.Second
+	// verificationInitialBackoff é o intervalo até a primeira consulta após o pedido de verificação
+	verificationInitialBackoff = time.Minute
+	// verificationMaxBackoff limita o intervalo entre consultas de uma verificação pendente
+	verificationMaxBackoff = time.Hour
+	// verificationExpiry é a validade do link de verificação enviado pelo SES
+	verificationExpiry = 24 * time.Hour
+)
+
+// VerificationEvent representa a conclusão da verificação de um remetente
+type VerificationEvent struct {
+	Type           string     `json:"type"`
+	Email          string     `json:"email"`
+	Status         string     `json:"status"`
+	PreviousStatus string     `json:"previousStatus"`
+	RequestedAt    *time.Time `json:"requestedAt,omitempty"`
+	OccurredAt     time.Time  `json:"occurredAt"`
+}
+
+// ResendVerification pede ao SES um novo e-mail de verificação, com o
+// template personalizado do cadastro, e reinicia o acompanhamento. Retorna
+// nil se o remetente não existir.
+func (r *SenderRegistry) ResendVerification(email string) (*SenderResponse, error) {
+	sender, err := r.sesService.GetSender(email)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, err := r.store.GetSender(email)
+	if err != nil {
+		return nil, err
+	}
+
+	if record == nil && sender == nil {
+		return nil, nil // Remetente não encontrado
+	}
+
+	if sender != nil && sender.VerificationStatus == string(types.VerificationStatusSuccess) {
+		return nil, fmt.Errorf("remetente já verificado")
+	}
+
+	now := time.Now()
+	if record == nil {
+		record = newUnmanagedSender(email, now)
+	}
+
+	// O novo e-mail usa o mesmo template personalizado do cadastro
+	if _, err := r.sesService.RegisterSender(SenderRequest{Email: email, VerificationTemplate: record.VerificationTemplate}); err != nil {
+		return nil, err
+	}
+
+	if record.Drift == SenderDriftMissing {
+		record.clearDrift()
+	}
+	record.restartVerification(now)
+
+	if err := r.store.SaveSender(record); err != nil {
+		return nil, err
+	}
+
+	log.Printf("E-mail de verificação reenviado para o remetente %s", email)
+	return record.response(nil, true), nil
+}
+
+// RunVerificationPoller consulta periodicamente o status das verificações
+// pendentes até o contexto ser cancelado
+func (r *SenderRegistry) RunVerificationPoller(ctx context.Context) {
+	ticker := time.NewTicker(verificationPollTick)
+	defer ticker.Stop()
+
+	for {
+		if err := r.pollVerifications(time.Now()); err != nil {
+			log.Printf("Falha ao consultar verificações pendentes: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollVerifications consulta no SES os remetentes pendentes cuja próxima
+// consulta venceu. Os que continuam pendentes têm o intervalo dobrado, até
+// verificationMaxBackoff; após verificationExpiry, a verificação expira e
+// deixa de ser consultada.
+func (r *SenderRegistry) pollVerifications(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records, err := r.store.ListSenders()
+	if err != nil {
+		return err
+	}
+
+	var due []SenderRecord
+	var identities []string
+	for _, record := range records {
+		if record.awaitingVerification() && (record.NextVerificationCheck == nil || !record.NextVerificationCheck.After(now)) {
+			due = append(due, record)
+			identities = append(identities, record.Email)
+		}
+	}
+
+	if len(due) == 0 {
+		return nil
+	}
+
+	statuses, err := r.sesService.identityStatuses(identities)
+	if err != nil {
+		return err
+	}
+
+	for i := range due {
+		record := &due[i]
+
+		status, ok := statuses[record.Email]
+		if !ok {
+			// A identidade foi removida do SES; a reconciliação sinaliza a divergência
+			record.markMissing(now)
+			record.NextVerificationCheck = nil
+		} else {
+			r.applyStatus(record, status, now)
+		}
+
+		if record.awaitingVerification() {
+			requestedAt := record.RegisteredAt
+			if record.VerificationRequestedAt != nil {
+				requestedAt = *record.VerificationRequestedAt
+			}
+
+			if now.Sub(requestedAt) >= verificationExpiry {
+				record.VerificationExpired = true
+				record.NextVerificationCheck = nil
+				r.notify(VerificationEvent{
+					Type:           VerificationEventExpired,
+					Email:          record.Email,
+					Status:         record.VerificationStatus,
+					PreviousStatus: record.VerificationStatus,
+					RequestedAt:    record.VerificationRequestedAt,
+					OccurredAt:     now,
+				})
+			} else {
+				record.VerificationChecks++
+				next := now.Add(verificationBackoff(record.VerificationChecks))
+				record.NextVerificationCheck = &next
+			}
+		}
+
+		if err := r.store.SaveSender(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyStatus registra o status atual do remetente no SES e emite o evento
+// de verificação quando uma verificação pendente é concluída. Deve ser
+// chamado com r.mu travado. Retorna true se o cadastro mudou.
+func (r *SenderRegistry) applyStatus(record *SenderRecord, status string, now time.Time) bool {
+	previous := record.VerificationStatus
+	if !record.markPresent(status, now) {
+		return false
+	}
+
+	if previous == status || isPendingVerification(status) {
+		return true
+	}
+
+	record.NextVerificationCheck = nil
+
+	var eventType string
+	switch types.VerificationStatus(status) {
+	case types.VerificationStatusSuccess:
+		eventType = VerificationEventVerified
+	case types.VerificationStatusFailed:
+		// A expiração já foi notificada; o SES marca como Failed as verificações expiradas
+		if record.VerificationExpired {
+			return true
+		}
+		eventType = VerificationEventFailed
+	default:
+		return true
+	}
+
+	if isPendingVerification(previous) {
+		r.notify(VerificationEvent{
+			Type:           eventType,
+			Email:          record.Email,
+			Status:         status,
+			PreviousStatus: previous,
+			RequestedAt:    record.VerificationRequestedAt,
+			OccurredAt:     now,
+		})
+	}
+
+	return true
+}
+
+// notify registra o evento no log e o envia aos webhooks em segundo plano
+func (r *SenderRegistry) notify(event VerificationEvent) {
+	log.Printf("Verificação do remetente %s: %s (status %s)", event.Email, event.Type, event.Status)
+
+	for _, target := range r.webhooks {
+		go func(target string) {
+			if err := r.postWebhook(target, event); err != nil {
+				log.Printf("Falha ao enviar evento de verificação de %s ao webhook %s: %v", event.Email, target, err)
+			}
+		}(target)
+	}
+}
+
+// postWebhook envia o evento em JSON para a URL informada
+func (r *SenderRegistry) postWebhook(target string, event VerificationEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.httpClient.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook respondeu com status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// restartVerification marca o remetente como pendente após um novo pedido de
+// verificação e agenda a primeira consulta
+func (rec *SenderRecord) restartVerification(now time.Time) {
+	// O SES reinicia a verificação a cada novo pedido
+	rec.VerificationStatus = ""
+	rec.observe(string(types.VerificationStatusPending), now)
+
+	next := now.Add(verificationInitialBackoff)
+	rec.VerificationRequestedAt = &now
+	rec.VerificationExpired = false
+	rec.VerificationChecks = 0
+	rec.NextVerificationCheck = &next
+}
+
+// awaitingVerification indica se o poller deve consultar o status do remetente
+func (rec *SenderRecord) awaitingVerification() bool {
+	return rec.Drift != SenderDriftMissing && !rec.VerificationExpired && isPendingVerification(rec.VerificationStatus)
+}
+
+// isPendingVerification indica se o status ainda pode mudar sem um novo pedido de verificação
+func isPendingVerification(status string) bool {
+	switch types.VerificationStatus(status) {
+	case types.VerificationStatusPending, types.VerificationStatusTemporaryFailure:
+		return true
+	default:
+		return false
+	}
+}
+
+// verificationBackoff calcula o intervalo até a próxima consulta, dobrando a cada tentativa
+func verificationBackoff(checks int) time.Duration {
+	backoff := verificationInitialBackoff
+	for i := 0; i < checks && backoff < verificationMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, verificationMaxBackoff)
+}
+
+// identityStatuses obtém o status de verificação das identidades informadas,
+// omitindo as que não existem no SES
+func (s *SESService) identityStatuses(identities []string) (map[string]string, error) {
+	statuses := make(map[string]string, len(identities))
+
+	// O GetIdentityVerificationAttributes aceita no máximo 100 identidades por chamada
+	for start := 0; start < len(identities); start += 100 {
+		batch := identities[start:min(start+100, len(identities))]
+
+		result, err := s.sesClient.GetIdentityVerificationAttributes(context.Background(), &ses.GetIdentityVerificationAttributesInput{
+			Identities: batch,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("falha ao obter atributos de verificação: %w", err)
+		}
+
+		for identity, attr := range result.VerificationAttributes {
+			statuses[identity] = string(attr.VerificationStatus)
+		}
+	}
+
+	return statuses, nil
+}
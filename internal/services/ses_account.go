@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+)
+
+// SendQuota representa os limites de envio da conta no SES. Um Max24HourSend
+// de -1 indica envio ilimitado.
+type SendQuota struct {
+	Max24HourSend   float64 `json:"max24HourSend"`
+	MaxSendRate     float64 `json:"maxSendRate"`
+	SentLast24Hours float64 `json:"sentLast24Hours"`
+	UsagePercent    float64 `json:"usagePercent"`
+}
+
+// SendStatistic representa os envios da conta em um intervalo de 15 minutos
+type SendStatistic struct {
+	Timestamp        time.Time `json:"timestamp"`
+	DeliveryAttempts int64     `json:"deliveryAttempts"`
+	Bounces          int64     `json:"bounces"`
+	Complaints       int64     `json:"complaints"`
+	Rejects          int64     `json:"rejects"`
+}
+
+// AccountReputationMetrics representa as taxas de bounce e de reclamação que
+// o SES usa para a revisão da conta, em percentual. As taxas ficam nulas
+// enquanto o SES não publica as métricas de reputação no CloudWatch.
+type AccountReputationMetrics struct {
+	BounceRate    *float64 `json:"bounceRate,omitempty"`
+	ComplaintRate *float64 `json:"complaintRate,omitempty"`
+}
+
+// GetAccountSendingEnabled indica se o envio de e-mails está habilitado na conta do SES
+func (s *SESService) GetAccountSendingEnabled() (bool, error) {
+	result, err := s.sesClient.GetAccountSendingEnabled(context.Background(), &ses.GetAccountSendingEnabledInput{})
+	if err != nil {
+		return false, fmt.Errorf("falha ao obter status de envio da conta: %w", err)
+	}
+
+	return result.Enabled, nil
+}
+
+// SetAccountSendingEnabled habilita ou desabilita o envio de e-mails na conta do SES
+func (s *SESService) SetAccountSendingEnabled(enabled bool) error {
+	_, err := s.sesClient.UpdateAccountSendingEnabled(context.Background(), &ses.UpdateAccountSendingEnabledInput{
+		Enabled: enabled,
+	})
+	if err != nil {
+		return fmt.Errorf("falha ao atualizar status de envio da conta: %w", err)
+	}
+
+	return nil
+}
+
+// GetSendQuota obtém os limites de envio da conta e o uso nas últimas 24 horas
+func (s *SESService) GetSendQuota() (*SendQuota, error) {
+	result, err := s.sesClient.GetSendQuota(context.Background(), &ses.GetSendQuotaInput{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter cota de envio: %w", err)
+	}
+
+	quota := &SendQuota{
+		Max24HourSend:   result.Max24HourSend,
+		MaxSendRate:     result.MaxSendRate,
+		SentLast24Hours: result.SentLast24Hours,
+	}
+	if quota.Max24HourSend > 0 {
+		quota.UsagePercent = quota.SentLast24Hours / quota.Max24HourSend * 100
+	}
+
+	return quota, nil
+}
+
+// GetSendStatistics obtém os envios da conta nas últimas duas semanas, em
+// intervalos de 15 minutos e em ordem cronológica
+func (s *SESService) GetSendStatistics() ([]SendStatistic, error) {
+	result, err := s.sesClient.GetSendStatistics(context.Background(), &ses.GetSendStatisticsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter estatísticas de envio: %w", err)
+	}
+
+	statistics := make([]SendStatistic, 0, len(result.SendDataPoints))
+	for _, point := range result.SendDataPoints {
+		statistics = append(statistics, SendStatistic{
+			Timestamp:        aws.ToTime(point.Timestamp),
+			DeliveryAttempts: point.DeliveryAttempts,
+			Bounces:          point.Bounces,
+			Complaints:       point.Complaints,
+			Rejects:          point.Rejects,
+		})
+	}
+
+	// O SES não garante a ordem dos intervalos
+	sort.Slice(statistics, func(i, j int) bool {
+		return statistics[i].Timestamp.Before(statistics[j].Timestamp)
+	})
+
+	return statistics, nil
+}
+
+// GetAccountReputationMetrics obtém os valores mais recentes das métricas de
+// reputação da conta (Reputation.BounceRate e Reputation.ComplaintRate)
+// publicadas pelo SES no CloudWatch nas últimas 24 horas
+func (s *SESService) GetAccountReputationMetrics() (*AccountReputationMetrics, error) {
+	now := time.Now()
+	queries := make([]cwtypes.MetricDataQuery, 0, 2)
+	for id, name := range map[string]string{"bounceRate": "Reputation.BounceRate", "complaintRate": "Reputation.ComplaintRate"} {
+		queries = append(queries, cwtypes.MetricDataQuery{
+			Id: aws.String(id),
+			MetricStat: &cwtypes.MetricStat{
+				Metric: &cwtypes.Metric{
+					Namespace:  aws.String("AWS/SES"),
+					MetricName: aws.String(name),
+				},
+				Period: aws.Int32(3600),
+				Stat:   aws.String("Maximum"),
+			},
+		})
+	}
+
+	result, err := s.cloudWatchClient.GetMetricData(context.Background(), &cloudwatch.GetMetricDataInput{
+		StartTime:         aws.Time(now.Add(-24 * time.Hour)),
+		EndTime:           aws.Time(now),
+		MetricDataQueries: queries,
+		ScanBy:            cwtypes.ScanByTimestampDescending,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter métricas de reputação do CloudWatch: %w", err)
+	}
+
+	metrics := &AccountReputationMetrics{}
+	for _, series := range result.MetricDataResults {
+		if len(series.Values) == 0 {
+			continue
+		}
+
+		// Em ordem decrescente, o primeiro valor é o mais recente; o SES publica as taxas como fração
+		rate := series.Values[0] * 100
+		switch aws.ToString(series.Id) {
+		case "bounceRate":
+			metrics.BounceRate = &rate
+		case "complaintRate":
+			metrics.ComplaintRate = &rate
+		}
+	}
+
+	return metrics, nil
+}
@@ -0,0 +1,491 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+)
+
+// ConfigurationSetRequest representa os dados de um configuration set. Na
+// atualização, um trackingRedirectDomain vazio remove o domínio personalizado
+// e os demais campos vazios mantêm o valor atual.
+type ConfigurationSetRequest struct {
+	Name                     string `json:"name"`
+	TrackingRedirectDomain   string `json:"trackingRedirectDomain,omitempty" binding:"omitempty,fqdn"`
+	ReputationMetricsEnabled *bool  `json:"reputationMetricsEnabled,omitempty"`
+	TlsPolicy                string `json:"tlsPolicy,omitempty"`
+}
+
+// Validate verifica o nome e a política de TLS do configuration set
+func (r *ConfigurationSetRequest) Validate() error {
+	if err := validateConfigurationSetName(r.Name); err != nil {
+		return err
+	}
+
+	if r.TlsPolicy != "" && !slices.Contains(types.TlsPolicy("").Values(), types.TlsPolicy(r.TlsPolicy)) {
+		return fmt.Errorf("política de TLS inválida: use Require ou Optional")
+	}
+
+	r.TrackingRedirectDomain = strings.ToLower(strings.TrimSuffix(r.TrackingRedirectDomain, "."))
+	return nil
+}
+
+// ConfigurationSet representa um configuration set do SES. A listagem
+// retorna apenas os nomes.
+type ConfigurationSet struct {
+	Name                     string             `json:"name"`
+	TrackingRedirectDomain   string             `json:"trackingRedirectDomain,omitempty"`
+	ReputationMetricsEnabled bool               `json:"reputationMetricsEnabled"`
+	SendingEnabled           bool               `json:"sendingEnabled"`
+	LastFreshStart           *time.Time         `json:"lastFreshStart,omitempty"`
+	TlsPolicy                string             `json:"tlsPolicy,omitempty"`
+	EventDestinations        []EventDestination `json:"eventDestinations"`
+}
+
+// EventDestination representa um destino de eventos de um configuration set.
+// Deve ter exatamente um destino: SNS, CloudWatch ou Firehose.
+type EventDestination struct {
+	Name       string                 `json:"name"`
+	Enabled    *bool                  `json:"enabled,omitempty"`
+	EventTypes []string               `json:"eventTypes" binding:"required,min=1"`
+	SNS        *SNSDestination        `json:"sns,omitempty"`
+	CloudWatch *CloudWatchDestination `json:"cloudWatch,omitempty"`
+	Firehose   *FirehoseDestination   `json:"firehose,omitempty"`
+}
+
+// SNSDestination publica os eventos em um tópico SNS
+type SNSDestination struct {
+	TopicARN string `json:"topicArn" binding:"required"`
+}
+
+// CloudWatchDestination publica os eventos como métricas do CloudWatch
+type CloudWatchDestination struct {
+	Dimensions []CloudWatchDimension `json:"dimensions" binding:"required,min=1,dive"`
+}
+
+// CloudWatchDimension define uma dimensão das métricas do CloudWatch. A
+// origem do valor é messageTag, emailHeader ou linkTag.
+type CloudWatchDimension struct {
+	Name         string `json:"name" binding:"required"`
+	Source       string `json:"source" binding:"required"`
+	DefaultValue string `json:"defaultValue" binding:"required"`
+}
+
+// FirehoseDestination envia os eventos para um delivery stream do Kinesis Firehose
+type FirehoseDestination struct {
+	DeliveryStreamARN string `json:"deliveryStreamArn" binding:"required"`
+	IAMRoleARN        string `json:"iamRoleArn" binding:"required"`
+}
+
+// Validate verifica o nome, os tipos de evento e o destino. Sem enabled, o
+// destino é criado habilitado.
+func (d *EventDestination) Validate() error {
+	if len(d.Name) > 64 || !sesName.MatchString(d.Name) {
+		return fmt.Errorf("destino de eventos inválido: use até 64 letras, números, '-' ou '_' no nome")
+	}
+
+	if len(d.EventTypes) == 0 {
+		return fmt.Errorf("destino de eventos inválido: informe pelo menos um tipo de evento")
+	}
+	for _, eventType := range d.EventTypes {
+		if !slices.Contains(types.EventType("").Values(), types.EventType(eventType)) {
+			return fmt.Errorf("destino de eventos inválido: tipo de evento desconhecido %s", eventType)
+		}
+	}
+
+	destinations := 0
+	if d.SNS != nil {
+		destinations++
+		if !strings.HasPrefix(d.SNS.TopicARN, "arn:") {
+			return fmt.Errorf("destino de eventos inválido: ARN do tópico SNS inválido")
+		}
+	}
+	if d.CloudWatch != nil {
+		destinations++
+		for _, dimension := range d.CloudWatch.Dimensions {
+			if !slices.Contains(types.DimensionValueSource("").Values(), types.DimensionValueSource(dimension.Source)) {
+				return fmt.Errorf("destino de eventos inválido: origem da dimensão %s deve ser messageTag, emailHeader ou linkTag", dimension.Name)
+			}
+		}
+	}
+	if d.Firehose != nil {
+		destinations++
+		if !strings.HasPrefix(d.Firehose.DeliveryStreamARN, "arn:") || !strings.HasPrefix(d.Firehose.IAMRoleARN, "arn:") {
+			return fmt.Errorf("destino de eventos inválido: ARNs do Firehose inválidos")
+		}
+	}
+	if destinations != 1 {
+		return fmt.Errorf("destino de eventos inválido: informe exatamente um destino (sns, cloudWatch ou firehose)")
+	}
+
+	if d.Enabled == nil {
+		d.Enabled = aws.Bool(true)
+	}
+	return nil
+}
+
+// CreateConfigurationSet cria um configuration set com as opções de
+// rastreamento, reputação e entrega informadas
+func (s *SESService) CreateConfigurationSet(req ConfigurationSetRequest) (*ConfigurationSet, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.sesClient.CreateConfigurationSet(context.Background(), &ses.CreateConfigurationSetInput{
+		ConfigurationSet: &types.ConfigurationSet{Name: aws.String(req.Name)},
+	})
+	if err != nil {
+		var exists *types.ConfigurationSetAlreadyExistsException
+		if errors.As(err, &exists) {
+			return nil, fmt.Errorf("configuration set já existe: %s", req.Name)
+		}
+		return nil, fmt.Errorf("falha ao criar configuration set: %w", err)
+	}
+
+	if err := s.applyConfigurationSetOptions(req, ""); err != nil {
+		// Desfazer a criação para não deixar um configuration set parcialmente configurado
+		if _, deleteErr := s.sesClient.DeleteConfigurationSet(context.Background(), &ses.DeleteConfigurationSetInput{
+			ConfigurationSetName: aws.String(req.Name),
+		}); deleteErr != nil {
+			return nil, fmt.Errorf("%w (e falha ao remover o configuration set criado: %v)", err, deleteErr)
+		}
+		return nil, err
+	}
+
+	return s.GetConfigurationSet(req.Name)
+}
+
+// UpdateConfigurationSet atualiza as opções do configuration set, retornando
+// nil se ele não existir
+func (s *SESService) UpdateConfigurationSet(req ConfigurationSetRequest) (*ConfigurationSet, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	current, err := s.GetConfigurationSet(req.Name)
+	if err != nil || current == nil {
+		return nil, err
+	}
+
+	if err := s.applyConfigurationSetOptions(req, current.TrackingRedirectDomain); err != nil {
+		return nil, err
+	}
+
+	return s.GetConfigurationSet(req.Name)
+}
+
+// applyConfigurationSetOptions aplica o domínio de rastreamento, as métricas
+// de reputação e a política de TLS, a partir do domínio de rastreamento atual
+func (s *SESService) applyConfigurationSetOptions(req ConfigurationSetRequest, currentDomain string) error {
+	ctx := context.Background()
+	name := aws.String(req.Name)
+	tracking := &types.TrackingOptions{CustomRedirectDomain: aws.String(req.TrackingRedirectDomain)}
+
+	var err error
+	switch {
+	case req.TrackingRedirectDomain == currentDomain:
+	case currentDomain == "":
+		_, err = s.sesClient.CreateConfigurationSetTrackingOptions(ctx, &ses.CreateConfigurationSetTrackingOptionsInput{
+			ConfigurationSetName: name,
+			TrackingOptions:      tracking,
+		})
+	case req.TrackingRedirectDomain == "":
+		_, err = s.sesClient.DeleteConfigurationSetTrackingOptions(ctx, &ses.DeleteConfigurationSetTrackingOptionsInput{
+			ConfigurationSetName: name,
+		})
+	default:
+		_, err = s.sesClient.UpdateConfigurationSetTrackingOptions(ctx, &ses.UpdateConfigurationSetTrackingOptionsInput{
+			ConfigurationSetName: name,
+			TrackingOptions:      tracking,
+		})
+	}
+	if err != nil {
+		var invalid *types.InvalidTrackingOptionsException
+		if errors.As(err, &invalid) {
+			return fmt.Errorf("domínio de rastreamento inválido: %s", req.TrackingRedirectDomain)
+		}
+		return fmt.Errorf("falha ao configurar o domínio de rastreamento: %w", err)
+	}
+
+	if req.ReputationMetricsEnabled != nil {
+		if _, err := s.sesClient.UpdateConfigurationSetReputationMetricsEnabled(ctx, &ses.UpdateConfigurationSetReputationMetricsEnabledInput{
+			ConfigurationSetName: name,
+			Enabled:              *req.ReputationMetricsEnabled,
+		}); err != nil {
+			return fmt.Errorf("falha ao configurar as métricas de reputação: %w", err)
+		}
+	}
+
+	if req.TlsPolicy != "" {
+		if _, err := s.sesClient.PutConfigurationSetDeliveryOptions(ctx, &ses.PutConfigurationSetDeliveryOptionsInput{
+			ConfigurationSetName: name,
+			DeliveryOptions:      &types.DeliveryOptions{TlsPolicy: types.TlsPolicy(req.TlsPolicy)},
+		}); err != nil {
+			return fmt.Errorf("falha ao configurar a política de TLS: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListConfigurationSets lista os nomes dos configuration sets
+func (s *SESService) ListConfigurationSets() ([]ConfigurationSet, error) {
+	sets := []ConfigurationSet{}
+	var nextToken *string
+
+	for {
+		result, err := s.sesClient.ListConfigurationSets(context.Background(), &ses.ListConfigurationSetsInput{
+			MaxItems:  aws.Int32(1000),
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("falha ao listar configuration sets: %w", err)
+		}
+
+		for _, set := range result.ConfigurationSets {
+			sets = append(sets, ConfigurationSet{Name: aws.ToString(set.Name), EventDestinations: []EventDestination{}})
+		}
+
+		if result.NextToken == nil {
+			break
+		}
+		nextToken = result.NextToken
+	}
+
+	return sets, nil
+}
+
+// GetConfigurationSet obtém o configuration set com as opções e os destinos
+// de eventos, retornando nil se ele não existir
+func (s *SESService) GetConfigurationSet(name string) (*ConfigurationSet, error) {
+	result, err := s.sesClient.DescribeConfigurationSet(context.Background(), &ses.DescribeConfigurationSetInput{
+		ConfigurationSetName:           aws.String(name),
+		ConfigurationSetAttributeNames: types.ConfigurationSetAttribute("").Values(),
+	})
+	if err != nil {
+		if isConfigurationSetNotFound(err) {
+			return nil, nil // Configuration set não encontrado
+		}
+		return nil, fmt.Errorf("falha ao obter configuration set: %w", err)
+	}
+
+	set := &ConfigurationSet{
+		Name:              name,
+		EventDestinations: make([]EventDestination, 0, len(result.EventDestinations)),
+	}
+	if result.TrackingOptions != nil {
+		set.TrackingRedirectDomain = aws.ToString(result.TrackingOptions.CustomRedirectDomain)
+	}
+	if result.ReputationOptions != nil {
+		set.ReputationMetricsEnabled = result.ReputationOptions.ReputationMetricsEnabled
+		set.SendingEnabled = result.ReputationOptions.SendingEnabled
+		set.LastFreshStart = result.ReputationOptions.LastFreshStart
+	}
+	if result.DeliveryOptions != nil {
+		set.TlsPolicy = string(result.DeliveryOptions.TlsPolicy)
+	}
+	for _, destination := range result.EventDestinations {
+		set.EventDestinations = append(set.EventDestinations, newEventDestination(destination))
+	}
+
+	return set, nil
+}
+
+// DeleteConfigurationSet remove um configuration set e os seus destinos de eventos
+func (s *SESService) DeleteConfigurationSet(name string) error {
+	_, err := s.sesClient.DeleteConfigurationSet(context.Background(), &ses.DeleteConfigurationSetInput{
+		ConfigurationSetName: aws.String(name),
+	})
+	if err != nil {
+		return fmt.Errorf("falha ao remover configuration set: %w", err)
+	}
+
+	return nil
+}
+
+// SetConfigurationSetSendingEnabled habilita ou pausa os envios que usam o
+// configuration set, retornando nil se ele não existir
+func (s *SESService) SetConfigurationSetSendingEnabled(name string, enabled bool) (*ConfigurationSet, error) {
+	_, err := s.sesClient.UpdateConfigurationSetSendingEnabled(context.Background(), &ses.UpdateConfigurationSetSendingEnabledInput{
+		ConfigurationSetName: aws.String(name),
+		Enabled:              enabled,
+	})
+	if err != nil {
+		if isConfigurationSetNotFound(err) {
+			return nil, nil // Configuration set não encontrado
+		}
+		return nil, fmt.Errorf("falha ao atualizar status de envio do configuration set: %w", err)
+	}
+
+	return s.GetConfigurationSet(name)
+}
+
+// CreateEventDestination cria um destino de eventos no configuration set
+func (s *SESService) CreateEventDestination(setName string, req EventDestination) (*EventDestination, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.sesClient.CreateConfigurationSetEventDestination(context.Background(), &ses.CreateConfigurationSetEventDestinationInput{
+		ConfigurationSetName: aws.String(setName),
+		EventDestination:     req.sesEventDestination(),
+	})
+	if err != nil {
+		var exists *types.EventDestinationAlreadyExistsException
+		if errors.As(err, &exists) {
+			return nil, fmt.Errorf("destino de eventos já existe: %s", req.Name)
+		}
+		return nil, eventDestinationError("falha ao criar destino de eventos", err)
+	}
+
+	return &req, nil
+}
+
+// UpdateEventDestination substitui um destino de eventos do configuration
+// set, retornando nil se ele não existir
+func (s *SESService) UpdateEventDestination(setName string, req EventDestination) (*EventDestination, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.sesClient.UpdateConfigurationSetEventDestination(context.Background(), &ses.UpdateConfigurationSetEventDestinationInput{
+		ConfigurationSetName: aws.String(setName),
+		EventDestination:     req.sesEventDestination(),
+	})
+	if err != nil {
+		var notFound *types.EventDestinationDoesNotExistException
+		if errors.As(err, &notFound) || isConfigurationSetNotFound(err) {
+			return nil, nil // Destino de eventos não encontrado
+		}
+		return nil, eventDestinationError("falha ao atualizar destino de eventos", err)
+	}
+
+	return &req, nil
+}
+
+// DeleteEventDestination remove um destino de eventos do configuration set
+func (s *SESService) DeleteEventDestination(setName, name string) error {
+	_, err := s.sesClient.DeleteConfigurationSetEventDestination(context.Background(), &ses.DeleteConfigurationSetEventDestinationInput{
+		ConfigurationSetName: aws.String(setName),
+		EventDestinationName: aws.String(name),
+	})
+	if err != nil {
+		return fmt.Errorf("falha ao remover destino de eventos: %w", err)
+	}
+
+	return nil
+}
+
+// checkConfigurationSet verifica se o configuration set informado existe.
+// Um nome vazio é aceito.
+func (s *SESService) checkConfigurationSet(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	if err := validateConfigurationSetName(name); err != nil {
+		return err
+	}
+
+	set, err := s.GetConfigurationSet(name)
+	if err != nil {
+		return err
+	}
+	if set == nil {
+		return fmt.Errorf("configuration set não encontrado: %s", name)
+	}
+
+	return nil
+}
+
+// sesEventDestination converte o destino de eventos para o formato do SES
+func (d EventDestination) sesEventDestination() *types.EventDestination {
+	destination := &types.EventDestination{
+		Name:    aws.String(d.Name),
+		Enabled: aws.ToBool(d.Enabled),
+	}
+	for _, eventType := range d.EventTypes {
+		destination.MatchingEventTypes = append(destination.MatchingEventTypes, types.EventType(eventType))
+	}
+
+	switch {
+	case d.SNS != nil:
+		destination.SNSDestination = &types.SNSDestination{TopicARN: aws.String(d.SNS.TopicARN)}
+	case d.CloudWatch != nil:
+		cloudWatch := &types.CloudWatchDestination{}
+		for _, dimension := range d.CloudWatch.Dimensions {
+			cloudWatch.DimensionConfigurations = append(cloudWatch.DimensionConfigurations, types.CloudWatchDimensionConfiguration{
+				DimensionName:         aws.String(dimension.Name),
+				DimensionValueSource:  types.DimensionValueSource(dimension.Source),
+				DefaultDimensionValue: aws.String(dimension.DefaultValue),
+			})
+		}
+		destination.CloudWatchDestination = cloudWatch
+	case d.Firehose != nil:
+		destination.KinesisFirehoseDestination = &types.KinesisFirehoseDestination{
+			DeliveryStreamARN: aws.String(d.Firehose.DeliveryStreamARN),
+			IAMRoleARN:        aws.String(d.Firehose.IAMRoleARN),
+		}
+	}
+
+	return destination
+}
+
+// newEventDestination converte o destino de eventos do SES na resposta da API
+func newEventDestination(destination types.EventDestination) EventDestination {
+	d := EventDestination{
+		Name:       aws.ToString(destination.Name),
+		Enabled:    aws.Bool(destination.Enabled),
+		EventTypes: make([]string, 0, len(destination.MatchingEventTypes)),
+	}
+	for _, eventType := range destination.MatchingEventTypes {
+		d.EventTypes = append(d.EventTypes, string(eventType))
+	}
+
+	if destination.SNSDestination != nil {
+		d.SNS = &SNSDestination{TopicARN: aws.ToString(destination.SNSDestination.TopicARN)}
+	}
+	if destination.CloudWatchDestination != nil {
+		d.CloudWatch = &CloudWatchDestination{}
+		for _, dimension := range destination.CloudWatchDestination.DimensionConfigurations {
+			d.CloudWatch.Dimensions = append(d.CloudWatch.Dimensions, CloudWatchDimension{
+				Name:         aws.ToString(dimension.DimensionName),
+				Source:       string(dimension.DimensionValueSource),
+				DefaultValue: aws.ToString(dimension.DefaultDimensionValue),
+			})
+		}
+	}
+	if destination.KinesisFirehoseDestination != nil {
+		d.Firehose = &FirehoseDestination{
+			DeliveryStreamARN: aws.ToString(destination.KinesisFirehoseDestination.DeliveryStreamARN),
+			IAMRoleARN:        aws.ToString(destination.KinesisFirehoseDestination.IAMRoleARN),
+		}
+	}
+
+	return d
+}
+
+// eventDestinationError classifica os erros do SES de destino de eventos
+// inválido, que decorrem dos dados informados
+func eventDestinationError(message string, err error) error {
+	var invalidSNS *types.InvalidSNSDestinationException
+	var invalidCloudWatch *types.InvalidCloudWatchDestinationException
+	var invalidFirehose *types.InvalidFirehoseDestinationException
+	if errors.As(err, &invalidSNS) || errors.As(err, &invalidCloudWatch) || errors.As(err, &invalidFirehose) {
+		return fmt.Errorf("destino de eventos inválido: %w", err)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// isConfigurationSetNotFound indica se o erro do SES é de configuration set inexistente
+func isConfigurationSetNotFound(err error) bool {
+	var notFound *types.ConfigurationSetDoesNotExistException
+	return errors.As(err, &notFound)
+}
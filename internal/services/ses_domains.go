@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+)
+
+// dkimRecordTarget é o domínio de destino dos registros CNAME do Easy DKIM
+const dkimRecordTarget = "dkim.amazonses.com"
+
+// DomainRequest representa os dados para cadastro de um domínio
+type DomainRequest struct {
+	Domain string `json:"domain" binding:"required,fqdn"`
+}
+
+// DNSRecord representa um registro DNS que deve ser publicado no domínio
+type DNSRecord struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Purpose string `json:"purpose"`
+}
+
+// DomainResponse representa o estado de verificação, de DKIM e do MAIL FROM
+// de um domínio e os registros DNS que devem ser publicados
+type DomainResponse struct {
+	Domain             string              `json:"domain"`
+	VerificationStatus string              `json:"verificationStatus"`
+	DkimEnabled        bool                `json:"dkimEnabled"`
+	DkimStatus         string              `json:"dkimStatus"`
+	Records            []DNSRecord         `json:"records"`
+	MailFrom           *MailFromAttributes `json:"mailFrom,omitempty"`
+}
+
+// RegisterDomain cadastra o domínio no SES e habilita o Easy DKIM, retornando
+// o registro TXT de verificação e os registros CNAME do DKIM
+func (s *SESService) RegisterDomain(req DomainRequest) (*DomainResponse, error) {
+	domain := normalizeDomain(req.Domain)
+
+	verification, err := s.sesClient.VerifyDomainIdentity(context.Background(), &ses.VerifyDomainIdentityInput{
+		Domain: aws.String(domain),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao verificar identidade do domínio: %w", err)
+	}
+
+	dkim, err := s.sesClient.VerifyDomainDkim(context.Background(), &ses.VerifyDomainDkimInput{
+		Domain: aws.String(domain),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao habilitar DKIM do domínio: %w", err)
+	}
+
+	return &DomainResponse{
+		Domain:             domain,
+		VerificationStatus: string(types.VerificationStatusPending),
+		DkimEnabled:        true,
+		DkimStatus:         string(types.VerificationStatusPending),
+		Records:            domainRecords(domain, aws.ToString(verification.VerificationToken), dkim.DkimTokens),
+	}, nil
+}
+
+// ListDomains lista os domínios cadastrados com o estado de verificação e de DKIM
+func (s *SESService) ListDomains() ([]DomainResponse, error) {
+	result, err := s.sesClient.ListIdentities(context.Background(), &ses.ListIdentitiesInput{
+		IdentityType: types.IdentityTypeDomain,
+		MaxItems:     aws.Int32(100),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar identidades: %w", err)
+	}
+
+	if len(result.Identities) == 0 {
+		return []DomainResponse{}, nil
+	}
+
+	domains, err := s.describeDomains(result.Identities)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i].Domain < domains[j].Domain
+	})
+
+	return domains, nil
+}
+
+// GetDomain obtém o estado de verificação e de DKIM de um domínio
+func (s *SESService) GetDomain(domain string) (*DomainResponse, error) {
+	domains, err := s.describeDomains([]string{normalizeDomain(domain)})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(domains) == 0 {
+		return nil, nil // Domínio não encontrado
+	}
+
+	return &domains[0], nil
+}
+
+// DeleteDomain remove um domínio
+func (s *SESService) DeleteDomain(domain string) error {
+	_, err := s.sesClient.DeleteIdentity(context.Background(), &ses.DeleteIdentityInput{
+		Identity: aws.String(normalizeDomain(domain)),
+	})
+	if err != nil {
+		return fmt.Errorf("falha ao remover identidade: %w", err)
+	}
+
+	return nil
+}
+
+// describeDomains consulta os atributos de verificação, de DKIM e de MAIL FROM dos
+// domínios, ignorando os que não estão cadastrados
+func (s *SESService) describeDomains(domains []string) ([]DomainResponse, error) {
+	vResult, err := s.sesClient.GetIdentityVerificationAttributes(context.Background(), &ses.GetIdentityVerificationAttributesInput{
+		Identities: domains,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter atributos de verificação: %w", err)
+	}
+
+	dResult, err := s.sesClient.GetIdentityDkimAttributes(context.Background(), &ses.GetIdentityDkimAttributesInput{
+		Identities: domains,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter atributos de DKIM: %w", err)
+	}
+
+	mailFrom, err := s.mailFromAttributes(domains)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]DomainResponse, 0, len(domains))
+	for _, domain := range domains {
+		attr, ok := vResult.VerificationAttributes[domain]
+		if !ok {
+			continue
+		}
+
+		response := DomainResponse{
+			Domain:             domain,
+			VerificationStatus: string(attr.VerificationStatus),
+			DkimStatus:         string(types.VerificationStatusNotStarted),
+			MailFrom:           mailFrom[domain],
+		}
+
+		var dkimTokens []string
+		if dkim, ok := dResult.DkimAttributes[domain]; ok {
+			response.DkimEnabled = dkim.DkimEnabled
+			response.DkimStatus = string(dkim.DkimVerificationStatus)
+			dkimTokens = dkim.DkimTokens
+		}
+		response.Records = domainRecords(domain, aws.ToString(attr.VerificationToken), dkimTokens)
+
+		result = append(result, response)
+	}
+
+	return result, nil
+}
+
+// domainRecords monta o registro TXT de verificação do domínio e os
+// registros CNAME do Easy DKIM
+func domainRecords(domain, verificationToken string, dkimTokens []string) []DNSRecord {
+	records := make([]DNSRecord, 0, len(dkimTokens)+1)
+
+	if verificationToken != "" {
+		records = append(records, DNSRecord{
+			Type:    "TXT",
+			Name:    "_amazonses." + domain,
+			Value:   verificationToken,
+			Purpose: "verification",
+		})
+	}
+
+	for _, token := range dkimTokens {
+		records = append(records, DNSRecord{
+			Type:    "CNAME",
+			Name:    token + "._domainkey." + domain,
+			Value:   token + "." + dkimRecordTarget,
+			Purpose: "dkim",
+		})
+	}
+
+	return records
+}
+
+// normalizeDomain remove espaços e o ponto final e converte o domínio para minúsculas
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+}
+
+// emailDomain retorna o domínio de um endereço de e-mail
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return normalizeDomain(email[at+1:])
+}
+
+// checkSenderVerified verifica se o endereço ou o seu domínio está
+// verificado no SES. Endereços de um domínio verificado podem enviar sem
+// verificação individual.
+func (s *SESService) checkSenderVerified(email string) error {
+	identities := []string{email}
+	domain := emailDomain(email)
+	if domain != "" {
+		identities = append(identities, domain)
+	}
+
+	result, err := s.sesClient.GetIdentityVerificationAttributes(context.Background(), &ses.GetIdentityVerificationAttributesInput{
+		Identities: identities,
+	})
+	if err != nil {
+		return fmt.Errorf("falha ao verificar remetente: %w", err)
+	}
+
+	emailAttr, emailFound := result.VerificationAttributes[email]
+	domainAttr, domainFound := result.VerificationAttributes[domain]
+
+	if (emailFound && emailAttr.VerificationStatus == types.VerificationStatusSuccess) ||
+		(domainFound && domainAttr.VerificationStatus == types.VerificationStatusSuccess) {
+		return nil
+	}
+
+	switch {
+	case emailFound:
+		return fmt.Errorf("remetente não verificado. Status atual: %s", emailAttr.VerificationStatus)
+	case domainFound:
+		return fmt.Errorf("remetente não verificado. Status atual do domínio %s: %s", domain, domainAttr.VerificationStatus)
+	default:
+		return fmt.Errorf("remetente não encontrado")
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+)
+
+// MailFromRequest representa os dados para configurar o domínio MAIL FROM de uma identidade
+type MailFromRequest struct {
+	MailFromDomain      string `json:"mailFromDomain" binding:"required,fqdn"`
+	BehaviorOnMXFailure string `json:"behaviorOnMxFailure,omitempty"`
+}
+
+// Validate verifica o comportamento em falha de MX e preenche o padrão do SES
+func (r *MailFromRequest) Validate() error {
+	r.MailFromDomain = normalizeDomain(r.MailFromDomain)
+
+	switch types.BehaviorOnMXFailure(r.BehaviorOnMXFailure) {
+	case "":
+		r.BehaviorOnMXFailure = string(types.BehaviorOnMXFailureUseDefaultValue)
+	case types.BehaviorOnMXFailureUseDefaultValue, types.BehaviorOnMXFailureRejectMessage:
+	default:
+		return fmt.Errorf("comportamento em falha de MX inválido: %s (use UseDefaultValue ou RejectMessage)", r.BehaviorOnMXFailure)
+	}
+
+	return nil
+}
+
+// MailFromAttributes representa o domínio MAIL FROM personalizado de uma
+// identidade e os registros DNS que devem ser publicados nele
+type MailFromAttributes struct {
+	MailFromDomain      string      `json:"mailFromDomain"`
+	Status              string      `json:"status"`
+	BehaviorOnMXFailure string      `json:"behaviorOnMxFailure"`
+	Records             []DNSRecord `json:"records"`
+}
+
+// SetMailFromDomain configura o domínio MAIL FROM personalizado da
+// identidade (endereço ou domínio). O domínio deve ser um subdomínio do
+// domínio da identidade, para que o SPF se alinhe no DMARC.
+func (s *SESService) SetMailFromDomain(identity string, req MailFromRequest) (*MailFromAttributes, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	identity = normalizeIdentity(identity)
+	parent := identity
+	if strings.Contains(identity, "@") {
+		parent = emailDomain(identity)
+	}
+
+	if !strings.HasSuffix(req.MailFromDomain, "."+parent) {
+		return nil, fmt.Errorf("domínio MAIL FROM inválido: %s deve ser um subdomínio de %s", req.MailFromDomain, parent)
+	}
+
+	exists, err := s.identityExists(identity)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil // Identidade não encontrada
+	}
+
+	_, err = s.sesClient.SetIdentityMailFromDomain(context.Background(), &ses.SetIdentityMailFromDomainInput{
+		Identity:            aws.String(identity),
+		MailFromDomain:      aws.String(req.MailFromDomain),
+		BehaviorOnMXFailure: types.BehaviorOnMXFailure(req.BehaviorOnMXFailure),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao configurar MAIL FROM: %w", err)
+	}
+
+	return &MailFromAttributes{
+		MailFromDomain:      req.MailFromDomain,
+		Status:              string(types.CustomMailFromStatusPending),
+		BehaviorOnMXFailure: req.BehaviorOnMXFailure,
+		Records:             s.mailFromRecords(req.MailFromDomain),
+	}, nil
+}
+
+// ClearMailFromDomain volta a identidade para o MAIL FROM padrão do SES
+func (s *SESService) ClearMailFromDomain(identity string) error {
+	_, err := s.sesClient.SetIdentityMailFromDomain(context.Background(), &ses.SetIdentityMailFromDomainInput{
+		Identity: aws.String(normalizeIdentity(identity)),
+	})
+	if err != nil {
+		return fmt.Errorf("falha ao remover MAIL FROM: %w", err)
+	}
+
+	return nil
+}
+
+// GetMailFromAttributes obtém o domínio MAIL FROM personalizado de uma
+// identidade, retornando nil se ela usa o MAIL FROM padrão do SES
+func (s *SESService) GetMailFromAttributes(identity string) (*MailFromAttributes, error) {
+	identity = normalizeIdentity(identity)
+
+	attrs, err := s.mailFromAttributes([]string{identity})
+	if err != nil {
+		return nil, err
+	}
+
+	return attrs[identity], nil
+}
+
+// mailFromAttributes obtém o MAIL FROM personalizado das identidades,
+// omitindo as que usam o MAIL FROM padrão
+func (s *SESService) mailFromAttributes(identities []string) (map[string]*MailFromAttributes, error) {
+	result, err := s.sesClient.GetIdentityMailFromDomainAttributes(context.Background(), &ses.GetIdentityMailFromDomainAttributesInput{
+		Identities: identities,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter atributos de MAIL FROM: %w", err)
+	}
+
+	attrs := make(map[string]*MailFromAttributes, len(result.MailFromDomainAttributes))
+	for identity, attr := range result.MailFromDomainAttributes {
+		mailFromDomain := aws.ToString(attr.MailFromDomain)
+		if mailFromDomain == "" {
+			continue
+		}
+
+		attrs[identity] = &MailFromAttributes{
+			MailFromDomain:      mailFromDomain,
+			Status:              string(attr.MailFromDomainStatus),
+			BehaviorOnMXFailure: string(attr.BehaviorOnMXFailure),
+			Records:             s.mailFromRecords(mailFromDomain),
+		}
+	}
+
+	return attrs, nil
+}
+
+// mailFromRecords monta os registros MX e SPF exigidos pelo SES no domínio MAIL FROM
+func (s *SESService) mailFromRecords(mailFromDomain string) []DNSRecord {
+	return []DNSRecord{
+		{
+			Type:    "MX",
+			Name:    mailFromDomain,
+			Value:   fmt.Sprintf("10 feedback-smtp.%s.amazonses.com", s.region),
+			Purpose: "mailFrom",
+		},
+		{
+			Type:    "TXT",
+			Name:    mailFromDomain,
+			Value:   "v=spf1 include:" + sesSPFInclude + " ~all",
+			Purpose: "mailFrom",
+		},
+	}
+}
+
+// identityExists verifica se o endereço ou domínio está cadastrado no SES
+func (s *SESService) identityExists(identity string) (bool, error) {
+	result, err := s.sesClient.GetIdentityVerificationAttributes(context.Background(), &ses.GetIdentityVerificationAttributesInput{
+		Identities: []string{identity},
+	})
+	if err != nil {
+		return false, fmt.Errorf("falha ao obter atributos de verificação: %w", err)
+	}
+
+	_, ok := result.VerificationAttributes[identity]
+	return ok, nil
+}
+
+// normalizeIdentity normaliza um domínio, mantendo os endereços de e-mail como informados
+func normalizeIdentity(identity string) string {
+	if strings.Contains(identity, "@") {
+		return strings.TrimSpace(identity)
+	}
+	return normalizeDomain(identity)
+}
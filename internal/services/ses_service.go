@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -14,28 +15,42 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ses"
 	"github.com/aws/aws-sdk-go-v2/service/ses/types"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
-	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/renat/poc-ses/internal/metrics"
 	"gopkg.in/gomail.v2"
 )
 
 // SenderRequest representa os dados para cadastro de um remetente
 type SenderRequest struct {
 	Email string `json:"email" binding:"required,email"`
-	Name  string `json:"name"`
+	SenderMetadata
+	// VerificationTemplate é o template personalizado do e-mail de verificação (opcional)
+	VerificationTemplate string `json:"verificationTemplate,omitempty"`
 }
 
 // EmailRequest representa os dados para envio de um e-mail
+// Os endereços aceitam o formato RFC 5322 ("Nome" <email>); ver Normalize.
 type EmailRequest struct {
-	From        string   `json:"from" binding:"required,email"`
-	To          []string `json:"to" binding:"required,dive,email"`
-	Cc          []string `json:"cc,omitempty" binding:"omitempty,dive,email"`
-	Bcc         []string `json:"bcc,omitempty" binding:"omitempty,dive,email"`
+	From        string   `json:"from" binding:"required"`
+	FromName    string   `json:"fromName,omitempty"`
+	To          []string `json:"to" binding:"required,dive,required"`
+	Cc          []string `json:"cc,omitempty" binding:"omitempty,dive,required"`
+	Bcc         []string `json:"bcc,omitempty" binding:"omitempty,dive,required"`
+	ReplyTo     []string `json:"replyTo,omitempty" binding:"omitempty,dive,required"`
+	// ReturnPath recebe os bounces e reclamações nos envios simples e com
+	// template pelo SES; nas mensagens raw, o SES os envia ao remetente
+	ReturnPath  string   `json:"returnPath,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	ConfigurationSet string `json:"configurationSet,omitempty"`
 	Subject     string   `json:"subject" binding:"required"`
 	HtmlBody    string   `json:"htmlBody,omitempty"`
 	TextBody    string   `json:"textBody,omitempty"`
 	Attachments []Attachment `json:"attachments,omitempty"`
 	TemplateId  string   `json:"templateId,omitempty"`
 	TemplateData map[string]interface{} `json:"templateData,omitempty"`
+	// systemAlert marca os alertas da própria aplicação (ex: reputação), que
+	// continuam sendo enviados com o kill switch acionado. Não é exposto na API.
+	systemAlert bool
 }
 
 // Attachment representa um anexo de e-mail
@@ -71,48 +86,72 @@ type EmailResponse struct {
 	SentAt     time.Time `json:"sentAt"`
 	StatusCode int       `json:"statusCode"`
 	Status     string    `json:"status"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	ConfigurationSet string `json:"configurationSet,omitempty"`
 }
 
-// SenderResponse representa os dados de resposta de um remetente
+// SenderResponse representa os dados de resposta de um remetente.
+// RegisteredAt é preenchido pelo SenderRegistry, que mantém a data de
+// cadastro; nas respostas obtidas diretamente do SES fica zerado.
 type SenderResponse struct {
 	Email            string    `json:"email"`
-	Name             string    `json:"name,omitempty"`
+	SenderMetadata
 	VerificationStatus string  `json:"verificationStatus"`
 	RegisteredAt     time.Time `json:"registeredAt"`
+	MailFrom         *MailFromAttributes `json:"mailFrom,omitempty"`
+	Drift            string     `json:"drift,omitempty"`
+	DriftDetectedAt  *time.Time `json:"driftDetectedAt,omitempty"`
+	VerificationHistory []VerificationChange `json:"verificationHistory,omitempty"`
+	VerificationRequestedAt *time.Time `json:"verificationRequestedAt,omitempty"`
+	VerificationTemplate string `json:"verificationTemplate,omitempty"`
 }
 
 // MetricsResponse representa as métricas gerais de envio de e-mails
 type MetricsResponse struct {
-	Period          string  `json:"period"`
-	TotalSent       int64   `json:"totalSent"`
-	TotalDelivered  int64   `json:"totalDelivered"`
-	TotalOpened     int64   `json:"totalOpened"`
-	TotalClicked    int64   `json:"totalClicked"`
-	TotalBounced    int64   `json:"totalBounced"`
-	TotalComplaints int64   `json:"totalComplaints"`
-	DeliveryRate    float64 `json:"deliveryRate"`
-	OpenRate        float64 `json:"openRate"`
-	ClickRate       float64 `json:"clickRate"`
-	BounceRate      float64 `json:"bounceRate"`
-	ComplaintRate   float64 `json:"complaintRate"`
+	Period          string                 `json:"period"`
+	TotalSent       int64                  `json:"totalSent"`
+	TotalDelivered  int64                  `json:"totalDelivered"`
+	TotalOpened     int64                  `json:"totalOpened"`
+	TotalClicked    int64                  `json:"totalClicked"`
+	TotalBounced    int64                  `json:"totalBounced"`
+	TotalComplaints int64                  `json:"totalComplaints"`
+	DeliveryRate    float64                `json:"deliveryRate"`
+	OpenRate        float64                `json:"openRate"`
+	ClickRate       float64                `json:"clickRate"`
+	BounceRate      float64                `json:"bounceRate"`
+	ComplaintRate   float64                `json:"complaintRate"`
+	Granularity     string                 `json:"granularity"`
+	Timezone        string                 `json:"timezone"`
+	Series          []TimeSeriesPoint      `json:"series"`
+	Trend           map[string]SeriesTrend `json:"trend,omitempty"`
+	Comparison      *MetricsComparison     `json:"comparison,omitempty"`
 }
 
-// SenderMetricsResponse representa as métricas de envio para um remetente específico
+// SenderMetricsResponse representa as métricas de envio para um remetente específico.
+// Também é usado nas métricas locais das demais dimensões (template, tag, domínio...),
+// identificadas por Dimension e Value.
 type SenderMetricsResponse struct {
-	Email           string            `json:"email"`
-	Period          string            `json:"period"`
-	TotalSent       int64             `json:"totalSent"`
-	TotalDelivered  int64             `json:"totalDelivered"`
-	TotalOpened     int64             `json:"totalOpened"`
-	TotalClicked    int64             `json:"totalClicked"`
-	TotalBounced    int64             `json:"totalBounced"`
-	TotalComplaints int64             `json:"totalComplaints"`
-	DeliveryRate    float64           `json:"deliveryRate"`
-	OpenRate        float64           `json:"openRate"`
-	ClickRate       float64           `json:"clickRate"`
-	BounceRate      float64           `json:"bounceRate"`
-	ComplaintRate   float64           `json:"complaintRate"`
-	DailyStats      []DailyMetrics    `json:"dailyStats,omitempty"`
+	Email           string                 `json:"email,omitempty"`
+	Dimension       string                 `json:"dimension,omitempty"`
+	Value           string                 `json:"value,omitempty"`
+	Period          string                 `json:"period"`
+	TotalSent       int64                  `json:"totalSent"`
+	TotalDelivered  int64                  `json:"totalDelivered"`
+	TotalOpened     int64                  `json:"totalOpened"`
+	TotalClicked    int64                  `json:"totalClicked"`
+	TotalBounced    int64                  `json:"totalBounced"`
+	TotalComplaints int64                  `json:"totalComplaints"`
+	DeliveryRate    float64                `json:"deliveryRate"`
+	OpenRate        float64                `json:"openRate"`
+	ClickRate       float64                `json:"clickRate"`
+	BounceRate      float64                `json:"bounceRate"`
+	ComplaintRate   float64                `json:"complaintRate"`
+	DailyStats      []DailyMetrics         `json:"dailyStats,omitempty"`
+	Granularity     string                 `json:"granularity"`
+	Timezone        string                 `json:"timezone"`
+	Series          []TimeSeriesPoint      `json:"series"`
+	Trend           map[string]SeriesTrend `json:"trend,omitempty"`
+	Comparison      *MetricsComparison     `json:"comparison,omitempty"`
 }
 
 // DailyMetrics representa métricas diárias
@@ -129,10 +168,22 @@ type DailyMetrics struct {
 	ClickRate       float64 `json:"clickRate"`
 }
 
+// SenderLookup obtém os dados cadastrados de um remetente, ou nil se ele não
+// estiver cadastrado. É usado para preencher os padrões do remetente no envio.
+type SenderLookup func(email string) (*SenderMetadata, error)
+
+// SendGuard é consultado antes de cada envio e retorna um erro quando o
+// remetente não pode enviar (ex: pausado pelo monitor de reputação)
+type SendGuard func(req EmailRequest) error
+
 // SESService gerencia as operações com o Amazon SES
 type SESService struct {
 	sesClient        *ses.Client
 	cloudWatchClient *cloudwatch.Client
+	metricsQuerier   *MetricsQuerier
+	sendGuards       []SendGuard
+	senderLookup     SenderLookup
+	region           string
 }
 
 // GetCloudWatchClient retorna o cliente CloudWatch para outros serviços
@@ -140,38 +191,57 @@ func (s *SESService) GetCloudWatchClient() *cloudwatch.Client {
 	return s.cloudWatchClient
 }
 
-// NewSESService cria uma nova instância do SESService
-func NewSESService() *SESService {
+// GetMetricsQuerier retorna o consultor de métricas do CloudWatch, com cache compartilhado entre os serviços
+func (s *SESService) GetMetricsQuerier() *MetricsQuerier {
+	return s.metricsQuerier
+}
+
+// NewSESService cria uma nova instância do SESService. As consultas de
+// métricas ao CloudWatch ficam em cache por metricsCacheTTL.
+func NewSESService(metricsCacheTTL time.Duration) *SESService {
 	// Carregar configuração da AWS
 	cfg, err := config.LoadDefaultConfig(context.Background())
 	if err != nil {
 		panic(fmt.Sprintf("Falha ao carregar configuração da AWS: %v", err))
 	}
 	
+	// Medir latência e erros de todas as chamadas à AWS
+	cfg.APIOptions = append(cfg.APIOptions, metrics.InstrumentAWS)
+	
+	cloudWatchClient := cloudwatch.NewFromConfig(cfg)
+	
 	return &SESService{
-		sesClient:      ses.NewFromConfig(cfg),
-		cloudWatchClient: cloudwatch.NewFromConfig(cfg),
+		sesClient:        ses.NewFromConfig(cfg),
+		cloudWatchClient: cloudWatchClient,
+		metricsQuerier:   NewMetricsQuerier(cloudWatchClient, metricsCacheTTL),
+		region:           cfg.Region,
 	}
 }
 
-// RegisterSender registra um novo remetente no Amazon SES
+// RegisterSender registra um novo remetente no Amazon SES. Com um template
+// de verificação, o e-mail de verificação usa o template personalizado.
 func (s *SESService) RegisterSender(req SenderRequest) (*SenderResponse, error) {
-	// Verificar identidade de e-mail no SES
-	input := &ses.VerifyEmailIdentityInput{
-		EmailAddress: aws.String(req.Email),
-	}
-	
-	_, err := s.sesClient.VerifyEmailIdentity(context.Background(), input)
-	if err != nil {
-		return nil, fmt.Errorf("falha ao verificar identidade do e-mail: %w", err)
+	if req.VerificationTemplate != "" {
+		if err := s.sendCustomVerificationEmail(req.Email, req.VerificationTemplate); err != nil {
+			return nil, err
+		}
+	} else {
+		// Verificar identidade de e-mail no SES
+		input := &ses.VerifyEmailIdentityInput{
+			EmailAddress: aws.String(req.Email),
+		}
+		
+		_, err := s.sesClient.VerifyEmailIdentity(context.Background(), input)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao verificar identidade do e-mail: %w", err)
+		}
 	}
 	
 	// Retornar resposta com status pendente
 	return &SenderResponse{
 		Email:            req.Email,
-		Name:             req.Name,
-		VerificationStatus: "PENDING",
-		RegisteredAt:     time.Now(),
+		SenderMetadata:   req.SenderMetadata,
+		VerificationStatus: string(types.VerificationStatusPending),
 	}, nil
 }
 
@@ -202,6 +272,11 @@ func (s *SESService) ListSenders() ([]SenderResponse, error) {
 		return nil, fmt.Errorf("falha ao obter atributos de verificação: %w", err)
 	}
 	
+	mailFrom, err := s.mailFromAttributes(result.Identities)
+	if err != nil {
+		return nil, err
+	}
+	
 	// Construir resposta
 	senders := make([]SenderResponse, 0, len(result.Identities))
 	for _, identity := range result.Identities {
@@ -213,13 +288,61 @@ func (s *SESService) ListSenders() ([]SenderResponse, error) {
 		senders = append(senders, SenderResponse{
 			Email:            identity,
 			VerificationStatus: status,
-			RegisteredAt:     time.Now(), // Na prática, seria armazenado em banco de dados
+			MailFrom:         mailFrom[identity],
 		})
 	}
 	
 	return senders, nil
 }
 
+// EmailIdentityStatuses obtém o status de verificação de todas as identidades
+// de e-mail da conta, percorrendo todas as páginas do ListIdentities
+func (s *SESService) EmailIdentityStatuses() (map[string]string, error) {
+	var identities []string
+	var nextToken *string
+	
+	for {
+		result, err := s.sesClient.ListIdentities(context.Background(), &ses.ListIdentitiesInput{
+			IdentityType: types.IdentityTypeEmailAddress,
+			MaxItems:     aws.Int32(1000),
+			NextToken:    nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("falha ao listar identidades: %w", err)
+		}
+		
+		identities = append(identities, result.Identities...)
+		
+		if result.NextToken == nil {
+			break
+		}
+		nextToken = result.NextToken
+	}
+	
+	// O GetIdentityVerificationAttributes aceita no máximo 100 identidades por chamada
+	statuses := make(map[string]string, len(identities))
+	for start := 0; start < len(identities); start += 100 {
+		end := min(start+100, len(identities))
+		
+		vResult, err := s.sesClient.GetIdentityVerificationAttributes(context.Background(), &ses.GetIdentityVerificationAttributesInput{
+			Identities: identities[start:end],
+		})
+		if err != nil {
+			return nil, fmt.Errorf("falha ao obter atributos de verificação: %w", err)
+		}
+		
+		for _, identity := range identities[start:end] {
+			status := "UNKNOWN"
+			if attr, ok := vResult.VerificationAttributes[identity]; ok {
+				status = string(attr.VerificationStatus)
+			}
+			statuses[identity] = status
+		}
+	}
+	
+	return statuses, nil
+}
+
 // GetSender obtém informações de um remetente específico
 func (s *SESService) GetSender(email string) (*SenderResponse, error) {
 	input := &ses.GetIdentityVerificationAttributesInput{
@@ -237,10 +360,15 @@ func (s *SESService) GetSender(email string) (*SenderResponse, error) {
 		return nil, nil // Remetente não encontrado
 	}
 	
+	mailFrom, err := s.GetMailFromAttributes(email)
+	if err != nil {
+		return nil, err
+	}
+	
 	return &SenderResponse{
 		Email:            email,
 		VerificationStatus: string(attr.VerificationStatus),
-		RegisteredAt:     time.Now(), // Na prática, seria armazenado em banco de dados
+		MailFrom:         mailFrom,
 	}, nil
 }
 
@@ -258,247 +386,100 @@ func (s *SESService) DeleteSender(email string) error {
 	return nil
 }
 
-// GetMetrics obtém métricas gerais de envio de e-mails
-func (s *SESService) GetMetrics(startDateStr, endDateStr string) (*MetricsResponse, error) {
-	// Definir período de consulta
-	startDate, endDate, err := parseDateRange(startDateStr, endDateStr)
-	if err != nil {
+// GetMetrics obtém métricas gerais de envio de e-mails, com a série temporal
+// na granularidade e no fuso horário informados
+func (s *SESService) GetMetrics(startDateStr, endDateStr string, opts SeriesOptions, compareTo string) (*MetricsResponse, error) {
+	if err := ValidateCompareTo(compareTo); err != nil {
 		return nil, err
 	}
 	
-	// Configurar período para CloudWatch
-	period := int32(86400) // 1 dia em segundos
-	
-	// Métricas a serem coletadas
-	metrics := map[string]string{
-		"Send":       "AWS/SES",
-		"Delivery":   "AWS/SES",
-		"Open":       "AWS/SES",
-		"Click":      "AWS/SES",
-		"Bounce":     "AWS/SES",
-		"Complaint":  "AWS/SES",
-	}
-	
-	// Coletar métricas do CloudWatch
-	metricData := make(map[string]int64)
-	for metricName, namespace := range metrics {
-		input := &cloudwatch.GetMetricStatisticsInput{
-			Namespace:  aws.String(namespace),
-			MetricName: aws.String(metricName),
-			StartTime:  &startDate,
-			EndTime:    &endDate,
-			Period:     &period,
-			Statistics: []cwtypes.Statistic{cwtypes.StatisticSum},
-		}
-		
-		result, err := s.cloudWatchClient.GetMetricStatistics(context.Background(), input)
-		if err != nil {
-			return nil, fmt.Errorf("falha ao obter métrica %s: %w", metricName, err)
-		}
-		
-		// Somar valores
-		var sum int64
-		for _, datapoint := range result.Datapoints {
-			sum += int64(*datapoint.Sum)
-		}
-		
-		metricData[metricName] = sum
-	}
-	
-	// Calcular taxas
-	sent := metricData["Send"]
-	delivered := metricData["Delivery"]
-	opened := metricData["Open"]
-	clicked := metricData["Click"]
-	bounced := metricData["Bounce"]
-	complaints := metricData["Complaint"]
-	
-	var deliveryRate, openRate, clickRate, bounceRate, complaintRate float64
-	
-	if sent > 0 {
-		deliveryRate = float64(delivered) / float64(sent) * 100
-		bounceRate = float64(bounced) / float64(sent) * 100
-		complaintRate = float64(complaints) / float64(sent) * 100
-	}
-	
-	if delivered > 0 {
-		openRate = float64(opened) / float64(delivered) * 100
-		clickRate = float64(clicked) / float64(delivered) * 100
-	}
-	
-	// Formatar período
-	periodStr := fmt.Sprintf("%s a %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
-	
-	return &MetricsResponse{
-		Period:          periodStr,
-		TotalSent:       sent,
-		TotalDelivered:  delivered,
-		TotalOpened:     opened,
-		TotalClicked:    clicked,
-		TotalBounced:    bounced,
-		TotalComplaints: complaints,
-		DeliveryRate:    deliveryRate,
-		OpenRate:        openRate,
-		ClickRate:       clickRate,
-		BounceRate:      bounceRate,
-		ComplaintRate:   complaintRate,
-	}, nil
-}
-
-// GetSenderMetrics obtém métricas de envio para um remetente específico
-func (s *SESService) GetSenderMetrics(email, startDateStr, endDateStr string) (*SenderMetricsResponse, error) {
-	// Verificar se o remetente existe
-	sender, err := s.GetSender(email)
+	// Definir período de consulta
+	startDate, endDate, err := parseDateRange(startDateStr, endDateStr, opts.Location)
 	if err != nil {
 		return nil, err
 	}
 	
-	if sender == nil {
-		return nil, nil // Remetente não encontrado
-	}
-	
-	// Definir período de consulta
-	startDate, endDate, err := parseDateRange(startDateStr, endDateStr)
+	result, series, err := s.metricsQuerier.QuerySeries(context.Background(), startDate, endDate, "", opts)
 	if err != nil {
 		return nil, err
 	}
 	
-	// Configurar período para CloudWatch
-	period := int32(86400) // 1 dia em segundos
+	rates := result.Rates()
 	
-	// Dimensão para filtrar pelo remetente específico
-	dimensions := []cwtypes.Dimension{
-		{
-			Name:  aws.String("Source"),
-			Value: aws.String(email),
-		},
+	// Formatar período
+	periodStr := fmt.Sprintf("%s a %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	
+	response := &MetricsResponse{
+		Period:          periodStr,
+		TotalSent:       result.Totals["send"],
+		TotalDelivered:  result.Totals["delivery"],
+		TotalOpened:     result.Totals["open"],
+		TotalClicked:    result.Totals["click"],
+		TotalBounced:    result.Totals["bounce"],
+		TotalComplaints: result.Totals["complaint"],
+		DeliveryRate:    rates.DeliveryRate,
+		OpenRate:        rates.OpenRate,
+		ClickRate:       rates.ClickRate,
+		BounceRate:      rates.BounceRate,
+		ComplaintRate:   rates.ComplaintRate,
+		Granularity:     opts.Granularity,
+		Timezone:        opts.Location.String(),
+		Series:          series,
+		Trend:           seriesTrends(series),
 	}
 	
-	// Métricas a serem coletadas
-	metrics := map[string]string{
-		"Send":       "AWS/SES",
-		"Delivery":   "AWS/SES",
-		"Open":       "AWS/SES",
-		"Click":      "AWS/SES",
-		"Bounce":     "AWS/SES",
-		"Complaint":  "AWS/SES",
-	}
-	
-	// Coletar métricas do CloudWatch
-	metricData := make(map[string]int64)
-	dailyMetrics := make(map[string]map[string]int64)
-	
-	for metricName, namespace := range metrics {
-		input := &cloudwatch.GetMetricStatisticsInput{
-			Namespace:  aws.String(namespace),
-			MetricName: aws.String(metricName),
-			StartTime:  &startDate,
-			EndTime:    &endDate,
-			Period:     &period,
-			Statistics: []cwtypes.Statistic{cwtypes.StatisticSum},
-			Dimensions: dimensions,
-		}
-		
-		result, err := s.cloudWatchClient.GetMetricStatistics(context.Background(), input)
+	// Consultar o período equivalente de comparação, se solicitado
+	if compareTo != "" {
+		prevStart, prevEnd := comparisonRange(compareTo, startDate, endDate)
+		previous, previousSeries, err := s.metricsQuerier.QuerySeries(context.Background(), prevStart, prevEnd, "", opts)
 		if err != nil {
-			return nil, fmt.Errorf("falha ao obter métrica %s: %w", metricName, err)
-		}
-		
-		// Somar valores totais
-		var sum int64
-		for _, datapoint := range result.Datapoints {
-			sum += int64(*datapoint.Sum)
-			
-			// Agrupar por dia
-			dateKey := datapoint.Timestamp.Format("2006-01-02")
-			if _, ok := dailyMetrics[dateKey]; !ok {
-				dailyMetrics[dateKey] = make(map[string]int64)
-			}
-			dailyMetrics[dateKey][metricName] += int64(*datapoint.Sum)
+			return nil, err
 		}
 		
-		metricData[metricName] = sum
+		prevPeriod := fmt.Sprintf("%s a %s", prevStart.Format("2006-01-02"), prevEnd.Format("2006-01-02"))
+		response.Comparison = newMetricsComparison(compareTo, prevPeriod, result, previous, previousSeries)
 	}
 	
-	// Calcular taxas globais
-	sent := metricData["Send"]
-	delivered := metricData["Delivery"]
-	opened := metricData["Open"]
-	clicked := metricData["Click"]
-	bounced := metricData["Bounce"]
-	complaints := metricData["Complaint"]
-	
-	var deliveryRate, openRate, clickRate, bounceRate, complaintRate float64
-	
-	if sent > 0 {
-		deliveryRate = float64(delivered) / float64(sent) * 100
-		bounceRate = float64(bounced) / float64(sent) * 100
-		complaintRate = float64(complaints) / float64(sent) * 100
-	}
-	
-	if delivered > 0 {
-		openRate = float64(opened) / float64(delivered) * 100
-		clickRate = float64(clicked) / float64(delivered) * 100
-	}
+	return response, nil
+}
+
+// RunMetricsExport atualiza periodicamente os gauges do Prometheus com as
+// métricas gerais do CloudWatch, até o contexto ser cancelado
+func (s *SESService) RunMetricsExport(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 	
-	// Preparar dados diários
-	daily := make([]DailyMetrics, 0, len(dailyMetrics))
-	for date, metrics := range dailyMetrics {
-		dailySent := metrics["Send"]
-		dailyDelivered := metrics["Delivery"]
-		dailyOpened := metrics["Open"]
-		dailyClicked := metrics["Click"]
-		dailyBounced := metrics["Bounce"]
-		dailyComplaints := metrics["Complaint"]
-		
-		var dailyDeliveryRate, dailyOpenRate, dailyClickRate float64
-		
-		if dailySent > 0 {
-			dailyDeliveryRate = float64(dailyDelivered) / float64(dailySent) * 100
+	for {
+		m, err := s.GetMetrics("", "", SeriesOptions{Granularity: GranularityDay, Location: time.UTC}, "")
+		if err != nil {
+			log.Printf("Falha ao exportar métricas do CloudWatch: %v", err)
+		} else {
+			metrics.SetCloudWatchMetrics(map[string]float64{
+				"delivery_rate":  m.DeliveryRate,
+				"open_rate":      m.OpenRate,
+				"click_rate":     m.ClickRate,
+				"bounce_rate":    m.BounceRate,
+				"complaint_rate": m.ComplaintRate,
+			}, map[string]float64{
+				"sent":       float64(m.TotalSent),
+				"delivered":  float64(m.TotalDelivered),
+				"opened":     float64(m.TotalOpened),
+				"clicked":    float64(m.TotalClicked),
+				"bounced":    float64(m.TotalBounced),
+				"complaints": float64(m.TotalComplaints),
+			})
 		}
 		
-		if dailyDelivered > 0 {
-			dailyOpenRate = float64(dailyOpened) / float64(dailyDelivered) * 100
-			dailyClickRate = float64(dailyClicked) / float64(dailyDelivered) * 100
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
-		
-		daily = append(daily, DailyMetrics{
-			Date:         date,
-			Sent:         dailySent,
-			Delivered:    dailyDelivered,
-			Opened:       dailyOpened,
-			Clicked:      dailyClicked,
-			Bounced:      dailyBounced,
-			Complaints:   dailyComplaints,
-			DeliveryRate: dailyDeliveryRate,
-			OpenRate:     dailyOpenRate,
-			ClickRate:    dailyClickRate,
-		})
 	}
-	
-	// Formatar período
-	periodStr := fmt.Sprintf("%s a %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
-	
-	return &SenderMetricsResponse{
-		Email:           email,
-		Period:          periodStr,
-		TotalSent:       sent,
-		TotalDelivered:  delivered,
-		TotalOpened:     opened,
-		TotalClicked:    clicked,
-		TotalBounced:    bounced,
-		TotalComplaints: complaints,
-		DeliveryRate:    deliveryRate,
-		OpenRate:        openRate,
-		ClickRate:       clickRate,
-		BounceRate:      bounceRate,
-		ComplaintRate:   complaintRate,
-		DailyStats:      daily,
-	}, nil
 }
 
-// parseDateRange analisa e valida os parâmetros de data
-func parseDateRange(startDateStr, endDateStr string) (time.Time, time.Time, error) {
+// parseDateRange analisa e valida os parâmetros de data, interpretados no fuso horário informado
+func parseDateRange(startDateStr, endDateStr string, loc *time.Location) (time.Time, time.Time, error) {
 	var startDate, endDate time.Time
 	var err error
 	
@@ -506,7 +487,7 @@ func parseDateRange(startDateStr, endDateStr string) (time.Time, time.Time, erro
 	if startDateStr == "" {
 		startDate = time.Now().AddDate(0, 0, -30)
 	} else {
-		startDate, err = time.Parse("2006-01-02", startDateStr)
+		startDate, err = time.ParseInLocation("2006-01-02", startDateStr, loc)
 		if err != nil {
 			return time.Time{}, time.Time{}, fmt.Errorf("formato de data inicial inválido (use YYYY-MM-DD): %w", err)
 		}
@@ -516,7 +497,7 @@ func parseDateRange(startDateStr, endDateStr string) (time.Time, time.Time, erro
 	if endDateStr == "" {
 		endDate = time.Now()
 	} else {
-		endDate, err = time.Parse("2006-01-02", endDateStr)
+		endDate, err = time.ParseInLocation("2006-01-02", endDateStr, loc)
 		if err != nil {
 			return time.Time{}, time.Time{}, fmt.Errorf("formato de data final inválido (use YYYY-MM-DD): %w", err)
 		}
@@ -645,18 +626,100 @@ func (s *SESService) DeleteTemplate(id string) error {
 
 // SendEmail envia um e-mail utilizando o Amazon SES
 func (s *SESService) SendEmail(req EmailRequest) (*EmailResponse, error) {
-	// Verificar se o remetente existe e está verificado
-	sender, err := s.GetSender(req.From)
+	// Os endereços são interpretados antes das verificações, que usam apenas o e-mail do remetente
+	var result *EmailResponse
+	err := req.Normalize()
+	if err == nil {
+		result, err = s.sendEmail(req)
+	}
+	
+	status := "success"
 	if err != nil {
-		return nil, fmt.Errorf("falha ao verificar remetente: %w", err)
+		status = "error"
 	}
+	metrics.ObserveSend(s.metricsSender(req.From), status, "ses")
+	
+	return result, err
+}
+
+// metricsSender retorna o rótulo de remetente das métricas: o e-mail dos
+// remetentes cadastrados ou "other", para não criar uma série por endereço
+// informado nos envios
+func (s *SESService) metricsSender(email string) string {
+	if s.senderLookup == nil {
+		return "other"
+	}
+	
+	sender, err := s.senderLookup(email)
+	if err != nil || sender == nil {
+		return "other"
+	}
+	return strings.ToLower(email)
+}
 
+// AddSendGuard registra uma verificação executada antes de cada envio. Deve
+// ser chamado durante a inicialização, antes de o serviço enviar e-mails.
+func (s *SESService) AddSendGuard(guard SendGuard) {
+	s.sendGuards = append(s.sendGuards, guard)
+}
+
+// SetSenderLookup define a consulta aos dados cadastrados dos remetentes.
+// Deve ser chamado durante a inicialização, antes de o serviço enviar e-mails.
+func (s *SESService) SetSenderLookup(lookup SenderLookup) {
+	s.senderLookup = lookup
+}
+
+// applySenderDefaults preenche os dados não informados no envio com os do
+// cadastro do remetente (nome de exibição, Reply-To e configuration set)
+func (s *SESService) applySenderDefaults(req *EmailRequest) error {
+	if s.senderLookup == nil {
+		return nil
+	}
+	
+	sender, err := s.senderLookup(req.From)
+	if err != nil {
+		return fmt.Errorf("falha ao obter dados do remetente: %w", err)
+	}
+	
 	if sender == nil {
-		return nil, fmt.Errorf("remetente não encontrado")
+		return nil
+	}
+	
+	if req.FromName == "" {
+		req.FromName = sender.Name
+	}
+	if len(req.ReplyTo) == 0 && sender.ReplyTo != "" {
+		req.ReplyTo = []string{sender.ReplyTo}
+	}
+	if req.ConfigurationSet == "" {
+		req.ConfigurationSet = sender.ConfigurationSet
 	}
+	
+	return nil
+}
 
-	if sender.VerificationStatus != "Success" {
-		return nil, fmt.Errorf("remetente não verificado. Status atual: %s", sender.VerificationStatus)
+// sendEmail valida o remetente e envia o e-mail pelo formato adequado (simples, com anexos ou template)
+func (s *SESService) sendEmail(req EmailRequest) (*EmailResponse, error) {
+	for _, guard := range s.sendGuards {
+		if err := guard(req); err != nil {
+			return nil, err
+		}
+	}
+	
+	// Verificar se o remetente (ou o seu domínio) existe e está verificado
+	if err := s.checkSenderVerified(req.From); err != nil {
+		return nil, err
+	}
+	
+	// O Return-Path também precisa ser uma identidade verificada no SES
+	if req.ReturnPath != "" {
+		if err := s.checkSenderVerified(req.ReturnPath); err != nil {
+			return nil, fmt.Errorf("Return-Path inválido: %s: %w", req.ReturnPath, err)
+		}
+	}
+	
+	if err := s.applySenderDefaults(&req); err != nil {
+		return nil, err
 	}
 
 	// Se estiver usando um template
@@ -711,19 +774,24 @@ func (s *SESService) SendEmail(req EmailRequest) (*EmailResponse, error) {
 	}
 	emailContent.Simple.Subject = subject
 
-	// Criar anexos se houver
-	if len(req.Attachments) > 0 {
-		// Convertemos para mensagem MIME com anexos
+	// Anexos e cabeçalhos personalizados exigem uma mensagem raw
+	if len(req.Attachments) > 0 || len(req.Headers) > 0 {
+		// Convertemos para mensagem MIME
 		rawMessage, err := s.createRawEmailWithAttachments(req)
 		if err != nil {
 			return nil, fmt.Errorf("falha ao criar e-mail com anexos: %w", err)
 		}
 		
-		// Enviar e-mail raw
+		// Enviar e-mail raw; o gomail não escreve o cabeçalho Bcc, então os
+		// destinatários vão no envelope
 		sendRawEmailInput := &ses.SendRawEmailInput{
+			Source:       aws.String(req.FormattedFrom()),
+			Destinations: req.envelopeDestinations(),
 			RawMessage: &types.RawMessage{
 				Data: rawMessage,
 			},
+			Tags:                 req.messageTags(),
+			ConfigurationSetName: req.configurationSetName(),
 		}
 		
 		result, err := s.sesClient.SendRawEmail(context.Background(), sendRawEmailInput)
@@ -739,6 +807,8 @@ func (s *SESService) SendEmail(req EmailRequest) (*EmailResponse, error) {
 			SentAt:     time.Now(),
 			StatusCode: 200,
 			Status:     "success",
+			Tags:       req.Tags,
+			ConfigurationSet: req.ConfigurationSet,
 		}, nil
 	}
 
@@ -757,9 +827,16 @@ func (s *SESService) SendEmail(req EmailRequest) (*EmailResponse, error) {
 
 	// Criar input para envio
 	input := &ses.SendEmailInput{
-		FromEmailAddress: aws.String(req.From),
+		FromEmailAddress: aws.String(req.FormattedFrom()),
 		Destination:      destination,
 		Content:          emailContent,
+		ReplyToAddresses: req.ReplyTo,
+		Tags:             req.messageTags(),
+		ConfigurationSetName: req.configurationSetName(),
+	}
+	
+	if req.ReturnPath != "" {
+		input.ReturnPath = aws.String(req.ReturnPath)
 	}
 
 	// Enviar e-mail
@@ -777,18 +854,25 @@ func (s *SESService) SendEmail(req EmailRequest) (*EmailResponse, error) {
 		SentAt:     time.Now(),
 		StatusCode: 200,
 		Status:     "success",
+		Tags:       req.Tags,
+		ConfigurationSet: req.ConfigurationSet,
 	}, nil
 }
 
-// createRawEmailWithAttachments cria uma mensagem de e-mail raw com anexos
+// createRawEmailWithAttachments cria uma mensagem de e-mail raw com os anexos
+// e os cabeçalhos personalizados
 func (s *SESService) createRawEmailWithAttachments(req EmailRequest) ([]byte, error) {
 	// Criar uma nova mensagem de e-mail
 	m := gomail.NewMessage()
 
 	// Definir cabeçalhos básicos
-	m.SetHeader("From", req.From)
+	m.SetHeader("From", req.FormattedFrom())
 	m.SetHeader("To", req.To...)
 	
+	if len(req.ReplyTo) > 0 {
+		m.SetHeader("Reply-To", req.ReplyTo...)
+	}
+	
 	if len(req.Cc) > 0 {
 		m.SetHeader("Cc", req.Cc...)
 	}
@@ -797,6 +881,11 @@ func (s *SESService) createRawEmailWithAttachments(req EmailRequest) ([]byte, er
 		m.SetHeader("Bcc", req.Bcc...)
 	}
 	
+	// Cabeçalhos personalizados, já validados em Normalize
+	for name, value := range req.Headers {
+		m.SetHeader(name, value)
+	}
+	
 	m.SetHeader("Subject", req.Subject)
 	
 	// Definir corpo de e-mail
@@ -866,12 +955,25 @@ func (s *SESService) sendEmailWithTemplate(req EmailRequest) (*EmailResponse, er
 		destination.BccAddresses = req.Bcc
 	}
 
+	// O SendTemplatedEmail não aceita cabeçalhos personalizados: o template é
+	// renderizado pelo SES e enviado como mensagem raw
+	if len(req.Headers) > 0 {
+		return s.sendRenderedTemplate(req, templateData)
+	}
+
 	// Criar input para envio com template
 	input := &ses.SendTemplatedEmailInput{
-		Source:       aws.String(req.From),
-		Destination:  destination,
-		Template:     aws.String(req.TemplateId),
-		TemplateData: aws.String(templateData),
+		Source:           aws.String(req.FormattedFrom()),
+		Destination:      destination,
+		Template:         aws.String(req.TemplateId),
+		TemplateData:     aws.String(templateData),
+		ReplyToAddresses: req.ReplyTo,
+		Tags:             req.messageTags(),
+		ConfigurationSetName: req.configurationSetName(),
+	}
+	
+	if req.ReturnPath != "" {
+		input.ReturnPath = aws.String(req.ReturnPath)
 	}
 
 	// Enviar e-mail
@@ -889,6 +991,46 @@ func (s *SESService) sendEmailWithTemplate(req EmailRequest) (*EmailResponse, er
 		SentAt:     time.Now(),
 		StatusCode: 200,
 		Status:     "success",
+		Tags:       req.Tags,
+		ConfigurationSet: req.ConfigurationSet,
+	}, nil
+}
+
+// sendRenderedTemplate renderiza o template no SES e envia o resultado como
+// mensagem raw, acrescentando os cabeçalhos de endereçamento e personalizados
+func (s *SESService) sendRenderedTemplate(req EmailRequest, templateData string) (*EmailResponse, error) {
+	rendered, err := s.sesClient.TestRenderTemplate(context.Background(), &ses.TestRenderTemplateInput{
+		TemplateName: aws.String(req.TemplateId),
+		TemplateData: aws.String(templateData),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao renderizar template: %w", err)
+	}
+	
+	// Os destinatários do envelope incluem a cópia oculta, que não aparece nos cabeçalhos
+	result, err := s.sesClient.SendRawEmail(context.Background(), &ses.SendRawEmailInput{
+		Source:       aws.String(req.FormattedFrom()),
+		Destinations: req.envelopeDestinations(),
+		RawMessage: &types.RawMessage{
+			Data: []byte(req.rawHeaders() + aws.ToString(rendered.RenderedTemplate)),
+		},
+		Tags:                 req.messageTags(),
+		ConfigurationSetName: req.configurationSetName(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao enviar e-mail com template: %w", err)
+	}
+	
+	return &EmailResponse{
+		MessageID:  *result.MessageId,
+		From:       req.From,
+		To:         req.To,
+		Subject:    "[Template: " + req.TemplateId + "]",
+		SentAt:     time.Now(),
+		StatusCode: 200,
+		Status:     "success",
+		Tags:       req.Tags,
+		ConfigurationSet: req.ConfigurationSet,
 	}, nil
 }
 
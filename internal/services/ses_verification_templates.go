@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+)
+
+// maxVerificationTemplateContent é o tamanho máximo do conteúdo de um template de verificação aceito pelo SES
+const maxVerificationTemplateContent = 10 * 1024 * 1024
+
+// verificationTemplateName restringe os nomes de template aos caracteres aceitos pelo SES
+var verificationTemplateName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// VerificationTemplateRequest representa os dados de um template do e-mail de
+// verificação de remetentes. O conteúdo é HTML e os endereços de
+// redirecionamento recebem o destinatário após a confirmação ou a falha.
+type VerificationTemplateRequest struct {
+	Name               string `json:"name"`
+	FromEmailAddress   string `json:"fromEmailAddress" binding:"required,email"`
+	Subject            string `json:"subject" binding:"required"`
+	Content            string `json:"content" binding:"required"`
+	SuccessRedirectURL string `json:"successRedirectUrl" binding:"required"`
+	FailureRedirectURL string `json:"failureRedirectUrl" binding:"required"`
+}
+
+// Validate verifica o nome, o tamanho do conteúdo e os endereços de redirecionamento
+func (r *VerificationTemplateRequest) Validate() error {
+	if !verificationTemplateName.MatchString(r.Name) {
+		return fmt.Errorf("nome de template inválido: use até 64 letras, números, '-' ou '_'")
+	}
+
+	if len(r.Content) > maxVerificationTemplateContent {
+		return fmt.Errorf("conteúdo inválido: o limite do SES é de 10 MB")
+	}
+
+	for _, target := range []string{r.SuccessRedirectURL, r.FailureRedirectURL} {
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("URL de redirecionamento inválida: %s", target)
+		}
+	}
+
+	return nil
+}
+
+// VerificationTemplate representa um template do e-mail de verificação. O
+// conteúdo não é retornado na listagem.
+type VerificationTemplate struct {
+	Name               string `json:"name"`
+	FromEmailAddress   string `json:"fromEmailAddress"`
+	Subject            string `json:"subject"`
+	Content            string `json:"content,omitempty"`
+	SuccessRedirectURL string `json:"successRedirectUrl"`
+	FailureRedirectURL string `json:"failureRedirectUrl"`
+}
+
+// CreateVerificationTemplate cria um template do e-mail de verificação
+func (s *SESService) CreateVerificationTemplate(req VerificationTemplateRequest) (*VerificationTemplate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.sesClient.CreateCustomVerificationEmailTemplate(context.Background(), &ses.CreateCustomVerificationEmailTemplateInput{
+		TemplateName:          aws.String(req.Name),
+		FromEmailAddress:      aws.String(req.FromEmailAddress),
+		TemplateSubject:       aws.String(req.Subject),
+		TemplateContent:       aws.String(req.Content),
+		SuccessRedirectionURL: aws.String(req.SuccessRedirectURL),
+		FailureRedirectionURL: aws.String(req.FailureRedirectURL),
+	})
+	if err != nil {
+		var exists *types.CustomVerificationEmailTemplateAlreadyExistsException
+		if errors.As(err, &exists) {
+			return nil, fmt.Errorf("template de verificação já existe: %s", req.Name)
+		}
+		return nil, fmt.Errorf("falha ao criar template de verificação: %w", err)
+	}
+
+	return newVerificationTemplate(req), nil
+}
+
+// UpdateVerificationTemplate substitui os dados de um template do e-mail de verificação
+func (s *SESService) UpdateVerificationTemplate(req VerificationTemplateRequest) (*VerificationTemplate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	_, err := s.sesClient.UpdateCustomVerificationEmailTemplate(context.Background(), &ses.UpdateCustomVerificationEmailTemplateInput{
+		TemplateName:          aws.String(req.Name),
+		FromEmailAddress:      aws.String(req.FromEmailAddress),
+		TemplateSubject:       aws.String(req.Subject),
+		TemplateContent:       aws.String(req.Content),
+		SuccessRedirectionURL: aws.String(req.SuccessRedirectURL),
+		FailureRedirectionURL: aws.String(req.FailureRedirectURL),
+	})
+	if err != nil {
+		if isVerificationTemplateNotFound(err) {
+			return nil, nil // Template não encontrado
+		}
+		return nil, fmt.Errorf("falha ao atualizar template de verificação: %w", err)
+	}
+
+	return newVerificationTemplate(req), nil
+}
+
+// ListVerificationTemplates lista os templates do e-mail de verificação, sem o conteúdo
+func (s *SESService) ListVerificationTemplates() ([]VerificationTemplate, error) {
+	templates := []VerificationTemplate{}
+	var nextToken *string
+
+	for {
+		result, err := s.sesClient.ListCustomVerificationEmailTemplates(context.Background(), &ses.ListCustomVerificationEmailTemplatesInput{
+			MaxResults: aws.Int32(50),
+			NextToken:  nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("falha ao listar templates de verificação: %w", err)
+		}
+
+		for _, t := range result.CustomVerificationEmailTemplates {
+			templates = append(templates, VerificationTemplate{
+				Name:               aws.ToString(t.TemplateName),
+				FromEmailAddress:   aws.ToString(t.FromEmailAddress),
+				Subject:            aws.ToString(t.TemplateSubject),
+				SuccessRedirectURL: aws.ToString(t.SuccessRedirectionURL),
+				FailureRedirectURL: aws.ToString(t.FailureRedirectionURL),
+			})
+		}
+
+		if result.NextToken == nil {
+			break
+		}
+		nextToken = result.NextToken
+	}
+
+	return templates, nil
+}
+
+// GetVerificationTemplate obtém um template do e-mail de verificação,
+// retornando nil se ele não existir
+func (s *SESService) GetVerificationTemplate(name string) (*VerificationTemplate, error) {
+	result, err := s.sesClient.GetCustomVerificationEmailTemplate(context.Background(), &ses.GetCustomVerificationEmailTemplateInput{
+		TemplateName: aws.String(name),
+	})
+	if err != nil {
+		if isVerificationTemplateNotFound(err) {
+			return nil, nil // Template não encontrado
+		}
+		return nil, fmt.Errorf("falha ao obter template de verificação: %w", err)
+	}
+
+	return &VerificationTemplate{
+		Name:               aws.ToString(result.TemplateName),
+		FromEmailAddress:   aws.ToString(result.FromEmailAddress),
+		Subject:            aws.ToString(result.TemplateSubject),
+		Content:            aws.ToString(result.TemplateContent),
+		SuccessRedirectURL: aws.ToString(result.SuccessRedirectionURL),
+		FailureRedirectURL: aws.ToString(result.FailureRedirectionURL),
+	}, nil
+}
+
+// DeleteVerificationTemplate remove um template do e-mail de verificação
+func (s *SESService) DeleteVerificationTemplate(name string) error {
+	_, err := s.sesClient.DeleteCustomVerificationEmailTemplate(context.Background(), &ses.DeleteCustomVerificationEmailTemplateInput{
+		TemplateName: aws.String(name),
+	})
+	if err != nil {
+		return fmt.Errorf("falha ao remover template de verificação: %w", err)
+	}
+
+	return nil
+}
+
+// sendCustomVerificationEmail envia o e-mail de verificação do remetente com o template informado
+func (s *SESService) sendCustomVerificationEmail(email, templateName string) error {
+	_, err := s.sesClient.SendCustomVerificationEmail(context.Background(), &ses.SendCustomVerificationEmailInput{
+		EmailAddress: aws.String(email),
+		TemplateName: aws.String(templateName),
+	})
+	if err != nil {
+		if isVerificationTemplateNotFound(err) {
+			return fmt.Errorf("template de verificação não encontrado: %s", templateName)
+		}
+		var fromNotVerified *types.FromEmailAddressNotVerifiedException
+		if errors.As(err, &fromNotVerified) {
+			return fmt.Errorf("remetente do template de verificação não verificado: %s", templateName)
+		}
+		return fmt.Errorf("falha ao enviar e-mail de verificação personalizado: %w", err)
+	}
+
+	return nil
+}
+
+// isVerificationTemplateNotFound indica se o erro do SES é de template de verificação inexistente
+func isVerificationTemplateNotFound(err error) bool {
+	var notFound *types.CustomVerificationEmailTemplateDoesNotExistException
+	return errors.As(err, &notFound)
+}
+
+// newVerificationTemplate converte a requisição na resposta da API
+func newVerificationTemplate(req VerificationTemplateRequest) *VerificationTemplate {
+	return &VerificationTemplate{
+		Name:               req.Name,
+		FromEmailAddress:   req.FromEmailAddress,
+		Subject:            req.Subject,
+		Content:            req.Content,
+		SuccessRedirectURL: req.SuccessRedirectURL,
+		FailureRedirectURL: req.FailureRedirectURL,
+	}
+}
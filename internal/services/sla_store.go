@@ -0,0 +1,56 @@
+package services
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+const slaConfigBucket = "sla_config"
+
+// slaConfigKey é a chave da configuração de SLA no bucket
+const slaConfigKey = "config"
+
+// SLAStore define a persistência da configuração de SLA de entrega
+type SLAStore interface {
+	// Get retorna a configuração gravada, ou nil se ainda não foi definida
+	Get() (*SLAConfig, error)
+	// Save grava (ou substitui) a configuração
+	Save(config SLAConfig) error
+}
+
+// BoltSLAStore persiste a configuração de SLA no banco embarcado
+type BoltSLAStore struct {
+	db *bolt.DB
+}
+
+// NewBoltSLAStore cria uma nova instância do BoltSLAStore
+func NewBoltSLAStore(db *bolt.DB) (*BoltSLAStore, error) {
+	if err := ensureBuckets(db, slaConfigBucket); err != nil {
+		return nil, err
+	}
+
+	return &BoltSLAStore{db: db}, nil
+}
+
+// Get retorna a configuração gravada, ou nil se ainda não foi definida
+func (s *BoltSLAStore) Get() (*SLAConfig, error) {
+	var config SLAConfig
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket([]byte(slaConfigBucket)), slaConfigKey, &config)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Save grava (ou substitui) a configuração
+func (s *BoltSLAStore) Save(config SLAConfig) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket([]byte(slaConfigBucket)), slaConfigKey, config)
+	})
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSigningCertSize limita o tamanho do certificado de assinatura baixado do SNS
+const maxSigningCertSize = 64 * 1024
+
+// SNSVerifier verifica a autenticidade das mensagens entregues pelo Amazon
+// SNS: o tópico deve estar na lista autorizada e a assinatura deve ser
+// válida para o certificado publicado pelo SNS da região do tópico
+type SNSVerifier struct {
+	topics     []string
+	httpClient *http.Client
+	certs      map[string]*x509.Certificate
+	mutex      sync.Mutex
+}
+
+// NewSNSVerifier cria uma nova instância do SNSVerifier. Sem tópicos
+// autorizados, todas as mensagens são recusadas.
+func NewSNSVerifier(topics []string) *SNSVerifier {
+	return &SNSVerifier{
+		topics:     topics,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		certs:      make(map[string]*x509.Certificate),
+	}
+}
+
+// Verify verifica o tópico, a versão e a assinatura da mensagem
+func (v *SNSVerifier) Verify(msg SNSMessage) error {
+	if !slices.Contains(v.topics, msg.TopicArn) {
+		return fmt.Errorf("tópico SNS não autorizado: %s", msg.TopicArn)
+	}
+
+	var algorithm x509.SignatureAlgorithm
+	switch msg.SignatureVersion {
+	case "1":
+		algorithm = x509.SHA1WithRSA
+	case "2":
+		algorithm = x509.SHA256WithRSA
+	default:
+		return fmt.Errorf("assinatura SNS inválida: versão %q não suportada", msg.SignatureVersion)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return fmt.Errorf("assinatura SNS inválida: %w", err)
+	}
+
+	canonical, err := msg.canonicalString()
+	if err != nil {
+		return err
+	}
+
+	cert, err := v.signingCert(msg)
+	if err != nil {
+		return err
+	}
+
+	if err := cert.CheckSignature(algorithm, []byte(canonical), signature); err != nil {
+		return fmt.Errorf("assinatura SNS inválida: %w", err)
+	}
+	return nil
+}
+
+// signingCert obtém o certificado de assinatura, aceitando apenas o endpoint
+// do SNS na região do tópico. Os certificados baixados ficam em cache.
+func (v *SNSVerifier) signingCert(msg SNSMessage) (*x509.Certificate, error) {
+	// O ARN do tópico tem o formato arn:aws:sns:<região>:<conta>:<nome>
+	parts := strings.Split(msg.TopicArn, ":")
+	if len(parts) < 6 || parts[2] != "sns" {
+		return nil, fmt.Errorf("tópico SNS inválido: %s", msg.TopicArn)
+	}
+
+	u, err := url.Parse(msg.SigningCertURL)
+	if err != nil || u.Scheme != "https" || u.Host != "sns."+parts[3]+".amazonaws.com" || !strings.HasSuffix(u.Path, ".pem") {
+		return nil, fmt.Errorf("URL do certificado de assinatura SNS inválida: %s", msg.SigningCertURL)
+	}
+
+	v.mutex.Lock()
+	cert, cached := v.certs[u.String()]
+	v.mutex.Unlock()
+	if cached {
+		return cert, nil
+	}
+
+	resp, err := v.httpClient.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter certificado de assinatura SNS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("falha ao obter certificado de assinatura SNS: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSigningCertSize))
+	if err != nil {
+		return nil, fmt.Errorf("falha ao obter certificado de assinatura SNS: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("certificado de assinatura SNS inválido")
+	}
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("certificado de assinatura SNS inválido: %w", err)
+	}
+
+	v.mutex.Lock()
+	v.certs[u.String()] = cert
+	v.mutex.Unlock()
+
+	return cert, nil
+}
+
+// canonicalString monta o texto assinado pelo SNS, com os campos do tipo da
+// mensagem em ordem alfabética no formato "Nome\nValor\n"
+func (m SNSMessage) canonicalString() (string, error) {
+	var fields [][2]string
+	switch m.Type {
+	case "Notification":
+		fields = [][2]string{{"Message", m.Message}, {"MessageId", m.MessageId}}
+		// Subject só é assinado quando presente
+		if m.Subject != "" {
+			fields = append(fields, [2]string{"Subject", m.Subject})
+		}
+		fields = append(fields, [][2]string{{"Timestamp", m.Timestamp}, {"TopicArn", m.TopicArn}, {"Type", m.Type}}...)
+	case "SubscriptionConfirmation", "UnsubscribeConfirmation":
+		fields = [][2]string{
+			{"Message", m.Message},
+			{"MessageId", m.MessageId},
+			{"SubscribeURL", m.SubscribeURL},
+			{"Timestamp", m.Timestamp},
+			{"Token", m.Token},
+			{"TopicArn", m.TopicArn},
+			{"Type", m.Type},
+		}
+	default:
+		return "", fmt.Errorf("tipo de mensagem SNS desconhecido: %s", m.Type)
+	}
+
+	var b strings.Builder
+	for _, field := range fields {
+		b.WriteString(field[0] + "\n" + field[1] + "\n")
+	}
+	return b.String(), nil
+}
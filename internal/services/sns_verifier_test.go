@@ -0,0 +1,192 @@
+package services
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+)
+
+const (
+	testTopicArn = "arn:aws:sns:us-east-1:123456789012:ses-eventos"
+	testCertURL  = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-teste.pem"
+)
+
+func TestSNSMessageCanonicalString(t *testing.T) {
+	notification := SNSMessage{
+		Type:      "Notification",
+		MessageId: "22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324",
+		TopicArn:  testTopicArn,
+		Message:   `{"eventType":"Delivery"}`,
+		Timestamp: "2026-03-01T12:00:00.000Z",
+		// Campos não assinados
+		Token:            "ignorado",
+		SubscribeURL:     "https://ignorado",
+		SignatureVersion: "1",
+		Signature:        "ignorado",
+		SigningCertURL:   testCertURL,
+	}
+	withSubject := notification
+	withSubject.Subject = "Evento do SES"
+
+	confirmation := SNSMessage{
+		Type:         "SubscriptionConfirmation",
+		MessageId:    "165545c9-2a5c-472c-8df2-7ff2be2b3b1b",
+		TopicArn:     testTopicArn,
+		Subject:      "ignorado",
+		Message:      "You have chosen to subscribe to the topic.",
+		Timestamp:    "2026-03-01T12:00:00.000Z",
+		Token:        "2336412f37fb687f5d51e6e241d09c80",
+		SubscribeURL: "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription",
+	}
+	unsubscribe := confirmation
+	unsubscribe.Type = "UnsubscribeConfirmation"
+
+	tests := []struct {
+		name string
+		msg  SNSMessage
+		want string
+		err  string
+	}{
+		{
+			name: "notificação sem Subject",
+			msg:  notification,
+			want: "Message\n{\"eventType\":\"Delivery\"}\n" +
+				"MessageId\n22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324\n" +
+				"Timestamp\n2026-03-01T12:00:00.000Z\n" +
+				"TopicArn\narn:aws:sns:us-east-1:123456789012:ses-eventos\n" +
+				"Type\nNotification\n",
+		},
+		{
+			name: "notificação com Subject",
+			msg:  withSubject,
+			want: "Message\n{\"eventType\":\"Delivery\"}\n" +
+				"MessageId\n22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324\n" +
+				"Subject\nEvento do SES\n" +
+				"Timestamp\n2026-03-01T12:00:00.000Z\n" +
+				"TopicArn\narn:aws:sns:us-east-1:123456789012:ses-eventos\n" +
+				"Type\nNotification\n",
+		},
+		{
+			name: "confirmação de inscrição",
+			msg:  confirmation,
+			want: "Message\nYou have chosen to subscribe to the topic.\n" +
+				"MessageId\n165545c9-2a5c-472c-8df2-7ff2be2b3b1b\n" +
+				"SubscribeURL\nhttps://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription\n" +
+				"Timestamp\n2026-03-01T12:00:00.000Z\n" +
+				"Token\n2336412f37fb687f5d51e6e241d09c80\n" +
+				"TopicArn\narn:aws:sns:us-east-1:123456789012:ses-eventos\n" +
+				"Type\nSubscriptionConfirmation\n",
+		},
+		{
+			name: "cancelamento de inscrição",
+			msg:  unsubscribe,
+			want: "Message\nYou have chosen to subscribe to the topic.\n" +
+				"MessageId\n165545c9-2a5c-472c-8df2-7ff2be2b3b1b\n" +
+				"SubscribeURL\nhttps://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription\n" +
+				"Timestamp\n2026-03-01T12:00:00.000Z\n" +
+				"Token\n2336412f37fb687f5d51e6e241d09c80\n" +
+				"TopicArn\narn:aws:sns:us-east-1:123456789012:ses-eventos\n" +
+				"Type\nUnsubscribeConfirmation\n",
+		},
+		{name: "tipo desconhecido", msg: SNSMessage{Type: "Teste"}, err: "tipo de mensagem SNS desconhecido"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.msg.canonicalString()
+			assertError(t, err, tt.err)
+			if got != tt.want {
+				t.Errorf("canonicalString() = %q, esperado %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSNSVerifierVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// O certificado em cache evita o download durante o teste
+	verifier := NewSNSVerifier([]string{testTopicArn})
+	verifier.certs[testCertURL] = cert
+
+	sign := func(msg SNSMessage) SNSMessage {
+		canonical, err := msg.canonicalString()
+		if err != nil {
+			t.Fatal(err)
+		}
+		digest := sha256.Sum256([]byte(canonical))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg.Signature = base64.StdEncoding.EncodeToString(signature)
+		return msg
+	}
+
+	base := SNSMessage{
+		Type:             "Notification",
+		MessageId:        "22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324",
+		TopicArn:         testTopicArn,
+		Message:          `{"eventType":"Delivery"}`,
+		Timestamp:        "2026-03-01T12:00:00.000Z",
+		SignatureVersion: "2",
+		SigningCertURL:   testCertURL,
+	}
+	withSubject := base
+	withSubject.Subject = "Evento do SES"
+
+	tampered := sign(base)
+	tampered.Message = `{"eventType":"Bounce"}`
+	subjectAdded := sign(base)
+	subjectAdded.Subject = "Evento do SES"
+	otherTopic := sign(base)
+	otherTopic.TopicArn = "arn:aws:sns:us-east-1:123456789012:outro"
+	otherHost := sign(base)
+	otherHost.SigningCertURL = "https://exemplo.com/SimpleNotificationService-teste.pem"
+	oldVersion := sign(base)
+	oldVersion.SignatureVersion = "3"
+
+	tests := []struct {
+		name string
+		msg  SNSMessage
+		err  string
+	}{
+		{name: "assinatura válida", msg: sign(base)},
+		{name: "assinatura válida com Subject", msg: sign(withSubject)},
+		{name: "mensagem alterada", msg: tampered, err: "assinatura SNS inválida"},
+		{name: "Subject incluído após assinar", msg: subjectAdded, err: "assinatura SNS inválida"},
+		{name: "tópico não autorizado", msg: otherTopic, err: "tópico SNS não autorizado"},
+		{name: "certificado fora do SNS", msg: otherHost, err: "URL do certificado"},
+		{name: "versão não suportada", msg: oldVersion, err: "não suportada"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertError(t, verifier.Verify(tt.msg), tt.err)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// OpenDatabase abre (ou cria) o banco de dados embarcado usado pelos serviços
+func OpenDatabase(path string) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao abrir banco de dados %s: %w", path, err)
+	}
+
+	return db, nil
+}
+
+// CompactDatabase reescreve o arquivo do banco de dados, devolvendo ao sistema
+// o espaço das páginas liberadas pela retenção. Deve ser chamado antes de
+// OpenDatabase, com o banco fechado.
+func CompactDatabase(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	src, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("falha ao abrir banco de dados %s: %w", path, err)
+	}
+	defer src.Close()
+
+	tmpPath := path + ".compact"
+	dst, err := bolt.Open(tmpPath, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return fmt.Errorf("falha ao criar banco de dados compactado: %w", err)
+	}
+
+	if err := bolt.Compact(dst, src, 64*1024*1024); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("falha ao compactar banco de dados: %w", err)
+	}
+
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("falha ao fechar banco de dados compactado: %w", err)
+	}
+	src.Close()
+
+	return os.Rename(tmpPath, path)
+}
+
+// ensureBuckets cria os buckets informados caso ainda não existam
+func ensureBuckets(db *bolt.DB, names ...string) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		for _, name := range names {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return fmt.Errorf("falha ao criar bucket %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// putJSON serializa o valor em JSON e grava na chave informada
+func putJSON(b *bolt.Bucket, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("falha ao serializar %s: %w", key, err)
+	}
+
+	return b.Put([]byte(key), data)
+}
+
+// getJSON lê e desserializa o valor da chave informada, retornando false se não existir
+func getJSON(b *bolt.Bucket, key string, value interface{}) (bool, error) {
+	data := b.Get([]byte(key))
+	if data == nil {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(data, value); err != nil {
+		return false, fmt.Errorf("falha ao desserializar %s: %w", key, err)
+	}
+
+	return true, nil
+}
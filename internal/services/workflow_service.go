@@ -0,0 +1,702 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/renat/poc-ses/internal/metrics"
+)
+
+// Status possíveis de uma inscrição em workflow
+const (
+	EnrollmentActive    = "ACTIVE"
+	EnrollmentCompleted = "COMPLETED"
+	EnrollmentExited    = "EXITED"
+)
+
+// maxStepAttempts é o número máximo de tentativas de envio de uma etapa
+const maxStepAttempts = 3
+
+// workflowEvents são os eventos de entrega que podem ser usados em condições e saídas
+var workflowEvents = map[string]bool{
+	StatusDelivered:  true,
+	StatusOpened:     true,
+	StatusClicked:    true,
+	StatusBounced:    true,
+	StatusComplained: true,
+}
+
+// Workflow representa uma jornada de e-mails em múltiplas etapas
+type Workflow struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	From      string         `json:"from"`
+	ExitOn    []string       `json:"exitOn,omitempty"`
+	Steps     []WorkflowStep `json:"steps"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// WorkflowStep representa uma etapa de um workflow
+type WorkflowStep struct {
+	ID           string                 `json:"id" binding:"required"`
+	Delay        string                 `json:"delay,omitempty"`
+	Condition    *StepCondition         `json:"condition,omitempty"`
+	Subject      string                 `json:"subject,omitempty"`
+	HtmlBody     string                 `json:"htmlBody,omitempty"`
+	TextBody     string                 `json:"textBody,omitempty"`
+	TemplateId   string                 `json:"templateId,omitempty"`
+	TemplateData map[string]interface{} `json:"templateData,omitempty"`
+}
+
+// StepCondition define quando uma etapa deve ser executada, com base nos
+// eventos da mensagem enviada em uma etapa anterior. Se a condição não for
+// atendida, a etapa é pulada e a inscrição segue para a próxima.
+type StepCondition struct {
+	StepID   string `json:"stepId" binding:"required"`
+	Event    string `json:"event" binding:"required"`
+	Occurred bool   `json:"occurred"`
+}
+
+// WorkflowRequest representa uma solicitação para criar um workflow
+type WorkflowRequest struct {
+	Name   string         `json:"name" binding:"required"`
+	From   string         `json:"from" binding:"required,email"`
+	ExitOn []string       `json:"exitOn,omitempty"`
+	Steps  []WorkflowStep `json:"steps" binding:"required,min=1,dive"`
+}
+
+// EnrollmentRequest representa uma solicitação para inscrever um contato em um workflow
+type EnrollmentRequest struct {
+	Email string                 `json:"email" binding:"required,email"`
+	Data  map[string]interface{} `json:"data,omitempty"`
+}
+
+// Enrollment representa a inscrição de um contato em um workflow
+type Enrollment struct {
+	ID          string                 `json:"id"`
+	WorkflowID  string                 `json:"workflowId"`
+	Email       string                 `json:"email"`
+	Data        map[string]interface{} `json:"data,omitempty"`
+	Status      string                 `json:"status"`
+	ExitReason  string                 `json:"exitReason,omitempty"`
+	CurrentStep int                    `json:"currentStep"`
+	NextRunAt   time.Time              `json:"nextRunAt,omitempty"`
+	Attempts    int                    `json:"attempts"`
+	LastError   string                 `json:"lastError,omitempty"`
+	EnrolledAt  time.Time              `json:"enrolledAt"`
+	UpdatedAt   time.Time              `json:"updatedAt"`
+	Steps       []EnrollmentStep       `json:"steps"`
+}
+
+// EnrollmentStep registra a execução de uma etapa para uma inscrição
+type EnrollmentStep struct {
+	StepID     string    `json:"stepId"`
+	MessageID  string    `json:"messageId,omitempty"`
+	Skipped    bool      `json:"skipped"`
+	ExecutedAt time.Time `json:"executedAt"`
+	Events     []string  `json:"events,omitempty"`
+}
+
+// WorkflowFunnel representa as métricas de funil de um workflow
+type WorkflowFunnel struct {
+	WorkflowID    string       `json:"workflowId"`
+	TotalEnrolled int          `json:"totalEnrolled"`
+	Active        int          `json:"active"`
+	Completed     int          `json:"completed"`
+	Exited        int          `json:"exited"`
+	Steps         []StepFunnel `json:"steps"`
+}
+
+// StepFunnel representa as métricas de uma etapa do funil. Exited conta as
+// inscrições encerradas antes de executar a etapa.
+type StepFunnel struct {
+	StepID       string  `json:"stepId"`
+	Reached      int     `json:"reached"`
+	Sent         int     `json:"sent"`
+	Skipped      int     `json:"skipped"`
+	Delivered    int     `json:"delivered"`
+	Opened       int     `json:"opened"`
+	Clicked      int     `json:"clicked"`
+	Exited       int     `json:"exited"`
+	DeliveryRate float64 `json:"deliveryRate"`
+	OpenRate     float64 `json:"openRate"`
+	ClickRate    float64 `json:"clickRate"`
+}
+
+// Validate valida a definição do workflow
+func (r WorkflowRequest) Validate() error {
+	seen := make(map[string]bool, len(r.Steps))
+	for i, step := range r.Steps {
+		if seen[step.ID] {
+			return fmt.Errorf("etapa duplicada: %s", step.ID)
+		}
+
+		if _, err := parseDelay(step.Delay); err != nil {
+			return fmt.Errorf("atraso inválido na etapa %s: %w", step.ID, err)
+		}
+
+		if step.TemplateId == "" && step.HtmlBody == "" && step.TextBody == "" {
+			return fmt.Errorf("etapa %s deve ter um template ou corpo (HTML ou texto)", step.ID)
+		}
+
+		if step.TemplateId == "" && step.Subject == "" {
+			return fmt.Errorf("etapa %s deve ter um assunto", step.ID)
+		}
+
+		if cond := step.Condition; cond != nil {
+			if !seen[cond.StepID] {
+				return fmt.Errorf("condição da etapa %s deve referenciar uma etapa anterior", step.ID)
+			}
+			if !workflowEvents[cond.Event] {
+				return fmt.Errorf("evento inválido na condição da etapa %s: %s", step.ID, cond.Event)
+			}
+		}
+
+		if i == 0 && step.Condition != nil {
+			return fmt.Errorf("a primeira etapa não pode ter condição")
+		}
+
+		seen[step.ID] = true
+	}
+
+	for _, event := range r.ExitOn {
+		if !workflowEvents[event] {
+			return fmt.Errorf("evento de saída inválido: %s", event)
+		}
+	}
+
+	return nil
+}
+
+// parseDelay interpreta atrasos no formato de duração do Go, aceitando também dias (ex: "2d")
+func parseDelay(delay string) (time.Duration, error) {
+	delay = strings.TrimSpace(delay)
+	if delay == "" || delay == "0" {
+		return 0, nil
+	}
+
+	if strings.HasSuffix(delay, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(delay, "d"), 64)
+		if err != nil {
+			return 0, err
+		}
+		if days < 0 {
+			return 0, fmt.Errorf("atraso não pode ser negativo")
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+
+	d, err := time.ParseDuration(delay)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("atraso não pode ser negativo")
+	}
+
+	return d, nil
+}
+
+// WorkflowService gerencia workflows e executa as inscrições
+type WorkflowService struct {
+	store           WorkflowStore
+	sesService      *SESService
+	deliveryService *DeliveryService
+	interval        time.Duration
+	wake            chan struct{}
+	mutex           sync.Mutex
+}
+
+// NewWorkflowService cria uma nova instância do WorkflowService
+func NewWorkflowService(store WorkflowStore, sesService *SESService, deliveryService *DeliveryService, interval time.Duration) *WorkflowService {
+	s := &WorkflowService{
+		store:           store,
+		sesService:      sesService,
+		deliveryService: deliveryService,
+		interval:        interval,
+		wake:            make(chan struct{}, 1),
+	}
+
+	deliveryService.Subscribe(s.handleDeliveryEvent)
+	metrics.RegisterQueue("workflow_enrollments", func() float64 {
+		count, err := store.CountScheduled()
+		if err != nil {
+			log.Printf("Falha ao contar inscrições agendadas: %v", err)
+		}
+		return float64(count)
+	})
+	return s
+}
+
+// CreateWorkflow cria um novo workflow
+func (s *WorkflowService) CreateWorkflow(req WorkflowRequest) (*Workflow, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	workflow := &Workflow{
+		ID:        strings.ToLower(strings.ReplaceAll(req.Name, " ", "-")) + "-" + fmt.Sprintf("%d", time.Now().Unix()),
+		Name:      req.Name,
+		From:      req.From,
+		ExitOn:    req.ExitOn,
+		Steps:     req.Steps,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.store.SaveWorkflow(workflow); err != nil {
+		return nil, fmt.Errorf("falha ao salvar workflow: %w", err)
+	}
+
+	return workflow, nil
+}
+
+// ListWorkflows lista todos os workflows
+func (s *WorkflowService) ListWorkflows() ([]Workflow, error) {
+	return s.store.ListWorkflows()
+}
+
+// GetWorkflow obtém um workflow pelo ID, retornando nil se não existir
+func (s *WorkflowService) GetWorkflow(id string) (*Workflow, error) {
+	return s.store.GetWorkflow(id)
+}
+
+// DeleteWorkflow remove um workflow e encerra as inscrições ativas
+func (s *WorkflowService) DeleteWorkflow(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	enrollments, err := s.store.ListEnrollments(id)
+	if err != nil {
+		return err
+	}
+
+	for i := range enrollments {
+		if enrollments[i].Status == EnrollmentActive {
+			s.exit(&enrollments[i], "workflow removido")
+			if err := s.store.SaveEnrollment(&enrollments[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.store.DeleteWorkflow(id)
+}
+
+// Enroll inscreve um contato em um workflow
+func (s *WorkflowService) Enroll(workflow *Workflow, req EnrollmentRequest) (*Enrollment, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	existing, err := s.store.ListEnrollments(workflow.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range existing {
+		if e.Status == EnrollmentActive && strings.EqualFold(e.Email, req.Email) {
+			return nil, fmt.Errorf("contato já inscrito no workflow: %s", req.Email)
+		}
+	}
+
+	delay, _ := parseDelay(workflow.Steps[0].Delay)
+	now := time.Now()
+	enrollment := &Enrollment{
+		ID:         fmt.Sprintf("%s-%d", workflow.ID, now.UnixNano()),
+		WorkflowID: workflow.ID,
+		Email:      req.Email,
+		Data:       req.Data,
+		Status:     EnrollmentActive,
+		NextRunAt:  now.Add(delay),
+		EnrolledAt: now,
+		UpdatedAt:  now,
+		Steps:      []EnrollmentStep{},
+	}
+
+	if err := s.store.SaveEnrollment(enrollment); err != nil {
+		return nil, fmt.Errorf("falha ao salvar inscrição: %w", err)
+	}
+
+	if delay == 0 {
+		s.notify()
+	}
+
+	return enrollment, nil
+}
+
+// ListEnrollments lista as inscrições de um workflow
+func (s *WorkflowService) ListEnrollments(workflowID string) ([]Enrollment, error) {
+	return s.store.ListEnrollments(workflowID)
+}
+
+// Unenroll encerra manualmente uma inscrição ativa, retornando nil se não existir no workflow
+func (s *WorkflowService) Unenroll(workflowID, enrollmentID string) (*Enrollment, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	enrollment, err := s.store.GetEnrollment(enrollmentID)
+	if err != nil || enrollment == nil || enrollment.WorkflowID != workflowID {
+		return nil, err
+	}
+
+	if enrollment.Status == EnrollmentActive {
+		s.exit(enrollment, "removido manualmente")
+		if err := s.store.SaveEnrollment(enrollment); err != nil {
+			return nil, err
+		}
+	}
+
+	return enrollment, nil
+}
+
+// GetFunnel calcula as métricas de funil por etapa de um workflow
+func (s *WorkflowService) GetFunnel(workflow *Workflow) (*WorkflowFunnel, error) {
+	enrollments, err := s.store.ListEnrollments(workflow.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	funnel := &WorkflowFunnel{
+		WorkflowID:    workflow.ID,
+		TotalEnrolled: len(enrollments),
+		Steps:         make([]StepFunnel, len(workflow.Steps)),
+	}
+
+	index := make(map[string]int, len(workflow.Steps))
+	for i, step := range workflow.Steps {
+		funnel.Steps[i].StepID = step.ID
+		index[step.ID] = i
+	}
+
+	for _, e := range enrollments {
+		switch e.Status {
+		case EnrollmentActive:
+			funnel.Active++
+		case EnrollmentCompleted:
+			funnel.Completed++
+		case EnrollmentExited:
+			funnel.Exited++
+			if e.CurrentStep < len(funnel.Steps) {
+				funnel.Steps[e.CurrentStep].Exited++
+			}
+		}
+
+		for _, executed := range e.Steps {
+			i, ok := index[executed.StepID]
+			if !ok {
+				continue
+			}
+
+			stats := &funnel.Steps[i]
+			stats.Reached++
+			if executed.Skipped {
+				stats.Skipped++
+				continue
+			}
+
+			stats.Sent++
+			if hasEvent(executed.Events, StatusDelivered) {
+				stats.Delivered++
+			}
+			if hasEvent(executed.Events, StatusOpened) {
+				stats.Opened++
+			}
+			if hasEvent(executed.Events, StatusClicked) {
+				stats.Clicked++
+			}
+		}
+	}
+
+	for i := range funnel.Steps {
+		stats := &funnel.Steps[i]
+		if stats.Sent > 0 {
+			stats.DeliveryRate = float64(stats.Delivered) / float64(stats.Sent) * 100
+		}
+		if stats.Delivered > 0 {
+			stats.OpenRate = float64(stats.Opened) / float64(stats.Delivered) * 100
+			stats.ClickRate = float64(stats.Clicked) / float64(stats.Delivered) * 100
+		}
+	}
+
+	return funnel, nil
+}
+
+// Run executa as inscrições pendentes periodicamente até o contexto ser cancelado
+func (s *WorkflowService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.processDue(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// notify acorda o executor sem bloquear
+func (s *WorkflowService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// processDue executa as etapas de todas as inscrições vencidas
+func (s *WorkflowService) processDue(now time.Time) {
+	due, err := s.store.DueEnrollments(now)
+	if err != nil {
+		log.Printf("Falha ao buscar inscrições pendentes: %v", err)
+		return
+	}
+
+	for _, enrollment := range due {
+		s.advance(enrollment.ID, now)
+	}
+}
+
+// advance executa a etapa atual de uma inscrição e agenda a próxima. O lock
+// não é mantido durante o envio, para que uma chamada lenta ao SES não
+// bloqueie as demais inscrições nem os eventos de entrega; a inscrição é
+// recarregada depois do envio, preservando o que mudou nesse intervalo. Só o
+// Run executa etapas, então a mesma etapa não é enviada em paralelo.
+func (s *WorkflowService) advance(id string, now time.Time) {
+	s.mutex.Lock()
+	e, workflow := s.prepare(id, now)
+	s.mutex.Unlock()
+	if e == nil {
+		return
+	}
+
+	step := workflow.Steps[e.CurrentStep]
+	req := stepRequest(workflow, step, e)
+	result, sendErr := s.sesService.SendEmail(req)
+	if sendErr == nil {
+		// Registrada antes de reobter o lock, para que os eventos do SNS que
+		// chegarem enquanto a inscrição é atualizada encontrem a mensagem
+		s.deliveryService.TrackDelivery(result.From, result.MessageID, result.Subject, NewRecipients(req.To, nil, nil), TrackOptions{
+			Tags:             map[string]string{"workflow": workflow.ID, "step": step.ID},
+			TemplateID:       step.TemplateId,
+			ConfigurationSet: result.ConfigurationSet,
+		})
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current, err := s.store.GetEnrollment(id)
+	if err != nil {
+		log.Printf("Falha ao obter inscrição %s: %v", id, err)
+	}
+
+	if sendErr != nil {
+		if current != nil && current.Status == EnrollmentActive {
+			s.fail(current, step, sendErr, now)
+			s.save(current)
+		}
+		return
+	}
+
+	// A inscrição pode ter sido encerrada ou removida durante o envio; o envio
+	// fica registrado, mas a próxima etapa só é agendada para inscrições ativas
+	if current != nil {
+		executed := EnrollmentStep{StepID: step.ID, ExecutedAt: now, MessageID: result.MessageID}
+		if current.Status == EnrollmentActive {
+			s.record(current, workflow, executed, now)
+		} else {
+			current.Steps = append(current.Steps, executed)
+		}
+		s.save(current)
+
+		if err := s.store.IndexMessage(result.MessageID, current.ID); err != nil {
+			log.Printf("Falha ao indexar mensagem %s: %v", result.MessageID, err)
+		}
+	}
+}
+
+// prepare carrega uma inscrição vencida e resolve as etapas que não exigem
+// envio (condição não atendida, workflow removido ou concluído). Retorna a
+// inscrição e o workflow apenas quando a etapa atual deve ser enviada.
+func (s *WorkflowService) prepare(id string, now time.Time) (*Enrollment, *Workflow) {
+	e, err := s.store.GetEnrollment(id)
+	if err != nil {
+		log.Printf("Falha ao obter inscrição %s: %v", id, err)
+		return nil, nil
+	}
+	// A inscrição pode ter sido encerrada ou reagendada desde a busca
+	if e == nil || e.Status != EnrollmentActive || e.NextRunAt.After(now) {
+		return nil, nil
+	}
+
+	workflow, err := s.store.GetWorkflow(e.WorkflowID)
+	if err != nil {
+		log.Printf("Falha ao obter workflow %s: %v", e.WorkflowID, err)
+		return nil, nil
+	}
+
+	switch {
+	case workflow == nil:
+		s.exit(e, "workflow removido")
+	case e.CurrentStep >= len(workflow.Steps):
+		s.complete(e)
+	case workflow.Steps[e.CurrentStep].Condition != nil && !conditionMet(e, workflow.Steps[e.CurrentStep].Condition):
+		step := workflow.Steps[e.CurrentStep]
+		s.record(e, workflow, EnrollmentStep{StepID: step.ID, ExecutedAt: now, Skipped: true}, now)
+	default:
+		return e, workflow
+	}
+
+	s.save(e)
+	return nil, nil
+}
+
+// record registra a etapa executada e agenda a próxima
+func (s *WorkflowService) record(e *Enrollment, workflow *Workflow, executed EnrollmentStep, now time.Time) {
+	e.Steps = append(e.Steps, executed)
+	e.CurrentStep++
+	e.Attempts = 0
+	e.LastError = ""
+	e.UpdatedAt = now
+
+	if e.CurrentStep >= len(workflow.Steps) {
+		s.complete(e)
+		return
+	}
+
+	delay, _ := parseDelay(workflow.Steps[e.CurrentStep].Delay)
+	e.NextRunAt = now.Add(delay)
+}
+
+// fail registra a falha no envio da etapa e agenda uma nova tentativa
+func (s *WorkflowService) fail(e *Enrollment, step WorkflowStep, err error, now time.Time) {
+	e.LastError = err.Error()
+	e.UpdatedAt = now
+	// Envios suspensos aguardam a liberação sem consumir tentativas
+	if isSendingSuspended(err) {
+		e.NextRunAt = now.Add(suspendedRetryDelay)
+		log.Printf("Etapa %s da inscrição %s adiada, envios suspensos: %v", step.ID, e.ID, err)
+		return
+	}
+
+	e.Attempts++
+	if e.Attempts >= maxStepAttempts {
+		s.exit(e, "falha ao enviar etapa "+step.ID)
+	} else {
+		e.NextRunAt = now.Add(time.Duration(e.Attempts) * 5 * time.Minute)
+	}
+	log.Printf("Falha ao enviar etapa %s da inscrição %s: %v", step.ID, e.ID, err)
+}
+
+// save grava a inscrição, registrando a falha no log
+func (s *WorkflowService) save(e *Enrollment) {
+	if err := s.store.SaveEnrollment(e); err != nil {
+		log.Printf("Falha ao salvar inscrição %s: %v", e.ID, err)
+	}
+}
+
+// stepRequest monta o e-mail de uma etapa para o contato inscrito
+func stepRequest(workflow *Workflow, step WorkflowStep, e *Enrollment) EmailRequest {
+	req := EmailRequest{
+		From:       workflow.From,
+		To:         []string{e.Email},
+		Subject:    step.Subject,
+		HtmlBody:   step.HtmlBody,
+		TextBody:   step.TextBody,
+		TemplateId: step.TemplateId,
+	}
+
+	if step.TemplateId != "" {
+		req.TemplateData = make(map[string]interface{}, len(step.TemplateData)+len(e.Data))
+		for k, v := range step.TemplateData {
+			req.TemplateData[k] = v
+		}
+		for k, v := range e.Data {
+			req.TemplateData[k] = v
+		}
+	}
+
+	return req
+}
+
+// handleDeliveryEvent registra eventos de entrega nas inscrições e aplica as regras de saída
+func (s *WorkflowService) handleDeliveryEvent(status DeliveryStatus, event DeliveryEvent) {
+	if !workflowEvents[event.Type] {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	enrollment, err := s.store.FindEnrollmentByMessage(event.MessageID)
+	if err != nil || enrollment == nil {
+		return
+	}
+
+	for i := range enrollment.Steps {
+		if enrollment.Steps[i].MessageID == event.MessageID && !hasEvent(enrollment.Steps[i].Events, event.Type) {
+			enrollment.Steps[i].Events = append(enrollment.Steps[i].Events, event.Type)
+		}
+	}
+	enrollment.UpdatedAt = time.Now()
+
+	if enrollment.Status == EnrollmentActive {
+		workflow, err := s.store.GetWorkflow(enrollment.WorkflowID)
+		if err == nil && workflow != nil && shouldExit(workflow, event.Type) {
+			s.exit(enrollment, "evento "+event.Type)
+		}
+	}
+
+	if err := s.store.SaveEnrollment(enrollment); err != nil {
+		log.Printf("Falha ao salvar inscrição %s: %v", enrollment.ID, err)
+	}
+}
+
+// shouldExit indica se o evento encerra a inscrição. Bounces e reclamações sempre encerram.
+func shouldExit(workflow *Workflow, event string) bool {
+	if event == StatusBounced || event == StatusComplained {
+		return true
+	}
+	return hasEvent(workflow.ExitOn, event)
+}
+
+// conditionMet verifica se a condição de uma etapa é atendida pela inscrição
+func conditionMet(e *Enrollment, cond *StepCondition) bool {
+	occurred := false
+	for _, executed := range e.Steps {
+		if executed.StepID == cond.StepID && hasEvent(executed.Events, cond.Event) {
+			occurred = true
+			break
+		}
+	}
+	return occurred == cond.Occurred
+}
+
+// hasEvent verifica se o evento está presente na lista
+func hasEvent(events []string, event string) bool {
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *WorkflowService) complete(e *Enrollment) {
+	e.Status = EnrollmentCompleted
+	e.NextRunAt = time.Time{}
+	e.UpdatedAt = time.Now()
+}
+
+func (s *WorkflowService) exit(e *Enrollment, reason string) {
+	e.Status = EnrollmentExited
+	e.ExitReason = reason
+	e.NextRunAt = time.Time{}
+	e.UpdatedAt = time.Now()
+}
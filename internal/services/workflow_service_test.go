@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+)
+
+func TestParseDelay(t *testing.T) {
+	tests := []struct {
+		delay string
+		want  time.Duration
+		err   string
+	}{
+		{delay: "", want: 0},
+		{delay: "0", want: 0},
+		{delay: "  ", want: 0},
+		{delay: "30m", want: 30 * time.Minute},
+		{delay: "1h30m", want: 90 * time.Minute},
+		{delay: " 2h ", want: 2 * time.Hour},
+		{delay: "2d", want: 48 * time.Hour},
+		{delay: "0.5d", want: 12 * time.Hour},
+		{delay: "0d", want: 0},
+		{delay: "-1h", err: "negativo"},
+		{delay: "-2d", err: "negativo"},
+		{delay: "d", err: "invalid syntax"},
+		{delay: "dois dias", err: "invalid duration"},
+		{delay: "10", err: "missing unit"},
+		{delay: "1w", err: "unknown unit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.delay), func(t *testing.T) {
+			got, err := parseDelay(tt.delay)
+			assertError(t, err, tt.err)
+			if err == nil && got != tt.want {
+				t.Errorf("parseDelay() = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionMet(t *testing.T) {
+	enrollment := &Enrollment{Steps: []EnrollmentStep{
+		{StepID: "boas-vindas", MessageID: "m1", Events: []string{StatusDelivered, StatusOpened}},
+		{StepID: "pulada", Skipped: true},
+		{StepID: "lembrete", MessageID: "m2", Events: []string{StatusDelivered}},
+	}}
+
+	tests := []struct {
+		name string
+		cond StepCondition
+		want bool
+	}{
+		{name: "evento ocorrido", cond: StepCondition{StepID: "boas-vindas", Event: StatusOpened, Occurred: true}, want: true},
+		{name: "evento ocorrido esperado ausente", cond: StepCondition{StepID: "boas-vindas", Event: StatusOpened, Occurred: false}, want: false},
+		{name: "evento ausente", cond: StepCondition{StepID: "boas-vindas", Event: StatusClicked, Occurred: true}, want: false},
+		{name: "evento ausente esperado ausente", cond: StepCondition{StepID: "boas-vindas", Event: StatusClicked, Occurred: false}, want: true},
+		{name: "evento de outra etapa", cond: StepCondition{StepID: "lembrete", Event: StatusOpened, Occurred: true}, want: false},
+		{name: "etapa pulada", cond: StepCondition{StepID: "pulada", Event: StatusDelivered, Occurred: true}, want: false},
+		{name: "etapa pulada esperado ausente", cond: StepCondition{StepID: "pulada", Event: StatusDelivered, Occurred: false}, want: true},
+		{name: "etapa não executada", cond: StepCondition{StepID: "oferta", Event: StatusDelivered, Occurred: true}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := conditionMet(enrollment, &tt.cond); got != tt.want {
+				t.Errorf("conditionMet() = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldExit(t *testing.T) {
+	workflow := &Workflow{ExitOn: []string{StatusClicked}}
+
+	tests := []struct {
+		event string
+		want  bool
+	}{
+		{event: StatusBounced, want: true},
+		{event: StatusComplained, want: true},
+		{event: StatusClicked, want: true},
+		{event: StatusOpened, want: false},
+		{event: StatusDelivered, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			if got := shouldExit(workflow, tt.event); got != tt.want {
+				t.Errorf("shouldExit() = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("sem eventos de saída", func(t *testing.T) {
+		if shouldExit(&Workflow{}, StatusClicked) {
+			t.Error("shouldExit() deveria ignorar cliques sem exitOn")
+		}
+		if !shouldExit(&Workflow{}, StatusBounced) {
+			t.Error("shouldExit() deveria encerrar em bounces sem exitOn")
+		}
+	})
+}
+
+func TestWorkflowServiceFail(t *testing.T) {
+	now := storeBase
+	step := WorkflowStep{ID: "boas-vindas"}
+	sendErr := errors.New("MessageRejected: Email address is not verified")
+
+	tests := []struct {
+		name         string
+		attempts     int
+		err          error
+		wantAttempts int
+		wantStatus   string
+		wantNextRun  time.Time
+		wantReason   string
+	}{
+		{name: "primeira falha", err: sendErr, wantAttempts: 1, wantStatus: EnrollmentActive, wantNextRun: now.Add(5 * time.Minute)},
+		{name: "segunda falha", attempts: 1, err: sendErr, wantAttempts: 2, wantStatus: EnrollmentActive, wantNextRun: now.Add(10 * time.Minute)},
+		{
+			name:         "última tentativa",
+			attempts:     maxStepAttempts - 1,
+			err:          sendErr,
+			wantAttempts: maxStepAttempts,
+			wantStatus:   EnrollmentExited,
+			wantReason:   "falha ao enviar etapa boas-vindas",
+		},
+		{
+			name:         "kill switch",
+			attempts:     1,
+			err:          fmt.Errorf("%w pelo kill switch", ErrSendingSuspended),
+			wantAttempts: 1,
+			wantStatus:   EnrollmentActive,
+			wantNextRun:  now.Add(suspendedRetryDelay),
+		},
+		{
+			name:         "suspensão na última tentativa",
+			attempts:     maxStepAttempts - 1,
+			err:          fmt.Errorf("remetente pausado: %w", ErrSendingSuspended),
+			wantAttempts: maxStepAttempts - 1,
+			wantStatus:   EnrollmentActive,
+			wantNextRun:  now.Add(suspendedRetryDelay),
+		},
+		{
+			name:        "conta pausada no SES",
+			err:         fmt.Errorf("falha ao enviar: %w", &types.AccountSendingPausedException{}),
+			wantStatus:  EnrollmentActive,
+			wantNextRun: now.Add(suspendedRetryDelay),
+		},
+		{
+			name:        "configuration set pausado no SES",
+			err:         fmt.Errorf("falha ao enviar: %w", &types.ConfigurationSetSendingPausedException{}),
+			wantStatus:  EnrollmentActive,
+			wantNextRun: now.Add(suspendedRetryDelay),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &WorkflowService{}
+			e := &Enrollment{ID: "e1", Status: EnrollmentActive, Attempts: tt.attempts, NextRunAt: now}
+
+			s.fail(e, step, tt.err, now)
+
+			if e.Attempts != tt.wantAttempts {
+				t.Errorf("Attempts = %d, esperado %d", e.Attempts, tt.wantAttempts)
+			}
+			if e.Status != tt.wantStatus {
+				t.Errorf("Status = %s, esperado %s", e.Status, tt.wantStatus)
+			}
+			if !e.NextRunAt.Equal(tt.wantNextRun) {
+				t.Errorf("NextRunAt = %v, esperado %v", e.NextRunAt, tt.wantNextRun)
+			}
+			if e.ExitReason != tt.wantReason {
+				t.Errorf("ExitReason = %q, esperado %q", e.ExitReason, tt.wantReason)
+			}
+			if e.LastError != tt.err.Error() {
+				t.Errorf("LastError = %q, esperado %q", e.LastError, tt.err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	workflowsBucket          = "workflows"
+	enrollmentsBucket        = "enrollments"
+	enrollmentScheduleBucket = "enrollment_schedule"
+	enrollmentMessagesBucket = "enrollment_messages"
+)
+
+// WorkflowStore define a persistência de workflows e inscrições
+type WorkflowStore interface {
+	SaveWorkflow(workflow *Workflow) error
+	GetWorkflow(id string) (*Workflow, error)
+	ListWorkflows() ([]Workflow, error)
+	DeleteWorkflow(id string) error
+	SaveEnrollment(enrollment *Enrollment) error
+	GetEnrollment(id string) (*Enrollment, error)
+	ListEnrollments(workflowID string) ([]Enrollment, error)
+	DueEnrollments(now time.Time) ([]Enrollment, error)
+	CountScheduled() (int, error)
+	IndexMessage(messageID, enrollmentID string) error
+	FindEnrollmentByMessage(messageID string) (*Enrollment, error)
+}
+
+// BoltWorkflowStore persiste workflows e inscrições no banco embarcado.
+// As inscrições ativas são indexadas pelo horário da próxima execução para
+// que o executor retome o agendamento após reinicializações.
+type BoltWorkflowStore struct {
+	db *bolt.DB
+}
+
+// NewBoltWorkflowStore cria uma nova instância do BoltWorkflowStore
+func NewBoltWorkflowStore(db *bolt.DB) (*BoltWorkflowStore, error) {
+	if err := ensureBuckets(db, workflowsBucket, enrollmentsBucket, enrollmentScheduleBucket, enrollmentMessagesBucket); err != nil {
+		return nil, err
+	}
+
+	return &BoltWorkflowStore{db: db}, nil
+}
+
+// scheduleKey monta a chave ordenável do índice de agendamento
+func scheduleKey(at time.Time, id string) []byte {
+	return []byte(fmt.Sprintf("%020d|%s", at.UnixNano(), id))
+}
+
+// SaveWorkflow grava um workflow
+func (s *BoltWorkflowStore) SaveWorkflow(workflow *Workflow) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket([]byte(workflowsBucket)), workflow.ID, workflow)
+	})
+}
+
+// GetWorkflow obtém um workflow pelo ID, retornando nil se não existir
+func (s *BoltWorkflowStore) GetWorkflow(id string) (*Workflow, error) {
+	var workflow Workflow
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket([]byte(workflowsBucket)), id, &workflow)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &workflow, nil
+}
+
+// ListWorkflows lista todos os workflows ordenados por data de criação
+func (s *BoltWorkflowStore) ListWorkflows() ([]Workflow, error) {
+	workflows := []Workflow{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(workflowsBucket)).ForEach(func(k, v []byte) error {
+			var workflow Workflow
+			if err := json.Unmarshal(v, &workflow); err != nil {
+				return fmt.Errorf("falha ao desserializar workflow %s: %w", k, err)
+			}
+			workflows = append(workflows, workflow)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(workflows, func(i, j int) bool {
+		return workflows[i].CreatedAt.Before(workflows[j].CreatedAt)
+	})
+
+	return workflows, nil
+}
+
+// DeleteWorkflow remove um workflow
+func (s *BoltWorkflowStore) DeleteWorkflow(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(workflowsBucket)).Delete([]byte(id))
+	})
+}
+
+// SaveEnrollment grava uma inscrição e atualiza o índice de agendamento
+func (s *BoltWorkflowStore) SaveEnrollment(enrollment *Enrollment) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		enrollments := tx.Bucket([]byte(enrollmentsBucket))
+		schedule := tx.Bucket([]byte(enrollmentScheduleBucket))
+
+		var previous Enrollment
+		found, err := getJSON(enrollments, enrollment.ID, &previous)
+		if err != nil {
+			return err
+		}
+		if found && previous.Status == EnrollmentActive {
+			if err := schedule.Delete(scheduleKey(previous.NextRunAt, previous.ID)); err != nil {
+				return err
+			}
+		}
+
+		if enrollment.Status == EnrollmentActive {
+			if err := schedule.Put(scheduleKey(enrollment.NextRunAt, enrollment.ID), []byte(enrollment.ID)); err != nil {
+				return err
+			}
+		}
+
+		return putJSON(enrollments, enrollment.ID, enrollment)
+	})
+}
+
+// GetEnrollment obtém uma inscrição pelo ID, retornando nil se não existir
+func (s *BoltWorkflowStore) GetEnrollment(id string) (*Enrollment, error) {
+	var enrollment Enrollment
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket([]byte(enrollmentsBucket)), id, &enrollment)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return &enrollment, nil
+}
+
+// ListEnrollments lista as inscrições de um workflow
+func (s *BoltWorkflowStore) ListEnrollments(workflowID string) ([]Enrollment, error) {
+	result := []Enrollment{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(enrollmentsBucket)).ForEach(func(k, v []byte) error {
+			var enrollment Enrollment
+			if err := json.Unmarshal(v, &enrollment); err != nil {
+				return fmt.Errorf("falha ao desserializar inscrição %s: %w", k, err)
+			}
+			if enrollment.WorkflowID == workflowID {
+				result = append(result, enrollment)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].EnrolledAt.Before(result[j].EnrolledAt)
+	})
+
+	return result, nil
+}
+
+// DueEnrollments retorna as inscrições ativas cuja próxima execução já venceu
+func (s *BoltWorkflowStore) DueEnrollments(now time.Time) ([]Enrollment, error) {
+	result := []Enrollment{}
+	limit := []byte(fmt.Sprintf("%020d|", now.UnixNano()))
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		enrollments := tx.Bucket([]byte(enrollmentsBucket))
+		c := tx.Bucket([]byte(enrollmentScheduleBucket)).Cursor()
+
+		for k, v := c.First(); k != nil && bytes.Compare(k, limit) < 0; k, v = c.Next() {
+			var enrollment Enrollment
+			found, err := getJSON(enrollments, string(v), &enrollment)
+			if err != nil {
+				return err
+			}
+			if found {
+				result = append(result, enrollment)
+			}
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// CountScheduled retorna a quantidade de inscrições ativas aguardando a próxima etapa
+func (s *BoltWorkflowStore) CountScheduled() (int, error) {
+	var count int
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket([]byte(enrollmentScheduleBucket)).Stats().KeyN
+		return nil
+	})
+
+	return count, err
+}
+
+// IndexMessage associa uma mensagem enviada à inscrição que a originou
+func (s *BoltWorkflowStore) IndexMessage(messageID, enrollmentID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(enrollmentMessagesBucket)).Put([]byte(messageID), []byte(enrollmentID))
+	})
+}
+
+// FindEnrollmentByMessage obtém a inscrição que originou a mensagem, retornando nil se não existir
+func (s *BoltWorkflowStore) FindEnrollmentByMessage(messageID string) (*Enrollment, error) {
+	var enrollmentID []byte
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket([]byte(enrollmentMessagesBucket)).Get([]byte(messageID)); v != nil {
+			enrollmentID = append(enrollmentID, v...)
+		}
+		return nil
+	})
+	if err != nil || enrollmentID == nil {
+		return nil, err
+	}
+
+	return s.GetEnrollment(string(enrollmentID))
+}